@@ -0,0 +1,61 @@
+package gotile
+
+import (
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// EncodeIndicesSA returns the non-zero indices of the tile-coded
+// representation of the state-action pair (state, action), for use
+// with tile-coded action-value functions. The features of each of the
+// numActions actions form a separate block of VecLength() features,
+// so the returned indices are those returned by EncodeIndices for
+// state, shifted into the block of action. Each block includes its own
+// bias unit if the receiver includes a bias unit. The tile-coded
+// representation of state-action pairs has numActions * VecLength()
+// features.
+//
+// If action is not in [0, numActions), or if some tiling uses the
+// BoundsError policy and state falls outside its bounds,
+// EncodeIndicesSA panics. See TryEncodeIndicesSA for a non-panicking
+// variant.
+func (t *TileCoder) EncodeIndicesSA(state mat.Vector, action,
+	numActions int) []float64 {
+	indices, err := t.TryEncodeIndicesSA(state, action, numActions)
+	if err != nil {
+		panic(err)
+	}
+	return indices
+}
+
+// TryEncodeIndicesSA returns the non-zero indices of the tile-coded
+// representation of the state-action pair (state, action), as in
+// EncodeIndicesSA. If action is not in [0, numActions) an error is
+// returned. If the state-action representation would have more than
+// MaxFeatures features, an error wrapping ErrFeatureSpace is returned,
+// and if some tiling uses the BoundsError policy and state falls
+// outside its bounds, an error wrapping ErrOutOfBounds is returned.
+func (t *TileCoder) TryEncodeIndicesSA(state mat.Vector, action,
+	numActions int) ([]float64, error) {
+	if action < 0 || action >= numActions {
+		return nil, fmt.Errorf("encodeIndicesSA: action %d out of range "+
+			"[0, %d)", action, numActions)
+	}
+	if _, err := mulFeatures(numActions, t.VecLength()); err != nil {
+		return nil, fmt.Errorf("encodeIndicesSA: %w", err)
+	}
+
+	indices := make([]float64, t.numIndices())
+	if err := t.encodeIndicesTo(indices, state); err != nil {
+		return nil, fmt.Errorf("encodeIndicesSA: %w", err)
+	}
+
+	t.order(indices)
+
+	offset := float64(action * t.VecLength())
+	for i := range indices {
+		indices[i] += offset
+	}
+	return indices, nil
+}
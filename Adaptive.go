@@ -0,0 +1,270 @@
+package gotile
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// BoundsHook is called by a TileCoder using WithAdaptiveBounds each
+// time it rescales its tilings, with the new bounds of each input
+// dimension. After the hook is called, vectors may map to different
+// features than they did before.
+type BoundsHook func(minDims, maxDims mat.Vector)
+
+// adaptiveBounds tracks the bounds of the vectors encoded by a
+// TileCoder so that its tilings can be rescaled to cover them
+type adaptiveBounds struct {
+	// Held from observing each vector until it has been encoded, since
+	// observing a vector may rescale the tilings of the TileCoder
+	mu sync.Mutex
+
+	minDims, maxDims *mat.VecDense
+	margin           float64
+	hook             BoundsHook
+	fixed            []bool // Whether each dimension is never rescaled
+	log              []bool // Whether each dimension is log-scaled
+}
+
+// newAdaptiveBounds returns a new adaptiveBounds starting from the
+// bounds minDims and maxDims, configured by cfg
+func newAdaptiveBounds(minDims, maxDims mat.Vector,
+	cfg *config) *adaptiveBounds {
+	fixed := make([]bool, minDims.Len())
+	log := make([]bool, minDims.Len())
+	for _, d := range cfg.categorical {
+		if d >= 0 && d < len(fixed) {
+			fixed[d] = true
+		}
+	}
+	for i, s := range cfg.scales {
+		log[i] = s == ScaleLog
+	}
+
+	return &adaptiveBounds{
+		minDims: mat.VecDenseCopyOf(minDims),
+		maxDims: mat.VecDenseCopyOf(maxDims),
+		margin:  cfg.adaptMargin,
+		hook:    cfg.adaptHook,
+		fixed:   fixed,
+		log:     log,
+	}
+}
+
+// extend returns the bounds of dimension i widened to include all
+// values in [lo, hi], and whether they differ from the current bounds
+func (a *adaptiveBounds) extend(i int, lo, hi float64) (float64, float64,
+	bool) {
+	min, max := a.minDims.AtVec(i), a.maxDims.AtVec(i)
+	if a.fixed[i] {
+		return min, max, false
+	}
+	newMin, newMax := min, max
+
+	if lo < min && !math.IsInf(lo, -1) {
+		// Log-scaled dimensions keep a positive minimum
+		newMin = lo - a.margin*(max-lo)
+		if a.log[i] && newMin <= 0 {
+			newMin = lo
+		}
+		if a.log[i] && newMin <= 0 {
+			newMin = min
+		}
+	}
+	if hi > max && !math.IsInf(hi, 1) {
+		newMax = hi + a.margin*(hi-min)
+	}
+	return newMin, newMax, newMin != min || newMax != max
+}
+
+// rescale moves the bounds of the receiver to minDims and maxDims,
+// rescales the tilings of t to the new bounds, and notifies the hook.
+// If some tiling cannot be rescaled, an error is returned and neither
+// the receiver nor t is changed.
+func (a *adaptiveBounds) rescale(t *TileCoder, minDims,
+	maxDims *mat.VecDense) error {
+	for k, tiling := range t.tilings {
+		if err := tiling.checkRescale(minDims, maxDims); err != nil {
+			return fmt.Errorf("adapting bounds: tiling %d: %v", k, err)
+		}
+	}
+
+	a.minDims, a.maxDims = minDims, maxDims
+	for _, tiling := range t.tilings {
+		// The bounds have been validated, so no error can occur
+		if err := tiling.rescale(a.minDims, a.maxDims); err != nil {
+			panic(err)
+		}
+	}
+	t.uniform = newUniformTilings(t.tilings)
+	atomic.AddUint64(&t.layout, 1)
+	if a.hook != nil {
+		a.hook(mat.VecDenseCopyOf(a.minDims), mat.VecDenseCopyOf(a.maxDims))
+	}
+	return nil
+}
+
+// lockAdaptive locks the receiver against concurrent encoding if it
+// adapts its bounds, since encoding a vector may then rescale its
+// tilings. It must be followed by unlockAdaptive once the vectors
+// observed by the receiver have been encoded.
+func (t *TileCoder) lockAdaptive() {
+	if t.adaptive != nil {
+		t.adaptive.mu.Lock()
+	}
+}
+
+// unlockAdaptive unlocks the receiver after lockAdaptive
+func (t *TileCoder) unlockAdaptive() {
+	if t.adaptive != nil {
+		t.adaptive.mu.Unlock()
+	}
+}
+
+// observe widens the bounds of the receiver to include v, rescaling
+// its tilings if needed. If the TileCoder does not adapt its bounds,
+// observe does nothing. The receiver must be locked by lockAdaptive.
+// Vectors which cannot be encoded, since they have the wrong number of
+// dimensions or a non-finite feature rejected by some tiling, are not
+// observed, and the error from validate is returned instead. If the
+// tilings cannot be rescaled to the widened bounds, for example
+// because a bound would overflow, an error is returned. In either
+// case, the receiver is unchanged.
+func (t *TileCoder) observe(v mat.Vector) error {
+	a := t.adaptive
+	if a == nil {
+		return nil
+	}
+	if err := t.validate(v); err != nil {
+		return err
+	}
+
+	var minDims, maxDims *mat.VecDense
+	for i := 0; i < v.Len() && i < a.minDims.Len(); i++ {
+		if min, max, changed := a.extend(i, v.AtVec(i), v.AtVec(i)); changed {
+			minDims, maxDims = a.widen(minDims, maxDims, i, min, max)
+		}
+	}
+	if minDims == nil {
+		return nil
+	}
+	return a.rescale(t, minDims, maxDims)
+}
+
+// observeBatch widens the bounds of the receiver to include each
+// vector in the batch b, rescaling its tilings at most once, as in
+// observe. If any vector in the batch cannot be encoded, no vector is
+// observed.
+func (t *TileCoder) observeBatch(b *mat.Dense) error {
+	a := t.adaptive
+	if a == nil {
+		return nil
+	}
+	if err := t.validateBatch(b); err != nil {
+		return err
+	}
+
+	rows, _ := b.Dims()
+	var minDims, maxDims *mat.VecDense
+	for i := 0; i < rows && i < a.minDims.Len(); i++ {
+		row := b.RawRowView(i)
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, x := range row {
+			// NaN features, which some policies allow, are skipped
+			if x < lo {
+				lo = x
+			}
+			if x > hi {
+				hi = x
+			}
+		}
+		if min, max, changed := a.extend(i, lo, hi); changed {
+			minDims, maxDims = a.widen(minDims, maxDims, i, min, max)
+		}
+	}
+	if minDims == nil {
+		return nil
+	}
+	return a.rescale(t, minDims, maxDims)
+}
+
+// widen sets the bounds of dimension i in minDims and maxDims to min
+// and max, first copying the bounds of the receiver if minDims and
+// maxDims are nil, so that the bounds of the receiver are copied only
+// when they change
+func (a *adaptiveBounds) widen(minDims, maxDims *mat.VecDense, i int, min,
+	max float64) (*mat.VecDense, *mat.VecDense) {
+	if minDims == nil {
+		minDims = mat.VecDenseCopyOf(a.minDims)
+		maxDims = mat.VecDenseCopyOf(a.maxDims)
+	}
+	minDims.SetVec(i, min)
+	maxDims.SetVec(i, max)
+	return minDims, maxDims
+}
+
+// SetBounds moves the bounds of each input dimension of the receiver
+// to minDims and maxDims, so that a TileCoder can be re-targeted when
+// the ranges of observations change, for example between tasks. The
+// number of bins along each dimension is kept, and bin lengths, bin
+// edges, and offsets are stretched so that each offset remains the
+// same fraction of the width of a tile. Each feature thus covers the
+// same relative region of the new bounds as it did of the old bounds,
+// and VecLength is unchanged. Categorical dimensions are never
+// rescaled, and their bounds are ignored. If the receiver adapts its
+// bounds, adaptation continues from the new bounds, and its BoundsHook
+// is called.
+//
+// An error wrapping ErrNilInput is returned if either bound is nil, a
+// *DimensionError if the bounds do not have InputDims() dimensions,
+// and an error if the bounds of some tiled dimension are invalid. If
+// an error is returned, the receiver is unchanged. SetBounds must not
+// be called concurrently with encoding.
+func (t *TileCoder) SetBounds(minDims, maxDims mat.Vector) error {
+	if isNil(minDims) || isNil(maxDims) {
+		return fmt.Errorf("setBounds: %w", ErrNilInput)
+	}
+	for _, bound := range []mat.Vector{minDims, maxDims} {
+		if bound.Len() != t.inputDims {
+			return fmt.Errorf("setBounds: %w", &DimensionError{
+				Have: bound.Len(),
+				Want: t.inputDims,
+			})
+		}
+	}
+	for k, tiling := range t.tilings {
+		if err := tiling.checkRescale(minDims, maxDims); err != nil {
+			return fmt.Errorf("setBounds: tiling %d: %v", k, err)
+		}
+	}
+
+	if a := t.adaptive; a != nil {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+
+		newMin := mat.VecDenseCopyOf(a.minDims)
+		newMax := mat.VecDenseCopyOf(a.maxDims)
+		for i := 0; i < a.minDims.Len(); i++ {
+			if !a.fixed[i] {
+				newMin.SetVec(i, minDims.AtVec(i))
+				newMax.SetVec(i, maxDims.AtVec(i))
+			}
+		}
+		if err := a.rescale(t, newMin, newMax); err != nil {
+			return fmt.Errorf("setBounds: %v", err)
+		}
+		return nil
+	}
+	for _, tiling := range t.tilings {
+		// The bounds have been validated, so no error can occur
+		if err := tiling.rescale(minDims, maxDims); err != nil {
+			panic(err)
+		}
+	}
+	t.uniform = newUniformTilings(t.tilings)
+	atomic.AddUint64(&t.layout, 1)
+	return nil
+}
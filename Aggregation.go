@@ -0,0 +1,100 @@
+package gotile
+
+import (
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Cell returns the index of the cell within which the vector v falls
+// when the tiling is not offset, in [0, Tiles()). Cells are the tiles
+// of the tiling moved back to the origin, so that the cells of tilings
+// which differ only in their offsets coincide. If the tiling uses the
+// BoundsError policy and v falls outside its bounds, Cell panics. See
+// TryCell for a non-panicking variant.
+func (t *Tiling) Cell(v mat.Vector) int {
+	cell, err := t.TryCell(v)
+	if err != nil {
+		panic(err)
+	}
+	return cell
+}
+
+// TryCell returns the index of the cell within which the vector v
+// falls, as in Cell. If the tiling uses the BoundsError policy and v
+// falls outside its bounds, an error wrapping ErrOutOfBounds is
+// returned, and if v is nil or does not have each dimension tiled by
+// the tiling, an error wrapping ErrNilInput or ErrDimension is
+// returned.
+func (t *Tiling) TryCell(v mat.Vector) (int, error) {
+	if err := t.checkVector(v); err != nil {
+		return 0, fmt.Errorf("cell: %w", err)
+	}
+	if t.nonFinite == NonFiniteTile && t.anyNonFinite(v) {
+		return t.nonFiniteTile(), nil
+	}
+	cell := 0
+	for i := len(t.bins) - 1; i > -1; i-- {
+		tile, err := t.placeOffset(i, v.AtVec(t.dims[i]), 0)
+		if err != nil {
+			return 0, fmt.Errorf("cell: %w", err)
+		}
+		cell += tile * t.strides[i]
+	}
+	return cell, nil
+}
+
+// StateID returns a single integer identifying the cell of tiling
+// number tiling, ignoring its offset, within which v falls. State IDs
+// are in [0, NumStates(tiling)), so that the receiver can aggregate
+// states for tabular algorithms which require a discretized state
+// rather than a feature vector. StateID panics if tiling is out of
+// range, or if the tiling uses the BoundsError policy and v falls
+// outside its bounds. See TryStateID for a non-panicking variant.
+func (t *TileCoder) StateID(v mat.Vector, tiling int) int {
+	id, err := t.TryStateID(v, tiling)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// TryStateID returns a single integer identifying the cell of tiling
+// number tiling within which v falls, as in StateID. If tiling is out
+// of range an error is returned, and if the tiling uses the
+// BoundsError policy and v falls outside its bounds, an error wrapping
+// ErrOutOfBounds is returned.
+func (t *TileCoder) TryStateID(v mat.Vector, tiling int) (int, error) {
+	if tiling < 0 || tiling >= len(t.tilings) {
+		return 0, fmt.Errorf("stateID: tiling %d out of range [0, %d)",
+			tiling, len(t.tilings))
+	}
+	id, err := t.tilings[tiling].TryCell(v)
+	if err != nil {
+		return 0, fmt.Errorf("stateID: %w", err)
+	}
+	return id, nil
+}
+
+// NumStates returns the number of distinct state IDs of tiling number
+// tiling, which is the number of tiles in the tiling. NumStates panics
+// if tiling is out of range. See TryNumStates for a non-panicking
+// variant.
+func (t *TileCoder) NumStates(tiling int) int {
+	n, err := t.TryNumStates(tiling)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// TryNumStates returns the number of distinct state IDs of tiling
+// number tiling, as in NumStates. If tiling is out of range, an error
+// is returned.
+func (t *TileCoder) TryNumStates(tiling int) (int, error) {
+	if tiling < 0 || tiling >= len(t.tilings) {
+		return 0, fmt.Errorf("numStates: tiling %d out of range [0, %d)",
+			tiling, len(t.tilings))
+	}
+	return t.tilings[tiling].Tiles(), nil
+}
@@ -0,0 +1,356 @@
+package gotile
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Headers of the binary formats of tilings and tile coders. The last
+// byte of each header is the FormatVersion of the data. The binary
+// format was introduced in version 1, so version 0 binary data does not
+// exist.
+var (
+	tilingHeader    = []byte{'G', 'T', 'T', FormatVersion}
+	tileCoderHeader = []byte{'G', 'T', 'C', FormatVersion}
+)
+
+// errShortBuffer is returned when decoding truncated binary data
+var errShortBuffer = errors.New("unexpected end of data")
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+// The entire state of the tiling is encoded in a compact, versioned
+// layout which is much smaller than the JSON encoding.
+func (t *Tiling) MarshalBinary() ([]byte, error) {
+	buf := append([]byte(nil), tilingHeader...)
+	return appendTilingState(buf, t.state()), nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface
+func (t *Tiling) UnmarshalBinary(data []byte) error {
+	r := &binaryReader{data: data}
+	if err := r.header(tilingHeader); err != nil {
+		return fmt.Errorf("unmarshalBinary: %v", err)
+	}
+
+	s := r.tilingState()
+	if r.err == nil && len(r.data) != 0 {
+		r.err = fmt.Errorf("%d trailing bytes", len(r.data))
+	}
+	if r.err != nil {
+		return fmt.Errorf("unmarshalBinary: %v", r.err)
+	}
+
+	tiling, err := newTilingFromState(s)
+	if err != nil {
+		return fmt.Errorf("unmarshalBinary: %v", err)
+	}
+	*t = *tiling
+	return nil
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+// The entire state of the tile coder is encoded in a compact,
+// versioned layout which is much smaller than the JSON encoding. A
+// BoundsHook cannot be encoded; see SetBoundsHook.
+func (t *TileCoder) MarshalBinary() ([]byte, error) {
+	s := t.state()
+	buf := append([]byte(nil), tileCoderHeader...)
+
+	buf = appendBool(buf, s.IncludeBias)
+	buf = appendUvarint(buf, uint64(len(s.Tilings)))
+	for i := range s.Tilings {
+		buf = appendTilingState(buf, s.Tilings[i])
+	}
+
+	buf = appendBool(buf, s.Adaptive != nil)
+	if a := s.Adaptive; a != nil {
+		buf = appendUvarint(buf, uint64(len(a.MinDims)))
+		buf = appendFloat(buf, a.Margin)
+		for i := range a.MinDims {
+			buf = appendFloat(buf, a.MinDims[i])
+			buf = appendFloat(buf, a.MaxDims[i])
+			buf = appendBool(buf, a.Fixed[i])
+			buf = appendBool(buf, a.Log[i])
+		}
+	}
+
+	buf = appendBool(buf, s.Activations != nil)
+	for _, activation := range s.Activations {
+		buf = appendFloat(buf, activation)
+	}
+	buf = appendUvarint(buf, uint64(s.InputDims))
+	return buf, nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface
+func (t *TileCoder) UnmarshalBinary(data []byte) error {
+	r := &binaryReader{data: data}
+	if err := r.header(tileCoderHeader); err != nil {
+		return fmt.Errorf("unmarshalBinary: %v", err)
+	}
+
+	var s tileCoderState
+	s.IncludeBias = r.bool()
+	s.Tilings = make([]tilingState, r.length())
+	for i := range s.Tilings {
+		s.Tilings[i] = r.tilingState()
+	}
+
+	if r.bool() {
+		n := r.length()
+		a := &adaptiveState{
+			Margin:  r.float(),
+			MinDims: make(floats, n),
+			MaxDims: make(floats, n),
+			Fixed:   make([]bool, n),
+			Log:     make([]bool, n),
+		}
+		for i := 0; i < n; i++ {
+			a.MinDims[i] = r.float()
+			a.MaxDims[i] = r.float()
+			a.Fixed[i] = r.bool()
+			a.Log[i] = r.bool()
+		}
+		s.Adaptive = a
+	}
+
+	if r.bool() {
+		s.Activations = make([]float64, len(s.Tilings))
+		for i := range s.Activations {
+			s.Activations[i] = r.float()
+		}
+	}
+
+	s.InputDims = int(r.uvarint())
+
+	if r.err == nil && len(r.data) != 0 {
+		r.err = fmt.Errorf("%d trailing bytes", len(r.data))
+	}
+	if r.err != nil {
+		return fmt.Errorf("unmarshalBinary: %v", r.err)
+	}
+	if err := t.setState(s); err != nil {
+		return fmt.Errorf("unmarshalBinary: %v", err)
+	}
+	return nil
+}
+
+// appendTilingState appends the binary encoding of s to buf
+func appendTilingState(buf []byte, s tilingState) []byte {
+	buf = appendUvarint(buf, uint64(len(s.Bins)))
+	buf = appendUint64(buf, s.Seed)
+	buf = append(buf, byte(s.Policy))
+
+	for i := range s.Bins {
+		buf = appendUvarint(buf, uint64(s.Bins[i]))
+		buf = appendUvarint(buf, uint64(s.Dims[i]))
+		buf = append(buf, byte(s.Scales[i]), byte(s.Squashes[i].Func))
+		buf = appendBool(buf, s.Categories[i])
+		buf = appendFloat(buf, s.Squashes[i].Scale)
+		buf = appendFloat(buf, s.Offsets[i])
+		buf = appendFloat(buf, s.BinLengths[i])
+		buf = appendFloat(buf, s.MinDims[i])
+		buf = appendFloat(buf, s.Low[i])
+		buf = appendFloat(buf, s.High[i])
+
+		buf = appendUvarint(buf, uint64(len(s.Edges[i])))
+		for _, e := range s.Edges[i] {
+			buf = appendFloat(buf, e)
+		}
+	}
+
+	buf = appendBool(buf, s.Rotation != nil)
+	for _, r := range s.Rotation {
+		buf = appendFloat(buf, r)
+	}
+	buf = append(buf, byte(s.NonFinite))
+	return buf
+}
+
+// appendUvarint appends the varint encoding of x to buf
+func appendUvarint(buf []byte, x uint64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], x)
+	return append(buf, b[:n]...)
+}
+
+// appendUint64 appends the fixed-width encoding of x to buf
+func appendUint64(buf []byte, x uint64) []byte {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], x)
+	return append(buf, b[:]...)
+}
+
+// appendFloat appends the binary encoding of x to buf
+func appendFloat(buf []byte, x float64) []byte {
+	return appendUint64(buf, math.Float64bits(x))
+}
+
+// appendBool appends the binary encoding of b to buf
+func appendBool(buf []byte, b bool) []byte {
+	if b {
+		return append(buf, 1)
+	}
+	return append(buf, 0)
+}
+
+// binaryReader decodes binary data, recording the first error which
+// occurs. Once an error occurs, all further reads return zero values.
+type binaryReader struct {
+	data []byte
+	err  error
+}
+
+// header consumes and checks the header of the data
+func (r *binaryReader) header(want []byte) error {
+	if len(r.data) < len(want) {
+		return errShortBuffer
+	}
+	for i := 0; i < len(want)-1; i++ {
+		if r.data[i] != want[i] {
+			return fmt.Errorf("invalid header")
+		}
+	}
+	if version := int(r.data[len(want)-1]); version < 1 {
+		return fmt.Errorf("unsupported format version %d", version)
+	} else if err := checkVersion(version); err != nil {
+		return err
+	}
+	r.data = r.data[len(want):]
+	return nil
+}
+
+// uvarint decodes an unsigned varint
+func (r *binaryReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	x, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.err = errShortBuffer
+		return 0
+	}
+	r.data = r.data[n:]
+	return x
+}
+
+// length decodes the length of a sequence, ensuring that it is small
+// enough that each element could take at least one byte of the data
+func (r *binaryReader) length() int {
+	n := r.uvarint()
+	if r.err == nil && n > uint64(len(r.data)) {
+		r.err = errShortBuffer
+		return 0
+	}
+	return int(n)
+}
+
+// uint64 decodes a fixed-width uint64
+func (r *binaryReader) uint64() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.data) < 8 {
+		r.err = errShortBuffer
+		return 0
+	}
+	x := binary.LittleEndian.Uint64(r.data)
+	r.data = r.data[8:]
+	return x
+}
+
+// byte decodes a single byte
+func (r *binaryReader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.data) < 1 {
+		r.err = errShortBuffer
+		return 0
+	}
+	b := r.data[0]
+	r.data = r.data[1:]
+	return b
+}
+
+// float decodes a float64
+func (r *binaryReader) float() float64 {
+	return math.Float64frombits(r.uint64())
+}
+
+// bool decodes a bool
+func (r *binaryReader) bool() bool {
+	return r.byte() != 0
+}
+
+// square decodes an n x n matrix of float64s in row-major order,
+// ensuring that the data holds every element before allocating it
+func (r *binaryReader) square(n int) floats {
+	if r.err != nil {
+		return nil
+	}
+	if n > MaxFeatures {
+		r.err = fmt.Errorf("%d x %d matrix: %w", n, n, featureSpaceError())
+		return nil
+	}
+	if n > 0 && n > len(r.data)/8/n {
+		r.err = errShortBuffer
+		return nil
+	}
+
+	m := make(floats, n*n)
+	for i := range m {
+		m[i] = r.float()
+	}
+	return m
+}
+
+// tilingState decodes the state of a tiling
+func (r *binaryReader) tilingState() tilingState {
+	n := r.length()
+	s := tilingState{
+		Seed:       r.uint64(),
+		Policy:     BoundsPolicy(r.byte()),
+		Offsets:    make(floats, n),
+		Bins:       make([]int, n),
+		BinLengths: make(floats, n),
+		MinDims:    make(floats, n),
+		Scales:     make([]Scale, n),
+		Edges:      make([]floats, n),
+		Categories: make([]bool, n),
+		Dims:       make([]int, n),
+		Low:        make(floats, n),
+		High:       make(floats, n),
+		Squashes:   make([]Squash, n),
+	}
+
+	for i := 0; i < n; i++ {
+		s.Bins[i] = int(r.uvarint())
+		s.Dims[i] = int(r.uvarint())
+		s.Scales[i] = Scale(r.byte())
+		s.Squashes[i].Func = SquashFunc(r.byte())
+		s.Categories[i] = r.bool()
+		s.Squashes[i].Scale = r.float()
+		s.Offsets[i] = r.float()
+		s.BinLengths[i] = r.float()
+		s.MinDims[i] = r.float()
+		s.Low[i] = r.float()
+		s.High[i] = r.float()
+
+		if edges := r.length(); edges > 0 {
+			s.Edges[i] = make(floats, edges)
+			for j := range s.Edges[i] {
+				s.Edges[i][j] = r.float()
+			}
+		}
+	}
+
+	if r.bool() {
+		s.Rotation = r.square(n)
+	}
+
+	s.NonFinite = NonFinitePolicy(r.byte())
+	return s
+}
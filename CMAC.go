@@ -0,0 +1,91 @@
+package gotile
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// NewCMAC returns a new TileCoder with the semantics of the Cerebellar
+// Model Articulation Controller (CMAC) of Albus (1975). Each input
+// dimension i is quantized into cells[i] cells of equal width between
+// minDims and maxDims, and the generalization parameter gives both the
+// number of tilings, or layers, and the width of each tile in cells.
+// Layer k is displaced by k cells along every dimension, so that each
+// input activates generalization association cells, one in each layer,
+// and inputs which are fewer than generalization cells apart share
+// association cells.
+//
+// Association cells are indexed layer by layer, as in New, where layer
+// k has ⌈(cells[i] + generalization - 1) / generalization⌉ tiles along
+// dimension i. Each layer therefore covers the bounds minDims to
+// maxDims, extended at the upper bound to a whole number of tiles.
+//
+// Options which place tiles differently, such as WithScales,
+// WithEdges, WithSquashes, WithCategorical, WithDims, and WithGroups,
+// cannot be used. Other Options, such as WithBoundsPolicy, are applied
+// as in New.
+func NewCMAC(minDims, maxDims mat.Vector, cells []int, generalization int,
+	includeBias bool, opts ...Option) (*TileCoder, error) {
+	if isNil(minDims) || isNil(maxDims) {
+		return nil, fmt.Errorf("newCMAC: %w", ErrNilInput)
+	}
+	if minDims.Len() != maxDims.Len() || minDims.Len() != len(cells) {
+		return nil, fmt.Errorf("newCMAC: bounds and cells must have the "+
+			"same length: %d, %d, %d", minDims.Len(), maxDims.Len(),
+			len(cells))
+	}
+	if generalization < 1 {
+		return nil, fmt.Errorf("newCMAC: generalization must be at "+
+			"least 1: %d", generalization)
+	}
+
+	cfg, err := newConfig(opts)
+	if err != nil {
+		return nil, fmt.Errorf("newCMAC: %v", err)
+	}
+	if cfg.scales != nil || cfg.edges != nil || cfg.squashes != nil ||
+		cfg.categorical != nil || cfg.dims != nil || cfg.groups != nil {
+		return nil, fmt.Errorf("newCMAC: options which place tiles cannot " +
+			"be used")
+	}
+
+	// Each tile spans generalization cells, with enough tiles that the
+	// most displaced layer still covers the bounds
+	width := make([]float64, len(cells))
+	upper := mat.NewVecDense(len(cells), nil)
+	tileBins := make([]int, len(cells))
+	for i, c := range cells {
+		min, max := minDims.AtVec(i), maxDims.AtVec(i)
+		if c < 1 {
+			return nil, fmt.Errorf("newCMAC: cannot have less than 1 cell "+
+				"along dimension %d", i)
+		}
+		if math.IsInf(min, 0) || math.IsInf(max, 0) || max <= min {
+			return nil, fmt.Errorf("newCMAC: bounds of dimension %d must be "+
+				"finite with maximum exceeding minimum: [%v, %v]", i, min, max)
+		}
+		width[i] = (max - min) / float64(c)
+		tileBins[i] = (c + 2*generalization - 2) / generalization
+		upper.SetVec(i, min+float64(tileBins[i]*generalization)*width[i])
+	}
+
+	bins := make([][]int, generalization)
+	for k := range bins {
+		bins[k] = append([]int(nil), tileBins...)
+	}
+	tc, err := New(minDims, upper, bins, 0, includeBias, OffsetDiv, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("newCMAC: %v", err)
+	}
+
+	// Displace layer k by k cells along every dimension
+	for k, tiling := range tc.tilings {
+		for i := range cells {
+			tiling.offsets.Set(0, i, float64(k)*width[i])
+		}
+	}
+	tc.uniform = newUniformTilings(tc.tilings)
+	return tc, nil
+}
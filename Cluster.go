@@ -0,0 +1,117 @@
+package gotile
+
+import (
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Cluster is a group of observations with identical or highly
+// overlapping tile-coded representations
+type Cluster struct {
+	// Exemplar is the first observation of the cluster, whose active
+	// tiles are compared with those of each new observation
+	Exemplar *mat.VecDense
+
+	// Center is the mean of the observations of the cluster
+	Center *mat.VecDense
+
+	// Size is the number of observations in the cluster
+	Size int
+}
+
+// Clusterer groups a dataset or stream of observations into clusters
+// of observations sharing many active tiles, for state abstraction
+// analysis and dataset exploration. Each observation joins the cluster
+// whose exemplar shares the most active tiles with it, if the exemplar
+// shares at least the minimum overlap, and otherwise founds a new
+// cluster with itself as the exemplar. Clustering is online, so a
+// single pass over the observations is made, and the clusters found
+// depend on the order of the observations.
+//
+// A Clusterer is not safe for concurrent use.
+type Clusterer struct {
+	exemplars  *TileIndex
+	minOverlap int
+	clusters   []Cluster
+}
+
+// NewClusterer returns a new Clusterer which encodes observations with
+// coder. An observation joins a cluster if it falls in the same tile
+// as the cluster's exemplar in at least minOverlap tilings, which must
+// be between 1 and the number of tilings of coder. If minOverlap is
+// the number of tilings, only observations with identical tile-coded
+// representations are clustered together.
+func NewClusterer(coder *TileCoder, minOverlap int) (*Clusterer, error) {
+	exemplars, err := NewTileIndex(coder)
+	if err != nil {
+		return nil, fmt.Errorf("newClusterer: %w", err)
+	}
+	if minOverlap < 1 || minOverlap > coder.NumTilings() {
+		return nil, fmt.Errorf("newClusterer: minimum overlap must be in "+
+			"[1, %d]: %d", coder.NumTilings(), minOverlap)
+	}
+	return &Clusterer{exemplars: exemplars, minOverlap: minOverlap}, nil
+}
+
+// Add assigns v to a cluster and returns the index of the cluster in
+// Clusters. Errors are returned as in TryEncodeIndices.
+func (c *Clusterer) Add(v mat.Vector) (int, error) {
+	nearest, err := c.exemplars.Nearest(v, 1)
+	if err != nil {
+		return 0, fmt.Errorf("add: %w", err)
+	}
+
+	if len(nearest) == 1 && nearest[0].Overlap >= c.minOverlap {
+		cluster := &c.clusters[nearest[0].ID]
+		cluster.Size++
+
+		// Update the running mean of the cluster
+		diff := mat.NewVecDense(v.Len(), nil)
+		diff.SubVec(v, cluster.Center)
+		cluster.Center.AddScaledVec(cluster.Center,
+			1/float64(cluster.Size), diff)
+		return nearest[0].ID, nil
+	}
+
+	id, err := c.exemplars.Add(v)
+	if err != nil {
+		return 0, fmt.Errorf("add: %w", err)
+	}
+	c.clusters = append(c.clusters, Cluster{
+		Exemplar: c.exemplars.Sample(id),
+		Center:   mat.VecDenseCopyOf(v),
+		Size:     1,
+	})
+	return id, nil
+}
+
+// AddBatch assigns each vector in the batch b, where each column of b
+// is an observation, to a cluster, as in Add. Observations are added in
+// order, stopping at the first observation which cannot be encoded.
+func (c *Clusterer) AddBatch(b *mat.Dense) error {
+	if b == nil {
+		return fmt.Errorf("addBatch: %w", ErrNilInput)
+	}
+	_, cols := b.Dims()
+	for j := 0; j < cols; j++ {
+		if _, err := c.Add(b.ColView(j)); err != nil {
+			return fmt.Errorf("addBatch: observation %d: %w", j, err)
+		}
+	}
+	return nil
+}
+
+// Clusters returns a copy of each cluster found so far, in the order
+// in which they were founded
+func (c *Clusterer) Clusters() []Cluster {
+	clusters := make([]Cluster, len(c.clusters))
+	for i, cluster := range c.clusters {
+		clusters[i] = Cluster{
+			Exemplar: mat.VecDenseCopyOf(cluster.Exemplar),
+			Center:   mat.VecDenseCopyOf(cluster.Center),
+			Size:     cluster.Size,
+		}
+	}
+	return clusters
+}
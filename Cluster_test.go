@@ -0,0 +1,78 @@
+package gotile
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestClusterer(t *testing.T) {
+	tc, err := New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{2, 2}, {2, 2}},
+		1,
+		true,
+		1e300,
+		WithBoundsPolicy(BoundsError),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	c, err := NewClusterer(tc, tc.NumTilings())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Observations in the same quadrant have identical signatures
+	b := mat.NewDense(2, 5, []float64{
+		0.1, 0.9, 0.3, 0.2, 0.8,
+		0.1, 0.1, 0.3, 0.4, 0.2,
+	})
+	if err := c.AddBatch(b); err != nil {
+		t.Fatal(err)
+	}
+	clusters := c.Clusters()
+	if len(clusters) != 2 {
+		t.Fatalf("clusters: have(%d clusters) want(2)", len(clusters))
+	}
+	if clusters[0].Size != 3 || clusters[1].Size != 2 {
+		t.Errorf("clusters: have sizes(%d, %d) want(3, 2)", clusters[0].Size,
+			clusters[1].Size)
+	}
+	if !mat.Equal(clusters[0].Exemplar, b.ColView(0)) {
+		t.Errorf("clusters: have exemplar(%v) want(%v)",
+			clusters[0].Exemplar.RawVector().Data, []float64{0.1, 0.1})
+	}
+	want := mat.NewVecDense(2, []float64{0.2, 0.8 / 3})
+	if !mat.EqualApprox(clusters[0].Center, want, 1e-12) {
+		t.Errorf("clusters: have center(%v) want(%v)",
+			clusters[0].Center.RawVector().Data, want.RawVector().Data)
+	}
+
+	// Clusters are copies
+	clusters[0].Center.SetVec(0, math.NaN())
+	if math.IsNaN(c.Clusters()[0].Center.AtVec(0)) {
+		t.Error("clusters: modifying a returned cluster modified the receiver")
+	}
+
+	id, err := c.Add(mat.NewVecDense(2, []float64{0.4, 0.4}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 0 {
+		t.Errorf("add: have(%d) want(0)", id)
+	}
+	if _, err := c.Add(mat.NewVecDense(2, []float64{2, 0})); !errors.Is(err,
+		ErrOutOfBounds) {
+		t.Errorf("add: have(%v) want(%v)", err, ErrOutOfBounds)
+	}
+
+	for _, minOverlap := range []int{0, tc.NumTilings() + 1} {
+		if _, err := NewClusterer(tc, minOverlap); err == nil {
+			t.Errorf("newClusterer(%d): expected error", minOverlap)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package gotile
+
+import (
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Coder constructs feature vectors from input vectors. TileCoder
+// implements Coder, so that code written against Coder can swap tile
+// coding for another feature construction without changing types.
+//
+// A Coder panics when a vector cannot be encoded, as TileCoder does.
+// See TryCoder for Coders which can instead return errors.
+type Coder interface {
+	// Encode returns the feature vector of v, which has length
+	// VecLength()
+	Encode(v mat.Vector) *mat.VecDense
+
+	// EncodeIndices returns the indices of the non-zero features of
+	// the feature vector of v
+	EncodeIndices(v mat.Vector) []float64
+
+	// EncodeBatch returns the feature vectors of each vector in the
+	// batch b, where each column of b is a vector to encode and each
+	// column of the returned matrix is the feature vector of the
+	// corresponding column of b
+	EncodeBatch(b *mat.Dense) *mat.Dense
+
+	// VecLength returns the number of features in each feature vector
+	VecLength() int
+}
+
+// TryCoder is a Coder which also provides non-panicking variants of
+// its encoding methods, returning an error wherever the Coder would
+// panic, for example when given a nil vector or a vector with the
+// wrong number of dimensions. Every Coder in this package implements
+// TryCoder, so that Coders can be embedded in long-running services
+// without recovering from panics.
+type TryCoder interface {
+	Coder
+
+	// TryEncode returns the feature vector of v, as in Encode
+	TryEncode(v mat.Vector) (*mat.VecDense, error)
+
+	// TryEncodeIndices returns the indices of the non-zero features
+	// of the feature vector of v, as in EncodeIndices
+	TryEncodeIndices(v mat.Vector) ([]float64, error)
+
+	// TryEncodeBatch returns the feature vectors of each vector in the
+	// batch b, as in EncodeBatch
+	TryEncodeBatch(b *mat.Dense) (*mat.Dense, error)
+}
+
+// tryEncode returns c.TryEncode(v) if c is a TryCoder. Otherwise, it
+// returns c.Encode(v), recovering from any panic as an error.
+func tryEncode(c Coder, v mat.Vector) (encoded *mat.VecDense, err error) {
+	if t, ok := c.(TryCoder); ok {
+		return t.TryEncode(v)
+	}
+	defer recoverError(&err)
+	return c.Encode(v), nil
+}
+
+// tryEncodeIndices returns c.TryEncodeIndices(v) if c is a TryCoder.
+// Otherwise, it returns c.EncodeIndices(v), recovering from any panic
+// as an error.
+func tryEncodeIndices(c Coder, v mat.Vector) (indices []float64,
+	err error) {
+	if t, ok := c.(TryCoder); ok {
+		return t.TryEncodeIndices(v)
+	}
+	defer recoverError(&err)
+	return c.EncodeIndices(v), nil
+}
+
+// tryEncodeBatch returns c.TryEncodeBatch(b) if c is a TryCoder.
+// Otherwise, it returns c.EncodeBatch(b), recovering from any panic as
+// an error.
+func tryEncodeBatch(c Coder, b *mat.Dense) (encoded *mat.Dense, err error) {
+	if t, ok := c.(TryCoder); ok {
+		return t.TryEncodeBatch(b)
+	}
+	defer recoverError(&err)
+	return c.EncodeBatch(b), nil
+}
+
+// recoverError recovers from a panic, storing the recovered value in
+// err. It must be deferred directly.
+func recoverError(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if e, ok := r.(error); ok {
+		*err = e
+	} else {
+		*err = fmt.Errorf("%v", r)
+	}
+}
+
+// Ensure TileCoder implements TryCoder
+var _ TryCoder = (*TileCoder)(nil)
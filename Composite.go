@@ -0,0 +1,386 @@
+package gotile
+
+import (
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// CompositeCoder concatenates the feature vectors of several child
+// Coders into a single feature vector. The features of child i occupy
+// the indices Offset(i) to Offset(i) + child.VecLength() - 1, so that a
+// coarse joint tiling, fine per-dimension tilings, and raw input
+// features can be mixed in one feature vector. Each child receives the
+// full input vector.
+//
+// The offsets of the children are computed from their current lengths
+// whenever the receiver is used, so that a child which grows after
+// construction, such as a TileCoder given a new tiling with AddTiling,
+// shifts the features of the children after it.
+type CompositeCoder struct {
+	coders []Coder
+}
+
+// NewComposite returns a new CompositeCoder concatenating the features
+// of coders, in order. At least one Coder must be given, and the Coders
+// are shared with the returned CompositeCoder.
+func NewComposite(coders ...Coder) (*CompositeCoder, error) {
+	if len(coders) == 0 {
+		return nil, fmt.Errorf("newComposite: cannot have less than 1 coder")
+	}
+	for i, c := range coders {
+		if c == nil {
+			return nil, fmt.Errorf("newComposite: coder %d is nil", i)
+		}
+	}
+
+	c := &CompositeCoder{coders: append([]Coder(nil), coders...)}
+	if _, _, err := c.layout(); err != nil {
+		return nil, fmt.Errorf("newComposite: %w", err)
+	}
+	return c, nil
+}
+
+// layout returns the index of the first feature of each child and the
+// total number of features, using the current length of each child. An
+// error wrapping ErrFeatureSpace is returned if the children have more
+// than MaxFeatures features in total.
+func (c *CompositeCoder) layout() (offsets []int, length int, err error) {
+	offsets = make([]int, len(c.coders))
+	for i, coder := range c.coders {
+		offsets[i] = length
+		if length, err = addFeatures(length, coder.VecLength()); err != nil {
+			return nil, 0, err
+		}
+	}
+	return offsets, length, nil
+}
+
+// mustLayout returns the layout of the receiver, panicking if the
+// children have more than MaxFeatures features in total
+func (c *CompositeCoder) mustLayout() (offsets []int, length int) {
+	offsets, length, err := c.layout()
+	if err != nil {
+		panic(err)
+	}
+	return offsets, length
+}
+
+// Coders returns the child Coders of the receiver, in order
+func (c *CompositeCoder) Coders() []Coder {
+	return append([]Coder(nil), c.coders...)
+}
+
+// Offset returns the index of the first feature of child i in the
+// feature vectors of the receiver
+func (c *CompositeCoder) Offset(i int) int {
+	offsets, _ := c.mustLayout()
+	return offsets[i]
+}
+
+// Locate returns the child whose features include the feature at
+// index, and the index of that feature in the feature vectors of the
+// child. If index is out of range, Locate panics. See TryLocate for a
+// non-panicking variant.
+func (c *CompositeCoder) Locate(index int) (child, local int) {
+	child, local, err := c.TryLocate(index)
+	if err != nil {
+		panic(err)
+	}
+	return child, local
+}
+
+// TryLocate returns the child whose features include the feature at
+// index, and the index of that feature in the feature vectors of the
+// child, as in Locate. If index is out of range, an error is returned.
+func (c *CompositeCoder) TryLocate(index int) (child, local int, err error) {
+	offsets, length, err := c.layout()
+	if err != nil {
+		return 0, 0, fmt.Errorf("locate: %w", err)
+	}
+	if index < 0 || index >= length {
+		return 0, 0, fmt.Errorf("locate: index %d out of range [0, %d)",
+			index, length)
+	}
+
+	// Find the last child starting at or before index, skipping
+	// children without features
+	child = len(offsets) - 1
+	for offsets[child] > index || c.coders[child].VecLength() == 0 {
+		child--
+	}
+	return child, index - offsets[child], nil
+}
+
+// VecLength returns the number of features in each encoded vector,
+// which is the total number of features of the children. If the
+// children have grown to more than MaxFeatures features, VecLength
+// panics.
+func (c *CompositeCoder) VecLength() int {
+	_, length := c.mustLayout()
+	return length
+}
+
+// Encode returns the concatenated feature vectors of each child for v.
+// If some child cannot encode v, Encode panics. See TryEncode for a
+// non-panicking variant.
+func (c *CompositeCoder) Encode(v mat.Vector) *mat.VecDense {
+	encoded, err := c.TryEncode(v)
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
+// TryEncode returns the concatenated feature vectors of each child for
+// v, as in Encode. If some child cannot encode v, an error is returned.
+// Children which are not TryCoders are encoded with Encode, and any
+// panic is returned as an error.
+func (c *CompositeCoder) TryEncode(v mat.Vector) (*mat.VecDense, error) {
+	if isNil(v) {
+		return nil, fmt.Errorf("encode: %w", ErrNilInput)
+	}
+	offsets, length, err := c.layout()
+	if err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+	encoded := mat.NewVecDense(length, nil)
+	for i, coder := range c.coders {
+		features, err := tryEncode(coder, v)
+		if err != nil {
+			return nil, fmt.Errorf("encode: coder %d: %w", i, err)
+		}
+		for j := 0; j < features.Len(); j++ {
+			encoded.SetVec(offsets[i]+j, features.AtVec(j))
+		}
+	}
+	return encoded, nil
+}
+
+// EncodeIndices returns the indices of the non-zero features of v,
+// which are the indices returned by each child offset by Offset of the
+// child, in the order of the children. If some child cannot encode v,
+// EncodeIndices panics. See TryEncodeIndices for a non-panicking
+// variant.
+func (c *CompositeCoder) EncodeIndices(v mat.Vector) []float64 {
+	indices, err := c.TryEncodeIndices(v)
+	if err != nil {
+		panic(err)
+	}
+	return indices
+}
+
+// TryEncodeIndices returns the indices of the non-zero features of v,
+// as in EncodeIndices. Errors are returned as in TryEncode.
+func (c *CompositeCoder) TryEncodeIndices(v mat.Vector) ([]float64, error) {
+	if isNil(v) {
+		return nil, fmt.Errorf("encodeIndices: %w", ErrNilInput)
+	}
+	offsets, _, err := c.layout()
+	if err != nil {
+		return nil, fmt.Errorf("encodeIndices: %w", err)
+	}
+	var indices []float64
+	for i, coder := range c.coders {
+		childIndices, err := tryEncodeIndices(coder, v)
+		if err != nil {
+			return nil, fmt.Errorf("encodeIndices: coder %d: %w", i, err)
+		}
+		offset := float64(offsets[i])
+		for _, index := range childIndices {
+			indices = append(indices, index+offset)
+		}
+	}
+	return indices, nil
+}
+
+// EncodeBatch returns the concatenated feature vectors of each child
+// for each vector in the batch b. Each column of b is a vector to
+// encode, and each column of the returned matrix is the encoding of the
+// corresponding column of b. If some child cannot encode b,
+// EncodeBatch panics. See TryEncodeBatch for a non-panicking variant.
+func (c *CompositeCoder) EncodeBatch(b *mat.Dense) *mat.Dense {
+	encoded, err := c.TryEncodeBatch(b)
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
+// TryEncodeBatch returns the concatenated feature vectors of each child
+// for each vector in the batch b, as in EncodeBatch. Errors are
+// returned as in TryEncode.
+func (c *CompositeCoder) TryEncodeBatch(b *mat.Dense) (*mat.Dense, error) {
+	if b == nil {
+		return nil, fmt.Errorf("encodeBatch: %w", ErrNilInput)
+	}
+	offsets, length, err := c.layout()
+	if err != nil {
+		return nil, fmt.Errorf("encodeBatch: %w", err)
+	}
+	_, batchSize := b.Dims()
+	encoded := mat.NewDense(length, batchSize, nil)
+	for i, coder := range c.coders {
+		n := coder.VecLength()
+		if n == 0 {
+			continue
+		}
+		features, err := tryEncodeBatch(coder, b)
+		if err != nil {
+			return nil, fmt.Errorf("encodeBatch: coder %d: %w", i, err)
+		}
+		rows := encoded.Slice(offsets[i], offsets[i]+n, 0,
+			batchSize).(*mat.Dense)
+		rows.Copy(features)
+	}
+	return encoded, nil
+}
+
+// PassThroughCoder is a Coder whose features are the values of some
+// dimensions of the input vectors, unchanged. It is used with
+// CompositeCoder to include raw input features alongside constructed
+// features.
+type PassThroughCoder struct {
+	dims []int
+}
+
+// NewPassThrough returns a new PassThroughCoder whose features are the
+// input dimensions dims, in the given order
+func NewPassThrough(dims ...int) (*PassThroughCoder, error) {
+	if len(dims) == 0 {
+		return nil, fmt.Errorf("newPassThrough: must pass through at least " +
+			"one dimension")
+	}
+	for _, d := range dims {
+		if d < 0 {
+			return nil, fmt.Errorf("newPassThrough: dimension %d out of "+
+				"range", d)
+		}
+	}
+	return &PassThroughCoder{dims: append([]int(nil), dims...)}, nil
+}
+
+// VecLength returns the number of features in each encoded vector,
+// which is the number of dimensions passed through
+func (p *PassThroughCoder) VecLength() int {
+	return len(p.dims)
+}
+
+// Encode returns the values of the passed through dimensions of v. If
+// v does not have some passed through dimension, Encode panics. See
+// TryEncode for a non-panicking variant.
+func (p *PassThroughCoder) Encode(v mat.Vector) *mat.VecDense {
+	encoded, err := p.TryEncode(v)
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
+// TryEncode returns the values of the passed through dimensions of v,
+// as in Encode. If v is nil or does not have some passed through
+// dimension, an error wrapping ErrNilInput or ErrDimension is returned.
+func (p *PassThroughCoder) TryEncode(v mat.Vector) (*mat.VecDense, error) {
+	if err := p.check(v); err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+	encoded := mat.NewVecDense(len(p.dims), nil)
+	for i, d := range p.dims {
+		encoded.SetVec(i, v.AtVec(d))
+	}
+	return encoded, nil
+}
+
+// EncodeIndices returns the indices of the passed through dimensions of
+// v which are non-zero, in increasing order. If v does not have some
+// passed through dimension, EncodeIndices panics. See TryEncodeIndices
+// for a non-panicking variant.
+func (p *PassThroughCoder) EncodeIndices(v mat.Vector) []float64 {
+	indices, err := p.TryEncodeIndices(v)
+	if err != nil {
+		panic(err)
+	}
+	return indices
+}
+
+// TryEncodeIndices returns the indices of the passed through dimensions
+// of v which are non-zero, as in EncodeIndices. Errors are returned as
+// in TryEncode.
+func (p *PassThroughCoder) TryEncodeIndices(v mat.Vector) ([]float64,
+	error) {
+	if err := p.check(v); err != nil {
+		return nil, fmt.Errorf("encodeIndices: %w", err)
+	}
+	var indices []float64
+	for i, d := range p.dims {
+		if v.AtVec(d) != 0 {
+			indices = append(indices, float64(i))
+		}
+	}
+	return indices, nil
+}
+
+// EncodeBatch returns the values of the passed through dimensions of
+// each vector in the batch b. Each column of b is a vector to encode,
+// and each column of the returned matrix is the encoding of the
+// corresponding column of b. If the vectors do not have some passed
+// through dimension, EncodeBatch panics. See TryEncodeBatch for a
+// non-panicking variant.
+func (p *PassThroughCoder) EncodeBatch(b *mat.Dense) *mat.Dense {
+	encoded, err := p.TryEncodeBatch(b)
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
+// TryEncodeBatch returns the values of the passed through dimensions
+// of each vector in the batch b, as in EncodeBatch. If b is nil or the
+// vectors do not have some passed through dimension, an error wrapping
+// ErrNilInput or ErrDimension is returned.
+func (p *PassThroughCoder) TryEncodeBatch(b *mat.Dense) (*mat.Dense,
+	error) {
+	if b == nil {
+		return nil, fmt.Errorf("encodeBatch: %w", ErrNilInput)
+	}
+	rows, batchSize := b.Dims()
+	if want := p.inputDims(); rows < want {
+		return nil, fmt.Errorf("encodeBatch: %w", &DimensionError{Have: rows,
+			Want: want})
+	}
+	encoded := mat.NewDense(len(p.dims), batchSize, nil)
+	for i, d := range p.dims {
+		encoded.SetRow(i, b.RawRowView(d))
+	}
+	return encoded, nil
+}
+
+// check returns an error if v is nil or does not have some passed
+// through dimension
+func (p *PassThroughCoder) check(v mat.Vector) error {
+	if isNil(v) {
+		return ErrNilInput
+	}
+	if want := p.inputDims(); v.Len() < want {
+		return &DimensionError{Have: v.Len(), Want: want}
+	}
+	return nil
+}
+
+// inputDims returns the fewest dimensions of a vector which has each
+// passed through dimension
+func (p *PassThroughCoder) inputDims() int {
+	dims := 0
+	for _, d := range p.dims {
+		if d+1 > dims {
+			dims = d + 1
+		}
+	}
+	return dims
+}
+
+// Ensure CompositeCoder and PassThroughCoder implement TryCoder
+var (
+	_ TryCoder = (*CompositeCoder)(nil)
+	_ TryCoder = (*PassThroughCoder)(nil)
+)
@@ -0,0 +1,237 @@
+package gotile
+
+import (
+	"errors"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestCompositeCoder(t *testing.T) {
+	minDims := mat.NewVecDense(2, []float64{0, 0})
+	maxDims := mat.NewVecDense(2, []float64{1, 1})
+	coarse, err := New(minDims, maxDims, [][]int{{2, 2}, {2, 2}}, 1, true,
+		-1)
+	if err != nil {
+		t.Fatalf("could not create coarse tile coder: %v", err)
+	}
+	fine, err := New(minDims, maxDims, [][]int{{8}, {8}}, 2, false, -1,
+		WithGroups([][]int{{0}, {1}}))
+	if err != nil {
+		t.Fatalf("could not create fine tile coder: %v", err)
+	}
+	raw, err := NewPassThrough(1, 0)
+	if err != nil {
+		t.Fatalf("could not create pass-through coder: %v", err)
+	}
+
+	cc, err := NewComposite(coarse, fine, raw)
+	if err != nil {
+		t.Fatalf("could not create composite coder: %v", err)
+	}
+	want := coarse.VecLength() + fine.VecLength() + 2
+	if n := cc.VecLength(); n != want {
+		t.Errorf("vecLength: have(%v) want(%v)", n, want)
+	}
+
+	batch := mat.NewDense(2, 3, []float64{
+		0.1, 0.5, 0.9,
+		0.3, 0.0, 0.7,
+	})
+	encodedBatch := cc.EncodeBatch(batch)
+	for j := 0; j < 3; j++ {
+		v := batch.ColView(j)
+		encoded := cc.Encode(v)
+
+		// Each child's features occupy its own block of indices
+		for i, coder := range cc.Coders() {
+			child := coder.Encode(v)
+			block := encoded.SliceVec(cc.Offset(i), cc.Offset(i)+child.Len())
+			if !mat.Equal(block, child) {
+				t.Errorf("encode(%v): block %d: have(%v) want(%v)",
+					mat.Formatted(v.T()), i, mat.Formatted(block.T()),
+					mat.Formatted(child.T()))
+			}
+		}
+
+		// Indices are those of the non-zero features, and locate maps
+		// each back to its child
+		active := 0
+		for _, index := range cc.EncodeIndices(v) {
+			if encoded.AtVec(int(index)) == 0 {
+				t.Errorf("encodeIndices(%v): feature %v is zero",
+					mat.Formatted(v.T()), index)
+			}
+			active++
+
+			child, local := cc.Locate(int(index))
+			if have := cc.Coders()[child].Encode(v).AtVec(local); have !=
+				encoded.AtVec(int(index)) {
+				t.Errorf("locate(%v): have(%v, %v) which is %v want(%v)",
+					index, child, local, have, encoded.AtVec(int(index)))
+			}
+		}
+		if nonZero := nonZeros(encoded); active != nonZero {
+			t.Errorf("encodeIndices(%v): have(%v) indices want(%v)",
+				mat.Formatted(v.T()), active, nonZero)
+		}
+
+		if !mat.Equal(encodedBatch.ColView(j), encoded) {
+			t.Errorf("encodeBatch(%v): batch encoding differs from encode",
+				mat.Formatted(v.T()))
+		}
+	}
+
+	if _, err := NewComposite(); err == nil {
+		t.Error("expected error with no coders")
+	}
+}
+
+func TestCompositeCoderGrowingChild(t *testing.T) {
+	minDims := mat.NewVecDense(2, []float64{0, 0})
+	maxDims := mat.NewVecDense(2, []float64{1, 1})
+	tc, err := New(minDims, maxDims, [][]int{{2, 2}}, 1, false, -1)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	raw, err := NewPassThrough(0, 1)
+	if err != nil {
+		t.Fatalf("could not create pass-through coder: %v", err)
+	}
+	cc, err := NewComposite(tc, raw)
+	if err != nil {
+		t.Fatalf("could not create composite coder: %v", err)
+	}
+
+	// Grow the first child after constructing the composite coder
+	if err := tc.AddTiling(tc.Tilings()[0]); err != nil {
+		t.Fatalf("could not add tiling: %v", err)
+	}
+	if want := tc.VecLength() + 2; cc.VecLength() != want {
+		t.Errorf("vecLength: have(%v) want(%v)", cc.VecLength(), want)
+	}
+	if cc.Offset(1) != tc.VecLength() {
+		t.Errorf("offset(1): have(%v) want(%v)", cc.Offset(1),
+			tc.VecLength())
+	}
+
+	v := mat.NewVecDense(2, []float64{0.25, 0.75})
+	encoded, err := cc.TryEncode(v)
+	if err != nil {
+		t.Fatalf("tryEncode(%v): %v", mat.Formatted(v.T()), err)
+	}
+	for i, coder := range cc.Coders() {
+		child := coder.Encode(v)
+		block := encoded.SliceVec(cc.Offset(i), cc.Offset(i)+child.Len())
+		if !mat.Equal(block, child) {
+			t.Errorf("tryEncode(%v): block %d: have(%v) want(%v)",
+				mat.Formatted(v.T()), i, mat.Formatted(block.T()),
+				mat.Formatted(child.T()))
+		}
+	}
+
+	batch := mat.NewDense(2, 1, []float64{0.25, 0.75})
+	encodedBatch, err := cc.TryEncodeBatch(batch)
+	if err != nil {
+		t.Fatalf("tryEncodeBatch: %v", err)
+	}
+	if !mat.Equal(encodedBatch.ColView(0), encoded) {
+		t.Error("tryEncodeBatch: batch encoding differs from tryEncode")
+	}
+
+	indices, err := cc.TryEncodeIndices(v)
+	if err != nil {
+		t.Fatalf("tryEncodeIndices(%v): %v", mat.Formatted(v.T()), err)
+	}
+	for _, index := range indices {
+		if encoded.AtVec(int(index)) == 0 {
+			t.Errorf("tryEncodeIndices(%v): feature %v is zero",
+				mat.Formatted(v.T()), index)
+		}
+		child, _, err := cc.TryLocate(int(index))
+		if err != nil {
+			t.Errorf("tryLocate(%v): %v", index, err)
+		} else if int(index) < tc.VecLength() && child != 0 {
+			t.Errorf("tryLocate(%v): have(%v) want(0)", index, child)
+		}
+	}
+	if last := cc.VecLength() - 1; encoded.AtVec(last) != v.AtVec(1) {
+		t.Errorf("tryEncode(%v): last feature have(%v) want(%v)",
+			mat.Formatted(v.T()), encoded.AtVec(last), v.AtVec(1))
+	}
+}
+
+func TestTryCoders(t *testing.T) {
+	minDims := mat.NewVecDense(2, []float64{0, 0})
+	maxDims := mat.NewVecDense(2, []float64{1, 1})
+	tc, err := New(minDims, maxDims, [][]int{{2, 2}}, 1, true, -1)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	kc, err := NewKanerva(minDims, maxDims, 8, 2, 1, true)
+	if err != nil {
+		t.Fatalf("could not create kanerva coder: %v", err)
+	}
+	sc, err := NewSplitting(minDims, maxDims, []int{2, 2}, 2, true)
+	if err != nil {
+		t.Fatalf("could not create splitting coder: %v", err)
+	}
+	pc, err := NewPassThrough(1)
+	if err != nil {
+		t.Fatalf("could not create pass-through coder: %v", err)
+	}
+	cc, err := NewComposite(tc, kc, sc, pc)
+	if err != nil {
+		t.Fatalf("could not create composite coder: %v", err)
+	}
+	stc, err := NewStacked(tc, 2, 3, StackBlocks)
+	if err != nil {
+		t.Fatalf("could not create stacked coder: %v", err)
+	}
+
+	coders := map[string]TryCoder{
+		"kanerva":     kc,
+		"splitting":   sc,
+		"passThrough": pc,
+		"composite":   cc,
+		"stacked":     stc,
+	}
+	short := mat.NewVecDense(1, []float64{0.5})
+	for name, c := range coders {
+		if _, err := c.TryEncode(nil); !errors.Is(err, ErrNilInput) {
+			t.Errorf("%v.tryEncode(nil): have(%v) want(%v)", name, err,
+				ErrNilInput)
+		}
+		if _, err := c.TryEncodeBatch(nil); !errors.Is(err, ErrNilInput) {
+			t.Errorf("%v.tryEncodeBatch(nil): have(%v) want(%v)", name, err,
+				ErrNilInput)
+		}
+		if _, err := c.TryEncodeIndices(short); !errors.Is(err,
+			ErrDimension) {
+			t.Errorf("%v.tryEncodeIndices(%v): have(%v) want(%v)", name,
+				short, err, ErrDimension)
+		}
+
+		v := mat.NewVecDense(2, []float64{0.25, 0.75})
+		have, err := c.TryEncode(v)
+		if err != nil {
+			t.Errorf("%v.tryEncode(%v): %v", name, v, err)
+			continue
+		}
+		if want := c.Encode(v); !mat.Equal(have, want) {
+			t.Errorf("%v.tryEncode(%v): have(%v) want(%v)", name, v, have,
+				want)
+		}
+	}
+}
+
+// nonZeros returns the number of non-zero elements of v
+func nonZeros(v mat.Vector) int {
+	n := 0
+	for i := 0; i < v.Len(); i++ {
+		if v.AtVec(i) != 0 {
+			n++
+		}
+	}
+	return n
+}
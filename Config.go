@@ -0,0 +1,157 @@
+package gotile
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Config holds the arguments needed to construct a TileCoder with New.
+// See New for a description of the first six fields. The remaining
+// fields are optional, and each corresponds to the Option of the same
+// name, which is only used when the field is non-zero. Configs can be
+// decoded from JSON, YAML, and TOML documents, such as:
+//
+//	min_dims: [-1.2, -0.07]
+//	max_dims: [0.6, 0.07]
+//	bins: [[8, 8], [8, 8], [8, 8]]
+//	seed: 1
+//	include_bias: true
+//	offset_div: 1.5
+//	scales: [linear, linear]
+//	bounds_policy: clip
+type Config struct {
+	MinDims     []float64 `json:"min_dims" yaml:"min_dims" toml:"min_dims"`
+	MaxDims     []float64 `json:"max_dims" yaml:"max_dims" toml:"max_dims"`
+	Bins        [][]int   `json:"bins" yaml:"bins" toml:"bins"`
+	Seed        uint64    `json:"seed" yaml:"seed" toml:"seed"`
+	IncludeBias bool      `json:"include_bias" yaml:"include_bias" toml:"include_bias"`
+	OffsetDiv   float64   `json:"offset_div" yaml:"offset_div" toml:"offset_div"`
+
+	Scales       []Scale      `json:"scales,omitempty" yaml:"scales,omitempty" toml:"scales,omitempty"`
+	Squashes     []Squash     `json:"squashes,omitempty" yaml:"squashes,omitempty" toml:"squashes,omitempty"`
+	Edges        [][]float64  `json:"edges,omitempty" yaml:"edges,omitempty" toml:"edges,omitempty"`
+	Categorical  []int        `json:"categorical,omitempty" yaml:"categorical,omitempty" toml:"categorical,omitempty"`
+	Groups       [][]int      `json:"groups,omitempty" yaml:"groups,omitempty" toml:"groups,omitempty"`
+	BoundsPolicy BoundsPolicy `json:"bounds_policy,omitempty" yaml:"bounds_policy,omitempty" toml:"bounds_policy,omitempty"`
+
+	OffsetStrategy  OffsetStrategy  `json:"offset_strategy,omitempty" yaml:"offset_strategy,omitempty" toml:"offset_strategy,omitempty"`
+	NonFinitePolicy NonFinitePolicy `json:"non_finite_policy,omitempty" yaml:"non_finite_policy,omitempty" toml:"non_finite_policy,omitempty"`
+	DistinctSeeds   bool            `json:"distinct_seeds,omitempty" yaml:"distinct_seeds,omitempty" toml:"distinct_seeds,omitempty"`
+
+	NormalizedActivations bool `json:"normalized_activations,omitempty" yaml:"normalized_activations,omitempty" toml:"normalized_activations,omitempty"`
+}
+
+// Options returns the Options described by the optional fields of the
+// receiver
+func (c Config) Options() []Option {
+	var opts []Option
+	if c.Scales != nil {
+		opts = append(opts, WithScales(c.Scales...))
+	}
+	if c.Squashes != nil {
+		opts = append(opts, WithSquashes(c.Squashes...))
+	}
+	if c.Edges != nil {
+		opts = append(opts, WithEdges(c.Edges))
+	}
+	if c.Categorical != nil {
+		opts = append(opts, WithCategorical(c.Categorical...))
+	}
+	if c.Groups != nil {
+		opts = append(opts, WithGroups(c.Groups))
+	}
+	if c.BoundsPolicy != BoundsClip {
+		opts = append(opts, WithBoundsPolicy(c.BoundsPolicy))
+	}
+	if c.OffsetStrategy != OffsetRandom {
+		opts = append(opts, WithOffsetStrategy(c.OffsetStrategy))
+	}
+	if c.NonFinitePolicy != NonFiniteClip {
+		opts = append(opts, WithNonFinitePolicy(c.NonFinitePolicy))
+	}
+	if c.DistinctSeeds {
+		opts = append(opts, WithDistinctSeeds())
+	}
+	if c.NormalizedActivations {
+		opts = append(opts, WithNormalizedActivations())
+	}
+	return opts
+}
+
+// New returns a new TileCoder constructed from the receiver. Any opts
+// given are applied after the Options of the receiver.
+func (c Config) New(opts ...Option) (*TileCoder, error) {
+	if len(c.MinDims) == 0 || len(c.MinDims) != len(c.MaxDims) {
+		return nil, fmt.Errorf("new: bounds must have the same, non-zero "+
+			"length: %d != %d", len(c.MinDims), len(c.MaxDims))
+	}
+	minDims := mat.NewVecDense(len(c.MinDims), append([]float64(nil),
+		c.MinDims...))
+	maxDims := mat.NewVecDense(len(c.MaxDims), append([]float64(nil),
+		c.MaxDims...))
+
+	return New(minDims, maxDims, c.Bins, c.Seed, c.IncludeBias,
+		c.OffsetDiv, append(c.Options(), opts...)...)
+}
+
+// ConfigForResolution returns a Config for a TileCoder over the bounds
+// minDims and maxDims which resolves dimension i to within
+// resolution[i] and generalizes over a width of width[i] along
+// dimension i.
+//
+// Following Sutton and Barto (2018), each tile is (at most) width[i]
+// wide along dimension i, so that each feature generalizes over
+// roughly width[i]. Offsetting n such tilings from each other divides
+// each tile into n regions, so that the coder resolves width[i] / n
+// along dimension i. The returned Config therefore uses the smallest
+// number of tilings which resolves every dimension to the requested
+// resolution, each with enough bins to cover the bounds with tiles of
+// the requested width, and offsets tilings by up to one tile width.
+// Since every tiling has the same bins, the returned Config uses
+// DistinctSeeds, so that the tilings are offset differently.
+//
+// The returned Config does not use a bias unit and uses a zero seed,
+// both of which may be changed before the Config is used.
+func ConfigForResolution(minDims, maxDims mat.Vector, resolution,
+	width []float64) (Config, error) {
+	dims := minDims.Len()
+	if maxDims.Len() != dims || len(resolution) != dims ||
+		len(width) != dims {
+		return Config{}, fmt.Errorf("configForResolution: bounds, " +
+			"resolution, and width must all have the same length")
+	}
+
+	tilings := 1
+	bins := make([]int, dims)
+	for i := 0; i < dims; i++ {
+		span := maxDims.AtVec(i) - minDims.AtVec(i)
+		if !(span > 0) || math.IsInf(span, 0) {
+			return Config{}, fmt.Errorf("configForResolution: dimension %d "+
+				"must have finite bounds with positive width", i)
+		}
+		if !(resolution[i] > 0) || !(width[i] >= resolution[i]) {
+			return Config{}, fmt.Errorf("configForResolution: dimension %d "+
+				"must have 0 < resolution <= width", i)
+		}
+
+		bins[i] = int(math.Ceil(span / width[i]))
+		n := int(math.Ceil(width[i] / resolution[i]))
+		if n > tilings {
+			tilings = n
+		}
+	}
+
+	config := Config{
+		MinDims:       mat.Col(nil, 0, minDims),
+		MaxDims:       mat.Col(nil, 0, maxDims),
+		Bins:          make([][]int, tilings),
+		OffsetDiv:     2.0, // Offsets are spread over an entire tile
+		DistinctSeeds: true,
+	}
+	for i := range config.Bins {
+		config.Bins[i] = append([]int(nil), bins...)
+	}
+	return config, nil
+}
@@ -0,0 +1,94 @@
+package gotile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ReadYAMLConfig reads a Config from the YAML document in r. Unknown
+// fields are reported as errors.
+func ReadYAMLConfig(r io.Reader) (Config, error) {
+	var c Config
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&c); err != nil {
+		return Config{}, fmt.Errorf("readYAMLConfig: %v", err)
+	}
+	return c, nil
+}
+
+// ReadTOMLConfig reads a Config from the TOML document in r. Unknown
+// fields are reported as errors.
+func ReadTOMLConfig(r io.Reader) (Config, error) {
+	var c Config
+	meta, err := toml.NewDecoder(r).Decode(&c)
+	if err != nil {
+		return Config{}, fmt.Errorf("readTOMLConfig: %v", err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		return Config{}, fmt.Errorf("readTOMLConfig: unknown fields %v",
+			undecoded)
+	}
+	return c, nil
+}
+
+// ReadJSONConfig reads a Config from the JSON document in r. Unknown
+// fields are reported as errors.
+func ReadJSONConfig(r io.Reader) (Config, error) {
+	var c Config
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return Config{}, fmt.Errorf("readJSONConfig: %v", err)
+	}
+	return c, nil
+}
+
+// LoadConfigFile reads a Config from the file at path. The format of
+// the file is determined by its extension, which should be one of
+// ".yaml", ".yml", ".toml", or ".json".
+func LoadConfigFile(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("loadConfigFile: %v", err)
+	}
+
+	var c Config
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		c, err = ReadYAMLConfig(bytes.NewReader(data))
+	case ".toml":
+		c, err = ReadTOMLConfig(bytes.NewReader(data))
+	case ".json":
+		c, err = ReadJSONConfig(bytes.NewReader(data))
+	default:
+		err = fmt.Errorf("unknown config format %q", ext)
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("loadConfigFile: %v", err)
+	}
+	return c, nil
+}
+
+// NewFromConfigFile returns a new TileCoder constructed from the Config
+// in the file at path. See LoadConfigFile for the supported formats.
+func NewFromConfigFile(path string, opts ...Option) (*TileCoder, error) {
+	c, err := LoadConfigFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("newFromConfigFile: %v", err)
+	}
+
+	t, err := c.New(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("newFromConfigFile: %v", err)
+	}
+	return t, nil
+}
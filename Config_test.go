@@ -0,0 +1,234 @@
+package gotile
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+const yamlConfig = `
+min_dims: [0, 1]
+max_dims: [.inf, 100]
+bins: [[4, 3], [2, 5], [3]]
+seed: 7
+include_bias: true
+offset_div: -1
+scales: [linear, log]
+squashes: [{func: tanh}, {}]
+groups: [[0, 1], [1, 0], [0]]
+bounds_policy: extend
+`
+
+const tomlConfig = `
+min_dims = [0.0, 1.0]
+max_dims = [inf, 100.0]
+bins = [[4, 3], [2, 5], [3]]
+seed = 7
+include_bias = true
+offset_div = -1.0
+scales = ["linear", "log"]
+groups = [[0, 1], [1, 0], [0]]
+bounds_policy = "extend"
+
+[[squashes]]
+func = "tanh"
+
+[[squashes]]
+`
+
+func TestConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.yaml": yamlConfig,
+		"config.toml": tomlConfig,
+	}
+
+	var want *TileCoder
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatalf("could not write %v: %v", name, err)
+		}
+
+		tc, err := NewFromConfigFile(path)
+		if err != nil {
+			t.Fatalf("could not load %v: %v", name, err)
+		}
+		if want == nil {
+			want = tc
+		} else {
+			assertSameEncoding(t, want, tc)
+		}
+	}
+
+	path := filepath.Join(dir, "unknown.yaml")
+	if err := os.WriteFile(path, []byte("bin: [[2]]"), 0644); err != nil {
+		t.Fatalf("could not write config: %v", err)
+	}
+	if _, err := LoadConfigFile(path); err == nil {
+		t.Error("expected error with unknown field")
+	}
+}
+
+const tiles3Config = `{
+	"dims": 2,
+	"tiles": 8,
+	"tilings": 4,
+	"input_ranges": [[-1.2, 0.6], [-0.07, 0.07]],
+	"iht_size": 4096,
+	"bias": true,
+	"scale_output": true
+}`
+
+func TestTiles3Config(t *testing.T) {
+	c, err := ReadTiles3Config(strings.NewReader(tiles3Config))
+	if err != nil {
+		t.Fatalf("could not read config: %v", err)
+	}
+	if want := []int{8, 8}; !reflect.DeepEqual(c.Tiles, want) {
+		t.Errorf("tiles: have(%v) want(%v)", c.Tiles, want)
+	}
+
+	config, err := c.Config(1)
+	if err != nil {
+		t.Fatalf("could not convert config: %v", err)
+	}
+	tc, err := config.New()
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	if want := 4*8*8 + 1; tc.VecLength() != want {
+		t.Errorf("vecLength: have(%v) want(%v)", tc.VecLength(), want)
+	}
+
+	// scale_output scales the features of the tilings, but not the bias
+	encoded := tc.Encode(mat.NewVecDense(2, []float64{-0.3, 0}))
+	if sum := mat.Sum(encoded); math.Abs(sum-2) > 1e-12 {
+		t.Errorf("scale_output: have(%v) sum want(%v)", sum, 2)
+	}
+
+	exported, err := Tiles3ConfigFrom(config)
+	if err != nil {
+		t.Fatalf("could not export config: %v", err)
+	}
+	if !exported.ScaleOutput {
+		t.Error("tiles3ConfigFrom: scale_output not exported")
+	}
+	if exported.IHTSize != 256 {
+		t.Errorf("ihtSize: have(%v) want(%v)", exported.IHTSize, 256)
+	}
+
+	var buf bytes.Buffer
+	if err := exported.Write(&buf); err != nil {
+		t.Fatalf("could not write config: %v", err)
+	}
+	read, err := ReadTiles3Config(&buf)
+	if err != nil {
+		t.Fatalf("could not read exported config: %v", err)
+	}
+	if !reflect.DeepEqual(read, exported) {
+		t.Errorf("round trip: have(%v) want(%v)", read, exported)
+	}
+
+	// Features tiles3 cannot express are not silently dropped
+	unsupported := []struct {
+		name   string
+		change func(c *Config)
+	}{
+		{"scales", func(c *Config) { c.Scales = []Scale{ScaleLinear, ScaleLog} }},
+		{"squashes", func(c *Config) {
+			c.Squashes = []Squash{{}, {Func: SquashTanh}}
+		}},
+		{"boundsPolicy", func(c *Config) { c.BoundsPolicy = BoundsExtend }},
+		{"offsetStrategy", func(c *Config) { c.OffsetStrategy = OffsetHalton }},
+		{"nonFinitePolicy", func(c *Config) {
+			c.NonFinitePolicy = NonFiniteError
+		}},
+	}
+	for _, test := range unsupported {
+		changed := config
+		test.change(&changed)
+		if _, err := Tiles3ConfigFrom(changed); err == nil {
+			t.Errorf("tiles3ConfigFrom: expected error with %s", test.name)
+		}
+	}
+	config.Scales = []Scale{ScaleLinear, ScaleLinear}
+	if _, err := Tiles3ConfigFrom(config); err != nil {
+		t.Errorf("tiles3ConfigFrom: linear scales: %v", err)
+	}
+
+	config.Bins[1] = []int{4, 8}
+	if _, err := Tiles3ConfigFrom(config); err == nil {
+		t.Error("expected error with differing tilings")
+	}
+}
+
+func TestGolden(t *testing.T) {
+	c, err := ReadYAMLConfig(strings.NewReader(yamlConfig))
+	if err != nil {
+		t.Fatalf("could not read config: %v", err)
+	}
+	g, err := NewGolden(c, 20, 1)
+	if err != nil {
+		t.Fatalf("could not generate golden vectors: %v", err)
+	}
+	if have, want := len(g.Vectors), 23; have != want {
+		t.Errorf("vectors: have(%v) want(%v)", have, want)
+	}
+
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatalf("could not marshal golden vectors: %v", err)
+	}
+	var read Golden
+	if err := json.Unmarshal(data, &read); err != nil {
+		t.Fatalf("could not unmarshal golden vectors: %v", err)
+	}
+	if !reflect.DeepEqual(&read, g) {
+		t.Errorf("round trip: have(%v) want(%v)", read, *g)
+	}
+	if err := read.VerifyConfig(c); err != nil {
+		t.Errorf("verifyConfig: %v", err)
+	}
+
+	again, err := NewGolden(c, 20, 1)
+	if err != nil {
+		t.Fatalf("could not generate golden vectors: %v", err)
+	}
+	if !reflect.DeepEqual(again, g) {
+		t.Error("golden vectors are not deterministic")
+	}
+
+	// Empty inputs and indices are reported rather than compared
+	tc, err := c.New()
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	empty := []GoldenVector{
+		{Input: g.Vectors[0].Input},
+		{Input: floats{}, Indices: g.Vectors[0].Indices},
+		{},
+	}
+	for _, gv := range empty {
+		golden := Golden{Vectors: []GoldenVector{gv}}
+		if err := golden.Verify(tc); err == nil {
+			t.Errorf("verify(%v): expected error", gv)
+		}
+	}
+	if err := (&Golden{}).Verify(tc); err != nil {
+		t.Errorf("verify(no vectors): %v", err)
+	}
+
+	// Changing the offsets of the tilings changes the feature mapping
+	c.Seed++
+	if err := g.VerifyConfig(c); err == nil {
+		t.Error("expected error with changed seed")
+	}
+}
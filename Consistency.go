@@ -0,0 +1,91 @@
+package gotile
+
+import (
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// ConsistencyCheck cross-validates the batch and single-vector
+// encoding paths of the receiver on the vectors in the batch b, and
+// returns an error describing the first divergence found, if any. For
+// each column of b, the column of EncodeIndicesBatch must equal
+// EncodeIndices of the column, the column of EncodeBatch must equal
+// Encode of the column, and ToVector of the indices must equal the
+// encoding. An error is also returned if any vector cannot be encoded.
+//
+// Visits are not counted, metrics are not reported, and adaptive
+// bounds are not changed while the check runs, so that the check has
+// no effect on the receiver. ConsistencyCheck must therefore not be
+// called concurrently with other methods of the receiver.
+func (t *TileCoder) ConsistencyCheck(b *mat.Dense) error {
+	visits, metrics, adaptive := t.visits, t.metrics, t.adaptive
+	t.visits, t.metrics, t.adaptive = nil, nil, nil
+	defer func() {
+		t.visits, t.metrics, t.adaptive = visits, metrics, adaptive
+	}()
+
+	indices, err := t.TryEncodeIndicesBatch(b)
+	if err != nil {
+		return fmt.Errorf("consistencyCheck: %w", err)
+	}
+	encoded, err := t.TryEncodeBatch(b)
+	if err != nil {
+		return fmt.Errorf("consistencyCheck: %w", err)
+	}
+
+	_, batchSize := b.Dims()
+	divergent, first := 0, error(nil)
+	for j := 0; j < batchSize; j++ {
+		if err := t.checkColumn(b.ColView(j), indices.ColView(j),
+			encoded.ColView(j)); err != nil {
+			if first == nil {
+				first = fmt.Errorf("vector %d: %w", j, err)
+			}
+			divergent++
+		}
+	}
+	if first != nil {
+		return fmt.Errorf("consistencyCheck: %d of %d vectors diverge: %w",
+			divergent, batchSize, first)
+	}
+	return nil
+}
+
+// checkColumn returns an error if the single-vector encodings of v
+// differ from the batch encodings indices and encoded of v
+func (t *TileCoder) checkColumn(v, indices, encoded mat.Vector) error {
+	single, err := t.TryEncodeIndices(v)
+	if err != nil {
+		return err
+	}
+	if !mat.Equal(indices, mat.NewVecDense(len(single), single)) {
+		return fmt.Errorf("encodeIndicesBatch gives indices %v, "+
+			"encodeIndices gives %v", mat.Col(nil, 0, indices), single)
+	}
+
+	vec, err := t.TryEncode(v)
+	if err != nil {
+		return err
+	}
+	if !mat.Equal(encoded, vec) {
+		return fmt.Errorf("encodeBatch differs from encode at features %v",
+			differences(encoded, vec))
+	}
+	if converted := t.ToVector(indices); !mat.Equal(converted, vec) {
+		return fmt.Errorf("toVector of the indices differs from encode at "+
+			"features %v", differences(converted, vec))
+	}
+	return nil
+}
+
+// differences returns the indices at which a and b differ
+func differences(a, b mat.Vector) []int {
+	var diff []int
+	for i := 0; i < a.Len(); i++ {
+		if a.AtVec(i) != b.AtVec(i) {
+			diff = append(diff, i)
+		}
+	}
+	return diff
+}
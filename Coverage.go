@@ -0,0 +1,140 @@
+package gotile
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"text/tabwriter"
+)
+
+// CoverageReport summarizes which tiles of a TileCoder have been
+// activated by the vectors it encoded, so that users can tell whether
+// the bounds and bins of the TileCoder match where their data lives.
+// Coverage is computed from the visit counts of a TileCoder; see
+// WithVisitCounts.
+type CoverageReport struct {
+	// Coverage is the fraction of all tiles, over all tilings, which
+	// have been activated at least once. The bias unit is not a tile.
+	Coverage float64
+
+	// Tilings holds the coverage of each tiling
+	Tilings []TilingCoverage
+}
+
+// TilingCoverage describes the coverage of a single tiling
+type TilingCoverage struct {
+	// Tiling is the tiling described
+	Tiling int
+
+	// Visited is the number of tiles of the tiling activated at least
+	// once, out of its Tiles tiles, and Coverage is the fraction of
+	// tiles activated at least once
+	Visited  int
+	Tiles    int
+	Coverage float64
+
+	// Occupancy is the number of times each tile of the tiling has
+	// been activated, indexed by the index of the tile in the tiling
+	Occupancy []uint64
+
+	// Entropy is the Shannon entropy, in nats, of the distribution of
+	// visits over the tiles of the tiling, and NormalizedEntropy is
+	// the entropy divided by its maximum, log(Tiles). A normalized
+	// entropy near 1 means the data is spread evenly over the tiles,
+	// while a normalized entropy near 0 means the data is concentrated
+	// in a few tiles. Both are 0 if no tile has been visited.
+	Entropy           float64
+	NormalizedEntropy float64
+}
+
+// CoverageReport returns the fraction of tiles activated, and the
+// occupancy and entropy of visits, of each tiling of the receiver. If
+// the receiver does not count visits, CoverageReport panics. See
+// TryCoverageReport for a non-panicking variant.
+func (t *TileCoder) CoverageReport() CoverageReport {
+	report, err := t.TryCoverageReport()
+	if err != nil {
+		panic(err)
+	}
+	return report
+}
+
+// TryCoverageReport returns the coverage of each tiling of the
+// receiver, as in CoverageReport. If the receiver does not count
+// visits, an error is returned.
+func (t *TileCoder) TryCoverageReport() (CoverageReport, error) {
+	counts := t.VisitCounts()
+	if counts == nil {
+		return CoverageReport{}, fmt.Errorf("coverageReport: visit counts " +
+			"are not tracked")
+	}
+
+	bias := 0
+	if t.includeBias {
+		bias = 1
+	}
+
+	var report CoverageReport
+	visited, tiles := 0, 0
+	for k, tiling := range t.tilings {
+		start := t.featuresBeforeTiling(k) + bias
+		c := tilingCoverage(k, counts[start:start+tiling.Tiles()])
+		report.Tilings = append(report.Tilings, c)
+		visited += c.Visited
+		tiles += c.Tiles
+	}
+	if tiles > 0 {
+		report.Coverage = float64(visited) / float64(tiles)
+	}
+	return report, nil
+}
+
+// tilingCoverage returns the coverage of tiling number k, given the
+// visit counts of each of its tiles
+func tilingCoverage(k int, occupancy []uint64) TilingCoverage {
+	c := TilingCoverage{
+		Tiling:    k,
+		Tiles:     len(occupancy),
+		Occupancy: occupancy,
+	}
+
+	total := 0.0
+	for _, count := range occupancy {
+		if count > 0 {
+			c.Visited++
+		}
+		total += float64(count)
+	}
+	if c.Tiles > 0 {
+		c.Coverage = float64(c.Visited) / float64(c.Tiles)
+	}
+	if total == 0 {
+		return c
+	}
+
+	for _, count := range occupancy {
+		if count > 0 {
+			p := float64(count) / total
+			c.Entropy -= p * math.Log(p)
+		}
+	}
+	if c.Tiles > 1 {
+		c.NormalizedEntropy = c.Entropy / math.Log(float64(c.Tiles))
+	}
+	return c
+}
+
+// String returns a table summarizing the coverage of each tiling
+func (r CoverageReport) String() string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Tiling\tVisited\tTiles\tCoverage\tEntropy\t"+
+		"Normalized Entropy")
+	for _, c := range r.Tilings {
+		fmt.Fprintf(w, "%d\t%d\t%d\t%.4g\t%.4g\t%.4g\n", c.Tiling, c.Visited,
+			c.Tiles, c.Coverage, c.Entropy, c.NormalizedEntropy)
+	}
+	fmt.Fprintf(w, "All\t\t\t%.4g\t\t\n", r.Coverage)
+	w.Flush()
+	return buf.String()
+}
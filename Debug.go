@@ -0,0 +1,74 @@
+package gotile
+
+import (
+	"fmt"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// DebugString returns a small ASCII drawing of each tiling of the
+// receiver with the tile in which v falls marked, for teaching and for
+// quickly checking offsets in a terminal. Each tiling is drawn as a
+// row of tiles if it tiles a single dimension, or as a grid of tiles
+// whose rows are ordered by decreasing value of its second dimension if
+// it tiles two dimensions. Inactive tiles are drawn as '.', and the
+// active tile as '#'. The underflow and overflow tiles of the
+// BoundsExtend policy are drawn at either end of each dimension.
+// Tilings over more than two dimensions are summarized by the index of
+// their active tile. If v falls in the non-finite tile of a tiling, or
+// cannot be encoded by a tiling, the tiling is summarized instead.
+//
+// Computing a debug string does not adapt the bounds of the receiver or
+// count visits.
+func (t *TileCoder) DebugString(v mat.Vector) string {
+	var b strings.Builder
+	for k, tiling := range t.tilings {
+		if k > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "tiling %d: dims %v, offsets %.3g\n", k, tiling.dims,
+			tiling.Offsets())
+
+		index, err := tiling.TryIndex(v)
+		if err != nil {
+			fmt.Fprintf(&b, "  %v\n", err)
+			continue
+		}
+		coords, err := tiling.tileCoordinates(index)
+		if err != nil || len(coords) > 2 {
+			fmt.Fprintf(&b, "  active tile %d of %d\n", index, tiling.Tiles())
+			continue
+		}
+
+		if len(coords) == 1 {
+			b.WriteString("  ")
+			writeTiles(&b, tiling.size(0), coords[0])
+			continue
+		}
+		for row := tiling.size(1) - 1; row >= 0; row-- {
+			b.WriteString("  ")
+			active := -1
+			if row == coords[1] {
+				active = coords[0]
+			}
+			writeTiles(&b, tiling.size(0), active)
+		}
+	}
+	return b.String()
+}
+
+// writeTiles writes a row of n tiles to b, marking tile active
+func writeTiles(b *strings.Builder, n, active int) {
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		if i == active {
+			b.WriteByte('#')
+		} else {
+			b.WriteByte('.')
+		}
+	}
+	b.WriteByte('\n')
+}
@@ -0,0 +1,267 @@
+package gotile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// ErrCoderChanged is wrapped by errors returned by a DensityModel whose
+// TileCoder has moved, added, or removed tiles since the model was
+// created, so that the counts of the model no longer describe the
+// tiles of the coder
+var ErrCoderChanged = errors.New("tile coder changed")
+
+// DensityModel estimates the density of observations with tile coding,
+// for novelty detection and density-based reward shaping. The model
+// counts the observations falling in each tile, and estimates the
+// density of a vector as the fraction of observations falling in the
+// tile it activates, averaged over tilings. Averaging over many offset
+// tilings smooths the estimate, as in a histogram averaged over
+// shifted bins. Estimates are not divided by the volume of each tile,
+// so they are probabilities in [0, 1] rather than densities which
+// integrate to 1 over the input space, and are comparable between
+// tilings over different dimensions.
+//
+// Observations are counted with the same sharded counters as the visit
+// counts of a TileCoder, so that observations may be counted and
+// densities estimated concurrently. The counts are kept apart from the
+// visit counts of the coder, so that only observations, and not every
+// encoded vector, are counted. Like visit counts, the counts can be
+// read with Counts and restored with SetCounts, and a DensityModel,
+// including its counts, can also be serialized with encoding/json.
+//
+// The counts describe the tiles of the TileCoder when the model was
+// created. If the coder later moves its tiles, with SetBounds or by
+// adapting its bounds, or adds or removes tilings, the model returns
+// errors wrapping ErrCoderChanged.
+type DensityModel struct {
+	total  uint64 // First so that it is 64-bit aligned on 32-bit platforms
+	coder  *TileCoder
+	layout uint64 // Layout of the tiles of coder counted by the model
+	counts *visitCounts
+}
+
+// NewDensityModel returns a new DensityModel which has not counted any
+// observations, and which finds the tiles activated by each vector
+// with coder. Counting observations does not count visits, adapt
+// bounds, or report metrics of coder.
+func NewDensityModel(coder *TileCoder) (*DensityModel, error) {
+	if coder == nil {
+		return nil, fmt.Errorf("newDensityModel: %w", ErrNilInput)
+	}
+	return &DensityModel{
+		coder:  coder,
+		layout: atomic.LoadUint64(&coder.layout),
+		counts: newVisitCounts(coder.VecLength(), 0),
+	}, nil
+}
+
+// Coder returns the TileCoder of the receiver
+func (d *DensityModel) Coder() *TileCoder {
+	return d.coder
+}
+
+// Total returns the number of observations counted by the receiver
+func (d *DensityModel) Total() uint64 {
+	return atomic.LoadUint64(&d.total)
+}
+
+// check returns an error wrapping ErrCoderChanged if the tiles of the
+// receiver's TileCoder have changed since the receiver was created,
+// and otherwise checks v as in (*TileCoder).check
+func (d *DensityModel) check(v mat.Vector) error {
+	if atomic.LoadUint64(&d.coder.layout) != d.layout {
+		return ErrCoderChanged
+	}
+	return d.coder.check(v)
+}
+
+// Observe counts v in the tile it activates in each tiling. If some
+// tiling uses the BoundsError policy and v falls outside its bounds,
+// an error wrapping ErrOutOfBounds is returned and v is not counted.
+// If the tiles of the TileCoder have changed since the receiver was
+// created, an error wrapping ErrCoderChanged is returned.
+func (d *DensityModel) Observe(v mat.Vector) error {
+	if err := d.check(v); err != nil {
+		return fmt.Errorf("observe: %w", err)
+	}
+
+	workspace := getInts(len(d.coder.tilings))
+	defer putInts(workspace)
+	d.coder.activeFeatures(v, *workspace)
+	counts := d.counts.local()
+	for _, index := range *workspace {
+		atomic.AddUint64(&counts[index], 1)
+	}
+	atomic.AddUint64(&d.total, 1)
+	return nil
+}
+
+// ObserveBatch counts each vector in the batch b, where each column of
+// b is an observation, as in Observe. Observations are counted in
+// order, stopping at the first observation which cannot be encoded.
+func (d *DensityModel) ObserveBatch(b *mat.Dense) error {
+	if b == nil {
+		return fmt.Errorf("observeBatch: %w", ErrNilInput)
+	}
+	_, cols := b.Dims()
+	for j := 0; j < cols; j++ {
+		if err := d.Observe(b.ColView(j)); err != nil {
+			return fmt.Errorf("observeBatch: observation %d: %w", j, err)
+		}
+	}
+	return nil
+}
+
+// Density returns the estimated density of v, which is the fraction of
+// the observations counted by the receiver which fall in the tile
+// activated by v, averaged over tilings. The density is 0 if no
+// observations have been counted. If some tiling uses the BoundsError
+// policy and v falls outside its bounds, Density panics. See
+// TryDensity for a non-panicking variant.
+func (d *DensityModel) Density(v mat.Vector) float64 {
+	density, err := d.TryDensity(v)
+	if err != nil {
+		panic(err)
+	}
+	return density
+}
+
+// TryDensity returns the estimated density of v, as in Density. If some
+// tiling uses the BoundsError policy and v falls outside its bounds,
+// an error wrapping ErrOutOfBounds is returned. If the tiles of the
+// TileCoder have changed since the receiver was created, an error
+// wrapping ErrCoderChanged is returned.
+func (d *DensityModel) TryDensity(v mat.Vector) (float64, error) {
+	if err := d.check(v); err != nil {
+		return 0, fmt.Errorf("density: %w", err)
+	}
+	total := atomic.LoadUint64(&d.total)
+	if total == 0 {
+		return 0, nil
+	}
+
+	workspace := getInts(len(d.coder.tilings))
+	defer putInts(workspace)
+	d.coder.activeFeatures(v, *workspace)
+
+	count := 0.0
+	for _, index := range *workspace {
+		count += float64(d.counts.count(index))
+	}
+	return count / float64(len(d.coder.tilings)) / float64(total), nil
+}
+
+// Counts returns the number of observations counted by the receiver in
+// each feature of the tile-coded representation. The bias unit is never
+// counted.
+func (d *DensityModel) Counts() []uint64 {
+	counts := make([]uint64, d.counts.features)
+	for i := range counts {
+		counts[i] = d.counts.count(i)
+	}
+	return counts
+}
+
+// SetCounts sets the number of observations counted by the receiver in
+// each feature, for example to restore counts returned by Counts, and
+// counts the observations of the receiver's TileCoder as it is now, as
+// if the receiver had been created anew. The counts must have length
+// VecLength() of the coder, and each tiling must count the same number
+// of observations, which becomes the total returned by Total.
+func (d *DensityModel) SetCounts(counts []uint64) error {
+	total, err := d.coder.countTotal(counts)
+	if err != nil {
+		return fmt.Errorf("setCounts: %v", err)
+	}
+	d.counts = newVisitCounts(len(counts), 0)
+	copy(d.counts.shard(0), counts)
+	atomic.StoreUint64(&d.total, total)
+	d.layout = atomic.LoadUint64(&d.coder.layout)
+	return nil
+}
+
+// countTotal returns the number of observations counted by counts,
+// which hold a count for each feature of the receiver, or an error if
+// counts has the wrong length or its tilings count different numbers
+// of observations
+func (t *TileCoder) countTotal(counts []uint64) (uint64, error) {
+	if len(counts) != t.VecLength() {
+		return 0, fmt.Errorf("there should be a count for each feature: "+
+			"\n\thave(%d) \n\twant(%d)", len(counts), t.VecLength())
+	}
+	var total uint64
+	for k := range t.tilings {
+		start, end := t.FeatureRange(k)
+		var n uint64
+		for _, count := range counts[start:end] {
+			n += count
+		}
+		if k > 0 && n != total {
+			return 0, fmt.Errorf("tiling %d counts %d observations, but "+
+				"tiling 0 counts %d", k, n, total)
+		}
+		total = n
+	}
+	return total, nil
+}
+
+// densityState holds a DensityModel in exported form
+type densityState struct {
+	Version int             `json:"version"`
+	Coder   json.RawMessage `json:"coder"`
+	Total   uint64          `json:"total"`
+	Counts  []uint64        `json:"counts"`
+}
+
+// MarshalJSON implements the json.Marshaler interface. Both the
+// TileCoder and the counts of observations are marshaled.
+func (d *DensityModel) MarshalJSON() ([]byte, error) {
+	coder, err := d.coder.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("marshalJSON: %v", err)
+	}
+	return json.Marshal(densityState{FormatVersion, coder, d.Total(),
+		d.Counts()})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Tile coders
+// saved with older format versions are migrated.
+func (d *DensityModel) UnmarshalJSON(data []byte) error {
+	var s densityState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("unmarshalJSON: %v", err)
+	}
+	if err := checkVersion(s.Version); err != nil {
+		return fmt.Errorf("unmarshalJSON: %v", err)
+	}
+	if s.Coder == nil || string(s.Coder) == "null" {
+		return fmt.Errorf("unmarshalJSON: coder must be present")
+	}
+
+	coder := &TileCoder{}
+	if err := coder.UnmarshalJSON(s.Coder); err != nil {
+		return fmt.Errorf("unmarshalJSON: %v", err)
+	}
+	total, err := coder.countTotal(s.Counts)
+	if err != nil {
+		return fmt.Errorf("unmarshalJSON: %v", err)
+	}
+	if total != s.Total {
+		return fmt.Errorf("unmarshalJSON: counts hold %d observations, but "+
+			"total is %d", total, s.Total)
+	}
+
+	loaded, err := NewDensityModel(coder)
+	if err != nil {
+		return fmt.Errorf("unmarshalJSON: %v", err)
+	}
+	copy(loaded.counts.shard(0), s.Counts)
+	loaded.total = total
+	*d = *loaded
+	return nil
+}
@@ -0,0 +1,225 @@
+package gotile
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestDensityModel(t *testing.T) {
+	tc, err := New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{2, 2}, {4, 4}},
+		1,
+		true,
+		1e300,
+		WithBoundsPolicy(BoundsError),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	d, err := NewDensityModel(tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := mat.NewVecDense(2, []float64{0.1, 0.1})
+	if density := d.Density(q); density != 0 {
+		t.Errorf("density(%v): have(%v) want(0) with no observations", q,
+			density)
+	}
+
+	// Observations are counted concurrently. Three of the four fall in
+	// the lower left quadrant, and one of those in the same 4x4 tile as
+	// q, so that the density of q is (3/4 + 1/4) / 2.
+	b := mat.NewDense(2, 4, []float64{
+		0.1, 0.3, 0.4, 0.9,
+		0.2, 0.1, 0.4, 0.9,
+	})
+	var wg sync.WaitGroup
+	for j := 0; j < 4; j++ {
+		wg.Add(1)
+		go func(j int) {
+			defer wg.Done()
+			if err := d.Observe(b.ColView(j)); err != nil {
+				t.Error(err)
+			}
+		}(j)
+	}
+	wg.Wait()
+	if d.Total() != 4 {
+		t.Errorf("total: have(%d) want(4)", d.Total())
+	}
+	if have, want := d.Density(q), 0.5; math.Abs(have-want) > 1e-12 {
+		t.Errorf("density(%v): have(%v) want(%v)", q, have, want)
+	}
+
+	// Counts survive a round trip through JSON
+	data, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded DensityModel
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Total() != 4 || loaded.Density(q) != d.Density(q) {
+		t.Errorf("unmarshalJSON: have(%d, %v) want(%d, %v)", loaded.Total(),
+			loaded.Density(q), d.Total(), d.Density(q))
+	}
+	if err := loaded.ObserveBatch(b); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Total() != 8 || d.Total() != 4 {
+		t.Errorf("observeBatch: have totals(%d, %d) want(8, 4)",
+			loaded.Total(), d.Total())
+	}
+
+	out := mat.NewVecDense(2, []float64{2, 0})
+	if err := d.Observe(out); !errors.Is(err, ErrOutOfBounds) {
+		t.Errorf("observe(%v): have(%v) want(%v)", out, err, ErrOutOfBounds)
+	}
+	if _, err := d.TryDensity(out); !errors.Is(err, ErrOutOfBounds) {
+		t.Errorf("density(%v): have(%v) want(%v)", out, err, ErrOutOfBounds)
+	}
+	if _, err := NewDensityModel(nil); !errors.Is(err, ErrNilInput) {
+		t.Errorf("newDensityModel(nil): have(%v) want(%v)", err, ErrNilInput)
+	}
+	if err := loaded.UnmarshalJSON([]byte(`{"version":1,"coder":null}`)); err ==
+		nil {
+		t.Error("expected error unmarshaling without coder")
+	}
+}
+
+func TestDensityModelCoderChanged(t *testing.T) {
+	newModel := func() (*TileCoder, *DensityModel) {
+		tc, err := New(
+			mat.NewVecDense(2, []float64{0, 0}),
+			mat.NewVecDense(2, []float64{1, 1}),
+			[][]int{{2, 2}, {4, 4}},
+			1,
+			true,
+			1e300,
+		)
+		if err != nil {
+			t.Fatalf("could not create tile coder: %v", err)
+		}
+		d, err := NewDensityModel(tc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tc, d
+	}
+	v := mat.NewVecDense(2, []float64{0.1, 0.1})
+	b := mat.NewDense(2, 1, []float64{0.1, 0.1})
+
+	tests := []struct {
+		name   string
+		change func(tc *TileCoder) error
+	}{
+		{"addTiling", func(tc *TileCoder) error {
+			return tc.AddTiling(tc.Tilings()[0])
+		}},
+		{"removeTiling", func(tc *TileCoder) error {
+			return tc.RemoveTiling(0)
+		}},
+		{"setBounds", func(tc *TileCoder) error {
+			return tc.SetBounds(mat.NewVecDense(2, []float64{-1, -1}),
+				mat.NewVecDense(2, []float64{1, 1}))
+		}},
+	}
+	for _, test := range tests {
+		tc, d := newModel()
+		if err := d.Observe(v); err != nil {
+			t.Fatal(err)
+		}
+		if err := test.change(tc); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		if err := d.Observe(v); !errors.Is(err, ErrCoderChanged) {
+			t.Errorf("%s: observe(%v): have(%v) want(%v)", test.name, v, err,
+				ErrCoderChanged)
+		}
+		if err := d.ObserveBatch(b); !errors.Is(err, ErrCoderChanged) {
+			t.Errorf("%s: observeBatch(%v): have(%v) want(%v)", test.name, b,
+				err, ErrCoderChanged)
+		}
+		if _, err := d.TryDensity(v); !errors.Is(err, ErrCoderChanged) {
+			t.Errorf("%s: density(%v): have(%v) want(%v)", test.name, v, err,
+				ErrCoderChanged)
+		}
+		if d.Total() != 1 {
+			t.Errorf("%s: total: have(%d) want(1)", test.name, d.Total())
+		}
+
+		// Restoring counts for the changed coder makes the model usable
+		// again
+		if err := d.SetCounts(make([]uint64, tc.VecLength()-1)); err == nil {
+			t.Errorf("%s: expected error setting too few counts", test.name)
+		}
+		if err := d.SetCounts(make([]uint64, tc.VecLength())); err != nil {
+			t.Fatalf("%s: setCounts: %v", test.name, err)
+		}
+		if err := d.Observe(v); err != nil {
+			t.Errorf("%s: observe(%v) after setCounts: %v", test.name, v, err)
+		}
+		if density := d.Density(v); density != 1 {
+			t.Errorf("%s: density(%v): have(%v) want(1)", test.name, v,
+				density)
+		}
+	}
+}
+
+func TestDensityModelCounts(t *testing.T) {
+	tc, err := New(
+		mat.NewVecDense(1, []float64{0}),
+		mat.NewVecDense(1, []float64{1}),
+		[][]int{{2}, {2}},
+		1,
+		true,
+		1e300,
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	d, err := NewDensityModel(tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, x := range []float64{0.1, 0.2, 0.9} {
+		if err := d.Observe(mat.NewVecDense(1, []float64{x})); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The bias is never counted
+	want := []uint64{0, 2, 1, 2, 1}
+	if have := d.Counts(); !reflect.DeepEqual(have, want) {
+		t.Errorf("counts: have(%v) want(%v)", have, want)
+	}
+	restored, err := NewDensityModel(tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := restored.SetCounts(d.Counts()); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Total() != 3 || !reflect.DeepEqual(restored.Counts(), want) {
+		t.Errorf("setCounts: have(%d, %v) want(3, %v)", restored.Total(),
+			restored.Counts(), want)
+	}
+
+	// Tilings must count the same number of observations
+	if err := restored.SetCounts([]uint64{0, 2, 1, 2, 2}); err == nil {
+		t.Error("expected error setting inconsistent counts")
+	}
+	if restored.Total() != 3 {
+		t.Errorf("setCounts: total changed on error to %d", restored.Total())
+	}
+}
@@ -0,0 +1,140 @@
+package gotile
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Feature describes a single feature of a tile-coded representation
+type Feature struct {
+	// Bias is whether the feature is the bias unit, in which case
+	// Tiling is -1 and Tile and Dims are nil
+	Bias bool
+
+	// Tiling is the tiling to which the feature belongs
+	Tiling int
+
+	// Tile holds the coordinate of the feature's tile along each
+	// dimension of the tiling. Coordinates along dimension k range
+	// from 0 to the number of bins along the dimension minus one. For
+	// tilings using the BoundsExtend policy, a coordinate of -1 refers
+	// to the underflow tile and a coordinate equal to the number of
+	// bins refers to the overflow tile.
+	Tile []int
+
+	// Dims holds the input dimension tiled along each dimension of the
+	// tiling
+	Dims []int
+}
+
+// DescribeFeature returns a description of feature i of the
+// tile-coded representation produced by the receiver, which is useful
+// for interpreting learned weight vectors. An error is returned if i
+// is not a feature of the tile-coded representation.
+func (t *TileCoder) DescribeFeature(i int) (Feature, error) {
+	if i < 0 || i >= t.VecLength() {
+		return Feature{}, fmt.Errorf("describeFeature: feature %d out of "+
+			"range [0, %d)", i, t.VecLength())
+	}
+
+	if t.includeBias {
+		if i == 0 {
+			return Feature{Bias: true, Tiling: -1}, nil
+		}
+		i--
+	}
+
+	tiling := 0
+	for i >= t.tilings[tiling].Tiles() {
+		i -= t.tilings[tiling].Tiles()
+		tiling++
+	}
+
+	return Feature{
+		Tiling: tiling,
+		Tile:   t.tilings[tiling].coordinates(i),
+		Dims:   t.tilings[tiling].Dims(),
+	}, nil
+}
+
+// coordinates returns the coordinate along each dimension of the tile
+// with the given index in the tiling. For the BoundsExtend policy,
+// coordinates are shifted so that -1 refers to the underflow tile.
+func (t *Tiling) coordinates(index int) []int {
+	coords := make([]int, len(t.bins))
+	for i := range t.bins {
+		coords[i] = index / t.strides[i]
+		index %= t.strides[i]
+		if t.policy == BoundsExtend {
+			coords[i]--
+		}
+	}
+	return coords
+}
+
+// Reconstruct returns an approximate inverse of encoding, given the
+// non-zero indices of a tile-coded vector. Each input dimension of the
+// returned vector is the average of the centers (see
+// Tiling.TileCenter) of the active tiles along that dimension. The
+// returned vector has one element for each input dimension up to the
+// largest dimension tiled by any tiling, and dimensions which are not
+// tiled by an active tile are NaN. The bias unit is ignored.
+// Reconstruct panics if some index is not a feature of the tile-coded
+// representation. See TryReconstruct for a non-panicking variant.
+func (t *TileCoder) Reconstruct(indices []int) *mat.VecDense {
+	v, err := t.TryReconstruct(indices)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// TryReconstruct returns an approximate inverse of encoding, given the
+// non-zero indices of a tile-coded vector, as in Reconstruct. If some
+// index is not a feature of the tile-coded representation, or is the
+// non-finite tile of a tiling using the NonFiniteTile policy, an error
+// is returned.
+func (t *TileCoder) TryReconstruct(indices []int) (*mat.VecDense, error) {
+	dims := 0
+	for _, tiling := range t.tilings {
+		for _, d := range tiling.dims {
+			if d+1 > dims {
+				dims = d + 1
+			}
+		}
+	}
+
+	sums := make([]float64, dims)
+	counts := make([]int, dims)
+	for _, index := range indices {
+		f, err := t.DescribeFeature(index)
+		if err != nil {
+			return nil, fmt.Errorf("reconstruct: %w", err)
+		}
+		if f.Bias {
+			continue
+		}
+
+		tiling := t.tilings[f.Tiling]
+		start, _ := t.FeatureRange(f.Tiling)
+		center, err := tiling.TryTileCenter(index - start)
+		if err != nil {
+			return nil, fmt.Errorf("reconstruct: %w", err)
+		}
+		for k, d := range f.Dims {
+			sums[d] += center[k]
+			counts[d]++
+		}
+	}
+
+	for d := range sums {
+		if counts[d] == 0 {
+			sums[d] = math.NaN()
+		} else {
+			sums[d] /= float64(counts[d])
+		}
+	}
+	return mat.NewVecDense(dims, sums), nil
+}
@@ -0,0 +1,119 @@
+package gotile
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// ErrDimension is wrapped by errors returned when encoding a vector
+// which does not have the number of dimensions expected by a TileCoder
+var ErrDimension = errors.New("dimension mismatch")
+
+// ErrNilInput is wrapped by errors returned when a nil vector or batch
+// is given to a non-panicking method
+var ErrNilInput = errors.New("nil input")
+
+// DimensionError describes a vector, or batch of vectors, whose number
+// of dimensions differs from the number of input dimensions of a
+// TileCoder, or which has too few dimensions for a tiling or another
+// Coder. DimensionErrors wrap ErrDimension.
+type DimensionError struct {
+	Have int // Dimensions of the vector
+	Want int // Input dimensions expected
+}
+
+// Error implements the error interface
+func (e *DimensionError) Error() string {
+	return fmt.Sprintf("%v: vector has %d dimensions, want %d", ErrDimension,
+		e.Have, e.Want)
+}
+
+// Unwrap returns ErrDimension
+func (e *DimensionError) Unwrap() error {
+	return ErrDimension
+}
+
+// InputDims returns the number of dimensions of the vectors encoded by
+// the receiver
+func (t *TileCoder) InputDims() int {
+	return t.inputDims
+}
+
+// checkDims returns a *DimensionError if v does not have the input
+// dimensions of the receiver, or an error wrapping ErrNilInput if v is
+// nil
+func (t *TileCoder) checkDims(v mat.Vector) error {
+	if isNil(v) {
+		return ErrNilInput
+	}
+	if v.Len() != t.inputDims {
+		return &DimensionError{Have: v.Len(), Want: t.inputDims}
+	}
+	return nil
+}
+
+// checkBatchDims returns a *DimensionError if the vectors in the batch
+// b do not have the input dimensions of the receiver, or an error
+// wrapping ErrNilInput if b is nil
+func (t *TileCoder) checkBatchDims(b *mat.Dense) error {
+	if b == nil {
+		return ErrNilInput
+	}
+	if rows, _ := b.Dims(); rows != t.inputDims {
+		return &DimensionError{Have: rows, Want: t.inputDims}
+	}
+	return nil
+}
+
+// tiledDims returns one more than the largest input dimension tiled by
+// any of tilings, which is the fewest dimensions the tilings can encode
+func tiledDims(tilings []*Tiling) int {
+	dims := 0
+	for _, tiling := range tilings {
+		for _, d := range tiling.dims {
+			if d+1 > dims {
+				dims = d + 1
+			}
+		}
+	}
+	return dims
+}
+
+// checkVector returns a *DimensionError if v does not have a dimension
+// for each dimension tiled by the receiver, or an error wrapping
+// ErrNilInput if v is nil
+func (t *Tiling) checkVector(v mat.Vector) error {
+	if isNil(v) {
+		return ErrNilInput
+	}
+	if want := tiledDims([]*Tiling{t}); v.Len() < want {
+		return &DimensionError{Have: v.Len(), Want: want}
+	}
+	return nil
+}
+
+// checkBatch returns a *DimensionError if the vectors in the batch b
+// do not have a dimension for each dimension tiled by the receiver, or
+// an error wrapping ErrNilInput if b is nil
+func (t *Tiling) checkBatch(b *mat.Dense) error {
+	if b == nil {
+		return ErrNilInput
+	}
+	rows, _ := b.Dims()
+	if want := tiledDims([]*Tiling{t}); rows < want {
+		return &DimensionError{Have: rows, Want: want}
+	}
+	return nil
+}
+
+// isNil returns whether v is nil, or a nil pointer to a vector
+func isNil(v mat.Vector) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
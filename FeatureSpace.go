@@ -0,0 +1,44 @@
+package gotile
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// MaxFeatures is the largest number of features in any encoded
+// representation. Indices are computed with int, which has only 32
+// bits on some platforms, and returned as float64, which represents
+// integers exactly only up to 2^53. On 64-bit platforms MaxFeatures is
+// 2^53 - 1, and on 32-bit platforms it is math.MaxInt32.
+const MaxFeatures = math.MaxInt >> (strconv.IntSize / 64 * (63 - 53))
+
+// ErrFeatureSpace is wrapped by errors returned when a tiling, tile
+// coder, or other Coder would have more than MaxFeatures features
+var ErrFeatureSpace = errors.New("feature space too large")
+
+// addFeatures returns a + b, or an error wrapping ErrFeatureSpace if
+// the sum exceeds MaxFeatures. Both a and b must be in [0, MaxFeatures].
+func addFeatures(a, b int) (int, error) {
+	if a > MaxFeatures-b {
+		return 0, featureSpaceError()
+	}
+	return a + b, nil
+}
+
+// mulFeatures returns a * b, or an error wrapping ErrFeatureSpace if
+// the product exceeds MaxFeatures. Both a and b must be in
+// [0, MaxFeatures].
+func mulFeatures(a, b int) (int, error) {
+	if b != 0 && a > MaxFeatures/b {
+		return 0, featureSpaceError()
+	}
+	return a * b, nil
+}
+
+// featureSpaceError returns an error wrapping ErrFeatureSpace
+func featureSpaceError() error {
+	return fmt.Errorf("%w: more than %d features", ErrFeatureSpace,
+		MaxFeatures)
+}
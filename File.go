@@ -0,0 +1,91 @@
+package gotile
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SaveFile saves the tile coder to the file at path. The format of the
+// file is determined by the extension of path: ".json" files use JSON,
+// ".gob" files use gob, and all other files use the compact binary
+// format of MarshalBinary. The file is written atomically, so that an
+// existing file at path is either entirely replaced or left untouched.
+func (t *TileCoder) SaveFile(path string) error {
+	var data []byte
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		data, err = json.Marshal(t)
+	case ".gob":
+		var buf bytes.Buffer
+		err = gob.NewEncoder(&buf).Encode(t)
+		data = buf.Bytes()
+	default:
+		data, err = t.MarshalBinary()
+	}
+	if err != nil {
+		return fmt.Errorf("saveFile: could not encode tile coder: %v", err)
+	}
+
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("saveFile: %v", err)
+	}
+	return nil
+}
+
+// LoadFile loads a tile coder from the file at path, which may have
+// been saved in any of the formats used by SaveFile. The format is
+// detected from the contents of the file rather than its extension.
+func LoadFile(path string) (*TileCoder, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("loadFile: %v", err)
+	}
+
+	t := &TileCoder{}
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(data, tileCoderHeader[:len(tileCoderHeader)-1]):
+		err = t.UnmarshalBinary(data)
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		err = json.Unmarshal(trimmed, t)
+	default:
+		err = gob.NewDecoder(bytes.NewReader(data)).Decode(t)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loadFile: could not decode %v: %v", path, err)
+	}
+	return t, nil
+}
+
+// writeFileAtomic writes data to the file at path by first writing a
+// temporary file in the same directory and then renaming it to path
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path),
+		"."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,300 @@
+package gotile
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+)
+
+// FitTiling returns a new tiling whose bin edges are placed at the
+// empirical quantiles of the sample data, so that each bin along a
+// dimension holds roughly the same number of samples. Similarly to
+// IndexBatch, each column of data should be a single sample and each
+// row a single feature. The returned tiling has bins[i] bins along
+// dimension i, bounded by the smallest and largest sample along the
+// dimension, unless the sample has repeated values, in which case
+// bins which would have zero width are merged and dimension i has
+// fewer than bins[i] bins.
+//
+// The returned tiling is not offset. Any opts given are passed to
+// NewTilingEdges.
+func FitTiling(data *mat.Dense, bins []int, opts ...Option) (*Tiling,
+	error) {
+	if data == nil {
+		return nil, fmt.Errorf("fitTiling: %w", ErrNilInput)
+	}
+	rows, cols := data.Dims()
+	if len(bins) != rows {
+		return nil, fmt.Errorf("fitTiling: there should be a single number "+
+			"of bins for each dimension: \n\thave(%d) \n\twant (%d)",
+			len(bins), rows)
+	}
+
+	edges := make([][]float64, rows)
+	sorted := make([]float64, cols)
+	for i := 0; i < rows; i++ {
+		if bins[i] < 1 {
+			return nil, fmt.Errorf("fitTiling: cannot have less than 1 bin "+
+				"along dimension %d", i)
+		}
+
+		mat.Row(sorted, i, data)
+		sort.Float64s(sorted)
+
+		edges[i] = append(edges[i], sorted[0])
+		for j := 1; j <= bins[i]; j++ {
+			p := float64(j) / float64(bins[i])
+			edge := stat.Quantile(p, stat.Empirical, sorted, nil)
+			if edge > edges[i][len(edges[i])-1] {
+				edges[i] = append(edges[i], edge)
+			}
+		}
+
+		if len(edges[i]) < 2 {
+			return nil, fmt.Errorf("fitTiling: all samples along dimension "+
+				"%d are equal", i)
+		}
+	}
+
+	// An infinite offset divisor ensures the tiling is not offset
+	return NewTilingEdges(edges, 0, math.Inf(1), opts...)
+}
+
+// QuantizationError returns the mean squared distance between each
+// sample of data and its reconstruction from the receiver, where each
+// column of data is a sample. The reconstruction of a sample along an
+// input dimension is the center of the tile it activates, as returned
+// by TileCenter, averaged over the tilings which tile the dimension.
+// Dimensions tiled by no tiling are not reconstructed and do not
+// contribute to the error. The error measures how well the tilings
+// resolve the data: offsets which spread the tile boundaries of
+// different tilings evenly through the data give lower errors.
+//
+// If some tiling uses the BoundsError policy and a sample falls
+// outside its bounds, an error wrapping ErrOutOfBounds is returned.
+func (t *TileCoder) QuantizationError(data *mat.Dense) (float64, error) {
+	q, err := t.newQuantizer(data)
+	if err != nil {
+		return 0, fmt.Errorf("quantizationError: %w", err)
+	}
+	return q.error(0, q.centers[0]), nil
+}
+
+// FitOffsets returns a copy of the receiver whose tiling offsets are
+// tuned to the sample data, where each column of data is a sample, by
+// minimizing the quantization error of the data, as returned by
+// QuantizationError, with coordinate descent. Each sweep visits every
+// offset of every tiling in turn, and sets it to whichever of
+// candidates evenly spaced offsets spanning a tile width, or its
+// current value, gives the lowest error. The receiver is not modified,
+// and the quantization error of the returned TileCoder is never higher
+// than that of the receiver. Categorical dimensions are never offset.
+//
+// Settings which are not serialized, such as the concurrency threshold
+// and the order of indices, are kept. Visit counts of the returned
+// TileCoder start at zero, and metrics are not copied. An error is
+// returned if some tiling is rotated, and an error wrapping
+// ErrOutOfBounds is returned if some tiling uses the
+// BoundsError policy and a sample falls outside its bounds.
+func (t *TileCoder) FitOffsets(data *mat.Dense, candidates,
+	sweeps int) (*TileCoder, error) {
+	if candidates < 2 {
+		return nil, fmt.Errorf("fitOffsets: cannot have less than 2 "+
+			"candidate offsets: %d", candidates)
+	}
+	if sweeps < 1 {
+		return nil, fmt.Errorf("fitOffsets: cannot have less than 1 sweep: "+
+			"%d", sweeps)
+	}
+	for k, tiling := range t.tilings {
+		if tiling.rotation != nil {
+			return nil, fmt.Errorf("fitOffsets: cannot fit offsets of "+
+				"rotated tiling %d", k)
+		}
+	}
+	q, err := t.newQuantizer(data)
+	if err != nil {
+		return nil, fmt.Errorf("fitOffsets: %w", err)
+	}
+
+	s := t.state()
+	for sweep := 0; sweep < sweeps; sweep++ {
+		for k := range s.Tilings {
+			tiling := &s.Tilings[k]
+			for i := range tiling.Offsets {
+				if tiling.Categories[i] {
+					continue
+				}
+
+				// Search offsets spanning a tile width, which is the
+				// narrowest bin for dimensions with bin edges
+				width := tiling.BinLengths[i]
+				if e := tiling.Edges[i]; e != nil {
+					width = math.Inf(1)
+					for j := 1; j < len(e); j++ {
+						width = math.Min(width, e[j]-e[j-1])
+					}
+				}
+
+				best, bestCenters := tiling.Offsets[i], q.centers[k]
+				bestErr := q.error(k, bestCenters)
+				for c := 0; c < candidates; c++ {
+					offset := width * (float64(c)/float64(candidates-1) - 0.5)
+					tiling.Offsets[i] = offset
+					centers, err := q.tilingCenters(*tiling)
+					if err != nil {
+						return nil, fmt.Errorf("fitOffsets: %w", err)
+					}
+					if e := q.error(k, centers); e < bestErr {
+						best, bestCenters, bestErr = offset, centers, e
+					}
+				}
+				tiling.Offsets[i] = best
+				q.set(k, bestCenters)
+			}
+		}
+	}
+
+	fit := *t
+	fit.metrics = nil
+	if err := fit.setState(s); err != nil {
+		return nil, fmt.Errorf("fitOffsets: %v", err)
+	}
+	if t.visits != nil {
+		fit.visits = newVisitCounts(fit.VecLength(), t.visitShards())
+	}
+	return &fit, nil
+}
+
+// quantizer computes the quantization error of a sample of data,
+// keeping the tile centers of each sample in each tiling so that the
+// error can be recomputed quickly when a single tiling changes
+type quantizer struct {
+	data *mat.Dense
+
+	// centers[k][m][j] is the center along the m-th dimension of
+	// tiling k of the tile activated by sample j
+	centers [][][]float64
+	dims    [][]int // Input dimension of each dimension of each tiling
+
+	// sum[d][j] is the sum of the centers along input dimension d of
+	// the tiles activated by sample j, and count[d] is the number of
+	// tilings summed
+	sum   [][]float64
+	count []int
+}
+
+// newQuantizer returns a new quantizer of data for the receiver's
+// tilings
+func (t *TileCoder) newQuantizer(data *mat.Dense) (*quantizer, error) {
+	if data == nil {
+		return nil, ErrNilInput
+	}
+	rows, cols := data.Dims()
+	if rows != t.inputDims {
+		return nil, fmt.Errorf("%w: samples have %d dimensions, want %d",
+			ErrDimension, rows, t.inputDims)
+	}
+
+	q := &quantizer{
+		data:    data,
+		centers: make([][][]float64, len(t.tilings)),
+		dims:    make([][]int, len(t.tilings)),
+		sum:     make([][]float64, rows),
+		count:   make([]int, rows),
+	}
+	for d := range q.sum {
+		q.sum[d] = make([]float64, cols)
+	}
+	for k, tiling := range t.tilings {
+		q.dims[k] = tiling.Dims()
+		centers, err := q.tilingCenters(tiling.state())
+		if err != nil {
+			return nil, err
+		}
+		q.set(k, centers)
+	}
+	return q, nil
+}
+
+// tilingCenters returns the centers of the tiles activated by each
+// sample in the tiling with state s, indexed as in q.centers[k]
+func (q *quantizer) tilingCenters(s tilingState) ([][]float64, error) {
+	tiling, err := newTilingFromState(s)
+	if err != nil {
+		return nil, err
+	}
+	indices, err := tiling.TryIndexBatch(q.data)
+	if err != nil {
+		return nil, err
+	}
+
+	_, cols := q.data.Dims()
+	centers := make([][]float64, len(s.Bins))
+	for m := range centers {
+		centers[m] = make([]float64, cols)
+	}
+	cache := make(map[int][]float64)
+	for j := 0; j < cols; j++ {
+		index := int(indices.AtVec(j))
+		center, ok := cache[index]
+		if !ok {
+			if center, err = tiling.TryTileCenter(index); err != nil {
+				return nil, fmt.Errorf("sample %d: %v", j, err)
+			}
+			cache[index] = center
+		}
+		for m := range centers {
+			centers[m][j] = center[m]
+		}
+	}
+	return centers, nil
+}
+
+// set replaces the centers of tiling k in the reconstructions
+func (q *quantizer) set(k int, centers [][]float64) {
+	for m, d := range q.dims[k] {
+		if q.centers[k] == nil {
+			q.count[d]++
+		}
+		for j, c := range centers[m] {
+			if q.centers[k] != nil {
+				c -= q.centers[k][m][j]
+			}
+			q.sum[d][j] += c
+		}
+	}
+	q.centers[k] = centers
+}
+
+// error returns the quantization error of the reconstructions, with
+// the centers of tiling k replaced by centers
+func (q *quantizer) error(k int, centers [][]float64) float64 {
+	rows, cols := q.data.Dims()
+	replaced := make([]int, rows)
+	for d := range replaced {
+		replaced[d] = -1
+	}
+	for m, d := range q.dims[k] {
+		replaced[d] = m
+	}
+
+	total := 0.0
+	for d := 0; d < rows; d++ {
+		if q.count[d] == 0 {
+			continue
+		}
+		for j := 0; j < cols; j++ {
+			sum := q.sum[d][j]
+			if m := replaced[d]; m >= 0 {
+				sum += centers[m][j] - q.centers[k][m][j]
+			}
+			diff := q.data.At(d, j) - sum/float64(q.count[d])
+			total += diff * diff
+		}
+	}
+	return total / float64(cols)
+}
@@ -0,0 +1,80 @@
+package gotile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// String returns a string representation of a *Tiling, including its
+// input dimensions, bins, bounds, bin lengths, offsets, and
+// BoundsPolicy
+func (t *Tiling) String() string {
+	low, high := t.Bounds()
+	bounds := make([]string, len(low))
+	for i := range low {
+		bounds[i] = fmt.Sprintf("[%v, %v]", low[i], high[i])
+	}
+	return fmt.Sprintf("Dims: %v  |  Bins: %v  |  Bounds: [%v]  |  "+
+		"Bin Lengths: %.4g  |  Offsets: %.4g  |  Policy: %v", t.dims, t.bins,
+		strings.Join(bounds, " "), t.binLengths, t.Offsets(), t.policy)
+}
+
+// GoString returns a Go-syntax representation of a *Tiling, which is
+// an expression building an identical Tiling from its JSON encoding
+func (t *Tiling) GoString() string {
+	return goString("Tiling", t)
+}
+
+// GoString returns a Go-syntax representation of a *TileCoder, which
+// is an expression building an identical TileCoder, including each of
+// its tilings, from its JSON encoding
+func (t *TileCoder) GoString() string {
+	return goString("TileCoder", t)
+}
+
+// goString returns a Go expression of type *gotile.<name> which
+// unmarshals the JSON encoding of v
+func goString(name string, v json.Marshaler) string {
+	data, err := v.MarshalJSON()
+	if err != nil {
+		return fmt.Sprintf("%%!v(*gotile.%s=%v)", name, err)
+	}
+	return fmt.Sprintf("func() *gotile.%[1]s { v := new(gotile.%[1]s); "+
+		"if err := v.UnmarshalJSON([]byte(%[2]s)); err != nil { "+
+		"panic(err) }; return v }()", name, strconv.Quote(string(data)))
+}
+
+// verboseString returns a multi-line string representation of a
+// *TileCoder, including its bias setting, total number of features,
+// and a description of each tiling
+func (t *TileCoder) verboseString() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v  |  Bias: %v  |  Features: %d", t.String(),
+		t.includeBias, t.VecLength())
+	for i, tiling := range t.tilings {
+		fmt.Fprintf(&b, "\n  Tiling %d  |  %v", i, tiling)
+	}
+	return b.String()
+}
+
+// Format implements the fmt.Formatter interface. The %v and %s verbs
+// print the one-line summary returned by String, the %+v verb
+// additionally prints the bias setting, total number of features, and
+// the bounds, bin lengths, and offsets of each tiling on separate
+// lines, and the %#v verb prints the representation returned by
+// GoString.
+func (t *TileCoder) Format(f fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && f.Flag('#'):
+		io.WriteString(f, t.GoString())
+	case verb == 'v' && f.Flag('+'):
+		io.WriteString(f, t.verboseString())
+	case verb == 'v' || verb == 's':
+		io.WriteString(f, t.String())
+	default:
+		fmt.Fprintf(f, "%%!%c(*gotile.TileCoder=%v)", verb, t.String())
+	}
+}
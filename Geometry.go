@@ -0,0 +1,157 @@
+package gotile
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/spatial/r1"
+)
+
+// TileBounds returns the hyper-rectangle covered by the tile with the
+// given index, as returned by Index, in the input space. The returned
+// slice holds the interval covered along each dimension of the tiling,
+// in the order of Dims. Intervals account for the offset of the tiling
+// and are clipped to the bounds of the tiling, except for the
+// underflow and overflow tiles of the BoundsExtend policy, which
+// extend to infinity. For the BoundsWrap policy, the part of a tile
+// which wraps around to the opposite end of a dimension is not
+// included. TileBounds panics if index is not the index of a tile. See
+// TryTileBounds for a non-panicking variant.
+func (t *Tiling) TileBounds(index int) []r1.Interval {
+	bounds, err := t.TryTileBounds(index)
+	if err != nil {
+		panic(err)
+	}
+	return bounds
+}
+
+// TryTileBounds returns the hyper-rectangle covered by the tile with
+// the given index, as in TileBounds. If index is not the index of a
+// tile, or is the index of the non-finite tile of the NonFiniteTile
+// policy, an error is returned.
+func (t *Tiling) TryTileBounds(index int) ([]r1.Interval, error) {
+	coords, err := t.tileCoordinates(index)
+	if err != nil {
+		return nil, fmt.Errorf("tileBounds: %v", err)
+	}
+	bounds := make([]r1.Interval, len(t.bins))
+	for i, c := range coords {
+		lo, hi := t.tileInterval(i, c)
+		bounds[i] = r1.Interval{Min: t.invert(i, lo), Max: t.invert(i, hi)}
+	}
+	return bounds, nil
+}
+
+// TileCenter returns the center of the tile with the given index, as
+// returned by Index, in the input space. The returned slice holds the
+// center along each dimension of the tiling, in the order of Dims.
+// Centers are computed in the space in which tiles are equally spaced,
+// so that the center of a log-scaled tile is the geometric mean of its
+// bounds. The center of an underflow or overflow tile of the
+// BoundsExtend policy is placed half a bin beyond the bound of the
+// tiling which it borders, or at the bound itself if no such point
+// exists, as for infinite bounds of squashed dimensions.
+// TileCenter panics if index is not the index of a tile. See
+// TryTileCenter for a non-panicking variant.
+func (t *Tiling) TileCenter(index int) []float64 {
+	center, err := t.TryTileCenter(index)
+	if err != nil {
+		panic(err)
+	}
+	return center
+}
+
+// TryTileCenter returns the center of the tile with the given index, as
+// in TileCenter. If index is not the index of a tile, or is the index
+// of the non-finite tile of the NonFiniteTile policy, an error is
+// returned.
+func (t *Tiling) TryTileCenter(index int) ([]float64, error) {
+	coords, err := t.tileCoordinates(index)
+	if err != nil {
+		return nil, fmt.Errorf("tileCenter: %v", err)
+	}
+	center := make([]float64, len(t.bins))
+	for i, c := range coords {
+		lo, hi := t.tileInterval(i, c)
+		switch {
+		case math.IsInf(lo, -1):
+			center[i] = t.invert(i, hi-t.binLengths[i]/2)
+			if math.IsNaN(center[i]) {
+				center[i] = t.low[i]
+			}
+		case math.IsInf(hi, 1):
+			center[i] = t.invert(i, lo+t.binLengths[i]/2)
+			if math.IsNaN(center[i]) {
+				center[i] = t.high[i]
+			}
+		default:
+			center[i] = t.invert(i, (lo+hi)/2)
+		}
+	}
+	return center, nil
+}
+
+// tileCoordinates returns the coordinates of the tile with the given
+// index, as used by place, or an error if index is not the index of a
+// tile covering a region
+func (t *Tiling) tileCoordinates(index int) ([]int, error) {
+	if index < 0 || index >= t.Tiles() {
+		return nil, fmt.Errorf("tile index %d out of range [0, %d)", index,
+			t.Tiles())
+	}
+	if t.nonFinite == NonFiniteTile && index == t.nonFiniteTile() {
+		return nil, fmt.Errorf("tile index %d is the non-finite tile, "+
+			"which covers no region", index)
+	}
+
+	coords := t.coordinates(index)
+	if t.policy == BoundsExtend {
+		// Undo the shift applied by coordinates, so that the underflow
+		// tile has coordinate 0
+		for i := range coords {
+			coords[i]++
+		}
+	}
+	return coords, nil
+}
+
+// tileInterval returns the interval covered along dimension i by the
+// tile with coordinate c, in the space in which tiles are equally
+// spaced. The coordinate includes the underflow tile of the
+// BoundsExtend policy, as returned by place.
+func (t *Tiling) tileInterval(i, c int) (lo, hi float64) {
+	min := t.minDims.AtVec(i)
+	max := min + float64(t.bins[i])*t.binLengths[i]
+
+	if t.policy == BoundsExtend {
+		c--
+		if c < 0 {
+			return math.Inf(-1), min
+		} else if c >= t.bins[i] {
+			return max, math.Inf(1)
+		}
+	}
+
+	offset := t.offsets.At(0, i)
+	if e := t.edges[i]; e != nil {
+		lo, hi = e[c]-offset, e[c+1]-offset
+	} else {
+		lo = min + float64(c)*t.binLengths[i] - offset
+		hi = lo + t.binLengths[i]
+	}
+
+	// Inputs outside the offset tiling fall in the first or last tile
+	if c == 0 {
+		lo = min
+	}
+	if c == t.bins[i]-1 {
+		hi = max
+	}
+	return math.Max(lo, min), math.Min(hi, max)
+}
+
+// invert moves x along dimension i from the space in which tiles are
+// equally spaced back into the input space, inverting transform
+func (t *Tiling) invert(i int, x float64) float64 {
+	return t.squashes[i].Inverse(t.scales[i].invert(x))
+}
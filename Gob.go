@@ -0,0 +1,65 @@
+package gotile
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+)
+
+func init() {
+	// Allow tilings and tile coders to be gob encoded when stored in
+	// interface values
+	gob.Register(&Tiling{})
+	gob.Register(&TileCoder{})
+}
+
+// GobEncode implements the gob.GobEncoder interface. The entire state
+// of the tiling is encoded, including its offsets.
+func (t *Tiling) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(t.state()); err != nil {
+		return nil, fmt.Errorf("gobEncode: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements the gob.GobDecoder interface, migrating data
+// saved with older format versions
+func (t *Tiling) GobDecode(data []byte) error {
+	s, err := decodeTilingGob(data)
+	if err != nil {
+		return fmt.Errorf("gobDecode: %v", err)
+	}
+
+	tiling, err := newTilingFromState(s)
+	if err != nil {
+		return fmt.Errorf("gobDecode: %v", err)
+	}
+	*t = *tiling
+	return nil
+}
+
+// GobEncode implements the gob.GobEncoder interface. The entire state
+// of the tile coder is encoded, including the offsets of each tiling
+// and any adaptive bounds. A BoundsHook cannot be encoded; see
+// SetBoundsHook.
+func (t *TileCoder) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(t.state()); err != nil {
+		return nil, fmt.Errorf("gobEncode: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements the gob.GobDecoder interface, migrating data
+// saved with older format versions
+func (t *TileCoder) GobDecode(data []byte) error {
+	s, err := decodeTileCoderGob(data)
+	if err != nil {
+		return fmt.Errorf("gobDecode: %v", err)
+	}
+	if err := t.setState(s); err != nil {
+		return fmt.Errorf("gobDecode: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package gotile
+
+import (
+	"fmt"
+	"math"
+
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Golden holds a canonical set of input vectors and the non-zero
+// indices of their tile-coded representations. Saving a Golden set
+// alongside learned weights and verifying it after upgrading the
+// library detects any change to the feature mapping underneath the
+// weights. Golden sets are marshaled to JSON.
+type Golden struct {
+	Seed    uint64         `json:"seed"`
+	Vectors []GoldenVector `json:"vectors"`
+}
+
+// GoldenVector is a single input vector of a Golden set and the
+// non-zero indices of its tile-coded representation, ordered as in
+// EncodeIndices
+type GoldenVector struct {
+	Input   floats    `json:"input"`
+	Indices []float64 `json:"indices"`
+}
+
+// NewGolden returns a Golden set for the TileCoder constructed from c.
+// The input vectors are the minimum, center, and maximum of the bounds
+// of c, followed by n vectors sampled uniformly within the bounds using
+// seed. Along dimensions with infinite bounds, the finite bound (or 0)
+// is used in place of the center and infinite bounds, and samples are
+// drawn from a standard normal distribution about it.
+func NewGolden(c Config, n int, seed uint64) (*Golden, error) {
+	tc, err := c.New()
+	if err != nil {
+		return nil, fmt.Errorf("newGolden: %v", err)
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("newGolden: cannot sample less than 0 "+
+			"vectors: %d", n)
+	}
+
+	dims := len(c.MinDims)
+	inputs := make([][]float64, 3, n+3)
+	for k := range inputs {
+		inputs[k] = make([]float64, dims)
+	}
+	rng := rand.New(rand.NewSource(seed))
+	for i := 0; i < dims; i++ {
+		lo, hi := c.MinDims[i], c.MaxDims[i]
+		inputs[0][i], inputs[1][i], inputs[2][i] = goldenBounds(lo, hi)
+	}
+	for k := 0; k < n; k++ {
+		v := make([]float64, dims)
+		for i := range v {
+			lo, hi := c.MinDims[i], c.MaxDims[i]
+			if math.IsInf(lo, 0) || math.IsInf(hi, 0) {
+				_, center, _ := goldenBounds(lo, hi)
+				v[i] = center + rng.NormFloat64()
+			} else {
+				v[i] = lo + rng.Float64()*(hi-lo)
+			}
+		}
+		inputs = append(inputs, v)
+	}
+
+	g := &Golden{Seed: seed, Vectors: make([]GoldenVector, len(inputs))}
+	for k, v := range inputs {
+		indices, err := tc.TryEncodeIndices(mat.NewVecDense(dims, v))
+		if err != nil {
+			return nil, fmt.Errorf("newGolden: vector %d: %w", k, err)
+		}
+		g.Vectors[k] = GoldenVector{Input: v, Indices: indices}
+	}
+	return g, nil
+}
+
+// goldenBounds returns the minimum, center, and maximum of the bounds
+// lo and hi, replacing infinite bounds by the finite bound or 0
+func goldenBounds(lo, hi float64) (min, center, max float64) {
+	switch {
+	case !math.IsInf(lo, 0) && !math.IsInf(hi, 0):
+		return lo, lo + (hi-lo)/2, hi
+	case !math.IsInf(lo, 0):
+		return lo, lo, lo
+	case !math.IsInf(hi, 0):
+		return hi, hi, hi
+	default:
+		return 0, 0, 0
+	}
+}
+
+// Verify returns an error describing the first vector of the receiver
+// which tc encodes differently, if any. Vectors without inputs cannot be
+// encoded, and so are reported as errors.
+func (g *Golden) Verify(tc *TileCoder) error {
+	for k, gv := range g.Vectors {
+		if len(gv.Input) == 0 {
+			return fmt.Errorf("verify: vector %d: %w", k, &DimensionError{
+				Have: 0, Want: tc.InputDims()})
+		}
+		v := mat.NewVecDense(len(gv.Input), append([]float64(nil),
+			gv.Input...))
+		indices, err := tc.TryEncodeIndices(v)
+		if err != nil {
+			return fmt.Errorf("verify: vector %d: %w", k, err)
+		}
+		if !equalIndices(indices, gv.Indices) {
+			return fmt.Errorf("verify: vector %d (%v): have indices %v, "+
+				"want %v", k, gv.Input, indices, gv.Indices)
+		}
+	}
+	return nil
+}
+
+// equalIndices returns whether a and b hold the same indices in the
+// same order. Either may be empty.
+func equalIndices(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// VerifyConfig returns an error describing the first vector of the
+// receiver which the TileCoder constructed from c encodes differently,
+// if any
+func (g *Golden) VerifyConfig(c Config) error {
+	tc, err := c.New()
+	if err != nil {
+		return fmt.Errorf("verifyConfig: %v", err)
+	}
+	if err := g.Verify(tc); err != nil {
+		return fmt.Errorf("verifyConfig: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package gotile
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/spatial/r1"
+)
+
+// Heatmap projects the learned weights of a linear function of the
+// tile-coded features back onto a 2D slice of input space, for
+// plotting value functions. The slice varies input dimension x over
+// xBounds and input dimension y over yBounds, holding every other
+// input dimension fixed at its value in point. The slice is divided
+// into a grid of rows by cols cells, and element (i, j) of the
+// returned matrix is the sum of the weights of the tiles overlapping
+// the cell in row i and column j, over every tiling, plus the weight
+// of the bias unit. Rows are ordered by increasing y and columns by
+// increasing x. Cells which overlap no tile of some tiling using the
+// BoundsError policy, since they lie outside its bounds, are NaN.
+//
+// The tiles of tilings with random rotations (see WithRotation) which
+// overlap a cell are found by sampling the cell on a grid finer than
+// their tiles, so that tiles overlapping only a sliver of a cell may
+// be missed.
+//
+// There should be a single weight for each of the VecLength()
+// features of the receiver, and point should have the input
+// dimensions of the receiver, otherwise a *DimensionError is returned
+// for point.
+// Computing a heatmap does not adapt the bounds of the receiver or
+// count visits.
+func (t *TileCoder) Heatmap(weights []float64, point mat.Vector, x, y int,
+	xBounds, yBounds r1.Interval, rows, cols int) (*mat.Dense, error) {
+	if len(weights) != t.VecLength() {
+		return nil, fmt.Errorf("heatmap: there should be a single weight "+
+			"for each feature: \n\thave(%d) \n\twant(%d)", len(weights),
+			t.VecLength())
+	}
+	if err := checkSlice(point, x, y, rows, cols); err != nil {
+		return nil, fmt.Errorf("heatmap: %w", err)
+	}
+	if err := t.checkDims(point); err != nil {
+		return nil, fmt.Errorf("heatmap: %w", err)
+	}
+	for _, bounds := range []r1.Interval{xBounds, yBounds} {
+		if !isFinite(bounds.Min) || !isFinite(bounds.Max) ||
+			bounds.Min >= bounds.Max {
+			return nil, fmt.Errorf("heatmap: bounds must be finite and "+
+				"non-empty: have([%v, %v])", bounds.Min, bounds.Max)
+		}
+	}
+
+	bias := 0.0
+	if t.includeBias {
+		bias = weights[0]
+	}
+	v := mat.VecDenseCopyOf(point).RawVector().Data
+	xWidth := (xBounds.Max - xBounds.Min) / float64(cols)
+	yWidth := (yBounds.Max - yBounds.Min) / float64(rows)
+
+	heatmap := mat.NewDense(rows, cols, nil)
+	for i := 0; i < rows; i++ {
+		yCell := r1.Interval{
+			Min: yBounds.Min + float64(i)*yWidth,
+			Max: yBounds.Min + float64(i+1)*yWidth,
+		}
+	cells:
+		for j := 0; j < cols; j++ {
+			xCell := r1.Interval{
+				Min: xBounds.Min + float64(j)*xWidth,
+				Max: xBounds.Min + float64(j+1)*xWidth,
+			}
+
+			value := bias
+			for k, tiling := range t.tilings {
+				offset := t.featuresBeforeTiling(k)
+				if t.includeBias {
+					offset++
+				}
+				err := tiling.cellTiles(v, x, y, xCell, yCell, func(tile int) {
+					value += weights[offset+tile]
+				})
+				if errors.Is(err, ErrOutOfBounds) {
+					heatmap.Set(i, j, math.NaN())
+					continue cells
+				} else if err != nil {
+					return nil, fmt.Errorf("heatmap: tiling %d: %w", k, err)
+				}
+			}
+			heatmap.Set(i, j, value)
+		}
+	}
+	return heatmap, nil
+}
+
+// cellTiles calls f with each tile of the tiling which overlaps the
+// cell of a 2D slice of input space in which input dimension x varies
+// over xCell and input dimension y varies over yCell, while every other
+// input dimension d is fixed at v[d]. If the tiling uses the
+// BoundsError policy and the cell lies outside its bounds, an error
+// wrapping ErrOutOfBounds is returned.
+func (t *Tiling) cellTiles(v []float64, x, y int, xCell, yCell r1.Interval,
+	f func(tile int)) error {
+	// The NonFiniteTile policy places the whole cell in a single tile
+	// if any fixed dimension is non-finite
+	if t.nonFinite == NonFiniteTile {
+		for i, d := range t.dims {
+			if d != x && d != y && t.isNonFinite(i, v[d]) {
+				f(t.nonFiniteTile())
+				return nil
+			}
+		}
+	}
+
+	// Find the part of the cell along each tiled dimension, which is
+	// the fixed value of dimensions outside the slice
+	n := len(t.bins)
+	lower, upper := make([]float64, n), make([]float64, n)
+	for i, d := range t.dims {
+		switch d {
+		case x:
+			lower[i], upper[i] = xCell.Min, xCell.Max
+		case y:
+			lower[i], upper[i] = yCell.Min, yCell.Max
+		default:
+			lower[i], upper[i] = v[d], v[d]
+			continue
+		}
+		if t.policy == BoundsError {
+			lower[i] = math.Max(lower[i], t.low[i])
+			upper[i] = math.Min(upper[i], t.high[i])
+			if lower[i] >= upper[i] {
+				return fmt.Errorf("dimension %d: cell outside [%v, %v]: %w",
+					d, t.low[i], t.high[i], ErrOutOfBounds)
+			}
+		}
+
+		// Tiles overlap the interior of the cell, so that tiles which
+		// only touch its boundary are excluded
+		lower[i] = math.Nextafter(lower[i], math.Inf(1))
+		upper[i] = math.Nextafter(upper[i], math.Inf(-1))
+	}
+
+	if t.rotation != nil {
+		return t.sampleCellTiles(v, lower, upper, f)
+	}
+
+	// Along each dimension, the cell overlaps count[i] consecutive
+	// tiles starting from first[i], which wrap around with the
+	// BoundsWrap policy
+	first, count := make([]int, n), make([]int, n)
+	for i := range t.bins {
+		lo, err := t.place(i, lower[i])
+		if err != nil {
+			return err
+		}
+		hi, err := t.place(i, upper[i])
+		if err != nil {
+			return err
+		}
+		first[i], count[i] = lo, hi-lo+1
+		if t.policy == BoundsWrap {
+			width := float64(t.bins[i]) * t.binLengths[i]
+			if t.transform(i, upper[i])-t.transform(i, lower[i]) >= width {
+				first[i], count[i] = 0, t.bins[i]
+			} else {
+				count[i] = (hi-lo+t.bins[i])%t.bins[i] + 1
+			}
+		}
+	}
+
+	// Visit every combination of overlapped tiles along each dimension
+	steps := make([]int, n)
+	for {
+		index := 0
+		for i, step := range steps {
+			index += ((first[i] + step) % t.size(i)) * t.strides[i]
+		}
+		f(index)
+
+		i := n - 1
+		for ; i >= 0; i-- {
+			if steps[i]++; steps[i] < count[i] {
+				break
+			}
+			steps[i] = 0
+		}
+		if i < 0 {
+			return nil
+		}
+	}
+}
+
+// sampleCellTiles calls f with each distinct tile of the rotated
+// tiling in which a grid of samples of the cell falls, where tiled
+// dimension i of the cell spans [lower[i], upper[i]] and v holds the
+// remaining input dimensions
+func (t *Tiling) sampleCellTiles(v []float64, lower, upper []float64,
+	f func(tile int)) error {
+	// Take enough samples along each dimension that neighbouring
+	// samples are at most a quarter of a tile apart, or four samples
+	// per tile across the whole tiling for cells wider than the tiling
+	n := len(t.bins)
+	samples := make([]int, n)
+	for i := range samples {
+		width := t.transform(i, upper[i]) - t.transform(i, lower[i])
+		width = math.Min(width, float64(t.bins[i])*t.binLengths[i])
+		samples[i] = int(math.Ceil(4*width/t.binLengths[i])) + 1
+	}
+
+	x := make([]float64, len(v))
+	seen := make(map[int]bool)
+	steps := make([]int, n)
+	for {
+		copy(x, v)
+		for i, d := range t.dims {
+			if samples[i] > 1 {
+				frac := float64(steps[i]) / float64(samples[i]-1)
+				x[d] = lower[i] + frac*(upper[i]-lower[i])
+			}
+		}
+		tile, err := t.TryIndexSlice(x)
+		if err != nil {
+			return err
+		}
+		if !seen[tile] {
+			seen[tile] = true
+			f(tile)
+		}
+
+		i := n - 1
+		for ; i >= 0; i-- {
+			if steps[i]++; steps[i] < samples[i] {
+				break
+			}
+			steps[i] = 0
+		}
+		if i < 0 {
+			return nil
+		}
+	}
+}
+
+// checkSlice returns an error if x and y do not select two distinct
+// dimensions of point, or if a grid of rows by cols cells is empty
+func checkSlice(point mat.Vector, x, y, rows, cols int) error {
+	if isNil(point) {
+		return ErrNilInput
+	}
+	if x < 0 || x >= point.Len() || y < 0 || y >= point.Len() {
+		return fmt.Errorf("dimensions (%d, %d) out of range [0, %d)", x, y,
+			point.Len())
+	}
+	if x == y {
+		return fmt.Errorf("cannot slice along dimension %d twice", x)
+	}
+	if rows <= 0 || cols <= 0 {
+		return fmt.Errorf("grid must have at least one row and column: "+
+			"have(%d x %d)", rows, cols)
+	}
+	return nil
+}
+
+// forCells calls f with the center of the cell in each row i and column
+// j of a grid of rows by cols cells over the 2D slice of input space
+// which varies dimension x over xBounds and dimension y over yBounds,
+// holding every other dimension fixed at its value in point. The
+// vector passed to f is reused between calls.
+func forCells(point mat.Vector, x, y int, xBounds, yBounds r1.Interval,
+	rows, cols int, f func(i, j int, v mat.Vector)) {
+	v := mat.VecDenseCopyOf(point)
+	xWidth := (xBounds.Max - xBounds.Min) / float64(cols)
+	yWidth := (yBounds.Max - yBounds.Min) / float64(rows)
+	for i := 0; i < rows; i++ {
+		v.SetVec(y, yBounds.Min+(float64(i)+0.5)*yWidth)
+		for j := 0; j < cols; j++ {
+			v.SetVec(x, xBounds.Min+(float64(j)+0.5)*xWidth)
+			f(i, j, v)
+		}
+	}
+}
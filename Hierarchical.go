@@ -0,0 +1,73 @@
+package gotile
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// NewHierarchical returns a new TileCoder whose tilings form a pyramid
+// of levels at doubling resolutions over the bounds minDims and
+// maxDims, giving coarse-to-fine generalization. Level l has tilings
+// tilings, each with 2^l bins along every input dimension, so that the
+// first level has a single tile, the second 2 × 2 × ... tiles, and so
+// on. Tilings are ordered by level, so that tiling k belongs to level
+// k / tilings. See New for a description of the remaining arguments.
+// Since the dimensions of each tiling are determined by its level,
+// WithGroups cannot be used, and since the tilings of a level have the
+// same bins, they are offset using distinct seeds (see
+// WithDistinctSeeds).
+//
+// If levelScales is not nil, it gives the value of the active feature
+// of each tiling at each level in the tile-coded representation, in
+// place of 1.0. For example, decreasing scales weight coarse levels
+// more heavily than fine levels in linear function approximation.
+func NewHierarchical(minDims, maxDims mat.Vector, levels, tilings int,
+	seed uint64, includeBias bool, offsetDiv float64, levelScales []float64,
+	opts ...Option) (*TileCoder, error) {
+	if isNil(minDims) || isNil(maxDims) {
+		return nil, fmt.Errorf("newHierarchical: %w", ErrNilInput)
+	}
+	if levels < 1 || tilings < 1 {
+		return nil, fmt.Errorf("newHierarchical: cannot have less than 1 "+
+			"level or tiling per level: have(%d levels, %d tilings)", levels,
+			tilings)
+	}
+	if levelScales != nil && len(levelScales) != levels {
+		return nil, fmt.Errorf("newHierarchical: there should be a single "+
+			"scale for each level: \n\thave(%d) \n\twant(%d)",
+			len(levelScales), levels)
+	}
+	for l, scale := range levelScales {
+		if math.IsInf(scale, 0) || math.IsNaN(scale) {
+			return nil, fmt.Errorf("newHierarchical: scale of level %d is "+
+				"not finite: %v", l, scale)
+		}
+	}
+
+	bins := make([][]int, 0, levels*tilings)
+	for l := 0; l < levels; l++ {
+		for k := 0; k < tilings; k++ {
+			levelBins := make([]int, minDims.Len())
+			for i := range levelBins {
+				levelBins[i] = 1 << l
+			}
+			bins = append(bins, levelBins)
+		}
+	}
+
+	tc, err := New(minDims, maxDims, bins, seed, includeBias, offsetDiv,
+		append([]Option{WithDistinctSeeds()}, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("newHierarchical: %v", err)
+	}
+
+	if levelScales != nil {
+		tc.activations = make([]float64, len(bins))
+		for k := range tc.activations {
+			tc.activations[k] = levelScales[k/tilings]
+		}
+	}
+	return tc, nil
+}
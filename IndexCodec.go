@@ -0,0 +1,179 @@
+package gotile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// IndexCodec serializes the non-zero indices of tile-coded
+// representations compactly, for storing millions of encoded
+// transitions on disk or sending them over the wire. Each index is
+// stored as the zig-zag varint encoded difference from the previous
+// index of the same sample, so that the increasing indices of
+// successive tilings take only a byte or two each, while the order of
+// the indices is preserved exactly.
+//
+// A single sample is encoded as its number of indices followed by its
+// indices. A batch, as returned by EncodeIndicesBatch, is encoded as a
+// frame holding its number of samples and number of indices per
+// sample, followed by the indices of each sample. Encoded samples and
+// batches are self-delimiting, so many can be concatenated in a single
+// file or stream.
+//
+// The zero value is ready to use.
+type IndexCodec struct{}
+
+// AppendIndices appends the encoding of the indices of a single sample,
+// as returned by EncodeIndices, to dst and returns the extended buffer
+func (IndexCodec) AppendIndices(dst []byte, indices []float64) []byte {
+	dst = appendUvarint(dst, uint64(len(indices)))
+	prev := 0
+	for _, index := range indices {
+		dst, prev = appendDelta(dst, prev, int(index))
+	}
+	return dst
+}
+
+// DecodeIndices decodes the indices of a single sample encoded at the
+// start of data by AppendIndices, returning the indices and the number
+// of bytes consumed
+func (IndexCodec) DecodeIndices(data []byte) ([]float64, int, error) {
+	r := bytes.NewReader(data)
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, 0, fmt.Errorf("decodeIndices: %v", decodeErr(err))
+	}
+	if n > uint64(r.Len()) {
+		return nil, 0, fmt.Errorf("decodeIndices: %v", errShortBuffer)
+	}
+
+	indices, err := readDeltas(r, int(n), make([]float64, 0, n))
+	if err != nil {
+		return nil, 0, fmt.Errorf("decodeIndices: %v", decodeErr(err))
+	}
+	return indices, len(data) - r.Len(), nil
+}
+
+// AppendBatch appends the frame encoding a batch of indices, as
+// returned by EncodeIndicesBatch, to dst and returns the extended
+// buffer
+func (IndexCodec) AppendBatch(dst []byte, indices *mat.Dense) []byte {
+	rows, cols := indices.Dims()
+	dst = appendUvarint(dst, uint64(cols))
+	dst = appendUvarint(dst, uint64(rows))
+	for j := 0; j < cols; j++ {
+		prev := 0
+		for i := 0; i < rows; i++ {
+			dst, prev = appendDelta(dst, prev, int(indices.At(i, j)))
+		}
+	}
+	return dst
+}
+
+// DecodeBatch decodes the batch encoded at the start of data by
+// AppendBatch, returning the batch of indices, with a column for each
+// sample, and the number of bytes consumed
+func (c IndexCodec) DecodeBatch(data []byte) (*mat.Dense, int, error) {
+	r := bytes.NewReader(data)
+	indices, err := c.readBatch(r)
+	if err != nil {
+		return nil, 0, fmt.Errorf("decodeBatch: %v", decodeErr(err))
+	}
+	return indices, len(data) - r.Len(), nil
+}
+
+// WriteBatch writes the frame encoding a batch of indices, as returned
+// by EncodeIndicesBatch, to w
+func (c IndexCodec) WriteBatch(w io.Writer, indices *mat.Dense) error {
+	if _, err := w.Write(c.AppendBatch(nil, indices)); err != nil {
+		return fmt.Errorf("writeBatch: %v", err)
+	}
+	return nil
+}
+
+// ReadBatch reads the next frame written by WriteBatch from r and
+// returns the batch of indices, with a column for each sample. If r
+// holds no more frames, io.EOF is returned. Wrap readers which do not
+// implement io.ByteReader with a bufio.Reader.
+func (c IndexCodec) ReadBatch(r io.ByteReader) (*mat.Dense, error) {
+	indices, err := c.readBatch(r)
+	if err == io.EOF {
+		return nil, io.EOF
+	} else if err != nil {
+		return nil, fmt.Errorf("readBatch: %v", decodeErr(err))
+	}
+	return indices, nil
+}
+
+// readBatch reads a frame from r, returning io.EOF only if r holds no
+// more data
+func (IndexCodec) readBatch(r io.ByteReader) (*mat.Dense, error) {
+	cols, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := binary.ReadUvarint(r)
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return nil, err
+	}
+	if rows == 0 || cols == 0 {
+		return nil, fmt.Errorf("empty batch of %d x %d indices", rows, cols)
+	}
+
+	// Indices are read sample by sample, rather than allocated up front,
+	// so that corrupt lengths cannot cause huge allocations
+	var backing []float64
+	for j := uint64(0); j < cols; j++ {
+		if backing, err = readDeltas(r, int(rows), backing); err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	// The indices of each sample are contiguous in backing, which
+	// therefore holds the transpose of the batch
+	return mat.DenseCopyOf(mat.NewDense(int(cols), int(rows), backing).T()),
+		nil
+}
+
+// appendDelta appends the zig-zag varint encoded difference between
+// index and prev to dst, returning the extended buffer and index
+func appendDelta(dst []byte, prev, index int) ([]byte, int) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(b[:], int64(index-prev))
+	return append(dst, b[:n]...), index
+}
+
+// readDeltas reads n indices encoded by appendDelta from r and appends
+// them to dst
+func readDeltas(r io.ByteReader, n int, dst []float64) ([]float64, error) {
+	index := int64(0)
+	for i := 0; i < n; i++ {
+		delta, err := binary.ReadVarint(r)
+		if err != nil {
+			return nil, err
+		}
+		index += delta
+		if index < 0 {
+			return nil, fmt.Errorf("negative index %d", index)
+		}
+		dst = append(dst, float64(index))
+	}
+	return dst, nil
+}
+
+// decodeErr returns errShortBuffer in place of the errors returned
+// when reading truncated data
+func decodeErr(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return errShortBuffer
+	}
+	return err
+}
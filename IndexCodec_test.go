@@ -0,0 +1,87 @@
+package gotile
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestIndexCodec(t *testing.T) {
+	tc := newTestTileCoder(t)
+	var c IndexCodec
+
+	// Single samples round trip, including out-of-order indices
+	for _, indices := range [][]float64{
+		tc.EncodeIndices(mat.NewVecDense(2, []float64{0.2, 50})),
+		{5, 3, 300, 0},
+		{},
+	} {
+		data := c.AppendIndices([]byte{0xff}, indices)[1:]
+		have, n, err := c.DecodeIndices(append(data, 0xff))
+		if err != nil {
+			t.Fatalf("decodeIndices(%v): %v", indices, err)
+		}
+		if n != len(data) || !reflect.DeepEqual(have, indices) {
+			t.Errorf("decodeIndices(%v): have(%v, %d) want(%v, %d)", indices,
+				have, n, indices, len(data))
+		}
+		if _, _, err := c.DecodeIndices(data[:len(data)-1]); err == nil {
+			t.Errorf("decodeIndices(%v): expected error with truncated data",
+				indices)
+		}
+	}
+
+	// Batches round trip through a stream of frames
+	b1 := mat.NewDense(2, 3, []float64{-3, 0, 0.2, -1, 5, 50})
+	b2 := mat.NewDense(2, 1, []float64{9, 1})
+	batches := []*mat.Dense{tc.EncodeIndicesBatch(b1), tc.EncodeIndicesBatch(b2)}
+
+	var buf bytes.Buffer
+	for _, batch := range batches {
+		if err := c.WriteBatch(&buf, batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The frames are far smaller than the indices as float64s
+	if float64s := 8 * 4 * (tc.NumTilings() + 1); buf.Len() >= float64s/4 {
+		t.Errorf("writeBatch: have(%d bytes) want < %d bytes", buf.Len(),
+			float64s/4)
+	}
+
+	data := buf.Bytes()
+	first, n, err := c.DecodeBatch(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mat.Equal(first, batches[0]) {
+		t.Errorf("decodeBatch: have(%v) want(%v)", mat.Formatted(first),
+			mat.Formatted(batches[0]))
+	}
+	if _, _, err := c.DecodeBatch(data[:n-1]); err == nil {
+		t.Error("expected error decoding truncated batch")
+	}
+
+	r := bufio.NewReader(bytes.NewReader(data))
+	for i, want := range batches {
+		have, err := c.ReadBatch(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !mat.Equal(have, want) {
+			t.Errorf("readBatch(%d): have(%v) want(%v)", i, mat.Formatted(have),
+				mat.Formatted(want))
+		}
+	}
+	if _, err := c.ReadBatch(r); err != io.EOF {
+		t.Errorf("readBatch: have(%v) want(%v)", err, io.EOF)
+	}
+	r = bufio.NewReader(bytes.NewReader(data[:n-1]))
+	if _, err := c.ReadBatch(r); err == nil || err == io.EOF {
+		t.Errorf("readBatch: have(%v) want error with truncated batch", err)
+	}
+}
@@ -0,0 +1,114 @@
+package gotile
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// MarshalJSON implements the json.Marshaler interface. The entire
+// state of the tiling is marshaled, including its offsets, so that the
+// tiling is reproduced exactly by UnmarshalJSON.
+func (t *Tiling) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.state())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface,
+// migrating data saved with older format versions
+func (t *Tiling) UnmarshalJSON(data []byte) error {
+	data, err := migrateJSON(data, false)
+	if err != nil {
+		return fmt.Errorf("unmarshalJSON: %v", err)
+	}
+
+	var s tilingState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("unmarshalJSON: %v", err)
+	}
+
+	tiling, err := newTilingFromState(s)
+	if err != nil {
+		return fmt.Errorf("unmarshalJSON: %v", err)
+	}
+	*t = *tiling
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface. The entire
+// state of the tile coder is marshaled, including the offsets of each
+// tiling and any adaptive bounds, so that the feature mapping of the
+// tile coder is reproduced exactly by UnmarshalJSON. A BoundsHook
+// cannot be marshaled; see SetBoundsHook.
+func (t *TileCoder) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.state())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface,
+// migrating data saved with older format versions
+func (t *TileCoder) UnmarshalJSON(data []byte) error {
+	data, err := migrateJSON(data, true)
+	if err != nil {
+		return fmt.Errorf("unmarshalJSON: %v", err)
+	}
+
+	var s tileCoderState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("unmarshalJSON: %v", err)
+	}
+	if err := t.setState(s); err != nil {
+		return fmt.Errorf("unmarshalJSON: %v", err)
+	}
+	return nil
+}
+
+// floats is a []float64 which can be marshaled to JSON even when it
+// holds infinite or NaN values, which are marshaled as strings
+type floats []float64
+
+// MarshalJSON implements the json.Marshaler interface
+func (f floats) MarshalJSON() ([]byte, error) {
+	if f == nil {
+		return []byte("null"), nil
+	}
+
+	values := make([]interface{}, len(f))
+	for i, x := range f {
+		if math.IsInf(x, 0) || math.IsNaN(x) {
+			values[i] = strconv.FormatFloat(x, 'g', -1, 64)
+		} else {
+			values[i] = x
+		}
+	}
+	return json.Marshal(values)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (f *floats) UnmarshalJSON(data []byte) error {
+	var values []interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	if values == nil {
+		*f = nil
+		return nil
+	}
+
+	out := make(floats, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case float64:
+			out[i] = v
+		case string:
+			x, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return fmt.Errorf("invalid float %q", v)
+			}
+			out[i] = x
+		default:
+			return fmt.Errorf("invalid float %v", v)
+		}
+	}
+	*f = out
+	return nil
+}
@@ -0,0 +1,266 @@
+package gotile
+
+import (
+	"fmt"
+	"math"
+
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+)
+
+// KanervaCoder performs Kanerva coding, a form of sparse distributed
+// coding. A number of prototype points are sampled uniformly at random
+// within the bounds of the input space, and a vector activates the k
+// prototypes nearest to it. Since the number of features is the number
+// of prototypes rather than growing exponentially with the number of
+// dimensions, Kanerva coding scales to high-dimensional inputs for
+// which tilings are infeasible.
+//
+// Distances are measured after scaling each dimension to [0, 1] by its
+// bounds, so that dimensions with large ranges do not dominate.
+type KanervaCoder struct {
+	prototypes  *mat.Dense // Prototypes in the rows, scaled to [0, 1]
+	low, ranges []float64  // Minimum and width of each dimension
+	k           int
+	includeBias bool
+}
+
+// NewKanerva returns a new KanervaCoder with the given number of
+// prototypes, sampled using seed within the bounds minDims and
+// maxDims, which must be finite. Each vector activates its k nearest
+// prototypes. If includeBias is true, the first feature is a bias unit
+// which is always active.
+func NewKanerva(minDims, maxDims mat.Vector, prototypes, k int, seed uint64,
+	includeBias bool) (*KanervaCoder, error) {
+	if isNil(minDims) || isNil(maxDims) {
+		return nil, fmt.Errorf("newKanerva: %w", ErrNilInput)
+	}
+	if minDims.Len() != maxDims.Len() {
+		return nil, fmt.Errorf("newKanerva: cannot specify minimum with "+
+			"fewer dimensions than maximum: %d != %d", minDims.Len(),
+			maxDims.Len())
+	}
+	if prototypes < 1 {
+		return nil, fmt.Errorf("newKanerva: cannot have less than 1 " +
+			"prototype")
+	}
+	if k < 1 || k > prototypes {
+		return nil, fmt.Errorf("newKanerva: number of active prototypes %d "+
+			"out of range [1, %d]", k, prototypes)
+	}
+
+	dims := minDims.Len()
+	low := make([]float64, dims)
+	ranges := make([]float64, dims)
+	for i := 0; i < dims; i++ {
+		min, max := minDims.AtVec(i), maxDims.AtVec(i)
+		if math.IsInf(min, 0) || math.IsInf(max, 0) || max <= min {
+			return nil, fmt.Errorf("newKanerva: bounds of dimension %d "+
+				"must be finite with maximum exceeding minimum: [%v, %v]",
+				i, min, max)
+		}
+		low[i], ranges[i] = min, max-min
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	points := mat.NewDense(prototypes, dims, nil)
+	raw := points.RawMatrix().Data
+	for i := range raw {
+		raw[i] = rng.Float64()
+	}
+
+	return &KanervaCoder{
+		prototypes:  points,
+		low:         low,
+		ranges:      ranges,
+		k:           k,
+		includeBias: includeBias,
+	}, nil
+}
+
+// Prototypes returns the prototypes of the receiver in input space,
+// one in each row of the returned matrix
+func (c *KanervaCoder) Prototypes() *mat.Dense {
+	rows, cols := c.prototypes.Dims()
+	points := mat.NewDense(rows, cols, nil)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			points.Set(i, j, c.low[j]+c.prototypes.At(i, j)*c.ranges[j])
+		}
+	}
+	return points
+}
+
+// K returns the number of prototypes activated by each vector
+func (c *KanervaCoder) K() int {
+	return c.k
+}
+
+// VecLength returns the number of features in each encoded vector,
+// which is the number of prototypes plus one if the receiver includes
+// a bias unit
+func (c *KanervaCoder) VecLength() int {
+	rows, _ := c.prototypes.Dims()
+	if c.includeBias {
+		return rows + 1
+	}
+	return rows
+}
+
+// EncodeIndices returns the indices of the features of the k
+// prototypes nearest to v, from nearest to furthest, with the index of
+// the bias unit last if the receiver includes a bias unit. Ties are
+// broken in favour of the prototype with the lower index. If v does not
+// have a dimension for each dimension of the prototypes,
+// EncodeIndices panics. See TryEncodeIndices for a non-panicking
+// variant.
+func (c *KanervaCoder) EncodeIndices(v mat.Vector) []float64 {
+	indices, err := c.TryEncodeIndices(v)
+	if err != nil {
+		panic(err)
+	}
+	return indices
+}
+
+// TryEncodeIndices returns the indices of the features of the k
+// prototypes nearest to v, as in EncodeIndices. If v is nil or does
+// not have a dimension for each dimension of the prototypes, an error
+// wrapping ErrNilInput or ErrDimension is returned.
+func (c *KanervaCoder) TryEncodeIndices(v mat.Vector) ([]float64, error) {
+	nearest, err := c.nearest(v)
+	if err != nil {
+		return nil, fmt.Errorf("encodeIndices: %w", err)
+	}
+
+	indices := make([]float64, 0, c.k+1)
+	bias := 0
+	if c.includeBias {
+		bias = 1
+	}
+	for _, p := range nearest {
+		indices = append(indices, float64(p+bias))
+	}
+	if c.includeBias {
+		indices = append(indices, 0)
+	}
+	return indices, nil
+}
+
+// Encode returns the Kanerva-coded representation of v, in which the
+// features of the k prototypes nearest to v are 1.0 and all others
+// are 0.0. If v does not have a dimension for each dimension of the
+// prototypes, Encode panics. See TryEncode for a non-panicking variant.
+func (c *KanervaCoder) Encode(v mat.Vector) *mat.VecDense {
+	encoded, err := c.TryEncode(v)
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
+// TryEncode returns the Kanerva-coded representation of v, as in
+// Encode. If v is nil or does not have a dimension for each dimension
+// of the prototypes, an error wrapping ErrNilInput or ErrDimension is
+// returned.
+func (c *KanervaCoder) TryEncode(v mat.Vector) (*mat.VecDense, error) {
+	indices, err := c.TryEncodeIndices(v)
+	if err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+	encoded := mat.NewVecDense(c.VecLength(), nil)
+	for _, index := range indices {
+		encoded.SetVec(int(index), 1.0)
+	}
+	return encoded, nil
+}
+
+// EncodeBatch returns the Kanerva-coded representation of each vector
+// in the batch b. Each column of b is a vector to encode, and each
+// column of the returned matrix is the encoding of the corresponding
+// column of b. If the vectors do not have a dimension for each
+// dimension of the prototypes, EncodeBatch panics. See TryEncodeBatch
+// for a non-panicking variant.
+func (c *KanervaCoder) EncodeBatch(b *mat.Dense) *mat.Dense {
+	encoded, err := c.TryEncodeBatch(b)
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
+// TryEncodeBatch returns the Kanerva-coded representation of each
+// vector in the batch b, as in EncodeBatch. If b is nil or the vectors
+// do not have a dimension for each dimension of the prototypes, an
+// error wrapping ErrNilInput or ErrDimension is returned.
+func (c *KanervaCoder) TryEncodeBatch(b *mat.Dense) (*mat.Dense, error) {
+	if b == nil {
+		return nil, fmt.Errorf("encodeBatch: %w", ErrNilInput)
+	}
+	_, batchSize := b.Dims()
+	encoded := mat.NewDense(c.VecLength(), batchSize, nil)
+	for j := 0; j < batchSize; j++ {
+		nearest, err := c.nearest(b.ColView(j))
+		if err != nil {
+			return nil, fmt.Errorf("encodeBatch: vector %d: %w", j, err)
+		}
+		for _, p := range nearest {
+			if c.includeBias {
+				p++
+			}
+			encoded.Set(p, j, 1.0)
+		}
+		if c.includeBias {
+			encoded.Set(0, j, 1.0)
+		}
+	}
+	return encoded, nil
+}
+
+// nearest returns the k prototypes nearest to v, from nearest to
+// furthest. If v is nil or does not have a dimension for each
+// dimension of the prototypes, an error wrapping ErrNilInput or
+// ErrDimension is returned.
+func (c *KanervaCoder) nearest(v mat.Vector) ([]int, error) {
+	rows, cols := c.prototypes.Dims()
+	if isNil(v) {
+		return nil, ErrNilInput
+	}
+	if v.Len() != cols {
+		return nil, &DimensionError{Have: v.Len(), Want: cols}
+	}
+
+	scaled := make([]float64, cols)
+	for j := range scaled {
+		scaled[j] = (v.AtVec(j) - c.low[j]) / c.ranges[j]
+	}
+
+	// Keep the k nearest prototypes seen so far in order of distance,
+	// inserting each closer prototype into place
+	nearest := make([]int, 0, c.k)
+	distances := make([]float64, 0, c.k)
+	for i := 0; i < rows; i++ {
+		d := 0.0
+		for j, x := range c.prototypes.RawRowView(i) {
+			d += (x - scaled[j]) * (x - scaled[j])
+		}
+		if len(nearest) == c.k && d >= distances[c.k-1] {
+			continue
+		}
+
+		pos := len(nearest)
+		for pos > 0 && distances[pos-1] > d {
+			pos--
+		}
+		if len(nearest) < c.k {
+			nearest = append(nearest, 0)
+			distances = append(distances, 0)
+		}
+		copy(nearest[pos+1:], nearest[pos:])
+		copy(distances[pos+1:], distances[pos:])
+		nearest[pos], distances[pos] = i, d
+	}
+	return nearest, nil
+}
+
+// Ensure KanervaCoder implements TryCoder
+var _ TryCoder = (*KanervaCoder)(nil)
@@ -0,0 +1,64 @@
+package gotile
+
+import (
+	"sort"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestKanervaCoder(t *testing.T) {
+	minDims := mat.NewVecDense(3, []float64{-1, 0, 10})
+	maxDims := mat.NewVecDense(3, []float64{1, 1, 20})
+	const prototypes, k = 20, 3
+	kc, err := NewKanerva(minDims, maxDims, prototypes, k, 1, true)
+	if err != nil {
+		t.Fatalf("could not create Kanerva coder: %v", err)
+	}
+	if n := kc.VecLength(); n != prototypes+1 {
+		t.Errorf("vecLength: have(%v) want(%v)", n, prototypes+1)
+	}
+
+	// Each prototype is its own nearest prototype, and the remaining
+	// active prototypes are the next nearest
+	points := kc.Prototypes()
+	for i := 0; i < prototypes; i++ {
+		v := points.RowView(i)
+		indices := kc.EncodeIndices(v)
+		if len(indices) != k+1 || indices[0] != float64(i+1) ||
+			indices[k] != 0 {
+			t.Errorf("encodeIndices(prototype %v): have(%v)", i, indices)
+			continue
+		}
+
+		distances := make([]float64, prototypes)
+		for p := range distances {
+			for j := 0; j < 3; j++ {
+				d := (points.At(p, j) - v.AtVec(j)) /
+					(maxDims.AtVec(j) - minDims.AtVec(j))
+				distances[p] += d * d
+			}
+		}
+		sorted := append([]float64(nil), distances...)
+		sort.Float64s(sorted)
+		for rank, index := range indices[:k] {
+			if distances[int(index)-1] != sorted[rank] {
+				t.Errorf("encodeIndices(prototype %v): prototype %v is not "+
+					"nearest %v", i, index-1, rank)
+			}
+		}
+	}
+
+	v := points.RowView(0)
+	if sum := mat.Sum(kc.Encode(v)); sum != k+1 {
+		t.Errorf("encode: have(%v) active features want(%v)", sum, k+1)
+	}
+	batch := kc.EncodeBatch(mat.DenseCopyOf(points.T()))
+	if !mat.Equal(batch.ColView(0), kc.Encode(v)) {
+		t.Error("encodeBatch: batch encoding differs from encode")
+	}
+
+	if _, err := NewKanerva(minDims, maxDims, 2, 3, 1, true); err == nil {
+		t.Error("expected error with more active than total prototypes")
+	}
+}
@@ -0,0 +1,174 @@
+package gotile
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+)
+
+// DropoutMask returns a mask for the tilings of the receiver, for use
+// with EncodeMasked, in which each tiling is independently masked with
+// probability rate using rng. If rng is nil, a source seeded with 0 is
+// used.
+func (t *TileCoder) DropoutMask(rate float64, rng *rand.Rand) []bool {
+	if rng == nil {
+		rng = rand.New(rand.NewSource(0))
+	}
+	mask := make([]bool, len(t.tilings))
+	for k := range mask {
+		mask[k] = rng.Float64() >= rate
+	}
+	return mask
+}
+
+// EncodeMasked encodes v as in Encode, using only the tilings k for
+// which mask[k] is true. The features of masked tilings are all 0.0,
+// while every feature keeps its index, so that the encodings of
+// different masks can be used with the same weights. The bias unit, if
+// any, is always active. If mask does not have a value for each tiling,
+// or if some tiling uses the BoundsError policy and v falls outside its
+// bounds, EncodeMasked panics. See TryEncodeMasked for a non-panicking
+// variant.
+func (t *TileCoder) EncodeMasked(v mat.Vector, mask []bool) *mat.VecDense {
+	encoded, err := t.TryEncodeMasked(v, mask)
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
+// TryEncodeMasked encodes v using only the tilings selected by mask,
+// as in EncodeMasked. If mask does not have a value for each tiling an
+// error is returned, and if some tiling uses the BoundsError policy and
+// v falls outside its bounds, an error wrapping ErrOutOfBounds is
+// returned.
+func (t *TileCoder) TryEncodeMasked(v mat.Vector, mask []bool) (
+	*mat.VecDense, error) {
+	indices, err := t.encodeMasked(v, mask)
+	if err != nil {
+		return nil, fmt.Errorf("encodeMasked: %w", err)
+	}
+
+	encoded := mat.NewVecDense(t.VecLength(), nil)
+	for k, index := range indices {
+		if mask[k] {
+			encoded.SetVec(index, t.activation(k))
+		}
+	}
+	if t.includeBias {
+		encoded.SetVec(0, 1.0)
+	}
+	return encoded, nil
+}
+
+// EncodeIndicesMasked returns the non-zero indices of the tile coded
+// vector when v is encoded with only the tilings k for which mask[k] is
+// true, as in EncodeMasked. The indices of masked tilings are omitted,
+// and the remaining indices are ordered as in EncodeIndices. If mask does not have a value
+// for each tiling, or if some tiling uses the BoundsError policy and v
+// falls outside its bounds, EncodeIndicesMasked panics. See
+// TryEncodeIndicesMasked for a non-panicking variant.
+func (t *TileCoder) EncodeIndicesMasked(v mat.Vector, mask []bool) []float64 {
+	indices, err := t.TryEncodeIndicesMasked(v, mask)
+	if err != nil {
+		panic(err)
+	}
+	return indices
+}
+
+// TryEncodeIndicesMasked returns the non-zero indices of the tile
+// coded vector using only the tilings selected by mask, as in
+// EncodeIndicesMasked. If mask does not have a value for each tiling an
+// error is returned, and if some tiling uses the BoundsError policy and
+// v falls outside its bounds, an error wrapping ErrOutOfBounds is
+// returned.
+func (t *TileCoder) TryEncodeIndicesMasked(v mat.Vector, mask []bool) (
+	[]float64, error) {
+	indices, err := t.encodeMasked(v, mask)
+	if err != nil {
+		return nil, fmt.Errorf("encodeIndicesMasked: %w", err)
+	}
+
+	active := make([]float64, 0, t.numIndices())
+	if t.includeBias && t.sorted {
+		active = append(active, 0.0)
+	}
+	for k, index := range indices {
+		if mask[k] {
+			active = append(active, float64(index))
+		}
+	}
+	if t.includeBias && !t.sorted {
+		active = append(active, 0.0)
+	}
+	return active, nil
+}
+
+// EncodeBatchMasked encodes each vector in the batch b using only the
+// tilings selected by mask, as in EncodeMasked. Each column of b is a
+// vector to encode, and each column of the returned matrix is the
+// encoding of the corresponding column of b. If mask does not have a
+// value for each tiling, or if some tiling uses the BoundsError policy
+// and a vector in the batch falls outside its bounds, EncodeBatchMasked
+// panics. See TryEncodeBatchMasked for a non-panicking variant.
+func (t *TileCoder) EncodeBatchMasked(b *mat.Dense, mask []bool) *mat.Dense {
+	encoded, err := t.TryEncodeBatchMasked(b, mask)
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
+// TryEncodeBatchMasked encodes each vector in the batch b using only
+// the tilings selected by mask, as in EncodeBatchMasked. If mask does
+// not have a value for each tiling an error is returned, and if some
+// tiling uses the BoundsError policy and a vector in the batch falls
+// outside its bounds, an error wrapping ErrOutOfBounds is returned.
+func (t *TileCoder) TryEncodeBatchMasked(b *mat.Dense, mask []bool) (
+	*mat.Dense, error) {
+	_, batchSize := b.Dims()
+	encoded := mat.NewDense(t.VecLength(), batchSize, nil)
+	for j := 0; j < batchSize; j++ {
+		indices, err := t.encodeMasked(b.ColView(j), mask)
+		if err != nil {
+			return nil, fmt.Errorf("encodeBatchMasked: vector %d: %w", j, err)
+		}
+		for k, index := range indices {
+			if mask[k] {
+				encoded.Set(index, j, t.activation(k))
+			}
+		}
+		if t.includeBias {
+			encoded.Set(0, j, 1.0)
+		}
+	}
+	return encoded, nil
+}
+
+// encodeMasked returns the index of the active feature of each tiling
+// when v is encoded, counting visits only for the tilings selected by
+// mask
+func (t *TileCoder) encodeMasked(v mat.Vector, mask []bool) ([]int, error) {
+	if len(mask) != len(t.tilings) {
+		return nil, fmt.Errorf("mask has length %d, want %d", len(mask),
+			len(t.tilings))
+	}
+	t.lockAdaptive()
+	defer t.unlockAdaptive()
+	if err := t.observe(v); err != nil {
+		return nil, err
+	}
+	if err := t.check(v); err != nil {
+		return nil, err
+	}
+
+	indices := make([]int, len(t.tilings))
+	t.activeFeatures(v, indices)
+	for k, index := range indices {
+		if mask[k] {
+			t.visit(index)
+		}
+	}
+	return indices, nil
+}
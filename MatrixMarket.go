@@ -0,0 +1,66 @@
+package gotile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// matrixMarketHeader begins every MatrixMarket file written by
+// WriteMatrixMarket
+const matrixMarketHeader = "%%MatrixMarket matrix coordinate real general\n"
+
+// WriteMatrixMarket tile codes each vector in the batch b, where each
+// column of b is a vector to encode, and writes the resulting design
+// matrix to w in the MatrixMarket coordinate format, which can be read
+// by scientific computing tools such as scipy.io.mmread, MATLAB, and
+// Julia. Following the convention for design matrices, the written
+// matrix has a row for each vector of the batch and VecLength()
+// columns, and lists only the non-zero features of each tile-coded
+// representation. Rows and columns are numbered from 1, and entries
+// are ordered by row and then by column.
+//
+// If some tiling uses the BoundsError policy and a vector falls
+// outside its bounds, an error wrapping ErrOutOfBounds is returned.
+func (t *TileCoder) WriteMatrixMarket(w io.Writer, b *mat.Dense) error {
+	indices, err := t.TryEncodeIndicesBatch(b)
+	if err != nil {
+		return fmt.Errorf("writeMatrixMarket: %w", err)
+	}
+	rows, cols := indices.Dims()
+
+	buf := bufio.NewWriter(w)
+	buf.WriteString(matrixMarketHeader)
+	fmt.Fprintf(buf, "%d %d %d\n", cols, t.VecLength(), rows*cols)
+
+	row := make([]int, rows)
+	var scratch []byte
+	for j := 0; j < cols; j++ {
+		for i := range row {
+			row[i] = int(indices.At(i, j))
+		}
+		sort.Ints(row)
+
+		for _, index := range row {
+			scratch = strconv.AppendInt(scratch[:0], int64(j+1), 10)
+			scratch = append(scratch, ' ')
+			scratch = strconv.AppendInt(scratch, int64(index+1), 10)
+			scratch = append(scratch, ' ')
+			scratch = strconv.AppendFloat(scratch, t.featureActivation(index),
+				'g', -1, 64)
+			scratch = append(scratch, '\n')
+			if _, err := buf.Write(scratch); err != nil {
+				return fmt.Errorf("writeMatrixMarket: %v", err)
+			}
+		}
+	}
+
+	if err := buf.Flush(); err != nil {
+		return fmt.Errorf("writeMatrixMarket: %v", err)
+	}
+	return nil
+}
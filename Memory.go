@@ -0,0 +1,126 @@
+package gotile
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"text/tabwriter"
+	"unsafe"
+)
+
+// wordSize is the size in bytes of a float64, int, or uint64 on 64-bit
+// platforms
+const wordSize = 8
+
+// MemoryReport estimates the memory needed to encode batches with a
+// TileCoder, so that the feasibility of large configurations can be
+// checked before running out of memory. Sizes are in bytes, and are
+// held in float64s so that they do not overflow for huge feature
+// spaces.
+type MemoryReport struct {
+	// BatchSize is the number of vectors in each batch
+	BatchSize int
+
+	// Dense is the size of the dense tile-coded representations of a
+	// batch, as returned by EncodeBatch
+	Dense float64
+
+	// Indices is the size of the non-zero indices of the tile-coded
+	// representations of a batch, as returned by EncodeIndicesBatch
+	Indices float64
+
+	// Bitset is the size of the tile-coded representations of a batch
+	// stored as bitsets, with a bit per feature packed into 64-bit
+	// words, which is how binary features are stored most compactly
+	// when the indices of many samples are active
+	Bitset float64
+
+	// Coder is the approximate size of the state of the TileCoder
+	// itself, including its visit counts if it counts visits
+	Coder float64
+}
+
+// MemoryEstimate returns an estimate of the memory needed to encode
+// batches of batchSize vectors with the receiver, and of the memory
+// used by the receiver itself
+func (t *TileCoder) MemoryEstimate(batchSize int) MemoryReport {
+	const word = wordSize
+	n := float64(batchSize)
+	features := float64(t.VecLength())
+
+	return MemoryReport{
+		BatchSize: batchSize,
+		Dense:     features * n * word,
+		Indices:   float64(t.numIndices()) * n * word,
+		Bitset:    math.Ceil(features/64) * n * word,
+		Coder:     t.stateSize(),
+	}
+}
+
+// stateSize returns the approximate size in bytes of the state of the
+// receiver, counting each slice element held by the receiver and its
+// tilings
+func (t *TileCoder) stateSize() float64 {
+	const word = wordSize
+	size := float64(unsafe.Sizeof(*t))
+
+	for _, tiling := range t.tilings {
+		dims := float64(len(tiling.bins))
+		size += float64(unsafe.Sizeof(*tiling))
+
+		// Offsets, bins, bin lengths, minimums, scales, dims, bounds,
+		// squashes and strides each hold a word or two per dimension
+		size += 11 * dims * word
+		size += dims // categories
+		for _, e := range tiling.edges {
+			size += float64(len(e)) * word
+		}
+		if tiling.rotation != nil {
+			size += dims * dims * word
+		}
+	}
+
+	if u := t.uniform; u != nil {
+		dims := float64(len(u.dims))
+		size += (5 + float64(len(t.tilings))) * dims * word
+	}
+	if a := t.adaptive; a != nil {
+		size += 2 * float64(a.minDims.Len()) * (word + 1)
+	}
+	size += float64(len(t.activations)) * word
+
+	if t.visits != nil {
+		for i := range t.visits.shards {
+			size += float64(len(t.visits.allocated(i))) * word
+		}
+	}
+	return size
+}
+
+// String returns a table of the memory needed by each representation
+// of a batch and by the coder
+func (r MemoryReport) String() string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Output\tBytes (batch size %d)\n", r.BatchSize)
+	fmt.Fprintf(w, "Dense\t%s\n", formatBytes(r.Dense))
+	fmt.Fprintf(w, "Indices\t%s\n", formatBytes(r.Indices))
+	fmt.Fprintf(w, "Bitset\t%s\n", formatBytes(r.Bitset))
+	fmt.Fprintf(w, "Coder\t%s\n", formatBytes(r.Coder))
+	w.Flush()
+	return buf.String()
+}
+
+// formatBytes formats a number of bytes with binary prefixes
+func formatBytes(b float64) string {
+	const units = "KMGTPE"
+	if b < 1024 {
+		return fmt.Sprintf("%.0f B", b)
+	}
+	exp := int(math.Log2(b) / 10)
+	if exp > len(units) {
+		exp = len(units)
+	}
+	return fmt.Sprintf("%.1f %ciB", b/math.Pow(1024, float64(exp)),
+		units[exp-1])
+}
@@ -0,0 +1,173 @@
+package gotile
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+// Metrics receives instrumentation from a TileCoder, so that the
+// throughput of a TileCoder embedded in a service can be monitored
+// without wrapping every call. Methods of a Metrics may be called
+// concurrently by a TileCoder which encodes concurrently.
+type Metrics interface {
+	// Encoded is called after each call which encodes vectors, with
+	// the number of vectors encoded by the call and the time taken to
+	// encode them
+	Encoded(vectors int, elapsed time.Duration)
+
+	// TilingEncoded is called after tiling number tiling encodes some
+	// vectors, with the number of vectors encoded and the time taken
+	// to encode them. It is not called when all tilings are encoded in
+	// a single pass, which happens when the tilings of a TileCoder
+	// differ only in their offsets.
+	TilingEncoded(tiling, vectors int, elapsed time.Duration)
+}
+
+// SetMetrics sets the Metrics which receive instrumentation from the
+// receiver, replacing any Metrics set by WithMetrics. A nil Metrics
+// disables instrumentation. Since Metrics cannot be serialized,
+// SetMetrics can be used to restore instrumentation after a TileCoder
+// is deserialized.
+func (t *TileCoder) SetMetrics(metrics Metrics) {
+	t.metrics = metrics
+}
+
+// now returns the current time if the receiver is instrumented, and
+// the zero time otherwise, so that uninstrumented TileCoders do not
+// read the clock
+func (t *TileCoder) now() time.Time {
+	if t.metrics == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// reportEncoded reports to the Metrics of the receiver, if any, that
+// vectors vectors were encoded since start
+func (t *TileCoder) reportEncoded(vectors int, start time.Time) {
+	if t.metrics != nil {
+		t.metrics.Encoded(vectors, time.Since(start))
+	}
+}
+
+// reportTiling reports to the Metrics of the receiver, if any, that
+// tiling number tiling encoded vectors vectors since start
+func (t *TileCoder) reportTiling(tiling, vectors int, start time.Time) {
+	if t.metrics != nil {
+		t.metrics.TilingEncoded(tiling, vectors, time.Since(start))
+	}
+}
+
+// Counters is a Metrics which accumulates counts and durations of
+// encoding. The zero value is ready to use, and a Counters is safe for
+// concurrent use.
+type Counters struct {
+	mu            sync.Mutex
+	calls         int64
+	vectors       int64
+	elapsed       time.Duration
+	tilingVectors []int64
+	tilingElapsed []time.Duration
+}
+
+// Encoded implements the Metrics interface
+func (c *Counters) Encoded(vectors int, elapsed time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	c.vectors += int64(vectors)
+	c.elapsed += elapsed
+}
+
+// TilingEncoded implements the Metrics interface
+func (c *Counters) TilingEncoded(tiling, vectors int, elapsed time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.tilingVectors) <= tiling {
+		c.tilingVectors = append(c.tilingVectors, 0)
+		c.tilingElapsed = append(c.tilingElapsed, 0)
+	}
+	c.tilingVectors[tiling] += int64(vectors)
+	c.tilingElapsed[tiling] += elapsed
+}
+
+// Calls returns the number of calls which encoded vectors
+func (c *Counters) Calls() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+// Vectors returns the total number of vectors encoded
+func (c *Counters) Vectors() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.vectors
+}
+
+// Throughput returns the number of vectors encoded per second spent
+// encoding, or 0 if no time has been spent encoding
+func (c *Counters) Throughput() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.elapsed <= 0 {
+		return 0
+	}
+	return float64(c.vectors) / c.elapsed.Seconds()
+}
+
+// MeanBatchSize returns the mean number of vectors encoded per call,
+// or 0 if no calls have been made
+func (c *Counters) MeanBatchSize() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.calls == 0 {
+		return 0
+	}
+	return float64(c.vectors) / float64(c.calls)
+}
+
+// TilingLatency returns the mean time taken by each tiling to encode a
+// single vector. Tilings which have not reported any vectors have a
+// latency of 0.
+func (c *Counters) TilingLatency() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	latency := make([]time.Duration, len(c.tilingVectors))
+	for i := range latency {
+		if c.tilingVectors[i] > 0 {
+			latency[i] = c.tilingElapsed[i] / time.Duration(c.tilingVectors[i])
+		}
+	}
+	return latency
+}
+
+// Reset sets all counts and durations to zero
+func (c *Counters) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls, c.vectors, c.elapsed = 0, 0, 0
+	c.tilingVectors, c.tilingElapsed = nil, nil
+}
+
+// Publish publishes the receiver as an expvar variable with the given
+// name, reporting its calls, vectors, throughput, mean batch size, and
+// per-tiling latency in nanoseconds. Like expvar.Publish, Publish
+// panics if the name is already registered.
+func (c *Counters) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		latency := c.TilingLatency()
+		nanoseconds := make([]int64, len(latency))
+		for i := range latency {
+			nanoseconds[i] = latency[i].Nanoseconds()
+		}
+		return map[string]interface{}{
+			"calls":                c.Calls(),
+			"vectors":              c.Vectors(),
+			"vectors_per_second":   c.Throughput(),
+			"mean_batch_size":      c.MeanBatchSize(),
+			"tiling_latency_nanos": nanoseconds,
+		}
+	}))
+}
@@ -0,0 +1,129 @@
+package gotile
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// npyMagic begins every NumPy .npy file, followed by the version 1.0
+var npyMagic = []byte("\x93NUMPY\x01\x00")
+
+// WriteNPY tile codes each vector in the batch b, where each column of
+// b is a vector to encode, and writes the tile-coded representations
+// to w as a NumPy .npy file, which can be loaded with numpy.load
+// without the precision loss of a round trip through CSV. Following
+// NumPy conventions, the written array has a row for each vector of
+// the batch. If dense is true, each row holds the VecLength() features
+// of the tile-coded representation as float64s; otherwise, each row
+// holds the non-zero indices of the tile-coded representation as
+// int64s, ordered as in EncodeIndices.
+//
+// If some tiling uses the BoundsError policy and a vector falls
+// outside its bounds, an error wrapping ErrOutOfBounds is returned.
+func (t *TileCoder) WriteNPY(w io.Writer, b *mat.Dense, dense bool) error {
+	name, encoded, err := t.encodeNPY(b, dense)
+	if err != nil {
+		return fmt.Errorf("writeNPY: %w", err)
+	}
+	if err := writeNPY(w, encoded.T(), name == "indices"); err != nil {
+		return fmt.Errorf("writeNPY: %v", err)
+	}
+	return nil
+}
+
+// WriteNPZ tile codes each vector in the batch b, as in WriteNPY, and
+// writes a NumPy .npz archive to w holding the input vectors as the
+// float64 array "inputs", with a row for each vector, along with their
+// tile-coded representations as the array "features" if dense is true
+// or "indices" otherwise, as written by WriteNPY.
+func (t *TileCoder) WriteNPZ(w io.Writer, b *mat.Dense, dense bool) error {
+	name, encoded, err := t.encodeNPY(b, dense)
+	if err != nil {
+		return fmt.Errorf("writeNPZ: %w", err)
+	}
+
+	archive := zip.NewWriter(w)
+	arrays := []struct {
+		name string
+		m    mat.Matrix
+		ints bool
+	}{
+		{"inputs", b.T(), false},
+		{name, encoded.T(), name == "indices"},
+	}
+	for _, array := range arrays {
+		f, err := archive.CreateHeader(&zip.FileHeader{
+			Name:   array.name + ".npy",
+			Method: zip.Store,
+		})
+		if err != nil {
+			return fmt.Errorf("writeNPZ: %v", err)
+		}
+		if err := writeNPY(f, array.m, array.ints); err != nil {
+			return fmt.Errorf("writeNPZ: %v", err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("writeNPZ: %v", err)
+	}
+	return nil
+}
+
+// encodeNPY returns the name of the array holding the tile-coded
+// representations of the batch b, which are dense features if dense is
+// true and non-zero indices otherwise, along with the representations
+func (t *TileCoder) encodeNPY(b *mat.Dense, dense bool) (string, *mat.Dense,
+	error) {
+	if dense {
+		encoded, err := t.TryEncodeBatch(b)
+		return "features", encoded, err
+	}
+	encoded, err := t.TryEncodeIndicesBatch(b)
+	return "indices", encoded, err
+}
+
+// writeNPY writes m to w as a NumPy .npy file holding a two
+// dimensional array in row-major order. The elements of m are written
+// as little-endian int64s if ints is true, and float64s otherwise.
+func writeNPY(w io.Writer, m mat.Matrix, ints bool) error {
+	rows, cols := m.Dims()
+	descr := "<f8"
+	if ints {
+		descr = "<i8"
+	}
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, "+
+		"'shape': (%d, %d), }", descr, rows, cols)
+
+	// Pad the header with spaces and a newline so that the data is
+	// aligned to 64 bytes, as NumPy does
+	prefix := len(npyMagic) + 2
+	pad := 64 - (prefix+len(header)+1)%64
+	header += strings.Repeat(" ", pad%64) + "\n"
+
+	buf := bufio.NewWriter(w)
+	buf.Write(npyMagic)
+	binary.Write(buf, binary.LittleEndian, uint16(len(header)))
+	buf.WriteString(header)
+
+	var element [8]byte
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			if ints {
+				binary.LittleEndian.PutUint64(element[:], uint64(int64(m.At(i,
+					j))))
+			} else {
+				binary.LittleEndian.PutUint64(element[:],
+					math.Float64bits(m.At(i, j)))
+			}
+			buf.Write(element[:])
+		}
+	}
+	return buf.Flush()
+}
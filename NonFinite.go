@@ -0,0 +1,166 @@
+package gotile
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"gonum.org/v1/gonum/blas/blas64"
+	"gonum.org/v1/gonum/mat"
+)
+
+// ErrNonFinite is wrapped by errors returned when encoding a vector
+// with a NaN or infinite feature using the NonFiniteError policy
+var ErrNonFinite = errors.New("non-finite feature")
+
+// NonFinitePolicy determines how a tiling encodes vectors with NaN or
+// infinite features. Infinite features of squashed dimensions are
+// finite once squashed, and so are always encoded as usual.
+type NonFinitePolicy int
+
+const (
+	// NonFiniteClip treats non-finite features as out of bounds, so
+	// that they are encoded as determined by the BoundsPolicy of the
+	// tiling. Positive infinity is above the maximum of its dimension,
+	// while negative infinity and NaN are below the minimum. With the
+	// BoundsWrap policy, non-finite features are placed in the first or
+	// last tile of their dimension. NonFiniteClip is the default, so
+	// that infinite features are clipped to the bounds as they were
+	// before NonFinitePolicies were introduced.
+	NonFiniteClip NonFinitePolicy = iota
+
+	// NonFiniteError reports an error wrapping ErrNonFinite when
+	// encoding non-finite features
+	NonFiniteError
+
+	// NonFiniteTile adds a dedicated tile to the tiling, after all
+	// other tiles, in which every vector with a non-finite feature
+	// falls. The tile covers no region of the input space.
+	NonFiniteTile
+)
+
+// String returns the name of the NonFinitePolicy
+func (p NonFinitePolicy) String() string {
+	switch p {
+	case NonFiniteClip:
+		return "NonFiniteClip"
+	case NonFiniteError:
+		return "NonFiniteError"
+	case NonFiniteTile:
+		return "NonFiniteTile"
+	default:
+		return "NonFinitePolicy(unknown)"
+	}
+}
+
+// MarshalText implements the encoding.TextMarshaler interface, so that
+// NonFinitePolicies are encoded by name in JSON, YAML, and TOML
+func (p NonFinitePolicy) MarshalText() ([]byte, error) {
+	if p < NonFiniteClip || p > NonFiniteTile {
+		return nil, fmt.Errorf("marshalText: unknown non-finite policy %d",
+			int(p))
+	}
+	return []byte(strings.ToLower(strings.TrimPrefix(p.String(),
+		"NonFinite"))), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+// Both the short name ("clip") and the full name ("NonFiniteClip") of
+// each NonFinitePolicy are accepted, regardless of case.
+func (p *NonFinitePolicy) UnmarshalText(text []byte) error {
+	name := strings.TrimPrefix(strings.ToLower(string(text)), "nonfinite")
+	for q := NonFiniteClip; q <= NonFiniteTile; q++ {
+		if name == strings.ToLower(strings.TrimPrefix(q.String(),
+			"NonFinite")) {
+			*p = q
+			return nil
+		}
+	}
+	return fmt.Errorf("unmarshalText: unknown non-finite policy %q", text)
+}
+
+// NonFinitePolicy returns the policy used by the tiling to encode
+// non-finite features
+func (t *Tiling) NonFinitePolicy() NonFinitePolicy {
+	return t.nonFinite
+}
+
+// isNonFinite returns whether the input feature x along dimension i is
+// NaN or infinite, even after it is squashed and scaled
+func (t *Tiling) isNonFinite(i int, x float64) bool {
+	if !math.IsNaN(x) && !math.IsInf(x, 0) {
+		return false
+	}
+	y := t.transform(i, x)
+	return math.IsNaN(y) || math.IsInf(y, 0)
+}
+
+// anyNonFinite returns whether any feature of v tiled by the tiling is
+// non-finite
+func (t *Tiling) anyNonFinite(v mat.Vector) bool {
+	for i, d := range t.dims {
+		if t.isNonFinite(i, v.AtVec(d)) {
+			return true
+		}
+	}
+	return false
+}
+
+// nonFiniteTile returns the index of the tile in which vectors with
+// non-finite features fall with the NonFiniteTile policy
+func (t *Tiling) nonFiniteTile() int {
+	return t.Tiles() - 1
+}
+
+// placeNonFinite returns the tile along dimension i in which the
+// non-finite input feature x falls, as in place
+func (t *Tiling) placeNonFinite(i int, x float64) (int, error) {
+	if t.nonFinite == NonFiniteError {
+		return 0, fmt.Errorf("dimension %d: value %v: %w", t.dims[i], x,
+			ErrNonFinite)
+	}
+
+	below := math.IsNaN(x) || x < 0
+	switch t.policy {
+	case BoundsError:
+		return 0, fmt.Errorf("dimension %d: value outside [%v, %v]: %w",
+			t.dims[i], t.low[i], t.high[i], ErrOutOfBounds)
+	case BoundsExtend:
+		if below {
+			return 0, nil
+		}
+		return t.bins[i] + 1, nil
+	}
+	if below {
+		return 0, nil
+	}
+	return t.bins[i] - 1, nil
+}
+
+// isFinite returns whether x is neither NaN nor infinite
+func isFinite(x float64) bool {
+	return !math.IsNaN(x) && !math.IsInf(x, 0)
+}
+
+// finite returns whether every element of v is finite
+func finite(v mat.Vector) bool {
+	for i := 0; i < v.Len(); i++ {
+		if !isFinite(v.AtVec(i)) {
+			return false
+		}
+	}
+	return true
+}
+
+// finiteBatch returns whether every element of the batch b is finite
+func finiteBatch(b blas64.General) bool {
+	for i := 0; i < b.Rows; i++ {
+		for _, x := range b.Data[i*b.Stride : i*b.Stride+b.Cols] {
+			if !isFinite(x) {
+				return false
+			}
+		}
+	}
+	return true
+}
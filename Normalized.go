@@ -0,0 +1,157 @@
+package gotile
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// NormalizedCoder preprocesses each input vector with a Pipeline
+// before tile coding it. The Pipeline and TileCoder are serialized
+// together, so that a deployed NormalizedCoder preprocesses and encodes
+// vectors exactly as it did when it was saved.
+type NormalizedCoder struct {
+	pipeline *Pipeline
+	coder    *TileCoder
+}
+
+// NewNormalized returns a new NormalizedCoder which tile codes the
+// vectors preprocessed by pipeline with coder. The Pipeline and
+// TileCoder are shared with the returned NormalizedCoder.
+func NewNormalized(pipeline *Pipeline, coder *TileCoder) (*NormalizedCoder,
+	error) {
+	if pipeline == nil || coder == nil {
+		return nil, fmt.Errorf("newNormalized: pipeline and coder must be " +
+			"non-nil")
+	}
+	return &NormalizedCoder{pipeline: pipeline, coder: coder}, nil
+}
+
+// Pipeline returns the Pipeline which preprocesses vectors
+func (n *NormalizedCoder) Pipeline() *Pipeline {
+	return n.pipeline
+}
+
+// TileCoder returns the TileCoder which encodes preprocessed vectors
+func (n *NormalizedCoder) TileCoder() *TileCoder {
+	return n.coder
+}
+
+// VecLength returns the number of features in each encoded vector
+func (n *NormalizedCoder) VecLength() int {
+	return n.coder.VecLength()
+}
+
+// Encode returns the tile-coded representation of the preprocessed
+// vector v. If v cannot be preprocessed or encoded, Encode panics. See
+// TryEncode for a non-panicking variant.
+func (n *NormalizedCoder) Encode(v mat.Vector) *mat.VecDense {
+	encoded, err := n.TryEncode(v)
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
+// TryEncode returns the tile-coded representation of the preprocessed
+// vector v, as in Encode. If v cannot be preprocessed or encoded, an
+// error is returned.
+func (n *NormalizedCoder) TryEncode(v mat.Vector) (*mat.VecDense, error) {
+	x, err := n.pipeline.TryApply(v)
+	if err != nil {
+		return nil, fmt.Errorf("encode: %v", err)
+	}
+	return n.coder.TryEncode(x)
+}
+
+// EncodeIndices returns the non-zero indices of the tile-coded
+// representation of the preprocessed vector v. If v cannot be
+// preprocessed or encoded, EncodeIndices panics. See TryEncodeIndices
+// for a non-panicking variant.
+func (n *NormalizedCoder) EncodeIndices(v mat.Vector) []float64 {
+	indices, err := n.TryEncodeIndices(v)
+	if err != nil {
+		panic(err)
+	}
+	return indices
+}
+
+// TryEncodeIndices returns the non-zero indices of the tile-coded
+// representation of the preprocessed vector v, as in EncodeIndices. If
+// v cannot be preprocessed or encoded, an error is returned.
+func (n *NormalizedCoder) TryEncodeIndices(v mat.Vector) ([]float64,
+	error) {
+	x, err := n.pipeline.TryApply(v)
+	if err != nil {
+		return nil, fmt.Errorf("encodeIndices: %v", err)
+	}
+	return n.coder.TryEncodeIndices(x)
+}
+
+// EncodeBatch returns the tile-coded representation of each
+// preprocessed vector in the batch b, where each column of b is a
+// vector to encode. If the batch cannot be preprocessed or encoded,
+// EncodeBatch panics. See TryEncodeBatch for a non-panicking variant.
+func (n *NormalizedCoder) EncodeBatch(b *mat.Dense) *mat.Dense {
+	encoded, err := n.TryEncodeBatch(b)
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
+// TryEncodeBatch returns the tile-coded representation of each
+// preprocessed vector in the batch b, as in EncodeBatch. If the batch
+// cannot be preprocessed or encoded, an error is returned.
+func (n *NormalizedCoder) TryEncodeBatch(b *mat.Dense) (*mat.Dense, error) {
+	x, err := n.pipeline.TryApplyBatch(b)
+	if err != nil {
+		return nil, fmt.Errorf("encodeBatch: %v", err)
+	}
+	return n.coder.TryEncodeBatch(x)
+}
+
+// normalizedState holds a NormalizedCoder in exported form
+type normalizedState struct {
+	Version  int             `json:"version"`
+	Pipeline *Pipeline       `json:"pipeline"`
+	Coder    json.RawMessage `json:"coder"`
+}
+
+// MarshalJSON implements the json.Marshaler interface. Both the
+// Pipeline, including its running statistics, and the TileCoder are
+// marshaled.
+func (n *NormalizedCoder) MarshalJSON() ([]byte, error) {
+	coder, err := n.coder.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("marshalJSON: %v", err)
+	}
+	return json.Marshal(normalizedState{FormatVersion, n.pipeline, coder})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Tile coders
+// saved with older format versions are migrated.
+func (n *NormalizedCoder) UnmarshalJSON(data []byte) error {
+	var s normalizedState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("unmarshalJSON: %v", err)
+	}
+	if err := checkVersion(s.Version); err != nil {
+		return fmt.Errorf("unmarshalJSON: %v", err)
+	}
+	if s.Pipeline == nil || s.Coder == nil {
+		return fmt.Errorf("unmarshalJSON: pipeline and coder must both be " +
+			"present")
+	}
+
+	coder := &TileCoder{}
+	if err := coder.UnmarshalJSON(s.Coder); err != nil {
+		return fmt.Errorf("unmarshalJSON: %v", err)
+	}
+	n.pipeline, n.coder = s.Pipeline, coder
+	return nil
+}
+
+// Ensure NormalizedCoder implements TryCoder
+var _ TryCoder = (*NormalizedCoder)(nil)
@@ -0,0 +1,125 @@
+package gotile
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/spatial/r1"
+	"gonum.org/v1/gonum/stat/distmv"
+	"gonum.org/v1/gonum/stat/samplemv"
+)
+
+// OffsetStrategy determines how the offsets of a tiling are drawn from
+// the interval of allowed offsets along each dimension
+type OffsetStrategy int
+
+const (
+	// OffsetRandom samples the offset along each dimension uniformly
+	// at random, using the seed of the tiling
+	OffsetRandom OffsetStrategy = iota
+
+	// OffsetHalton draws the offsets of a tiling with seed s from the
+	// (s+1)-th point of the Halton sequence, whose k-th coordinate is
+	// the radical inverse of s+1 in the k-th prime base. Since New
+	// gives tilings using OffsetHalton consecutive seeds, starting from
+	// its seed, the tilings of a TileCoder take consecutive points of
+	// the sequence, which cover the space of offsets more evenly than
+	// uniform random samples. The benefit is greatest with many tilings
+	// over few dimensions.
+	OffsetHalton
+)
+
+// String returns the name of the OffsetStrategy
+func (o OffsetStrategy) String() string {
+	switch o {
+	case OffsetRandom:
+		return "OffsetRandom"
+	case OffsetHalton:
+		return "OffsetHalton"
+	default:
+		return "OffsetStrategy(unknown)"
+	}
+}
+
+// MarshalText implements the encoding.TextMarshaler interface, so that
+// OffsetStrategies are encoded by name in JSON, YAML, and TOML
+func (o OffsetStrategy) MarshalText() ([]byte, error) {
+	if o < OffsetRandom || o > OffsetHalton {
+		return nil, fmt.Errorf("marshalText: unknown offset strategy %d",
+			int(o))
+	}
+	return []byte(strings.ToLower(strings.TrimPrefix(o.String(),
+		"Offset"))), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+// Both the short name ("halton") and the full name ("OffsetHalton") of
+// each OffsetStrategy are accepted, regardless of case.
+func (o *OffsetStrategy) UnmarshalText(text []byte) error {
+	name := strings.TrimPrefix(strings.ToLower(string(text)), "offset")
+	for s := OffsetRandom; s <= OffsetHalton; s++ {
+		if name == strings.ToLower(strings.TrimPrefix(s.String(), "Offset")) {
+			*o = s
+			return nil
+		}
+	}
+	return fmt.Errorf("unmarshalText: unknown offset strategy %q", text)
+}
+
+// sampleOffsets returns a 1 × len(bounds) matrix holding an offset
+// within each of bounds, drawn using the strategy and seed
+func sampleOffsets(strategy OffsetStrategy, bounds []r1.Interval,
+	seed uint64) *mat.Dense {
+	offsets := mat.NewDense(1, len(bounds), nil)
+
+	switch strategy {
+	case OffsetHalton:
+		bases := primes(len(bounds))
+		for k, b := range bounds {
+			h := radicalInverse(seed+1, bases[k])
+			offsets.Set(0, k, b.Min+h*(b.Max-b.Min))
+		}
+	default:
+		// Create RNG for uniform sampling of Tiling offsets
+		source := rand.NewSource(seed)
+		u := distmv.NewUniform(bounds, source)
+		sampler := samplemv.IID{Dist: u}
+		sampler.Sample(offsets)
+	}
+	return offsets
+}
+
+// radicalInverse returns the radical inverse of index in base, which
+// reflects the digits of index in base about the radix point
+func radicalInverse(index uint64, base uint64) float64 {
+	inverse, scale := 0.0, 1.0/float64(base)
+	for index > 0 {
+		inverse += float64(index%base) * scale
+		index /= base
+		scale /= float64(base)
+	}
+	return inverse
+}
+
+// primes returns the first n prime numbers
+func primes(n int) []uint64 {
+	p := make([]uint64, 0, n)
+	for c := uint64(2); len(p) < n; c++ {
+		prime := true
+		for _, q := range p {
+			if q*q > c {
+				break
+			}
+			if c%q == 0 {
+				prime = false
+				break
+			}
+		}
+		if prime {
+			p = append(p, c)
+		}
+	}
+	return p
+}
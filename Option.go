@@ -0,0 +1,383 @@
+package gotile
+
+import (
+	"fmt"
+	"math"
+)
+
+// Option configures optional behaviour of a Tiling or TileCoder at
+// construction. Options are passed as trailing arguments to NewTiling
+// and New. Options which refer to individual dimensions always refer
+// to dimensions of the input vectors which will be tile coded.
+type Option func(*config) error
+
+// config holds the optional settings of a Tiling or TileCoder
+type config struct {
+	scales []Scale     // Scale of each input dimension
+	edges  [][]float64 // Bin edges of each input dimension
+
+	categorical []int  // Input dimensions which are categorical
+	categories  []bool // Whether each input dimension is categorical
+
+	dims   []int   // Input dimensions tiled by a Tiling
+	groups [][]int // Input dimensions tiled by each tiling of a TileCoder
+
+	policy    BoundsPolicy    // Policy for vectors outside the bounds
+	nonFinite NonFinitePolicy // Policy for non-finite features
+	squashes  []Squash        // Squashing transform of each input dimension
+
+	adaptive    bool       // Whether a TileCoder adapts its bounds
+	adaptMargin float64    // Fraction of range added when bounds adapt
+	adaptHook   BoundsHook // Called when bounds adapt
+
+	concurrency int // Minimum work for concurrent encoding
+	workers     int // Size of the worker pool for concurrent encoding
+
+	metrics Metrics // Receives instrumentation of encoding
+	sorted  bool    // Whether non-zero indices are sorted
+	visits  bool    // Whether a TileCoder counts tile visits
+
+	visitShards int // Number of shards of visit counts, 0 for the default
+
+	rotate  bool           // Whether tilings randomly rotate their input
+	offsets OffsetStrategy // How tiling offsets are drawn
+
+	distinctSeeds bool // Whether tiling i uses the seed given to New plus i
+
+	activations []float64 // Value of the active feature of each tiling
+	normalize   bool      // Whether active features are 1/tilings
+}
+
+// newConfig returns a config with all opts applied
+func newConfig(opts []Option) (*config, error) {
+	c := &config{}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// categoriesFor fills c.categories for input vectors with dims
+// dimensions
+func (c *config) categoriesFor(dims int) error {
+	c.categories = make([]bool, dims)
+	for _, d := range c.categorical {
+		if d < 0 || d >= dims {
+			return fmt.Errorf("categorical dimension %d out of range [0, %d)",
+				d, dims)
+		}
+		c.categories[d] = true
+	}
+	return nil
+}
+
+// WithScales sets the Scale used to place tiles along each dimension.
+// A single Scale should be given for each dimension of the input
+// vectors. By default, all dimensions use ScaleLinear.
+func WithScales(scales ...Scale) Option {
+	return func(c *config) error {
+		for i, s := range scales {
+			if s != ScaleLinear && s != ScaleLog {
+				return fmt.Errorf("withScales: unknown scale %v for "+
+					"dimension %d", s, i)
+			}
+		}
+		c.scales = append([]Scale(nil), scales...)
+		return nil
+	}
+}
+
+// WithEdges places the bins along each dimension at explicit, possibly
+// non-uniform, bin edges rather than spacing bins equally between the
+// minimum and maximum of the dimension. A set of edges should be given
+// for each dimension of the input vectors, and a nil set of edges
+// keeps equal-width bins along that dimension. The edges along
+// dimension i must be strictly increasing, must number one more than
+// the bins along dimension i, and must start and end at the minimum
+// and maximum of dimension i.
+func WithEdges(edges [][]float64) Option {
+	return func(c *config) error {
+		c.edges = make([][]float64, len(edges))
+		for i, e := range edges {
+			if e == nil {
+				continue
+			}
+			for j := 1; j < len(e); j++ {
+				if !(e[j] > e[j-1]) {
+					return fmt.Errorf("withEdges: bin edges of dimension "+
+						"%d are not strictly increasing", i)
+				}
+			}
+			c.edges[i] = append([]float64(nil), e...)
+		}
+		return nil
+	}
+}
+
+// WithCategorical marks each of dims as a categorical dimension of the
+// input vectors. Each integer value between the (integer) minimum and
+// maximum of a categorical dimension is placed in its own tile, so the
+// number of bins along the dimension must equal max - min + 1.
+// Categorical dimensions are never offset, so distinct values never
+// share a tile in any tiling. Inputs along a categorical dimension are
+// rounded to the nearest integer.
+func WithCategorical(dims ...int) Option {
+	return func(c *config) error {
+		c.categorical = append(c.categorical, dims...)
+		return nil
+	}
+}
+
+// WithDims restricts a Tiling to tile only the input dimensions dims,
+// in the given order. Each dimension should be given at most once.
+// This option is only used by NewTiling; see WithGroups to construct
+// a TileCoder whose tilings tile subsets of the input dimensions.
+func WithDims(dims ...int) Option {
+	return func(c *config) error {
+		if len(dims) == 0 {
+			return fmt.Errorf("withDims: must tile at least one dimension")
+		}
+		seen := make(map[int]bool, len(dims))
+		for _, d := range dims {
+			if seen[d] {
+				return fmt.Errorf("withDims: dimension %d given more than "+
+					"once", d)
+			}
+			seen[d] = true
+		}
+		c.dims = append([]int(nil), dims...)
+		return nil
+	}
+}
+
+// WithGroups restricts each tiling of a TileCoder to a subset of the
+// input dimensions, so that tiling i tiles only the input dimensions
+// groups[i]. For example, groups of {{0, 1}, {2}} jointly tile the
+// first two input dimensions with one tiling and tile the third input
+// dimension alone with another. When used, bins[i] passed to New
+// should hold the number of bins for each dimension in groups[i]. This
+// option is only used by New.
+func WithGroups(groups [][]int) Option {
+	return func(c *config) error {
+		c.groups = make([][]int, len(groups))
+		for i := range groups {
+			c.groups[i] = append([]int(nil), groups[i]...)
+		}
+		return nil
+	}
+}
+
+// WithAdaptiveBounds makes a TileCoder track the per-dimension minimum
+// and maximum of the vectors it encodes. Whenever an encoded vector
+// falls outside the current bounds along some dimension, the bound is
+// moved past the vector by margin times the distance from the vector
+// to the opposite bound, and every tiling is rescaled to the new bounds before
+// the vector is encoded. The number of features never changes, but
+// the mapping from vectors to features does, so hook (if non-nil) is
+// called with the new bounds after each rescaling. A negative margin
+// is treated as 0. Categorical dimensions, and log-scaled dimensions
+// whose minimum would become non-positive, are never rescaled. If the
+// tilings cannot be rescaled to the new bounds, for example because a
+// bound would overflow, the vector is not encoded: the Try variants of
+// the encoding methods return an error, and the others panic, and the
+// bounds are unchanged.
+//
+// Since encoding may rescale the tilings, a TileCoder adapting its
+// bounds encodes one vector or batch at a time, so that it may still be
+// used by concurrent encoders. Methods which read the tilings without
+// encoding, such as Overlap, Heatmap, or those of a DensityModel, must
+// not be called concurrently with encoding. This option is only used
+// by New.
+func WithAdaptiveBounds(margin float64, hook BoundsHook) Option {
+	return func(c *config) error {
+		c.adaptive = true
+		c.adaptMargin = math.Max(margin, 0)
+		c.adaptHook = hook
+		return nil
+	}
+}
+
+// WithBoundsPolicy sets the BoundsPolicy determining how vectors
+// outside the bounds of a tiling are encoded. By default, BoundsClip
+// is used.
+func WithBoundsPolicy(policy BoundsPolicy) Option {
+	return func(c *config) error {
+		if policy < BoundsClip || policy > BoundsExtend {
+			return fmt.Errorf("withBoundsPolicy: unknown policy %v", policy)
+		}
+		c.policy = policy
+		return nil
+	}
+}
+
+// WithNonFinitePolicy sets the NonFinitePolicy determining how vectors
+// with NaN or infinite features are encoded. By default, NonFiniteClip
+// is used, so that only NonFiniteError makes encoding such vectors
+// fail.
+func WithNonFinitePolicy(policy NonFinitePolicy) Option {
+	return func(c *config) error {
+		if policy < NonFiniteClip || policy > NonFiniteTile {
+			return fmt.Errorf("withNonFinitePolicy: unknown policy %v",
+				policy)
+		}
+		c.nonFinite = policy
+		return nil
+	}
+}
+
+// WithRotation applies a random rotation to the tiled dimensions of
+// each tiling before the input is tiled, so that tiles lie diagonally
+// to the input dimensions rather than along them. This breaks the
+// axis-aligned generalization of tilings which otherwise tile the same
+// dimensions. Each tiling samples its own rotation using its seed. The
+// rotation is applied after dimensions are scaled and squashed, with
+// each dimension normalized by its bounds, and rotated inputs are
+// shrunk just enough that inputs within the bounds remain within the
+// bounds. Tiles described by TileBounds and TileCenter are those of
+// the rotated space.
+//
+// Rotated tilings must use the BoundsClip or BoundsError policy, and
+// cannot tile categorical dimensions or dimensions with explicit bin
+// edges.
+func WithRotation() Option {
+	return func(c *config) error {
+		c.rotate = true
+		return nil
+	}
+}
+
+// WithOffsetStrategy sets the OffsetStrategy used to draw the offsets
+// of each tiling within the bounds determined by offsetDiv. By default,
+// OffsetRandom is used.
+func WithOffsetStrategy(strategy OffsetStrategy) Option {
+	return func(c *config) error {
+		if strategy < OffsetRandom || strategy > OffsetHalton {
+			return fmt.Errorf("withOffsetStrategy: unknown strategy %v",
+				strategy)
+		}
+		c.offsets = strategy
+		return nil
+	}
+}
+
+// WithDistinctSeeds makes New offset tiling i using its seed plus i,
+// rather than offsetting every tiling using its seed, so that tilings
+// with the same number of bins are offset differently. Tilings using
+// OffsetHalton always take distinct seeds. This option is only used by
+// New.
+func WithDistinctSeeds() Option {
+	return func(c *config) error {
+		c.distinctSeeds = true
+		return nil
+	}
+}
+
+// WithActivations sets the value of the active feature of each tiling
+// in the tile-coded representation, in place of 1.0, so that tilings
+// can be given different importance. A single finite value should be
+// given for each tiling. The bias unit is always 1.0. This option is
+// only used by New.
+func WithActivations(activations ...float64) Option {
+	return func(c *config) error {
+		for k, a := range activations {
+			if math.IsInf(a, 0) || math.IsNaN(a) {
+				return fmt.Errorf("withActivations: activation of tiling %d "+
+					"is not finite: %v", k, a)
+			}
+		}
+		c.activations = append([]float64(nil), activations...)
+		c.normalize = false
+		return nil
+	}
+}
+
+// WithNormalizedActivations sets the value of the active feature of
+// each tiling in the tile-coded representation to 1 / the number of
+// tilings, so that the features of the tilings sum to one. The bias
+// unit is always 1.0. This option is only used by New.
+func WithNormalizedActivations() Option {
+	return func(c *config) error {
+		c.activations = nil
+		c.normalize = true
+		return nil
+	}
+}
+
+// WithSquashes applies a squashing transform to each dimension before
+// it is tiled, so that unbounded dimensions can be tiled. A single
+// Squash should be given for each dimension of the input vectors, and
+// the zero Squash leaves a dimension unchanged. The bounds of squashed
+// dimensions may be infinite. Squashed dimensions cannot also be
+// log-scaled or categorical.
+func WithSquashes(squashes ...Squash) Option {
+	return func(c *config) error {
+		for i, s := range squashes {
+			if s.Func < SquashNone || s.Func > SquashSigmoid {
+				return fmt.Errorf("withSquashes: unknown squashing function "+
+					"%v for dimension %d", s.Func, i)
+			}
+		}
+		c.squashes = append([]Squash(nil), squashes...)
+		return nil
+	}
+}
+
+// WithConcurrency sets the concurrency threshold of a TileCoder. By
+// default, a TileCoder encodes its tilings sequentially, which is
+// fastest for few tilings and small batches. When the number of
+// tilings times the number of vectors encoded in a single call is at
+// least threshold, the tilings are instead encoded concurrently by a
+// pool of workers (see WithWorkers). A
+// non-positive threshold keeps encoding sequential. This option is only
+// used by New.
+func WithConcurrency(threshold int) Option {
+	return func(c *config) error {
+		if threshold < 0 {
+			threshold = 0
+		}
+		c.concurrency = threshold
+		return nil
+	}
+}
+
+// WithWorkers sets the number of workers used when a TileCoder encodes
+// concurrently (see WithConcurrency). Batches are split into chunks of
+// vectors, and the chunks of every tiling are shared between the
+// workers, so that large batches are encoded in parallel even with few
+// tilings. A non-positive number of workers uses runtime.GOMAXPROCS(0)
+// workers, which is the default. This option is only used by New.
+func WithWorkers(workers int) Option {
+	return func(c *config) error {
+		if workers < 0 {
+			workers = 0
+		}
+		c.workers = workers
+		return nil
+	}
+}
+
+// WithSortedIndices makes a TileCoder return the non-zero indices of
+// tile coded vectors in increasing order, as required by many
+// consumers of sparse vectors, by placing the index of the bias unit
+// first rather than last. See EncodeIndices for the default ordering.
+// This option is only used by New.
+func WithSortedIndices() Option {
+	return func(c *config) error {
+		c.sorted = true
+		return nil
+	}
+}
+
+// WithMetrics instruments a TileCoder, so that metrics receives the
+// number of vectors and time taken by each call which encodes vectors,
+// as well as the time taken by each tiling. See Counters for a Metrics
+// which accumulates throughput, batch sizes, and per-tiling latency.
+// This option is only used by New.
+func WithMetrics(metrics Metrics) Option {
+	return func(c *config) error {
+		c.metrics = metrics
+		return nil
+	}
+}
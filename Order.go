@@ -0,0 +1,56 @@
+package gotile
+
+import "gonum.org/v1/gonum/mat"
+
+// SetSortedIndices sets whether the receiver returns the non-zero
+// indices of tile coded vectors in increasing order, replacing the
+// setting of WithSortedIndices. See WithSortedIndices for more
+// details. Like the concurrency threshold, this setting is not
+// serialized.
+func (t *TileCoder) SetSortedIndices(sorted bool) {
+	t.sorted = sorted
+}
+
+// SortedIndices returns whether the receiver returns the non-zero
+// indices of tile coded vectors in increasing order
+func (t *TileCoder) SortedIndices() bool {
+	return t.sorted
+}
+
+// order reorders indices, laid out as by encodeIndicesTo with the
+// index of tiling k at position k and the index of the bias unit last,
+// into increasing order if the receiver sorts indices. Since the
+// features of each tiling follow those of the previous tiling, only
+// the bias unit must be moved to the front.
+func (t *TileCoder) order(indices []float64) {
+	if !t.sorted || !t.includeBias {
+		return
+	}
+	copy(indices[1:], indices[:len(t.tilings)])
+	indices[0] = 0.0
+}
+
+// orderBatch reorders each column of indices, laid out as by
+// encodeIndicesBatchTo, as in order
+func (t *TileCoder) orderBatch(indices *mat.Dense) {
+	if !t.sorted || !t.includeBias {
+		return
+	}
+	for k := len(t.tilings); k > 0; k-- {
+		copy(indices.RawRowView(k), indices.RawRowView(k-1))
+	}
+	row := indices.RawRowView(0)
+	for j := range row {
+		row[j] = 0.0
+	}
+}
+
+// firstTiling returns the position of the index of the first tiling in
+// the indices returned by the receiver, which is 1 if the bias unit is
+// moved to the front by order and 0 otherwise
+func (t *TileCoder) firstTiling() int {
+	if t.sorted && t.includeBias {
+		return 1
+	}
+	return 0
+}
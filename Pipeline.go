@@ -0,0 +1,405 @@
+package gotile
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Stage is a single preprocessing step of a Pipeline. Stages are
+// created with MinMax, ZScore, Clip, and Linear.
+type Stage struct {
+	kind      string
+	low, high []float64 // Bounds of MinMax and Clip stages
+	weights   []float64 // Row-major transform of Linear stages
+
+	// Running statistics of ZScore stages, computed with Welford's
+	// algorithm
+	count    uint64
+	mean, m2 []float64
+}
+
+// Names of each kind of Stage
+const (
+	stageMinMax = "minmax"
+	stageZScore = "zscore"
+	stageClip   = "clip"
+	stageLinear = "linear"
+)
+
+// MinMax returns a Stage which scales dimension i of its input from
+// [minDims[i], maxDims[i]] to [0, 1]. Inputs outside the bounds are
+// scaled beyond [0, 1]; follow MinMax with Clip to bound them.
+func MinMax(minDims, maxDims mat.Vector) Stage {
+	return Stage{
+		kind: stageMinMax,
+		low:  mat.Col(nil, 0, minDims),
+		high: mat.Col(nil, 0, maxDims),
+	}
+}
+
+// ZScore returns a Stage which standardizes each of dims dimensions of
+// its input by the running mean and standard deviation of the inputs it
+// has seen. Until a dimension has seen two distinct values, its
+// standard deviation is taken to be 1.0.
+func ZScore(dims int) Stage {
+	return Stage{
+		kind: stageZScore,
+		mean: make([]float64, dims),
+		m2:   make([]float64, dims),
+	}
+}
+
+// Clip returns a Stage which clips dimension i of its input to
+// [minDims[i], maxDims[i]]
+func Clip(minDims, maxDims mat.Vector) Stage {
+	return Stage{
+		kind: stageClip,
+		low:  mat.Col(nil, 0, minDims),
+		high: mat.Col(nil, 0, maxDims),
+	}
+}
+
+// Linear returns a Stage which centers its input by subtracting center
+// and then multiplies it by transform, so that correlated dimensions
+// can be tiled in a decorrelated basis. The transform is usually a
+// PCA or whitening matrix fit offline. It must be square, since every
+// stage of a Pipeline has the same number of dimensions, and each row
+// of the transform gives an output dimension. If center is nil, inputs
+// are not centered.
+func Linear(transform *mat.Dense, center mat.Vector) Stage {
+	rows, cols := transform.Dims()
+	s := Stage{
+		kind:    stageLinear,
+		mean:    make([]float64, cols),
+		weights: make([]float64, 0, rows*cols),
+	}
+	for i := 0; i < rows; i++ {
+		s.weights = append(s.weights, transform.RawRowView(i)...)
+	}
+	if center != nil {
+		s.mean = mat.Col(nil, 0, center)
+	}
+	return s
+}
+
+// dims returns the number of input dimensions of the receiver
+func (s *Stage) dims() int {
+	if s.kind == stageZScore || s.kind == stageLinear {
+		return len(s.mean)
+	}
+	return len(s.low)
+}
+
+// copy returns a deep copy of the receiver
+func (s *Stage) copy() Stage {
+	return Stage{
+		kind:    s.kind,
+		low:     append([]float64(nil), s.low...),
+		high:    append([]float64(nil), s.high...),
+		weights: append([]float64(nil), s.weights...),
+		count:   s.count,
+		mean:    append([]float64(nil), s.mean...),
+		m2:      append([]float64(nil), s.m2...),
+	}
+}
+
+// validate returns an error if the receiver is malformed
+func (s *Stage) validate() error {
+	switch s.kind {
+	case stageMinMax, stageClip:
+		if len(s.low) != len(s.high) {
+			return fmt.Errorf("%v bounds must have the same length: %d != %d",
+				s.kind, len(s.low), len(s.high))
+		}
+		for i := range s.low {
+			if s.kind == stageMinMax && (math.IsInf(s.low[i], 0) ||
+				math.IsInf(s.high[i], 0) || !(s.high[i] > s.low[i])) {
+				return fmt.Errorf("minmax bounds of dimension %d must be "+
+					"finite with maximum exceeding minimum: [%v, %v]", i,
+					s.low[i], s.high[i])
+			}
+			if s.kind == stageClip && !(s.high[i] >= s.low[i]) {
+				return fmt.Errorf("clip maximum of dimension %d is below its "+
+					"minimum: [%v, %v]", i, s.low[i], s.high[i])
+			}
+		}
+	case stageZScore:
+		if len(s.m2) != len(s.mean) {
+			return fmt.Errorf("zscore statistics must have the same length: "+
+				"%d != %d", len(s.mean), len(s.m2))
+		}
+	case stageLinear:
+		if len(s.weights) != len(s.mean)*len(s.mean) {
+			return fmt.Errorf("linear transform must be square with a row "+
+				"for each of %d dimensions: have(%d weights)", len(s.mean),
+				len(s.weights))
+		}
+		for _, w := range [][]float64{s.weights, s.mean} {
+			for _, x := range w {
+				if math.IsNaN(x) || math.IsInf(x, 0) {
+					return fmt.Errorf("linear transform and center must be "+
+						"finite: %v", x)
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("unknown stage %q", s.kind)
+	}
+	return nil
+}
+
+// apply applies the receiver to x in place, first updating any running
+// statistics with x if update is true
+func (s *Stage) apply(x []float64, update bool) {
+	switch s.kind {
+	case stageMinMax:
+		for i := range x {
+			x[i] = (x[i] - s.low[i]) / (s.high[i] - s.low[i])
+		}
+	case stageClip:
+		for i := range x {
+			x[i] = math.Max(s.low[i], math.Min(x[i], s.high[i]))
+		}
+	case stageZScore:
+		if update {
+			s.count++
+			for i := range x {
+				delta := x[i] - s.mean[i]
+				s.mean[i] += delta / float64(s.count)
+				s.m2[i] += delta * (x[i] - s.mean[i])
+			}
+		}
+		for i := range x {
+			std := 1.0
+			if s.count > 1 && s.m2[i] > 0 {
+				std = math.Sqrt(s.m2[i] / float64(s.count-1))
+			}
+			x[i] = (x[i] - s.mean[i]) / std
+		}
+	case stageLinear:
+		centered := make([]float64, len(x))
+		for i := range x {
+			centered[i] = x[i] - s.mean[i]
+		}
+		for i := range x {
+			row := s.weights[i*len(x) : (i+1)*len(x)]
+			x[i] = 0
+			for j, c := range centered {
+				x[i] += row[j] * c
+			}
+		}
+	}
+}
+
+// Pipeline preprocesses input vectors before they are encoded, by
+// applying a sequence of Stages in order. A Pipeline is usually
+// combined with a TileCoder in a NormalizedCoder, which serializes
+// both together so that the preprocessing used to train a learner is
+// the same as that used to deploy it.
+//
+// While a Pipeline is not frozen, each vector it preprocesses updates
+// the running statistics of its ZScore stages. Freeze the Pipeline
+// once training is done to fix the statistics. A Pipeline is safe for
+// concurrent use.
+type Pipeline struct {
+	mu     sync.Mutex
+	stages []Stage
+	frozen bool
+}
+
+// NewPipeline returns a new Pipeline applying stages in order. Every
+// stage must have the same number of input dimensions.
+func NewPipeline(stages ...Stage) (*Pipeline, error) {
+	if len(stages) == 0 {
+		return nil, fmt.Errorf("newPipeline: cannot have less than 1 stage")
+	}
+	p := &Pipeline{stages: make([]Stage, len(stages))}
+	for i := range stages {
+		if err := stages[i].validate(); err != nil {
+			return nil, fmt.Errorf("newPipeline: stage %d: %v", i, err)
+		}
+		if stages[i].dims() == 0 {
+			return nil, fmt.Errorf("newPipeline: stage %d has no dimensions",
+				i)
+		}
+		if stages[i].dims() != stages[0].dims() {
+			return nil, fmt.Errorf("newPipeline: stage %d has %d dimensions, "+
+				"want %d", i, stages[i].dims(), stages[0].dims())
+		}
+		p.stages[i] = stages[i].copy()
+	}
+	return p, nil
+}
+
+// Dims returns the number of dimensions of the vectors preprocessed by
+// the receiver
+func (p *Pipeline) Dims() int {
+	return p.stages[0].dims()
+}
+
+// Freeze stops the receiver from updating its running statistics
+func (p *Pipeline) Freeze() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.frozen = true
+}
+
+// Unfreeze resumes updating the running statistics of the receiver
+// with each vector it preprocesses
+func (p *Pipeline) Unfreeze() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.frozen = false
+}
+
+// Frozen returns whether the receiver has stopped updating its running
+// statistics
+func (p *Pipeline) Frozen() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.frozen
+}
+
+// Apply returns the preprocessed vector v. If v does not have Dims()
+// dimensions, Apply panics. See TryApply for a non-panicking variant.
+func (p *Pipeline) Apply(v mat.Vector) *mat.VecDense {
+	out, err := p.TryApply(v)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// TryApply returns the preprocessed vector v, as in Apply. If v is nil
+// or does not have Dims() dimensions, an error wrapping ErrNilInput or
+// ErrDimension is returned.
+func (p *Pipeline) TryApply(v mat.Vector) (*mat.VecDense, error) {
+	if isNil(v) {
+		return nil, fmt.Errorf("apply: %w", ErrNilInput)
+	}
+	if v.Len() != p.Dims() {
+		return nil, fmt.Errorf("apply: %w", &DimensionError{Have: v.Len(),
+			Want: p.Dims()})
+	}
+	x := mat.Col(nil, 0, v)
+	p.apply(x)
+	return mat.NewVecDense(len(x), x), nil
+}
+
+// ApplyBatch returns the preprocessed vectors of the batch b, where
+// each column of b is a vector and each column of the returned matrix
+// is the corresponding preprocessed vector. Vectors update running
+// statistics in the order of the columns. If the vectors do not have
+// Dims() dimensions, ApplyBatch panics. See TryApplyBatch for a
+// non-panicking variant.
+func (p *Pipeline) ApplyBatch(b *mat.Dense) *mat.Dense {
+	out, err := p.TryApplyBatch(b)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// TryApplyBatch returns the preprocessed vectors of the batch b, as in
+// ApplyBatch. If b is nil or the vectors do not have Dims() dimensions,
+// an error wrapping ErrNilInput or ErrDimension is returned.
+func (p *Pipeline) TryApplyBatch(b *mat.Dense) (*mat.Dense, error) {
+	if b == nil {
+		return nil, fmt.Errorf("applyBatch: %w", ErrNilInput)
+	}
+	rows, cols := b.Dims()
+	if rows != p.Dims() {
+		return nil, fmt.Errorf("applyBatch: %w", &DimensionError{Have: rows,
+			Want: p.Dims()})
+	}
+	out := mat.NewDense(rows, cols, nil)
+	x := make([]float64, rows)
+	for j := 0; j < cols; j++ {
+		mat.Col(x, j, b)
+		p.apply(x)
+		out.SetCol(j, x)
+	}
+	return out, nil
+}
+
+// apply applies each stage of the receiver to x in place
+func (p *Pipeline) apply(x []float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range p.stages {
+		p.stages[i].apply(x, !p.frozen)
+	}
+}
+
+// pipelineState holds every field of a Pipeline in exported form
+type pipelineState struct {
+	Frozen bool         `json:"frozen"`
+	Stages []stageState `json:"stages"`
+}
+
+// stageState holds every field of a Stage in exported form
+type stageState struct {
+	Kind    string `json:"kind"`
+	Low     floats `json:"low,omitempty"`
+	High    floats `json:"high,omitempty"`
+	Weights floats `json:"weights,omitempty"`
+	Count   uint64 `json:"count,omitempty"`
+	Mean    floats `json:"mean,omitempty"`
+	M2      floats `json:"m2,omitempty"`
+}
+
+// state returns the state of the receiver
+func (p *Pipeline) state() pipelineState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := pipelineState{Frozen: p.frozen, Stages: make([]stageState,
+		len(p.stages))}
+	for i := range p.stages {
+		c := p.stages[i].copy()
+		s.Stages[i] = stageState{c.kind, c.low, c.high, c.weights, c.count,
+			c.mean, c.m2}
+	}
+	return s
+}
+
+// newPipelineFromState returns a new Pipeline with state s
+func newPipelineFromState(s pipelineState) (*Pipeline, error) {
+	stages := make([]Stage, len(s.Stages))
+	for i, st := range s.Stages {
+		stages[i] = Stage{st.Kind, st.Low, st.High, st.Weights, st.Count,
+			st.Mean, st.M2}
+	}
+	p, err := NewPipeline(stages...)
+	if err != nil {
+		return nil, err
+	}
+	p.frozen = s.Frozen
+	return p, nil
+}
+
+// MarshalJSON implements the json.Marshaler interface. The running
+// statistics of the receiver are marshaled, so that it preprocesses
+// vectors exactly as before once unmarshaled.
+func (p *Pipeline) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.state())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (p *Pipeline) UnmarshalJSON(data []byte) error {
+	var s pipelineState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("unmarshalJSON: %v", err)
+	}
+	loaded, err := newPipelineFromState(s)
+	if err != nil {
+		return fmt.Errorf("unmarshalJSON: %v", err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stages, p.frozen = loaded.stages, loaded.frozen
+	return nil
+}
@@ -0,0 +1,155 @@
+package gotile
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestPipeline(t *testing.T) {
+	minDims := mat.NewVecDense(2, []float64{-10, 0})
+	maxDims := mat.NewVecDense(2, []float64{10, 100})
+	unit := mat.NewVecDense(2, []float64{0, 0})
+	one := mat.NewVecDense(2, []float64{1, 1})
+	p, err := NewPipeline(MinMax(minDims, maxDims), Clip(unit, one))
+	if err != nil {
+		t.Fatalf("could not create pipeline: %v", err)
+	}
+	have := p.Apply(mat.NewVecDense(2, []float64{0, 150}))
+	want := mat.NewVecDense(2, []float64{0.5, 1})
+	if !mat.EqualApprox(have, want, 1e-12) {
+		t.Errorf("apply: have(%v) want(%v)", mat.Formatted(have.T()),
+			mat.Formatted(want.T()))
+	}
+
+	// Running statistics standardize vectors until frozen
+	z, err := NewPipeline(ZScore(1))
+	if err != nil {
+		t.Fatalf("could not create pipeline: %v", err)
+	}
+	for _, x := range []float64{1, 2, 3, 4, 5} {
+		z.Apply(mat.NewVecDense(1, []float64{x}))
+	}
+	z.Freeze()
+	std := math.Sqrt(2.5)
+	for _, x := range []float64{3, 3 + std, 100} {
+		have := z.Apply(mat.NewVecDense(1, []float64{x})).AtVec(0)
+		if want := (x - 3) / std; math.Abs(have-want) > 1e-12 {
+			t.Errorf("apply(%v): have(%v) want(%v)", x, have, want)
+		}
+	}
+
+	if _, err := NewPipeline(MinMax(minDims, maxDims), ZScore(3)); err == nil {
+		t.Error("expected error with stages of different dimensions")
+	}
+	if _, err := p.TryApply(mat.NewVecDense(3, nil)); err == nil {
+		t.Error("expected error with vector of wrong dimension")
+	}
+}
+
+func TestPipelineLinear(t *testing.T) {
+	// Whitening correlated inputs with the inverse square root of their
+	// covariance [[2, 1], [1, 2]] decorrelates them
+	a, b := (1/math.Sqrt(3)+1)/2, (1/math.Sqrt(3)-1)/2
+	whiten := mat.NewDense(2, 2, []float64{a, b, b, a})
+	center := mat.NewVecDense(2, []float64{1, -1})
+	p, err := NewPipeline(Linear(whiten, center))
+	if err != nil {
+		t.Fatalf("could not create pipeline: %v", err)
+	}
+
+	v := mat.NewVecDense(2, []float64{2, 1})
+	var want mat.VecDense
+	want.SubVec(v, center)
+	want.MulVec(whiten, &want)
+	if have := p.Apply(v); !mat.EqualApprox(have, &want, 1e-12) {
+		t.Errorf("apply(%v): have(%v) want(%v)", mat.Formatted(v.T()),
+			mat.Formatted(have.T()), mat.Formatted(want.T()))
+	}
+
+	// The transform is serialized with the coder
+	nc, err := NewNormalized(p, newUniformTileCoder(t))
+	if err != nil {
+		t.Fatalf("could not create normalized coder: %v", err)
+	}
+	data, err := json.Marshal(nc)
+	if err != nil {
+		t.Fatalf("could not marshal: %v", err)
+	}
+	var loaded NormalizedCoder
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatalf("could not unmarshal: %v", err)
+	}
+	if have := loaded.Pipeline().Apply(v); !mat.EqualApprox(have, &want,
+		1e-12) {
+		t.Errorf("apply(%v) after unmarshaling: have(%v) want(%v)",
+			mat.Formatted(v.T()), mat.Formatted(have.T()),
+			mat.Formatted(want.T()))
+	}
+
+	// The transform is copied, and must be square and finite
+	whiten.Set(0, 0, 100)
+	if have := p.Apply(v); !mat.EqualApprox(have, &want, 1e-12) {
+		t.Error("apply: modifying the transform modified the stage")
+	}
+	if _, err := NewPipeline(Linear(mat.NewDense(1, 2, nil), nil)); err ==
+		nil {
+		t.Error("expected error with non-square transform")
+	}
+	whiten.Set(0, 0, math.NaN())
+	if _, err := NewPipeline(Linear(whiten, nil)); err == nil {
+		t.Error("expected error with non-finite transform")
+	}
+}
+
+func TestNormalizedCoder(t *testing.T) {
+	p, err := NewPipeline(ZScore(4))
+	if err != nil {
+		t.Fatalf("could not create pipeline: %v", err)
+	}
+	coder := newUniformTileCoder(t)
+	nc, err := NewNormalized(p, coder)
+	if err != nil {
+		t.Fatalf("could not create normalized coder: %v", err)
+	}
+
+	batch := mat.NewDense(4, 3, []float64{
+		10, 20, 30,
+		-1, 0, 1,
+		0.5, 0.5, 0.5,
+		100, 200, 400,
+	})
+	nc.EncodeBatch(batch)
+	p.Freeze()
+
+	data, err := json.Marshal(nc)
+	if err != nil {
+		t.Fatalf("could not marshal: %v", err)
+	}
+	var loaded NormalizedCoder
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatalf("could not unmarshal: %v", err)
+	}
+	if !loaded.Pipeline().Frozen() {
+		t.Error("frozen: have(false) want(true)")
+	}
+
+	// The loaded coder preprocesses and encodes exactly as the original
+	for j := 0; j < 3; j++ {
+		v := batch.ColView(j)
+		if have, want := loaded.EncodeIndices(v), nc.EncodeIndices(v); !mat.
+			Equal(mat.NewVecDense(len(have), have),
+				mat.NewVecDense(len(want), want)) {
+			t.Errorf("encodeIndices(%v): have(%v) want(%v)",
+				mat.Formatted(v.T()), have, want)
+		}
+		x := p.Apply(v)
+		if have, want := nc.Encode(v), coder.Encode(x); !mat.Equal(have,
+			want) {
+			t.Errorf("encode(%v): normalized encoding differs from encoding "+
+				"the preprocessed vector", mat.Formatted(v.T()))
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package gotile
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrOutOfBounds is wrapped by errors returned when encoding a vector
+// which falls outside the bounds of a tiling using BoundsError
+var ErrOutOfBounds = errors.New("out of bounds")
+
+// BoundsPolicy determines how a tiling encodes features which fall
+// outside of its bounds. Features are out of bounds when they fall
+// below the minimum or above the maximum of a dimension, regardless of
+// how the tiling is offset. In-bounds features which are offset past
+// the edge of the tiling always fall in the first or last tile of the
+// dimension.
+type BoundsPolicy int
+
+const (
+	// BoundsClip places out-of-bounds features in the nearest tile
+	// along the dimension
+	BoundsClip BoundsPolicy = iota
+
+	// BoundsError reports an error wrapping ErrOutOfBounds when
+	// encoding out-of-bounds features
+	BoundsError
+
+	// BoundsWrap treats each dimension as periodic, so that features
+	// past the maximum of a dimension wrap around to its minimum and
+	// vice versa. This is useful for angles.
+	BoundsWrap
+
+	// BoundsExtend adds an underflow tile at the start and an overflow
+	// tile at the end of each dimension, which hold all features below
+	// the minimum and above the maximum of the dimension respectively.
+	// Each dimension therefore has two more tiles than bins.
+	BoundsExtend
+)
+
+// String returns the name of the BoundsPolicy
+func (b BoundsPolicy) String() string {
+	switch b {
+	case BoundsClip:
+		return "BoundsClip"
+	case BoundsError:
+		return "BoundsError"
+	case BoundsWrap:
+		return "BoundsWrap"
+	case BoundsExtend:
+		return "BoundsExtend"
+	default:
+		return "BoundsPolicy(unknown)"
+	}
+}
+
+// MarshalText implements the encoding.TextMarshaler interface, so that
+// BoundsPolicies are encoded by name in JSON, YAML, and TOML
+func (b BoundsPolicy) MarshalText() ([]byte, error) {
+	if b < BoundsClip || b > BoundsExtend {
+		return nil, fmt.Errorf("marshalText: unknown bounds policy %d",
+			int(b))
+	}
+	return []byte(strings.ToLower(strings.TrimPrefix(b.String(),
+		"Bounds"))), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+// Both the short name ("clip") and the full name ("BoundsClip") of
+// each BoundsPolicy are accepted, regardless of case.
+func (b *BoundsPolicy) UnmarshalText(text []byte) error {
+	name := strings.TrimPrefix(strings.ToLower(string(text)), "bounds")
+	for p := BoundsClip; p <= BoundsExtend; p++ {
+		if name == strings.ToLower(strings.TrimPrefix(p.String(), "Bounds")) {
+			*b = p
+			return nil
+		}
+	}
+	return fmt.Errorf("unmarshalText: unknown bounds policy %q", text)
+}
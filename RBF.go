@@ -0,0 +1,311 @@
+package gotile
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// RBFCoder encodes vectors with Gaussian radial basis functions
+// centered on the tiles of a set of tilings, as a smooth alternative to
+// tile coding. Each feature corresponds to a tile of the equivalent
+// TileCoder, but rather than being 1.0 if the vector falls in the tile
+// and 0.0 otherwise, its activation is
+//
+//	exp(-‖p - c‖² / (2 width²))
+//
+// where p is the position of the vector and c is the center of the
+// tile, both measured in tiles along each dimension of the tiling in
+// the space in which tiles are equally spaced. Tilings are offset,
+// scaled, squashed, and bounded exactly as they are for tile coding.
+// Along categorical dimensions, a tile is fully active if the vector
+// falls in it and inactive otherwise.
+type RBFCoder struct {
+	coder     *TileCoder
+	width     float64
+	threshold float64
+}
+
+// NewRBF returns a new RBFCoder whose basis functions are centered on
+// the tiles of the tilings which New would create given the same
+// arguments. The width of each basis function is given in tiles, so
+// that a width of 0.5 places most of the mass of each basis function
+// within its tile. If includeBias is true, the first feature is a bias
+// unit which is always 1.0. Options which configure tilings are used,
+// while Options which configure only TileCoders are ignored.
+func NewRBF(minDims, maxDims mat.Vector, bins [][]int, seed uint64,
+	includeBias bool, offsetDiv, width float64,
+	opts ...Option) (*RBFCoder, error) {
+	if width <= 0 || math.IsInf(width, 0) || math.IsNaN(width) {
+		return nil, fmt.Errorf("newRBF: width must be positive and "+
+			"finite: %v", width)
+	}
+
+	coder, err := New(minDims, maxDims, bins, seed, includeBias, offsetDiv,
+		opts...)
+	if err != nil {
+		return nil, fmt.Errorf("newRBF: %v", err)
+	}
+	for _, tiling := range coder.tilings {
+		if tiling.rotation != nil {
+			return nil, fmt.Errorf("newRBF: rotated tilings are not supported")
+		}
+		if tiling.nonFinite == NonFiniteTile {
+			return nil, fmt.Errorf("newRBF: the NonFiniteTile policy is not " +
+				"supported")
+		}
+	}
+	coder.adaptive, coder.visits, coder.metrics = nil, nil, nil
+	return &RBFCoder{coder: coder, width: width}, nil
+}
+
+// SetThreshold sets the activation below which features are set to
+// 0.0, so that encodings are sparse. By default, the threshold is 0.0
+// and every feature is active.
+func (r *RBFCoder) SetThreshold(threshold float64) {
+	r.threshold = threshold
+}
+
+// Width returns the width of each basis function, in tiles
+func (r *RBFCoder) Width() float64 {
+	return r.width
+}
+
+// Tilings returns the tilings on whose tiles the basis functions are
+// centered. The tilings themselves are shared with the receiver and
+// should not be modified.
+func (r *RBFCoder) Tilings() []*Tiling {
+	return r.coder.Tilings()
+}
+
+// VecLength returns the number of features in each encoded vector
+func (r *RBFCoder) VecLength() int {
+	return r.coder.VecLength()
+}
+
+// Encode returns the activation of each basis function for the vector
+// v. If some tiling uses the BoundsError policy and v falls outside its
+// bounds, Encode panics. See TryEncode for a non-panicking variant.
+func (r *RBFCoder) Encode(v mat.Vector) *mat.VecDense {
+	encoded, err := r.TryEncode(v)
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
+// TryEncode returns the activation of each basis function for the
+// vector v, as in Encode. If some tiling uses the BoundsError policy and
+// v falls outside its bounds, an error wrapping ErrOutOfBounds is
+// returned.
+func (r *RBFCoder) TryEncode(v mat.Vector) (*mat.VecDense, error) {
+	dst := make([]float64, r.VecLength())
+	if err := r.encodeTo(dst, v); err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+	return mat.NewVecDense(len(dst), dst), nil
+}
+
+// EncodeIndices returns the indices of the features of v whose
+// activation is at least the threshold of the receiver, in increasing
+// order. If some tiling uses the BoundsError policy and v falls outside
+// its bounds, EncodeIndices panics. See TryEncodeIndices for a
+// non-panicking variant.
+func (r *RBFCoder) EncodeIndices(v mat.Vector) []float64 {
+	indices, err := r.TryEncodeIndices(v)
+	if err != nil {
+		panic(err)
+	}
+	return indices
+}
+
+// TryEncodeIndices returns the indices of the active features of v, as
+// in EncodeIndices. If some tiling uses the BoundsError policy and v
+// falls outside its bounds, an error wrapping ErrOutOfBounds is
+// returned.
+func (r *RBFCoder) TryEncodeIndices(v mat.Vector) ([]float64, error) {
+	dst := make([]float64, r.VecLength())
+	if err := r.encodeTo(dst, v); err != nil {
+		return nil, fmt.Errorf("encodeIndices: %w", err)
+	}
+
+	var indices []float64
+	for i, activation := range dst {
+		if activation != 0 {
+			indices = append(indices, float64(i))
+		}
+	}
+	return indices, nil
+}
+
+// EncodeBatch returns the activation of each basis function for each
+// vector in the batch b. Each column of b is a vector to encode, and
+// each column of the returned matrix holds the activations of the
+// corresponding column of b. If some tiling uses the BoundsError policy
+// and some vector falls outside its bounds, EncodeBatch panics. See
+// TryEncodeBatch for a non-panicking variant.
+func (r *RBFCoder) EncodeBatch(b *mat.Dense) *mat.Dense {
+	encoded, err := r.TryEncodeBatch(b)
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
+// TryEncodeBatch returns the activation of each basis function for
+// each vector in the batch b, as in EncodeBatch. If some tiling uses
+// the BoundsError policy and some vector falls outside its bounds, an
+// error wrapping ErrOutOfBounds is returned.
+func (r *RBFCoder) TryEncodeBatch(b *mat.Dense) (*mat.Dense, error) {
+	if err := r.coder.checkBatchDims(b); err != nil {
+		return nil, fmt.Errorf("encodeBatch: %w", err)
+	}
+	_, batchSize := b.Dims()
+	encoded := mat.NewDense(r.VecLength(), batchSize, nil)
+	column := make([]float64, r.VecLength())
+	for j := 0; j < batchSize; j++ {
+		if err := r.encodeTo(column, b.ColView(j)); err != nil {
+			return nil, fmt.Errorf("encodeBatch: vector %d: %w", j, err)
+		}
+		encoded.SetCol(j, column)
+	}
+	return encoded, nil
+}
+
+// encodeTo stores the activation of each basis function for v in dst,
+// which must have length r.VecLength()
+func (r *RBFCoder) encodeTo(dst []float64, v mat.Vector) error {
+	if err := r.coder.check(v); err != nil {
+		return err
+	}
+
+	start := 0
+	if r.coder.includeBias {
+		dst[0] = 1.0
+		start = 1
+	}
+	for _, tiling := range r.coder.tilings {
+		tiles := tiling.Tiles()
+		tiling.activations(v, r.width, dst[start:start+tiles])
+		start += tiles
+	}
+
+	for i := range dst {
+		if dst[i] < r.threshold {
+			dst[i] = 0.0
+		}
+	}
+	return nil
+}
+
+// activations stores in dst the activation of a Gaussian basis
+// function of width width centered on each tile of the tiling for the
+// vector v, where dst has length t.Tiles()
+func (t *Tiling) activations(v mat.Vector, width float64, dst []float64) {
+	// The activation of each tile is the product of its activation
+	// along each dimension
+	perDim := make([][]float64, len(t.bins))
+	for i := range t.bins {
+		perDim[i] = make([]float64, t.size(i))
+		t.dimActivations(i, v.AtVec(t.dims[i]), width, perDim[i])
+	}
+
+	for index := range dst {
+		activation := 1.0
+		for i, stride := range t.strides {
+			activation *= perDim[i][(index/stride)%t.size(i)]
+		}
+		dst[index] = activation
+	}
+}
+
+// dimActivations stores in dst the activation along dimension i of a
+// Gaussian basis function of width width centered on each tile along
+// the dimension, for the input feature x
+func (t *Tiling) dimActivations(i int, x, width float64, dst []float64) {
+	if t.categories[i] {
+		// Categorical dimensions are never offset, so no error can
+		// occur with the BoundsError policy
+		tile, _ := t.place(i, x)
+		dst[tile] = 1.0
+		return
+	}
+
+	p := t.position(i, x)
+	for tile := range dst {
+		d := p - (float64(tile) + 0.5)
+		if t.policy == BoundsWrap {
+			// Measure the shortest distance around the periodic
+			// dimension
+			period := float64(t.bins[i])
+			d = math.Mod(d, period)
+			if d > period/2 {
+				d -= period
+			} else if d < -period/2 {
+				d += period
+			}
+		}
+		dst[tile] = math.Exp(-d * d / (2 * width * width))
+	}
+}
+
+// position returns the position of the input feature x along dimension
+// i, measured in tiles from the start of the first tile along the
+// dimension, after applying the BoundsPolicy of the tiling. Tile j is
+// centered at position j + 0.5, including the underflow and overflow
+// tiles of the BoundsExtend policy.
+func (t *Tiling) position(i int, x float64) float64 {
+	if t.isNonFinite(i, x) {
+		// Non-finite features are centered in the tile in which they
+		// are placed. Errors are reported when vectors are checked.
+		tile, _ := t.placeNonFinite(i, x)
+		return float64(tile) + 0.5
+	}
+	x = t.transform(i, x)
+	lower := t.minDims.AtVec(i)
+	upper := lower + float64(t.bins[i])*t.binLengths[i]
+
+	switch t.policy {
+	case BoundsWrap:
+		width := upper - lower
+		x = lower + math.Mod(x-lower, width)
+		if x < lower {
+			x += width
+		}
+	case BoundsExtend:
+		// Vectors beyond the bounds are centered in the underflow or
+		// overflow tile
+		if x < lower {
+			return 0.5
+		} else if x > upper {
+			return float64(t.bins[i]) + 1.5
+		}
+	default:
+		x = math.Max(lower, math.Min(x, upper))
+	}
+	x += t.offsets.At(0, i)
+
+	var p float64
+	if e := t.edges[i]; e != nil {
+		j := int(t.tile(i, x))
+		if j < 0 {
+			j = 0
+		} else if j > len(e)-2 {
+			j = len(e) - 2
+		}
+		p = float64(j) + (x-e[j])/(e[j+1]-e[j])
+	} else {
+		p = (x - lower) / t.binLengths[i]
+	}
+
+	if t.policy == BoundsExtend {
+		// Within the bounds, vectors are never placed in the underflow
+		// or overflow tiles
+		return math.Max(1, math.Min(p+1, float64(t.bins[i])+1))
+	}
+	return p
+}
+
+// Ensure RBFCoder implements TryCoder
+var _ TryCoder = (*RBFCoder)(nil)
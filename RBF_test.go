@@ -0,0 +1,76 @@
+package gotile
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestRBFCoder(t *testing.T) {
+	rbf, err := NewRBF(
+		mat.NewVecDense(1, []float64{0}),
+		mat.NewVecDense(1, []float64{1}),
+		[][]int{{4}},
+		1,
+		false,
+		1e300,
+		0.5,
+	)
+	if err != nil {
+		t.Fatalf("could not create RBF coder: %v", err)
+	}
+
+	// A vector at the center of the second tile fully activates it
+	v := mat.NewVecDense(1, []float64{0.375})
+	want := []float64{math.Exp(-2), 1, math.Exp(-2), math.Exp(-8)}
+	got := rbf.Encode(v).RawVector().Data
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-12 {
+			t.Errorf("encode(%v): have(%v) want(%v)", v, got, want)
+			break
+		}
+	}
+
+	rbf.SetThreshold(0.1)
+	indices := rbf.EncodeIndices(v)
+	if len(indices) != 3 || indices[0] != 0 || indices[2] != 2 {
+		t.Errorf("encodeIndices(%v): have(%v) want(%v)", v, indices,
+			[]float64{0, 1, 2})
+	}
+
+	batch := rbf.EncodeBatch(mat.NewDense(1, 2, []float64{0.375, 0.9}))
+	if !mat.Equal(batch.ColView(0), rbf.Encode(v)) {
+		t.Errorf("encodeBatch: have(%v) want(%v)", mat.Formatted(batch.ColView(0)),
+			mat.Formatted(rbf.Encode(v)))
+	}
+
+	if _, err := NewRBF(mat.NewVecDense(1, []float64{0}),
+		mat.NewVecDense(1, []float64{1}), [][]int{{4}}, 1, false, -1,
+		0); err == nil {
+		t.Error("expected error with zero width")
+	}
+}
+
+func TestRBFCoderWrap(t *testing.T) {
+	rbf, err := NewRBF(
+		mat.NewVecDense(1, []float64{0}),
+		mat.NewVecDense(1, []float64{1}),
+		[][]int{{4}},
+		1,
+		true,
+		1e300,
+		0.5,
+		WithBoundsPolicy(BoundsWrap),
+	)
+	if err != nil {
+		t.Fatalf("could not create RBF coder: %v", err)
+	}
+
+	// A vector at the boundary of a periodic dimension is equally
+	// close to the first and last tiles
+	got := rbf.Encode(mat.NewVecDense(1, []float64{0})).RawVector().Data
+	if got[0] != 1 || math.Abs(got[1]-got[4]) > 1e-12 {
+		t.Errorf("encode(0): have(%v) want bias 1 and equal end tiles", got)
+	}
+}
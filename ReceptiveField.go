@@ -0,0 +1,116 @@
+package gotile
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/spatial/r1"
+)
+
+// ReceptiveField describes the region of a 2D slice of input space
+// which activates a single tile-coded feature, for interpreting the
+// learned weight of the feature
+type ReceptiveField struct {
+	// Feature is the global index of the feature in the tile-coded
+	// representation
+	Feature int
+
+	// Tiling is the tiling to which the feature belongs
+	Tiling int
+
+	// Field holds, for the cell in row i and column j of the slice, 1
+	// if the center of the cell activates the feature and 0 otherwise.
+	// Cells whose centers fall outside the bounds of a tiling using
+	// the BoundsError policy are NaN.
+	Field *mat.Dense
+
+	// Overlap holds, for the cell in row i and column j of the slice,
+	// the number of other tilings whose active tile at the center of
+	// the cell is also active somewhere in the receptive field. Cells
+	// inside the receptive field count the neighbouring tiles sharing
+	// generalization with the feature, while cells outside it show how
+	// far that generalization reaches. Cells whose centers fall outside
+	// the bounds of a tiling using the BoundsError policy are NaN.
+	Overlap *mat.Dense
+}
+
+// ReceptiveField returns the receptive field of feature i of the
+// tile-coded representation produced by the receiver over a 2D slice
+// of input space. The slice varies input dimension x over xBounds and
+// input dimension y over yBounds, holding every other input dimension
+// fixed at its value in point, and is divided into a grid of rows by
+// cols cells as in Heatmap. Rows are ordered by increasing y and
+// columns by increasing x.
+//
+// An error is returned if i is not a feature of the tile-coded
+// representation or is the bias unit, which is active everywhere.
+// Computing a receptive field does not adapt the bounds of the
+// receiver or count visits.
+func (t *TileCoder) ReceptiveField(i int, point mat.Vector, x, y int,
+	xBounds, yBounds r1.Interval, rows, cols int) (*ReceptiveField, error) {
+	feature, err := t.DescribeFeature(i)
+	if err != nil {
+		return nil, fmt.Errorf("receptiveField: %v", err)
+	}
+	if feature.Bias {
+		return nil, fmt.Errorf("receptiveField: feature %d is the bias "+
+			"unit", i)
+	}
+	if err := checkSlice(point, x, y, rows, cols); err != nil {
+		return nil, fmt.Errorf("receptiveField: %w", err)
+	}
+
+	// Record the active features of each cell, and which features of
+	// the other tilings are active somewhere in the receptive field
+	active := make([][]int, rows*cols)
+	neighbours := make(map[int]bool)
+	field := mat.NewDense(rows, cols, nil)
+	forCells(point, x, y, xBounds, yBounds, rows, cols, func(r, c int,
+		v mat.Vector) {
+		if err := t.check(v); err != nil {
+			field.Set(r, c, math.NaN())
+			return
+		}
+
+		index := make([]int, len(t.tilings))
+		t.activeFeatures(v, index)
+		active[r*cols+c] = index
+		if index[feature.Tiling] != i {
+			return
+		}
+
+		field.Set(r, c, 1)
+		for k, f := range index {
+			if k != feature.Tiling {
+				neighbours[f] = true
+			}
+		}
+	})
+
+	overlap := mat.NewDense(rows, cols, nil)
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			index := active[r*cols+c]
+			if index == nil {
+				overlap.Set(r, c, math.NaN())
+				continue
+			}
+
+			count := 0
+			for _, f := range index {
+				if neighbours[f] {
+					count++
+				}
+			}
+			overlap.Set(r, c, float64(count))
+		}
+	}
+
+	return &ReceptiveField{
+		Feature: i,
+		Tiling:  feature.Tiling,
+		Field:   field,
+		Overlap: overlap,
+	}, nil
+}
@@ -0,0 +1,145 @@
+package gotile
+
+import (
+	"fmt"
+)
+
+// ReindexHook is called by a TileCoder each time a tiling is added or
+// removed, with the new feature of each old feature and the new
+// VecLength, features. After the hook is called, old feature i is
+// feature index[i] of the tile-coded representation, or no feature if
+// index[i] is -1, so that a weight vector w can be carried over to the
+// new features by setting the weight of feature index[i] to w[i].
+// Features which are not the new feature of any old feature belong to
+// an added tiling.
+type ReindexHook func(index []int, features int)
+
+// SetReindexHook sets the hook called when a tiling is added to or
+// removed from the receiver, replacing any previous hook, or removes
+// the hook if hook is nil. Since hooks cannot be serialized,
+// SetReindexHook can be used to restore a hook after a TileCoder is
+// deserialized.
+func (t *TileCoder) SetReindexHook(hook ReindexHook) {
+	t.reindex = hook
+}
+
+// AddTiling adds a copy of tiling to the receiver as its last tiling,
+// so that resolution can be grown during training. The features of
+// the new tiling follow those of the existing tilings, so that the
+// index of every existing feature is unchanged and VecLength grows by
+// tiling.Tiles(). If the receiver sets the values of the active
+// features of its tilings, the active feature of the new tiling has
+// the same value as that of the current last tiling. Visit counts of
+// the new features start at zero. If the receiver adapts its bounds,
+// the new tiling is rescaled to the current bounds.
+//
+// AddTiling returns an error if tiling tiles an input dimension which
+// the receiver does not encode, and an error wrapping ErrFeatureSpace
+// if the receiver would have more than MaxFeatures features. If an
+// error is returned, the receiver is unchanged. AddTiling must not be
+// called concurrently with encoding.
+func (t *TileCoder) AddTiling(tiling *Tiling) error {
+	if tiling == nil {
+		return fmt.Errorf("addTiling: %w", ErrNilInput)
+	}
+	if dims := tiledDims([]*Tiling{tiling}); dims > t.inputDims {
+		return fmt.Errorf("addTiling: %w: tiling tiles %d input dimensions, "+
+			"but tile coder encodes %d", ErrDimension, dims, t.inputDims)
+	}
+
+	added, err := newTilingFromState(tiling.state())
+	if err != nil {
+		return fmt.Errorf("addTiling: %v", err)
+	}
+	if a := t.adaptive; a != nil {
+		if err := added.rescale(a.minDims, a.maxDims); err != nil {
+			return fmt.Errorf("addTiling: %v", err)
+		}
+	}
+
+	old := t.VecLength()
+	tilings := append(t.Tilings(), added)
+	if err := t.init(tilings, t.includeBias); err != nil {
+		return fmt.Errorf("addTiling: %w", err)
+	}
+	if t.activations != nil {
+		last := t.activations[len(t.activations)-1]
+		activations := append([]float64(nil), t.activations...)
+		t.activations = append(activations, last)
+	}
+
+	index := make([]int, old)
+	for i := range index {
+		index[i] = i
+	}
+	t.remap(index)
+	return nil
+}
+
+// RemoveTiling removes tiling number i from the receiver. The features
+// of tiling i are removed from the tile-coded representation, and the
+// features of each later tiling move down by the number of tiles of
+// tiling i, so that VecLength shrinks by the number of tiles of tiling
+// i while features before tiling i keep their indices. Tilings after
+// tiling i move down by one.
+//
+// RemoveTiling returns an error if i is not a tiling of the receiver
+// or if tiling i is the only tiling of the receiver. If an error is
+// returned, the receiver is unchanged. RemoveTiling must not be called
+// concurrently with encoding.
+func (t *TileCoder) RemoveTiling(i int) error {
+	start, end, err := t.TryFeatureRange(i)
+	if err != nil {
+		return fmt.Errorf("removeTiling: %v", err)
+	}
+	if len(t.tilings) == 1 {
+		return fmt.Errorf("removeTiling: cannot remove the only tiling")
+	}
+
+	old := t.VecLength()
+	tilings := append(t.Tilings()[:i], t.tilings[i+1:]...)
+
+	// Removing a tiling never increases the number of features, so no
+	// error can occur
+	if err := t.init(tilings, t.includeBias); err != nil {
+		panic(err)
+	}
+	if t.activations != nil {
+		activations := append([]float64(nil), t.activations[:i]...)
+		t.activations = append(activations, t.activations[i+1:]...)
+	}
+
+	index := make([]int, old)
+	for j := range index {
+		switch {
+		case j < start:
+			index[j] = j
+		case j < end:
+			index[j] = -1
+		default:
+			index[j] = j - (end - start)
+		}
+	}
+	t.remap(index)
+	return nil
+}
+
+// remap moves the visit counts of each old feature j of the receiver
+// to feature index[j], dropping the counts of features with index -1,
+// and notifies the hook. The receiver's tilings must already have been
+// changed.
+func (t *TileCoder) remap(index []int) {
+	if t.visits != nil {
+		old := t.VisitCounts()
+		t.visits = newVisitCounts(t.VecLength(), t.visitShards())
+		counts := t.visits.shard(0)
+		for j, count := range old {
+			if index[j] >= 0 {
+				counts[index[j]] = count
+			}
+		}
+	}
+	if t.reindex != nil {
+		t.reindex(index, t.VecLength())
+	}
+}
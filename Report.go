@@ -0,0 +1,119 @@
+package gotile
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"text/tabwriter"
+)
+
+// GeneralizationReport summarizes the resolution of a TileCoder along
+// each of its input dimensions, so that bins and tilings can be tuned
+// programmatically
+type GeneralizationReport struct {
+	// Dims holds a report for each input dimension tiled by some
+	// tiling, in increasing order of input dimension
+	Dims []DimensionReport
+}
+
+// DimensionReport describes the resolution of a TileCoder along a
+// single input dimension. Widths and offsets are measured in the space
+// in which tiles are equally spaced, so they are log-widths for
+// log-scaled dimensions and widths in the squashed space for squashed
+// dimensions. Tiles placed at explicit bin edges are described by
+// their mean width.
+type DimensionReport struct {
+	// Dim is the input dimension described by the report
+	Dim int
+
+	// Tilings holds each tiling which tiles the dimension, and
+	// TileWidths and Offsets hold the tile width and offset of each of
+	// these tilings along the dimension. The tile width of a tiling is
+	// also the widest distance over which the tiling generalizes,
+	// since inputs further apart never share a tile.
+	Tilings    []int
+	TileWidths []float64
+	Offsets    []float64
+
+	// OffsetSpread is the difference between the largest and smallest
+	// offsets of the tilings along the dimension
+	OffsetSpread float64
+
+	// GeneralizationWidth is the widest distance over which the
+	// TileCoder generalizes along the dimension, which is the widest
+	// tile width of any tiling
+	GeneralizationWidth float64
+
+	// Resolution is the mean tile width divided by the number of
+	// tilings, which is the smallest distance between inputs that the
+	// TileCoder can distinguish when tilings are evenly offset
+	Resolution float64
+}
+
+// GeneralizationReport returns a report of the tile width, offset
+// spread, and generalization width of the receiver along each input
+// dimension
+func (t *TileCoder) GeneralizationReport() GeneralizationReport {
+	reports := make(map[int]*DimensionReport)
+	maxDim := -1
+	for k, tiling := range t.tilings {
+		for i, d := range tiling.dims {
+			r, ok := reports[d]
+			if !ok {
+				r = &DimensionReport{Dim: d}
+				reports[d] = r
+			}
+			r.Tilings = append(r.Tilings, k)
+			r.TileWidths = append(r.TileWidths, tiling.binLengths[i])
+			r.Offsets = append(r.Offsets, tiling.offsets.At(0, i))
+			if d > maxDim {
+				maxDim = d
+			}
+		}
+	}
+
+	var report GeneralizationReport
+	for d := 0; d <= maxDim; d++ {
+		r, ok := reports[d]
+		if !ok {
+			continue
+		}
+
+		minOffset, maxOffset := math.Inf(1), math.Inf(-1)
+		meanWidth := 0.0
+		for i := range r.Tilings {
+			minOffset = math.Min(minOffset, r.Offsets[i])
+			maxOffset = math.Max(maxOffset, r.Offsets[i])
+			r.GeneralizationWidth = math.Max(r.GeneralizationWidth,
+				r.TileWidths[i])
+			meanWidth += r.TileWidths[i]
+		}
+		n := float64(len(r.Tilings))
+		r.OffsetSpread = maxOffset - minOffset
+		r.Resolution = meanWidth / n / n
+
+		report.Dims = append(report.Dims, *r)
+	}
+	return report
+}
+
+// String returns a table summarizing the report along each input
+// dimension
+func (r GeneralizationReport) String() string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Dim\tTilings\tTile Widths\tOffset Spread\t"+
+		"Generalization Width\tResolution")
+	for _, d := range r.Dims {
+		minWidth, maxWidth := math.Inf(1), math.Inf(-1)
+		for _, width := range d.TileWidths {
+			minWidth = math.Min(minWidth, width)
+			maxWidth = math.Max(maxWidth, width)
+		}
+		fmt.Fprintf(w, "%d\t%d\t[%.4g, %.4g]\t%.4g\t%.4g\t%.4g\n", d.Dim,
+			len(d.Tilings), minWidth, maxWidth, d.OffsetSpread,
+			d.GeneralizationWidth, d.Resolution)
+	}
+	w.Flush()
+	return buf.String()
+}
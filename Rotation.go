@@ -0,0 +1,106 @@
+package gotile
+
+import (
+	"math"
+
+	"github.com/samuelfneumann/goutils/floatutils"
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Rotation returns the rotation applied to the tiled dimensions of the
+// tiling, or nil if the tiling is not rotated. See WithRotation.
+func (t *Tiling) Rotation() *mat.Dense {
+	if t.rotation == nil {
+		return nil
+	}
+	return mat.DenseCopyOf(t.rotation)
+}
+
+// randomRotation returns an n × n rotation matrix sampled uniformly at
+// random using rng, as the orthonormal factor of the QR decomposition
+// of a matrix of standard normal samples
+func randomRotation(n int, rng *rand.Rand) *mat.Dense {
+	a := mat.NewDense(n, n, nil)
+	raw := a.RawMatrix().Data
+	for i := range raw {
+		raw[i] = rng.NormFloat64()
+	}
+
+	var qr mat.QR
+	qr.Factorize(a)
+	var q, r mat.Dense
+	qr.QTo(&q)
+	qr.RTo(&r)
+
+	// Fix the signs of the columns of Q so that the rotation is
+	// uniformly distributed
+	for j := 0; j < n; j++ {
+		if r.At(j, j) < 0 {
+			for i := 0; i < n; i++ {
+				q.Set(i, j, -q.At(i, j))
+			}
+		}
+	}
+	return &q
+}
+
+// rotatedIndex returns the index of the tile in which the input
+// features x of the tiled dimensions fall after rotation. The features
+// are transformed and rotated in place.
+func (t *Tiling) rotatedIndex(x []float64) (int, error) {
+	n := len(t.bins)
+	for i := range x {
+		if t.isNonFinite(i, x[i]) {
+			// Non-finite features are placed at the nearest bound
+			tile, err := t.placeNonFinite(i, x[i])
+			if err != nil {
+				return 0, err
+			}
+			x[i] = 1
+			if tile == 0 {
+				x[i] = -1
+			}
+			continue
+		}
+		if t.policy == BoundsError {
+			// Out-of-bounds inputs are detected before rotation
+			if _, err := t.place(i, x[i]); err != nil {
+				return 0, err
+			}
+		}
+
+		// Normalize each dimension to [-1, 1] in the scaled space
+		half := float64(t.bins[i]) * t.binLengths[i] / 2
+		center := t.minDims.AtVec(i) + half
+		x[i] = (t.transform(i, x[i]) - center) / half
+	}
+
+	var buf [8]float64
+	rotated := buf[:0]
+	if n > len(buf) {
+		rotated = make([]float64, 0, n)
+	}
+	for i := 0; i < n; i++ {
+		row := t.rotation.RawRowView(i)
+		sum, norm := 0.0, 0.0
+		for j, r := range row {
+			sum += r * x[j]
+			norm += math.Abs(r)
+		}
+
+		// Shrink the rotated dimension so that the rotated bounds fit
+		// within the bounds
+		rotated = append(rotated, sum/norm)
+	}
+
+	index := 0
+	for i, u := range rotated {
+		half := float64(t.bins[i]) * t.binLengths[i] / 2
+		x := t.minDims.AtVec(i) + half + u*half
+		tile := t.tile(i, x+t.offsets.At(0, i))
+		tile = floatutils.Clip(tile, 0.0, float64(t.bins[i]-1))
+		index += int(tile) * t.strides[i]
+	}
+	return index, nil
+}
@@ -0,0 +1,125 @@
+package gotile
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+
+	"gonum.org/v1/gonum/spatial/r1"
+)
+
+// Layout of the SVG rendering of a tiling, in pixels
+const (
+	svgSize   = 400 // Width and height of the tiling
+	svgMargin = 40  // Margin around the tiling, holding its labels
+)
+
+// RenderSVG writes a standalone SVG image of the tiles of the tiling
+// along the input dimensions dims[0], drawn horizontally, and dims[1],
+// drawn vertically, to w. Each tile is outlined once, in the input
+// space, so that offsets, log-scaled dimensions, and bin edges appear
+// as they divide the input space. The underflow and overflow tiles of
+// the BoundsExtend policy are clipped to the bounds of the tiling. The
+// image is labeled with the bounds of the tiling along each dimension
+// and requires no plotting dependency, so that it can be embedded in
+// papers and dashboards.
+//
+// An error is returned if the tiling does not tile both dimensions, if
+// its bounds along either dimension are infinite, or if w returns an
+// error.
+func (t *Tiling) RenderSVG(w io.Writer, dims [2]int) error {
+	if dims[0] == dims[1] {
+		return fmt.Errorf("renderSVG: cannot render dimension %d against "+
+			"itself", dims[0])
+	}
+	var pos [2]int
+	var clip [2]r1.Interval
+	low, high := t.Bounds()
+	for k, d := range dims {
+		pos[k] = -1
+		for i, dim := range t.dims {
+			if dim == d {
+				pos[k] = i
+			}
+		}
+		if pos[k] < 0 {
+			return fmt.Errorf("renderSVG: tiling does not tile dimension %d",
+				d)
+		}
+		lo, hi := low[pos[k]], high[pos[k]]
+		if math.IsInf(lo, 0) || math.IsInf(hi, 0) {
+			return fmt.Errorf("renderSVG: tiling has infinite bounds along "+
+				"dimension %d", d)
+		}
+		clip[k] = r1.Interval{Min: lo, Max: hi}
+	}
+
+	// Map the bounds of the tiling onto the image, with the vertical
+	// dimension increasing upwards
+	px := func(x float64) float64 {
+		return svgMargin + (x-clip[0].Min)/(clip[0].Max-clip[0].Min)*svgSize
+	}
+	py := func(y float64) float64 {
+		return svgMargin + (clip[1].Max-y)/(clip[1].Max-clip[1].Min)*svgSize
+	}
+
+	var buf bytes.Buffer
+	size := svgSize + 2*svgMargin
+	fmt.Fprintf(&buf, "<svg xmlns=\"http://www.w3.org/2000/svg\" "+
+		"width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", size, size,
+		size, size)
+	fmt.Fprintf(&buf, "<rect width=\"%d\" height=\"%d\" fill=\"white\"/>\n",
+		size, size)
+
+	buf.WriteString("<g fill=\"none\" stroke=\"black\" stroke-width=\"1\">\n")
+	seen := make(map[[4]float64]bool)
+	for index := 0; index < t.Tiles(); index++ {
+		bounds, err := t.TryTileBounds(index)
+		if err != nil {
+			// The non-finite tile covers no region
+			continue
+		}
+		x, y := clipInterval(bounds[pos[0]], clip[0]),
+			clipInterval(bounds[pos[1]], clip[1])
+		key := [4]float64{x.Min, x.Max, y.Min, y.Max}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		fmt.Fprintf(&buf, "<rect x=\"%.2f\" y=\"%.2f\" width=\"%.2f\" "+
+			"height=\"%.2f\"/>\n", px(x.Min), py(y.Max), px(x.Max)-px(x.Min),
+			py(y.Min)-py(y.Max))
+	}
+	buf.WriteString("</g>\n")
+
+	// Label the bounds along each dimension
+	buf.WriteString("<g font-family=\"sans-serif\" font-size=\"12\">\n")
+	fmt.Fprintf(&buf, "<text x=\"%d\" y=\"%d\">%.4g</text>\n", svgMargin,
+		svgMargin+svgSize+16, clip[0].Min)
+	fmt.Fprintf(&buf, "<text x=\"%d\" y=\"%d\" text-anchor=\"end\">%.4g"+
+		"</text>\n", svgMargin+svgSize, svgMargin+svgSize+16, clip[0].Max)
+	fmt.Fprintf(&buf, "<text x=\"%d\" y=\"%d\" text-anchor=\"middle\">"+
+		"dimension %d</text>\n", svgMargin+svgSize/2, svgMargin+svgSize+32,
+		dims[0])
+	fmt.Fprintf(&buf, "<text x=\"%d\" y=\"%d\" text-anchor=\"end\">%.4g"+
+		"</text>\n", svgMargin-4, svgMargin+svgSize, clip[1].Min)
+	fmt.Fprintf(&buf, "<text x=\"%d\" y=\"%d\" text-anchor=\"end\">%.4g"+
+		"</text>\n", svgMargin-4, svgMargin+12, clip[1].Max)
+	fmt.Fprintf(&buf, "<text x=\"%d\" y=\"%d\" text-anchor=\"middle\" "+
+		"transform=\"rotate(-90 %d %d)\">dimension %d</text>\n", svgMargin/2,
+		svgMargin+svgSize/2, svgMargin/2, svgMargin+svgSize/2, dims[1])
+	buf.WriteString("</g>\n</svg>\n")
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("renderSVG: %v", err)
+	}
+	return nil
+}
+
+// clipInterval returns the intersection of the intervals a and b,
+// which must overlap
+func clipInterval(a, b r1.Interval) r1.Interval {
+	return r1.Interval{Min: math.Max(a.Min, b.Min), Max: math.Min(a.Max,
+		b.Max)}
+}
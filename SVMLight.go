@@ -0,0 +1,75 @@
+package gotile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// WriteSVMLight tile codes each vector in the batch b, where each
+// column of b is a vector to encode, and writes the tile-coded
+// representation of each vector to w as a line in the sparse svmlight
+// format read by tools such as liblinear and Vowpal Wabbit:
+//
+//	label index:value index:value ...
+//
+// Each line lists the non-zero features of the tile-coded
+// representation in increasing order. Following the svmlight format,
+// features are numbered from 1 rather than 0, so that feature i of the
+// tile-coded representation has index i+1. If labels is nil, the label
+// column is omitted; otherwise, there should be a label for each vector
+// in the batch.
+//
+// Datasets far larger than memory can be written by calling
+// WriteSVMLight with successive chunks of the dataset. If some tiling
+// uses the BoundsError policy and a vector falls outside its bounds,
+// an error wrapping ErrOutOfBounds is returned.
+func (t *TileCoder) WriteSVMLight(w io.Writer, b *mat.Dense,
+	labels []float64) error {
+	indices, err := t.TryEncodeIndicesBatch(b)
+	if err != nil {
+		return fmt.Errorf("writeSVMLight: %w", err)
+	}
+	rows, cols := indices.Dims()
+	if labels != nil && len(labels) != cols {
+		return fmt.Errorf("writeSVMLight: there should be a label for each "+
+			"vector: \n\thave(%d) \n\twant(%d)", len(labels), cols)
+	}
+
+	buf := bufio.NewWriter(w)
+	line := make([]int, rows)
+	var scratch []byte
+	for j := 0; j < cols; j++ {
+		for i := range line {
+			line[i] = int(indices.At(i, j))
+		}
+		sort.Ints(line)
+
+		scratch = scratch[:0]
+		if labels != nil {
+			scratch = strconv.AppendFloat(scratch, labels[j], 'g', -1, 64)
+		}
+		for _, index := range line {
+			if len(scratch) > 0 {
+				scratch = append(scratch, ' ')
+			}
+			scratch = strconv.AppendInt(scratch, int64(index+1), 10)
+			scratch = append(scratch, ':')
+			scratch = strconv.AppendFloat(scratch, t.featureActivation(index),
+				'g', -1, 64)
+		}
+		scratch = append(scratch, '\n')
+		if _, err := buf.Write(scratch); err != nil {
+			return fmt.Errorf("writeSVMLight: %v", err)
+		}
+	}
+
+	if err := buf.Flush(); err != nil {
+		return fmt.Errorf("writeSVMLight: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package gotile
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Scale determines how tiles are spaced along a single dimension of a
+// Tiling
+type Scale int
+
+const (
+	// ScaleLinear places tiles of equal width along a dimension
+	ScaleLinear Scale = iota
+
+	// ScaleLog places tiles of equal width in log-space along a
+	// dimension, so that tiles become wider as values grow. This is
+	// useful for variables which span many orders of magnitude. Both
+	// bounds of a log-scaled dimension must be positive, and any
+	// non-positive input falls in the first tile of the dimension.
+	ScaleLog
+)
+
+// String returns the name of the Scale
+func (s Scale) String() string {
+	switch s {
+	case ScaleLinear:
+		return "ScaleLinear"
+	case ScaleLog:
+		return "ScaleLog"
+	default:
+		return "Scale(unknown)"
+	}
+}
+
+// MarshalText implements the encoding.TextMarshaler interface, so that
+// Scales are encoded by name in JSON, YAML, and TOML
+func (s Scale) MarshalText() ([]byte, error) {
+	switch s {
+	case ScaleLinear:
+		return []byte("linear"), nil
+	case ScaleLog:
+		return []byte("log"), nil
+	default:
+		return nil, fmt.Errorf("marshalText: unknown scale %d", int(s))
+	}
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+// Both the short name ("linear") and the full name ("ScaleLinear") of
+// each Scale are accepted, regardless of case.
+func (s *Scale) UnmarshalText(text []byte) error {
+	switch strings.TrimPrefix(strings.ToLower(string(text)), "scale") {
+	case "linear":
+		*s = ScaleLinear
+	case "log":
+		*s = ScaleLog
+	default:
+		return fmt.Errorf("unmarshalText: unknown scale %q", text)
+	}
+	return nil
+}
+
+// apply transforms x from the input space into the space in which
+// tiles are equally spaced
+func (s Scale) apply(x float64) float64 {
+	if s == ScaleLog {
+		if x <= 0 {
+			return math.Inf(-1)
+		}
+		return math.Log(x)
+	}
+	return x
+}
+
+// invert moves x from the space in which tiles are equally spaced back
+// into the input space, inverting apply
+func (s Scale) invert(x float64) float64 {
+	if s == ScaleLog {
+		return math.Exp(x)
+	}
+	return x
+}
@@ -0,0 +1,33 @@
+package gotile
+
+import (
+	"sync"
+)
+
+// scratch is a pool of integer workspaces used when encoding batches
+// of vectors, so that repeated batch encodes do not allocate new
+// buffers on each call
+var scratch = sync.Pool{
+	New: func() interface{} { return new([]int) },
+}
+
+// getInts returns a zeroed workspace of length n from the pool of
+// scratch buffers. The workspace should be returned with putInts when
+// no longer needed.
+func getInts(n int) *[]int {
+	ints := scratch.Get().(*[]int)
+	if cap(*ints) < n {
+		*ints = make([]int, n)
+	}
+	*ints = (*ints)[:n]
+	for i := range *ints {
+		(*ints)[i] = 0
+	}
+	return ints
+}
+
+// putInts returns the workspace ints to the pool of scratch buffers.
+// The workspace cannot be used after it is returned.
+func putInts(ints *[]int) {
+	scratch.Put(ints)
+}
@@ -0,0 +1,107 @@
+package gotile
+
+import (
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Overlap returns the number of tilings in which a and b fall in the
+// same tile, which is the number of active features shared by their
+// tile-coded representations, excluding the bias unit. Like Encode,
+// Overlap panics if some tiling uses the BoundsError policy and a or b
+// falls outside its bounds. See TryOverlap for a non-panicking variant.
+func (t *TileCoder) Overlap(a, b mat.Vector) int {
+	overlap, err := t.TryOverlap(a, b)
+	if err != nil {
+		panic(err)
+	}
+	return overlap
+}
+
+// TryOverlap returns the number of tilings in which a and b fall in the
+// same tile, as in Overlap. Errors are returned as in TryEncodeIndices.
+func (t *TileCoder) TryOverlap(a, b mat.Vector) (int, error) {
+	indicesA, err := t.TryEncodeIndices(a)
+	if err != nil {
+		return 0, fmt.Errorf("overlap: %w", err)
+	}
+	indicesB, err := t.TryEncodeIndices(b)
+	if err != nil {
+		return 0, fmt.Errorf("overlap: %w", err)
+	}
+
+	overlap := 0
+	first := t.firstTiling()
+	for k := first; k < first+len(t.tilings); k++ {
+		if indicesA[k] == indicesB[k] {
+			overlap++
+		}
+	}
+	return overlap, nil
+}
+
+// Similarity returns the tile-overlap kernel between a and b, which is
+// the fraction of tilings in which a and b fall in the same tile. The
+// similarity is 1 for vectors sharing all active tiles and 0 for
+// vectors sharing none. Like Encode, Similarity panics if some tiling
+// uses the BoundsError policy and a or b falls outside its bounds. See
+// TrySimilarity for a non-panicking variant.
+func (t *TileCoder) Similarity(a, b mat.Vector) float64 {
+	return float64(t.Overlap(a, b)) / float64(t.NumTilings())
+}
+
+// TrySimilarity returns the tile-overlap kernel between a and b, as in
+// Similarity. Errors are returned as in TryEncodeIndices.
+func (t *TileCoder) TrySimilarity(a, b mat.Vector) (float64, error) {
+	overlap, err := t.TryOverlap(a, b)
+	if err != nil {
+		return 0, fmt.Errorf("similarity: %w", err)
+	}
+	return float64(overlap) / float64(t.NumTilings()), nil
+}
+
+// Gram returns the Gram matrix of the tile-overlap kernel (see
+// Similarity) over the batch b, where each column of b is a vector.
+// Element (i, j) of the returned matrix is the similarity between
+// columns i and j of b, and the Gram matrix of an empty batch is
+// empty. Like EncodeBatch, Gram panics if some tiling uses the
+// BoundsError policy and a vector in the batch falls outside its
+// bounds. See TryGram for a non-panicking variant.
+func (t *TileCoder) Gram(b *mat.Dense) *mat.SymDense {
+	gram, err := t.TryGram(b)
+	if err != nil {
+		panic(err)
+	}
+	return gram
+}
+
+// TryGram returns the Gram matrix of the tile-overlap kernel over the
+// batch b, as in Gram. Errors are returned as in TryEncodeIndicesBatch.
+// If b is empty, holding no vectors, an empty matrix is returned.
+func (t *TileCoder) TryGram(b *mat.Dense) (*mat.SymDense, error) {
+	if b != nil && b.IsEmpty() {
+		return &mat.SymDense{}, nil
+	}
+	indices, err := t.TryEncodeIndicesBatch(b)
+	if err != nil {
+		return nil, fmt.Errorf("gram: %w", err)
+	}
+	_, n := b.Dims()
+
+	gram := mat.NewSymDense(n, nil)
+	first := t.firstTiling()
+	for k := first; k < first+len(t.tilings); k++ {
+		row := indices.RawRowView(k)
+		for i := 0; i < n; i++ {
+			for j := i; j < n; j++ {
+				if row[i] == row[j] {
+					gram.SetSym(i, j, gram.At(i, j)+1)
+				}
+			}
+		}
+	}
+
+	gram.ScaleSym(1/float64(t.NumTilings()), gram)
+	return gram, nil
+}
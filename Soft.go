@@ -0,0 +1,303 @@
+package gotile
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// DefaultSoftCap is the default maximum number of active cells per
+// tiling of a SoftCoder, which interpolates along up to 8 dimensions
+// without dropping any cell
+const DefaultSoftCap = 256
+
+// SoftCoder performs soft, or fuzzy, tile coding. Rather than
+// activating the single tile in which a vector falls, each tiling
+// spreads a total activation of 1.0 over the tiles surrounding the
+// vector by multilinear interpolation between tile centers: along each
+// dimension, the vector belongs fractionally to the two tiles whose
+// centers are nearest, in proportion to its closeness to each center.
+// Features are therefore continuous in the input, without the
+// discretization artifacts of hard tile boundaries, while remaining
+// sparse: at most 2^d cells are active in a tiling over d dimensions.
+// The number of active cells per tiling is further capped, see
+// SetCap.
+//
+// Tilings are offset, scaled, squashed, and bounded exactly as they
+// are for tile coding. Along categorical dimensions, and beyond the
+// outermost tile centers of dimensions which are not periodic, a
+// vector belongs fully to a single tile.
+type SoftCoder struct {
+	coder    *TileCoder
+	maxCells int
+}
+
+// softCell is an active cell of a tiling and its activation
+type softCell struct {
+	index      int
+	activation float64
+}
+
+// NewSoft returns a new SoftCoder over the tilings which New would
+// create given the same arguments. If includeBias is true, the first
+// feature is a bias unit which is always 1.0. Options which configure
+// tilings are used, while Options which configure only TileCoders are
+// ignored.
+func NewSoft(minDims, maxDims mat.Vector, bins [][]int, seed uint64,
+	includeBias bool, offsetDiv float64, opts ...Option) (*SoftCoder, error) {
+	coder, err := New(minDims, maxDims, bins, seed, includeBias, offsetDiv,
+		opts...)
+	if err != nil {
+		return nil, fmt.Errorf("newSoft: %v", err)
+	}
+	for _, tiling := range coder.tilings {
+		if tiling.rotation != nil {
+			return nil, fmt.Errorf("newSoft: rotated tilings are not supported")
+		}
+		if tiling.nonFinite == NonFiniteTile {
+			return nil, fmt.Errorf("newSoft: the NonFiniteTile policy is not " +
+				"supported")
+		}
+	}
+	coder.adaptive, coder.visits, coder.metrics = nil, nil, nil
+	return &SoftCoder{coder: coder, maxCells: DefaultSoftCap}, nil
+}
+
+// SetCap sets the maximum number of active cells per tiling to n. If
+// interpolation activates more cells, only the n most active cells
+// are kept and their activations are rescaled to sum to 1.0. A
+// non-positive n removes the limit.
+func (s *SoftCoder) SetCap(n int) {
+	s.maxCells = n
+}
+
+// Tilings returns the tilings of the receiver. The tilings themselves
+// are shared with the receiver and should not be modified.
+func (s *SoftCoder) Tilings() []*Tiling {
+	return s.coder.Tilings()
+}
+
+// VecLength returns the number of features in each encoded vector
+func (s *SoftCoder) VecLength() int {
+	return s.coder.VecLength()
+}
+
+// Encode returns the soft tile-coded representation of the vector v.
+// If some tiling uses the BoundsError policy and v falls outside its
+// bounds, Encode panics. See TryEncode for a non-panicking variant.
+func (s *SoftCoder) Encode(v mat.Vector) *mat.VecDense {
+	encoded, err := s.TryEncode(v)
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
+// TryEncode returns the soft tile-coded representation of the vector
+// v, as in Encode. If some tiling uses the BoundsError policy and v
+// falls outside its bounds, an error wrapping ErrOutOfBounds is
+// returned.
+func (s *SoftCoder) TryEncode(v mat.Vector) (*mat.VecDense, error) {
+	indices, activations, err := s.encodeSparse(v)
+	if err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+	encoded := mat.NewVecDense(s.VecLength(), nil)
+	for i, index := range indices {
+		encoded.SetVec(index, activations[i])
+	}
+	return encoded, nil
+}
+
+// EncodeIndices returns the indices of the active features of v,
+// ordered by tiling, with the bias unit last if the receiver includes
+// a bias unit. If some tiling uses the BoundsError policy and v falls
+// outside its bounds, EncodeIndices panics. See TryEncodeIndices for a
+// non-panicking variant.
+func (s *SoftCoder) EncodeIndices(v mat.Vector) []float64 {
+	indices, err := s.TryEncodeIndices(v)
+	if err != nil {
+		panic(err)
+	}
+	return indices
+}
+
+// TryEncodeIndices returns the indices of the active features of v, as
+// in EncodeIndices. If some tiling uses the BoundsError policy and v
+// falls outside its bounds, an error wrapping ErrOutOfBounds is
+// returned.
+func (s *SoftCoder) TryEncodeIndices(v mat.Vector) ([]float64, error) {
+	indices, _, err := s.encodeSparse(v)
+	if err != nil {
+		return nil, fmt.Errorf("encodeIndices: %w", err)
+	}
+	floats := make([]float64, len(indices))
+	for i, index := range indices {
+		floats[i] = float64(index)
+	}
+	return floats, nil
+}
+
+// EncodeSparse returns the indices of the active features of v, in the
+// order of EncodeIndices, along with the activation of each. If some
+// tiling uses the BoundsError policy and v falls outside its bounds,
+// EncodeSparse panics. See TryEncodeSparse for a non-panicking variant.
+func (s *SoftCoder) EncodeSparse(v mat.Vector) ([]int, []float64) {
+	indices, activations, err := s.TryEncodeSparse(v)
+	if err != nil {
+		panic(err)
+	}
+	return indices, activations
+}
+
+// TryEncodeSparse returns the indices and activations of the active
+// features of v, as in EncodeSparse. If some tiling uses the
+// BoundsError policy and v falls outside its bounds, an error wrapping
+// ErrOutOfBounds is returned.
+func (s *SoftCoder) TryEncodeSparse(v mat.Vector) ([]int, []float64,
+	error) {
+	indices, activations, err := s.encodeSparse(v)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encodeSparse: %w", err)
+	}
+	return indices, activations, nil
+}
+
+// EncodeBatch returns the soft tile-coded representation of each
+// vector in the batch b. Each column of b is a vector to encode, and
+// each column of the returned matrix is the encoding of the
+// corresponding column of b. If some tiling uses the BoundsError policy
+// and some vector falls outside its bounds, EncodeBatch panics. See
+// TryEncodeBatch for a non-panicking variant.
+func (s *SoftCoder) EncodeBatch(b *mat.Dense) *mat.Dense {
+	encoded, err := s.TryEncodeBatch(b)
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
+// TryEncodeBatch returns the soft tile-coded representation of each
+// vector in the batch b, as in EncodeBatch. If some tiling uses the
+// BoundsError policy and some vector falls outside its bounds, an error
+// wrapping ErrOutOfBounds is returned.
+func (s *SoftCoder) TryEncodeBatch(b *mat.Dense) (*mat.Dense, error) {
+	if err := s.coder.checkBatchDims(b); err != nil {
+		return nil, fmt.Errorf("encodeBatch: %w", err)
+	}
+	_, batchSize := b.Dims()
+	encoded := mat.NewDense(s.VecLength(), batchSize, nil)
+	for j := 0; j < batchSize; j++ {
+		indices, activations, err := s.encodeSparse(b.ColView(j))
+		if err != nil {
+			return nil, fmt.Errorf("encodeBatch: vector %d: %w", j, err)
+		}
+		for i, index := range indices {
+			encoded.Set(index, j, activations[i])
+		}
+	}
+	return encoded, nil
+}
+
+// encodeSparse returns the indices and activations of the active
+// features of v
+func (s *SoftCoder) encodeSparse(v mat.Vector) ([]int, []float64, error) {
+	if err := s.coder.check(v); err != nil {
+		return nil, nil, err
+	}
+
+	var indices []int
+	var activations []float64
+	start := 0
+	if s.coder.includeBias {
+		start = 1
+	}
+	for _, tiling := range s.coder.tilings {
+		for _, c := range tiling.softCells(v, s.maxCells) {
+			indices = append(indices, start+c.index)
+			activations = append(activations, c.activation)
+		}
+		start += tiling.Tiles()
+	}
+
+	if s.coder.includeBias {
+		indices = append(indices, 0)
+		activations = append(activations, 1.0)
+	}
+	return indices, activations, nil
+}
+
+// softCells returns the cells of the tiling activated by v under
+// multilinear interpolation between tile centers, keeping at most n
+// cells if n is positive
+func (t *Tiling) softCells(v mat.Vector, n int) []softCell {
+	cells := []softCell{{index: 0, activation: 1.0}}
+	for i := range t.bins {
+		tiles, weights := t.interpolate(i, v.AtVec(t.dims[i]))
+
+		next := make([]softCell, 0, len(cells)*len(tiles))
+		for _, c := range cells {
+			for k, tile := range tiles {
+				next = append(next, softCell{
+					index:      c.index + tile*t.strides[i],
+					activation: c.activation * weights[k],
+				})
+			}
+		}
+		cells = next
+	}
+
+	if n > 0 && len(cells) > n {
+		sort.SliceStable(cells, func(a, b int) bool {
+			return cells[a].activation > cells[b].activation
+		})
+		cells = cells[:n]
+
+		total := 0.0
+		for _, c := range cells {
+			total += c.activation
+		}
+		for k := range cells {
+			cells[k].activation /= total
+		}
+	}
+	return cells
+}
+
+// interpolate returns the tiles along dimension i to which the input
+// feature x belongs, and the membership of x in each. The memberships
+// sum to 1.0, and tiles with no membership are omitted.
+func (t *Tiling) interpolate(i int, x float64) ([]int, []float64) {
+	if t.categories[i] {
+		// Categorical dimensions are never offset, so no error can
+		// occur with the BoundsError policy
+		tile, _ := t.place(i, x)
+		return []int{tile}, []float64{1.0}
+	}
+
+	// Find the tile centers on either side of x
+	p := t.position(i, x) - 0.5
+	lo := math.Floor(p)
+	frac := p - lo
+	first, second := int(lo), int(lo)+1
+
+	size := t.size(i)
+	if t.policy == BoundsWrap {
+		first = ((first % size) + size) % size
+		second = ((second % size) + size) % size
+	} else if first < 0 {
+		return []int{0}, []float64{1.0}
+	} else if second >= size {
+		return []int{size - 1}, []float64{1.0}
+	}
+
+	if frac == 0 {
+		return []int{first}, []float64{1.0}
+	}
+	return []int{first, second}, []float64{1 - frac, frac}
+}
+
+// Ensure SoftCoder implements TryCoder
+var _ TryCoder = (*SoftCoder)(nil)
@@ -0,0 +1,84 @@
+package gotile
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestSoftCoder(t *testing.T) {
+	soft, err := NewSoft(
+		mat.NewVecDense(1, []float64{0}),
+		mat.NewVecDense(1, []float64{1}),
+		[][]int{{4}},
+		1,
+		true,
+		1e300,
+	)
+	if err != nil {
+		t.Fatalf("could not create soft coder: %v", err)
+	}
+
+	tests := []struct {
+		x    float64
+		want []float64
+	}{
+		// Halfway between the centers of the second and third tiles
+		{0.5, []float64{1, 0, 0.5, 0.5, 0}},
+		// At the center of the second tile
+		{0.375, []float64{1, 0, 1, 0, 0}},
+		// Before the center of the first tile
+		{0.05, []float64{1, 1, 0, 0, 0}},
+		{0.4375, []float64{1, 0, 0.75, 0.25, 0}},
+	}
+	for _, test := range tests {
+		v := mat.NewVecDense(1, []float64{test.x})
+		got := soft.Encode(v).RawVector().Data
+		for i := range test.want {
+			if math.Abs(got[i]-test.want[i]) > 1e-12 {
+				t.Errorf("encode(%v): have(%v) want(%v)", test.x, got,
+					test.want)
+				break
+			}
+		}
+	}
+
+	indices := soft.EncodeIndices(mat.NewVecDense(1, []float64{0.5}))
+	if len(indices) != 3 || indices[2] != 0 {
+		t.Errorf("encodeIndices(0.5): have(%v) want 2 tiles then bias",
+			indices)
+	}
+}
+
+func TestSoftCoderCap(t *testing.T) {
+	soft, err := NewSoft(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{4, 4}},
+		1,
+		false,
+		1e300,
+	)
+	if err != nil {
+		t.Fatalf("could not create soft coder: %v", err)
+	}
+
+	v := mat.NewVecDense(2, []float64{0.5, 0.4})
+	indices, activations := soft.EncodeSparse(v)
+	if len(indices) != 4 {
+		t.Errorf("encodeSparse(%v): have(%v) want(%v) cells", v,
+			len(indices), 4)
+	}
+	if sum := mat.Sum(soft.Encode(v)); math.Abs(sum-1) > 1e-12 {
+		t.Errorf("encode(%v): activations sum to %v", v, sum)
+	}
+
+	soft.SetCap(2)
+	indices, activations = soft.EncodeSparse(v)
+	if len(indices) != 2 || math.Abs(activations[0]+activations[1]-1) >
+		1e-12 {
+		t.Errorf("encodeSparse(%v) with cap: have(%v, %v) want 2 cells "+
+			"summing to 1", v, indices, activations)
+	}
+}
@@ -0,0 +1,101 @@
+package gotile
+
+import (
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// EncodeBatchSparse returns the tile-coded representation of each
+// vector in the batch b in compressed sparse column form, the sparse
+// counterpart of EncodeBatch. Every vector has the same number n of
+// non-zero features, one for each tiling and one for the bias unit if
+// included, so the non-zero features of the vector in column j of b
+// are indices[j*n:(j+1)*n], ordered as by EncodeIndices, with values
+// values[j*n:(j+1)*n], and the column pointers of the compressed
+// sparse column format are 0, n, 2n, and so on. As in EncodeBatch, the
+// indices are filled directly from the index of each vector in each
+// tiling. If some tiling uses the BoundsError policy and a vector in
+// the batch falls outside its bounds, EncodeBatchSparse panics. See
+// TryEncodeBatchSparse for a non-panicking variant.
+func (t *TileCoder) EncodeBatchSparse(b *mat.Dense) ([]int, []float64) {
+	indices, values, err := t.TryEncodeBatchSparse(b)
+	if err != nil {
+		panic(err)
+	}
+	return indices, values
+}
+
+// TryEncodeBatchSparse returns the tile-coded representation of each
+// vector in the batch b in compressed sparse column form, as in
+// EncodeBatchSparse. If some tiling uses the BoundsError policy and a
+// vector in the batch falls outside its bounds, an error wrapping
+// ErrOutOfBounds is returned.
+func (t *TileCoder) TryEncodeBatchSparse(b *mat.Dense) ([]int, []float64,
+	error) {
+	t.lockAdaptive()
+	defer t.unlockAdaptive()
+	if err := t.observeBatch(b); err != nil {
+		return nil, nil, fmt.Errorf("encodeBatchSparse: %w", err)
+	}
+	if err := t.checkBatch(b); err != nil {
+		return nil, nil, fmt.Errorf("encodeBatchSparse: %w", err)
+	}
+
+	_, batchSize := b.Dims()
+	n := t.numIndices()
+	indices := make([]int, n*batchSize)
+	values := make([]float64, n*batchSize)
+	t.encodeBatchSparseTo(b, indices, values)
+	return indices, values, nil
+}
+
+// encodeBatchSparseTo places the non-zero indices and values of each
+// tile coded vector in the batch b into indices and values, laid out
+// as by EncodeBatchSparse
+func (t *TileCoder) encodeBatchSparseTo(b *mat.Dense, indices []int,
+	values []float64) {
+	bias := 0
+	if t.includeBias {
+		bias = 1
+	}
+	n, first := t.numIndices(), t.firstTiling()
+
+	start := t.now()
+	_, batchSize := b.Dims()
+	src := b.RawMatrix()
+	t.forTilings(batchSize, func(tiling, lo, hi int) {
+		tilingStart := t.now()
+		workspace := getInts(hi - lo)
+		defer putInts(workspace)
+		index := *workspace
+
+		// Vectors have already been checked against the bounds of
+		// tilings using the BoundsError policy, so no error can occur
+		t.tilings[tiling].indexInts(src, lo, hi, index)
+
+		indexOffset := t.featuresBeforeTiling(tiling) + bias
+		activation := t.activation(tiling)
+		for j, ind := range index {
+			pos := (lo+j)*n + first + tiling
+			indices[pos] = indexOffset + ind
+			values[pos] = activation
+		}
+		t.visitInts(index, indexOffset)
+		t.reportTiling(tiling, hi-lo, tilingStart)
+	})
+
+	// If using a bias unit, its index 0 is 1.0 for every vector, and is
+	// placed first if indices are sorted and last otherwise
+	if t.includeBias {
+		pos := len(t.tilings)
+		if first == 1 {
+			pos = 0
+		}
+		for j := 0; j < batchSize; j++ {
+			indices[j*n+pos] = 0
+			values[j*n+pos] = 1.0
+		}
+	}
+	t.reportEncoded(batchSize, start)
+}
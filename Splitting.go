@@ -0,0 +1,418 @@
+package gotile
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// SplitHook is called by a SplittingCoder each time it splits a tile,
+// with the feature of the split tile, parent, and the new feature,
+// child, added by the split. The region of the split tile is divided
+// between parent and child, so that copying the weight of parent to
+// child in a weight vector, grown to the new VecLength, leaves the
+// predictions of a linear function of the features unchanged.
+type SplitHook func(parent, child int)
+
+// SplittingCoder is an adaptive tile coder which starts with coarse
+// tilings and refines them where they are most needed. The error of
+// predictions made from its features, such as TD errors, is reported
+// with Feedback and accumulated by each active tile. Split divides the
+// tiles which have accumulated the most absolute error in two, adding
+// a new feature for each split so that VecLength grows over time.
+//
+// Each tiling starts as a uniform grid over the bounds of the input
+// space, with tiling k offset by k/n of a tile along each dimension
+// for n tilings. Vectors outside the bounds are clipped to the bounds.
+type SplittingCoder struct {
+	tilings     []*splitTiling
+	features    int
+	includeBias bool
+	hook        SplitHook
+}
+
+// splitTiling is a grid of tiles, each of which may have been split
+// into a tree of smaller tiles
+type splitTiling struct {
+	start, width []float64 // Start and tile width of each dimension
+	cells        []int     // Number of grid cells along each dimension
+	strides      []int     // Index stride of each dimension
+	roots        []*splitNode
+}
+
+// splitNode is a tile of a splitTiling. Leaf tiles are features,
+// while split tiles send vectors to one of their two children.
+type splitNode struct {
+	low, high []float64 // Bounds of the tile along each dimension
+
+	// For split tiles, the dimension along which the tile was split,
+	// the value at which it was split, and the tiles below and above
+	// the split
+	dim          int
+	split        float64
+	below, above *splitNode
+
+	// For leaf tiles, the feature of the tile and the absolute error
+	// accumulated by the tile
+	feature int
+	error   float64
+}
+
+// NewSplitting returns a new SplittingCoder with the given number of
+// tilings, each of which initially has bins[i] tiles along dimension i
+// between minDims and maxDims, which must be finite. If includeBias is
+// true, the first feature is a bias unit which is always active.
+func NewSplitting(minDims, maxDims mat.Vector, bins []int, tilings int,
+	includeBias bool) (*SplittingCoder, error) {
+	if isNil(minDims) || isNil(maxDims) {
+		return nil, fmt.Errorf("newSplitting: %w", ErrNilInput)
+	}
+	if minDims.Len() != maxDims.Len() || minDims.Len() != len(bins) {
+		return nil, fmt.Errorf("newSplitting: bounds and bins must have "+
+			"the same length: %d, %d, %d", minDims.Len(), maxDims.Len(),
+			len(bins))
+	}
+	if tilings < 1 {
+		return nil, fmt.Errorf("newSplitting: cannot have less than 1 " +
+			"tiling")
+	}
+	for i, b := range bins {
+		min, max := minDims.AtVec(i), maxDims.AtVec(i)
+		if b < 1 {
+			return nil, fmt.Errorf("newSplitting: cannot have less than 1 "+
+				"bin along dimension %d", i)
+		}
+		if math.IsInf(min, 0) || math.IsInf(max, 0) || max <= min {
+			return nil, fmt.Errorf("newSplitting: bounds of dimension %d "+
+				"must be finite with maximum exceeding minimum: [%v, %v]",
+				i, min, max)
+		}
+	}
+
+	s := &SplittingCoder{includeBias: includeBias}
+	if includeBias {
+		s.features = 1
+	}
+	for k := 0; k < tilings; k++ {
+		s.tilings = append(s.tilings, s.newSplitTiling(minDims, maxDims,
+			bins, float64(k)/float64(tilings)))
+	}
+	return s, nil
+}
+
+// newSplitTiling returns a new grid of leaf tiles over the bounds
+// minDims and maxDims, offset by offset tiles along each dimension,
+// assigning the next features of the receiver to its tiles
+func (s *SplittingCoder) newSplitTiling(minDims, maxDims mat.Vector,
+	bins []int, offset float64) *splitTiling {
+	dims := len(bins)
+	t := &splitTiling{
+		start:   make([]float64, dims),
+		width:   make([]float64, dims),
+		cells:   make([]int, dims),
+		strides: make([]int, dims),
+	}
+
+	// An offset grid needs an additional cell to cover the bounds
+	stride := 1
+	for i := dims - 1; i > -1; i-- {
+		t.width[i] = (maxDims.AtVec(i) - minDims.AtVec(i)) / float64(bins[i])
+		t.start[i] = minDims.AtVec(i) - offset*t.width[i]
+		t.cells[i] = bins[i]
+		if offset > 0 {
+			t.cells[i]++
+		}
+		t.strides[i] = stride
+		stride *= t.cells[i]
+	}
+
+	t.roots = make([]*splitNode, stride)
+	for index := range t.roots {
+		node := &splitNode{
+			low:     make([]float64, dims),
+			high:    make([]float64, dims),
+			feature: s.features,
+		}
+		for i := range bins {
+			cell := (index / t.strides[i]) % t.cells[i]
+			node.low[i] = t.start[i] + float64(cell)*t.width[i]
+			node.high[i] = node.low[i] + t.width[i]
+		}
+		t.roots[index] = node
+		s.features++
+	}
+	return t
+}
+
+// SetSplitHook sets the hook called for each split tile, or removes
+// the hook if hook is nil
+func (s *SplittingCoder) SetSplitHook(hook SplitHook) {
+	s.hook = hook
+}
+
+// NumTilings returns the number of tilings of the receiver
+func (s *SplittingCoder) NumTilings() int {
+	return len(s.tilings)
+}
+
+// VecLength returns the current number of features in each encoded
+// vector, which grows each time a tile is split
+func (s *SplittingCoder) VecLength() int {
+	return s.features
+}
+
+// EncodeIndices returns the indices of the features of the tiles in
+// which v falls, one for each tiling, with the index of the bias unit
+// last if the receiver includes a bias unit. If v does not have a
+// dimension for each dimension of the tilings, EncodeIndices panics.
+// See TryEncodeIndices for a non-panicking variant.
+func (s *SplittingCoder) EncodeIndices(v mat.Vector) []float64 {
+	indices, err := s.TryEncodeIndices(v)
+	if err != nil {
+		panic(err)
+	}
+	return indices
+}
+
+// TryEncodeIndices returns the indices of the features of the tiles in
+// which v falls, as in EncodeIndices. If v is nil or does not have a
+// dimension for each dimension of the tilings, an error wrapping
+// ErrNilInput or ErrDimension is returned.
+func (s *SplittingCoder) TryEncodeIndices(v mat.Vector) ([]float64, error) {
+	if err := s.check(v); err != nil {
+		return nil, fmt.Errorf("encodeIndices: %w", err)
+	}
+
+	indices := make([]float64, 0, len(s.tilings)+1)
+	for _, t := range s.tilings {
+		indices = append(indices, float64(t.leaf(v).feature))
+	}
+	if s.includeBias {
+		indices = append(indices, 0)
+	}
+	return indices, nil
+}
+
+// Encode returns the encoded representation of v, in which the
+// features of the tiles in which v falls are 1.0 and all others are
+// 0.0. If v does not have a dimension for each dimension of the
+// tilings, Encode panics. See TryEncode for a non-panicking variant.
+func (s *SplittingCoder) Encode(v mat.Vector) *mat.VecDense {
+	encoded, err := s.TryEncode(v)
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
+// TryEncode returns the encoded representation of v, as in Encode. If
+// v is nil or does not have a dimension for each dimension of the
+// tilings, an error wrapping ErrNilInput or ErrDimension is returned.
+func (s *SplittingCoder) TryEncode(v mat.Vector) (*mat.VecDense, error) {
+	indices, err := s.TryEncodeIndices(v)
+	if err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+	encoded := mat.NewVecDense(s.VecLength(), nil)
+	for _, index := range indices {
+		encoded.SetVec(int(index), 1.0)
+	}
+	return encoded, nil
+}
+
+// EncodeBatch returns the encoded representation of each vector in the
+// batch b. Each column of b is a vector to encode, and each column of
+// the returned matrix is the encoding of the corresponding column of
+// b. If the vectors do not have a dimension for each dimension of the
+// tilings, EncodeBatch panics. See TryEncodeBatch for a non-panicking
+// variant.
+func (s *SplittingCoder) EncodeBatch(b *mat.Dense) *mat.Dense {
+	encoded, err := s.TryEncodeBatch(b)
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
+// TryEncodeBatch returns the encoded representation of each vector in
+// the batch b, as in EncodeBatch. If b is nil or the vectors do not
+// have a dimension for each dimension of the tilings, an error
+// wrapping ErrNilInput or ErrDimension is returned.
+func (s *SplittingCoder) TryEncodeBatch(b *mat.Dense) (*mat.Dense, error) {
+	if b == nil {
+		return nil, fmt.Errorf("encodeBatch: %w", ErrNilInput)
+	}
+	_, batchSize := b.Dims()
+	encoded := mat.NewDense(s.VecLength(), batchSize, nil)
+	for j := 0; j < batchSize; j++ {
+		indices, err := s.TryEncodeIndices(b.ColView(j))
+		if err != nil {
+			return nil, fmt.Errorf("encodeBatch: vector %d: %w", j, err)
+		}
+		for _, index := range indices {
+			encoded.Set(int(index), j, 1.0)
+		}
+	}
+	return encoded, nil
+}
+
+// Feedback reports the error delta of a prediction made from the
+// features of v, such as a TD error. The absolute error is accumulated
+// by the tile of each tiling in which v falls. If v does not have a
+// dimension for each dimension of the tilings, Feedback panics. See
+// TryFeedback for a non-panicking variant.
+func (s *SplittingCoder) Feedback(v mat.Vector, delta float64) {
+	if err := s.TryFeedback(v, delta); err != nil {
+		panic(err)
+	}
+}
+
+// TryFeedback reports the error delta of a prediction made from the
+// features of v, as in Feedback. If v is nil or does not have a
+// dimension for each dimension of the tilings, an error wrapping
+// ErrNilInput or ErrDimension is returned and no error is accumulated.
+func (s *SplittingCoder) TryFeedback(v mat.Vector, delta float64) error {
+	if err := s.check(v); err != nil {
+		return fmt.Errorf("feedback: %w", err)
+	}
+	for _, t := range s.tilings {
+		t.leaf(v).error += math.Abs(delta)
+	}
+	return nil
+}
+
+// check returns an error if v is nil or does not have a dimension for
+// each dimension of the tilings of the receiver
+func (s *SplittingCoder) check(v mat.Vector) error {
+	if isNil(v) {
+		return ErrNilInput
+	}
+	if want := len(s.tilings[0].cells); v.Len() != want {
+		return &DimensionError{Have: v.Len(), Want: want}
+	}
+	return nil
+}
+
+// Split splits up to n of the tiles which have accumulated the largest
+// absolute error, over all tilings, and returns the number of tiles
+// split. Tiles which have accumulated no error are never split. Each
+// tile is split in half along the dimension in which it is widest,
+// relative to the initial tile width, keeping its feature for the half
+// below the split and adding a new feature for the half above. The
+// split hook is called for each split, and the errors of both halves
+// start at 0.
+func (s *SplittingCoder) Split(n int) int {
+	var leaves []*splitNode
+	var tilings []*splitTiling
+	for _, t := range s.tilings {
+		for _, root := range t.roots {
+			root.walk(func(leaf *splitNode) {
+				if leaf.error > 0 {
+					leaves = append(leaves, leaf)
+					tilings = append(tilings, t)
+				}
+			})
+		}
+	}
+
+	order := make([]int, len(leaves))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return leaves[order[a]].error > leaves[order[b]].error
+	})
+	if n > len(order) {
+		n = len(order)
+	}
+
+	for _, i := range order[:n] {
+		leaf, t := leaves[i], tilings[i]
+		child := s.features
+		s.features++
+		leaf.splitAt(t.widest(leaf), child)
+		if s.hook != nil {
+			s.hook(leaf.below.feature, child)
+		}
+	}
+	return n
+}
+
+// leaf returns the leaf tile in which v falls
+func (t *splitTiling) leaf(v mat.Vector) *splitNode {
+	if v.Len() != len(t.cells) {
+		panic(fmt.Sprintf("encode: vector has %d dimensions but tilings "+
+			"have %d", v.Len(), len(t.cells)))
+	}
+
+	index := 0
+	for i := range t.cells {
+		cell := int(math.Floor((v.AtVec(i) - t.start[i]) / t.width[i]))
+		if cell < 0 {
+			cell = 0
+		} else if cell >= t.cells[i] {
+			cell = t.cells[i] - 1
+		}
+		index += cell * t.strides[i]
+	}
+
+	node := t.roots[index]
+	for node.below != nil {
+		if v.AtVec(node.dim) < node.split {
+			node = node.below
+		} else {
+			node = node.above
+		}
+	}
+	return node
+}
+
+// widest returns the dimension along which the tile node is widest,
+// relative to the initial tile width of the tiling
+func (t *splitTiling) widest(node *splitNode) int {
+	widest, width := 0, 0.0
+	for i := range node.low {
+		if w := (node.high[i] - node.low[i]) / t.width[i]; w > width {
+			widest, width = i, w
+		}
+	}
+	return widest
+}
+
+// splitAt splits the leaf tile n in half along dimension dim, giving
+// the half below the split the feature of n and the half above the
+// split the feature child
+func (n *splitNode) splitAt(dim, child int) {
+	mid := (n.low[dim] + n.high[dim]) / 2
+	below := &splitNode{
+		low:     append([]float64(nil), n.low...),
+		high:    append([]float64(nil), n.high...),
+		feature: n.feature,
+	}
+	above := &splitNode{
+		low:     append([]float64(nil), n.low...),
+		high:    append([]float64(nil), n.high...),
+		feature: child,
+	}
+	below.high[dim] = mid
+	above.low[dim] = mid
+
+	n.dim, n.split = dim, mid
+	n.below, n.above = below, above
+	n.feature, n.error = -1, 0
+}
+
+// walk calls f with each leaf tile under n
+func (n *splitNode) walk(f func(leaf *splitNode)) {
+	if n.below == nil {
+		f(n)
+		return
+	}
+	n.below.walk(f)
+	n.above.walk(f)
+}
+
+// Ensure SplittingCoder implements TryCoder
+var _ TryCoder = (*SplittingCoder)(nil)
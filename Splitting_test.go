@@ -0,0 +1,68 @@
+package gotile
+
+import (
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestSplittingCoder(t *testing.T) {
+	sc, err := NewSplitting(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[]int{2, 2},
+		2,
+		true,
+	)
+	if err != nil {
+		t.Fatalf("could not create splitting coder: %v", err)
+	}
+
+	// The first tiling has 2 × 2 tiles, and the offset second tiling
+	// has 3 × 3 tiles
+	if n := sc.VecLength(); n != 1+4+9 {
+		t.Errorf("vecLength: have(%v) want(%v)", n, 1+4+9)
+	}
+
+	var splits [][2]int
+	sc.SetSplitHook(func(parent, child int) {
+		splits = append(splits, [2]int{parent, child})
+	})
+
+	low := mat.NewVecDense(2, []float64{0.1, 0.1})
+	high := mat.NewVecDense(2, []float64{0.4, 0.1})
+	before := sc.EncodeIndices(low)
+	if after := sc.EncodeIndices(high); after[0] != before[0] {
+		t.Fatalf("encodeIndices: %v and %v should share a tile", low, high)
+	}
+
+	// Only the tiles with the largest error are split
+	sc.Feedback(low, -2)
+	sc.Feedback(mat.NewVecDense(2, []float64{0.9, 0.9}), 0.5)
+	if n := sc.Split(1); n != 1 {
+		t.Errorf("split: have(%v) want(%v)", n, 1)
+	}
+	if len(splits) != 1 || splits[0] != [2]int{int(before[0]), 14} {
+		t.Errorf("split hook: have(%v) want(%v)", splits,
+			[][2]int{{int(before[0]), 14}})
+	}
+	if n := sc.VecLength(); n != 15 {
+		t.Errorf("vecLength after split: have(%v) want(%v)", n, 15)
+	}
+
+	// The split tile is divided along its first dimension, keeping its
+	// feature for the lower half
+	got, gotHigh := sc.EncodeIndices(low), sc.EncodeIndices(high)
+	if got[0] != before[0] || gotHigh[0] != 14 {
+		t.Errorf("encodeIndices after split: have(%v, %v) want(%v, %v)",
+			got[0], gotHigh[0], before[0], 14)
+	}
+	if encoded := sc.Encode(high); encoded.Len() != 15 || encoded.AtVec(14) != 1 {
+		t.Errorf("encode after split: have(%v)", mat.Formatted(encoded.T()))
+	}
+
+	// Split tiles have no error, and tiles with no error are not split
+	if n := sc.Split(10); n != 3 {
+		t.Errorf("split: have(%v) want(%v)", n, 3)
+	}
+}
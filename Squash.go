@@ -0,0 +1,121 @@
+package gotile
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// SquashFunc is a squashing function which maps the real line onto a
+// bounded interval
+type SquashFunc int
+
+const (
+	// SquashNone leaves features unchanged
+	SquashNone SquashFunc = iota
+
+	// SquashTanh maps features onto (-1, 1) with the hyperbolic tangent
+	SquashTanh
+
+	// SquashArctan maps features onto (-π/2, π/2) with the arctangent
+	SquashArctan
+
+	// SquashSigmoid maps features onto (0, 1) with the logistic sigmoid
+	SquashSigmoid
+)
+
+// String returns the name of the SquashFunc
+func (s SquashFunc) String() string {
+	switch s {
+	case SquashNone:
+		return "SquashNone"
+	case SquashTanh:
+		return "SquashTanh"
+	case SquashArctan:
+		return "SquashArctan"
+	case SquashSigmoid:
+		return "SquashSigmoid"
+	default:
+		return "SquashFunc(unknown)"
+	}
+}
+
+// MarshalText implements the encoding.TextMarshaler interface, so that
+// SquashFuncs are encoded by name in JSON, YAML, and TOML
+func (s SquashFunc) MarshalText() ([]byte, error) {
+	if s < SquashNone || s > SquashSigmoid {
+		return nil, fmt.Errorf("marshalText: unknown squashing function %d",
+			int(s))
+	}
+	return []byte(strings.ToLower(strings.TrimPrefix(s.String(),
+		"Squash"))), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+// Both the short name ("tanh") and the full name ("SquashTanh") of
+// each SquashFunc are accepted, regardless of case. The empty string
+// is treated as SquashNone.
+func (s *SquashFunc) UnmarshalText(text []byte) error {
+	name := strings.TrimPrefix(strings.ToLower(string(text)), "squash")
+	if name == "" {
+		*s = SquashNone
+		return nil
+	}
+	for f := SquashNone; f <= SquashSigmoid; f++ {
+		if name == strings.ToLower(strings.TrimPrefix(f.String(), "Squash")) {
+			*s = f
+			return nil
+		}
+	}
+	return fmt.Errorf("unmarshalText: unknown squashing function %q", text)
+}
+
+// Squash is a squashing transform applied to a dimension before it is
+// tiled, so that unbounded dimensions can be tiled. A feature x is
+// transformed to Func(x / Scale), so that Scale controls the range of
+// features which are resolved finely. The bounds of a squashed
+// dimension are also squashed, and may therefore be infinite. A
+// non-positive Scale is treated as 1. The zero value leaves features
+// unchanged.
+type Squash struct {
+	Func  SquashFunc `json:"func" yaml:"func" toml:"func"`
+	Scale float64    `json:"scale" yaml:"scale" toml:"scale"`
+}
+
+// scale returns the scale of the squashing function
+func (s Squash) scale() float64 {
+	if s.Scale <= 0 {
+		return 1.0
+	}
+	return s.Scale
+}
+
+// Apply squashes the feature x
+func (s Squash) Apply(x float64) float64 {
+	switch s.Func {
+	case SquashTanh:
+		return math.Tanh(x / s.scale())
+	case SquashArctan:
+		return math.Atan(x / s.scale())
+	case SquashSigmoid:
+		return 1.0 / (1.0 + math.Exp(-x/s.scale()))
+	default:
+		return x
+	}
+}
+
+// Inverse returns the feature which Apply squashes to y. Inverse can
+// be used to move points such as tile centers from the squashed space
+// back to the input space.
+func (s Squash) Inverse(y float64) float64 {
+	switch s.Func {
+	case SquashTanh:
+		return s.scale() * math.Atanh(y)
+	case SquashArctan:
+		return s.scale() * math.Tan(y)
+	case SquashSigmoid:
+		return s.scale() * math.Log(y/(1.0-y))
+	default:
+		return y
+	}
+}
@@ -0,0 +1,273 @@
+package gotile
+
+import (
+	"fmt"
+	"sync"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// StackMode determines how a StackedCoder encodes its history of
+// observations
+type StackMode int
+
+const (
+	// StackBlocks encodes each observation in the history separately,
+	// placing the features of the i-th most recent observation in the
+	// i-th block of VecLength() / frames features
+	StackBlocks StackMode = iota
+
+	// StackConcat encodes the concatenation of the observations in the
+	// history, most recent first, so that the wrapped Coder must
+	// encode vectors of frames × dims dimensions. This allows tilings
+	// to generalize jointly over time.
+	StackConcat
+)
+
+// StackedCoder gives tile-coded features a short-term memory for
+// partially observable tasks, by encoding the last few observations
+// rather than only the most recent one. Each call which encodes a
+// vector first pushes it onto the history, so that the vectors passed
+// to a StackedCoder should be consecutive observations. Call Reset at
+// the start of each episode.
+//
+// A StackedCoder is safe for concurrent use, although concurrent
+// observations are pushed in an unspecified order.
+type StackedCoder struct {
+	coder  Coder
+	dims   int
+	frames int
+	mode   StackMode
+
+	mu      sync.Mutex
+	history []float64 // Observations, most recent first
+	empty   bool      // Whether the history has been reset
+}
+
+// NewStacked returns a new StackedCoder which encodes the last frames
+// observations, each with dims dimensions, using coder as determined
+// by mode
+func NewStacked(coder Coder, dims, frames int, mode StackMode) (
+	*StackedCoder, error) {
+	if coder == nil {
+		return nil, fmt.Errorf("newStacked: coder must be non-nil")
+	}
+	if dims < 1 || frames < 1 {
+		return nil, fmt.Errorf("newStacked: cannot have less than 1 "+
+			"dimension or frame: have(%d dimensions, %d frames)", dims, frames)
+	}
+	if mode != StackBlocks && mode != StackConcat {
+		return nil, fmt.Errorf("newStacked: unknown mode %d", int(mode))
+	}
+	if mode == StackBlocks {
+		if _, err := mulFeatures(frames, coder.VecLength()); err != nil {
+			return nil, fmt.Errorf("newStacked: %w", err)
+		}
+	}
+	return &StackedCoder{
+		coder:   coder,
+		dims:    dims,
+		frames:  frames,
+		mode:    mode,
+		history: make([]float64, dims*frames),
+		empty:   true,
+	}, nil
+}
+
+// Frames returns the number of observations in the history
+func (s *StackedCoder) Frames() int {
+	return s.frames
+}
+
+// Reset clears the history of observations. The next observation
+// fills the entire history, as if it had been observed frames times.
+func (s *StackedCoder) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.history {
+		s.history[i] = 0
+	}
+	s.empty = true
+}
+
+// Stack returns the concatenation of the observations in the history,
+// most recent first. Until an observation follows NewStacked or Reset,
+// the history is zero.
+func (s *StackedCoder) Stack() *mat.VecDense {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return mat.NewVecDense(len(s.history), append([]float64(nil),
+		s.history...))
+}
+
+// VecLength returns the number of features in each encoded vector
+func (s *StackedCoder) VecLength() int {
+	if s.mode == StackConcat {
+		return s.coder.VecLength()
+	}
+	return s.frames * s.coder.VecLength()
+}
+
+// Encode pushes v onto the history and returns the encoding of the
+// history. If v does not have the dimensions given to NewStacked, or
+// the wrapped Coder cannot encode the history, Encode panics. See
+// TryEncode for a non-panicking variant.
+func (s *StackedCoder) Encode(v mat.Vector) *mat.VecDense {
+	encoded, err := s.TryEncode(v)
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
+// TryEncode pushes v onto the history and returns the encoding of the
+// history, as in Encode. If v is nil or does not have the dimensions
+// given to NewStacked, an error wrapping ErrNilInput or ErrDimension is
+// returned and the history is unchanged. If the wrapped Coder cannot
+// encode the history, an error is returned after v has been pushed.
+func (s *StackedCoder) TryEncode(v mat.Vector) (*mat.VecDense, error) {
+	stack, err := s.push(v)
+	if err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+	encoded, err := s.encode(stack)
+	if err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+	return encoded, nil
+}
+
+// encode returns the encoding of the history stack
+func (s *StackedCoder) encode(stack *mat.VecDense) (*mat.VecDense, error) {
+	if s.mode == StackConcat {
+		return tryEncode(s.coder, stack)
+	}
+
+	encoded := mat.NewVecDense(s.VecLength(), nil)
+	n := s.coder.VecLength()
+	for i := 0; i < s.frames; i++ {
+		frame := stack.SliceVec(i*s.dims, (i+1)*s.dims)
+		features, err := tryEncode(s.coder, frame)
+		if err != nil {
+			return nil, fmt.Errorf("frame %d: %w", i, err)
+		}
+		encoded.SliceVec(i*n, (i+1)*n).(*mat.VecDense).CopyVec(features)
+	}
+	return encoded, nil
+}
+
+// EncodeIndices pushes v onto the history and returns the indices of
+// the non-zero features of the encoding of the history. With
+// StackBlocks, the indices of the i-th most recent observation are
+// offset by i times the number of features of the wrapped Coder. If v
+// does not have the dimensions given to NewStacked, or the wrapped
+// Coder cannot encode the history, EncodeIndices panics. See
+// TryEncodeIndices for a non-panicking variant.
+func (s *StackedCoder) EncodeIndices(v mat.Vector) []float64 {
+	indices, err := s.TryEncodeIndices(v)
+	if err != nil {
+		panic(err)
+	}
+	return indices
+}
+
+// TryEncodeIndices pushes v onto the history and returns the indices of
+// the non-zero features of the encoding of the history, as in
+// EncodeIndices. Errors are returned as in TryEncode.
+func (s *StackedCoder) TryEncodeIndices(v mat.Vector) ([]float64, error) {
+	stack, err := s.push(v)
+	if err != nil {
+		return nil, fmt.Errorf("encodeIndices: %w", err)
+	}
+	if s.mode == StackConcat {
+		indices, err := tryEncodeIndices(s.coder, stack)
+		if err != nil {
+			return nil, fmt.Errorf("encodeIndices: %w", err)
+		}
+		return indices, nil
+	}
+
+	var indices []float64
+	n := float64(s.coder.VecLength())
+	for i := 0; i < s.frames; i++ {
+		frame := stack.SliceVec(i*s.dims, (i+1)*s.dims)
+		frameIndices, err := tryEncodeIndices(s.coder, frame)
+		if err != nil {
+			return nil, fmt.Errorf("encodeIndices: frame %d: %w", i, err)
+		}
+		for _, index := range frameIndices {
+			indices = append(indices, index+float64(i)*n)
+		}
+	}
+	return indices, nil
+}
+
+// EncodeBatch pushes each column of the batch b onto the history in
+// turn, and returns a matrix whose j-th column is the encoding of the
+// history after pushing column j. The columns of b should therefore be
+// consecutive observations. If the vectors do not have the dimensions
+// given to NewStacked, or the wrapped Coder cannot encode the history,
+// EncodeBatch panics. See TryEncodeBatch for a non-panicking variant.
+func (s *StackedCoder) EncodeBatch(b *mat.Dense) *mat.Dense {
+	encoded, err := s.TryEncodeBatch(b)
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
+// TryEncodeBatch pushes each column of the batch b onto the history in
+// turn, as in EncodeBatch. If b is nil or the vectors do not have the
+// dimensions given to NewStacked, an error wrapping ErrNilInput or
+// ErrDimension is returned before any vector is pushed. If the wrapped
+// Coder cannot encode the history, an error is returned after the
+// vectors up to and including the failing column have been pushed.
+func (s *StackedCoder) TryEncodeBatch(b *mat.Dense) (*mat.Dense, error) {
+	if b == nil {
+		return nil, fmt.Errorf("encodeBatch: %w", ErrNilInput)
+	}
+	rows, batchSize := b.Dims()
+	if rows != s.dims {
+		return nil, fmt.Errorf("encodeBatch: %w", &DimensionError{Have: rows,
+			Want: s.dims})
+	}
+
+	encoded := mat.NewDense(s.VecLength(), batchSize, nil)
+	for j := 0; j < batchSize; j++ {
+		column, err := s.TryEncode(b.ColView(j))
+		if err != nil {
+			return nil, fmt.Errorf("encodeBatch: vector %d: %w", j, err)
+		}
+		encoded.SetCol(j, column.RawVector().Data)
+	}
+	return encoded, nil
+}
+
+// push pushes v onto the history and returns a copy of the history. If
+// v is nil or does not have the dimensions given to NewStacked, an
+// error is returned and the history is unchanged.
+func (s *StackedCoder) push(v mat.Vector) (*mat.VecDense, error) {
+	if isNil(v) {
+		return nil, ErrNilInput
+	}
+	if v.Len() != s.dims {
+		return nil, &DimensionError{Have: v.Len(), Want: s.dims}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.empty {
+		for i := 0; i < s.frames; i++ {
+			mat.Col(s.history[i*s.dims:(i+1)*s.dims], 0, v)
+		}
+		s.empty = false
+	} else {
+		copy(s.history[s.dims:], s.history[:len(s.history)-s.dims])
+		mat.Col(s.history[:s.dims], 0, v)
+	}
+	return mat.NewVecDense(len(s.history), append([]float64(nil),
+		s.history...)), nil
+}
+
+// Ensure StackedCoder implements TryCoder
+var _ TryCoder = (*StackedCoder)(nil)
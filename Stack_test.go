@@ -0,0 +1,82 @@
+package gotile
+
+import (
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestStackedCoder(t *testing.T) {
+	minDims := mat.NewVecDense(1, []float64{0})
+	maxDims := mat.NewVecDense(1, []float64{1})
+	coder, err := New(minDims, maxDims, [][]int{{4}, {4}}, 1, false, -1)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	const frames = 3
+	sc, err := NewStacked(coder, 1, frames, StackBlocks)
+	if err != nil {
+		t.Fatalf("could not create stacked coder: %v", err)
+	}
+	if n := sc.VecLength(); n != frames*coder.VecLength() {
+		t.Errorf("vecLength: have(%v) want(%v)", n, frames*coder.VecLength())
+	}
+
+	// The first observation fills the history, and later observations
+	// shift it
+	observations := []float64{0.1, 0.5, 0.9, 0.3}
+	history := []float64{0.1, 0.1, 0.1}
+	n := float64(coder.VecLength())
+	for _, x := range observations {
+		if x != observations[0] {
+			history = append([]float64{x}, history[:frames-1]...)
+		}
+		indices := sc.EncodeIndices(mat.NewVecDense(1, []float64{x}))
+		var want []float64
+		for i, h := range history {
+			for _, index := range coder.EncodeIndices(mat.NewVecDense(1,
+				[]float64{h})) {
+				want = append(want, index+float64(i)*n)
+			}
+		}
+		if !mat.Equal(mat.NewVecDense(len(indices), indices),
+			mat.NewVecDense(len(want), want)) {
+			t.Errorf("encodeIndices(%v): have(%v) want(%v)", x, indices, want)
+		}
+	}
+	if have, want := sc.Stack(), mat.NewVecDense(frames, history); !mat.Equal(
+		have, want) {
+		t.Errorf("stack: have(%v) want(%v)", mat.Formatted(have.T()),
+			mat.Formatted(want.T()))
+	}
+
+	// Encoding a batch of observations matches encoding each in turn
+	sc.Reset()
+	batch := mat.NewDense(1, len(observations), observations)
+	encoded := sc.EncodeBatch(batch)
+	sc.Reset()
+	for j, x := range observations {
+		if have := sc.Encode(mat.NewVecDense(1, []float64{x})); !mat.Equal(
+			have, encoded.ColView(j)) {
+			t.Errorf("encodeBatch(%v): batch encoding differs from encode", x)
+		}
+	}
+
+	// Concatenated observations are encoded jointly
+	joint, err := New(mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}), [][]int{{4, 4}}, 1, false, -1)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	sc, err = NewStacked(joint, 1, 2, StackConcat)
+	if err != nil {
+		t.Fatalf("could not create stacked coder: %v", err)
+	}
+	sc.Encode(mat.NewVecDense(1, []float64{0.2}))
+	have := sc.Encode(mat.NewVecDense(1, []float64{0.8}))
+	want := joint.Encode(mat.NewVecDense(2, []float64{0.8, 0.2}))
+	if !mat.Equal(have, want) {
+		t.Errorf("encode: have(%v) want(%v)", mat.Formatted(have.T()),
+			mat.Formatted(want.T()))
+	}
+}
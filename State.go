@@ -0,0 +1,275 @@
+package gotile
+
+import (
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// tilingState holds every field of a Tiling in exported form, so that
+// tilings can be serialized exactly
+type tilingState struct {
+	Version    int          `json:"version"`
+	Offsets    floats       `json:"offsets"`
+	Bins       []int        `json:"bins"`
+	BinLengths floats       `json:"bin_lengths"`
+	MinDims    floats       `json:"min_dims"`
+	Seed       uint64       `json:"seed"`
+	Scales     []Scale      `json:"scales"`
+	Edges      []floats     `json:"edges"`
+	Categories []bool       `json:"categories"`
+	Dims       []int        `json:"dims"`
+	Low        floats       `json:"low"`
+	High       floats       `json:"high"`
+	Policy     BoundsPolicy `json:"policy"`
+	Squashes   []Squash     `json:"squashes"`
+	Rotation   floats       `json:"rotation,omitempty"`
+
+	NonFinite NonFinitePolicy `json:"non_finite,omitempty"`
+}
+
+// state returns the state of the receiver
+func (t *Tiling) state() tilingState {
+	edges := make([]floats, len(t.edges))
+	for i := range t.edges {
+		edges[i] = append(floats(nil), t.edges[i]...)
+	}
+
+	var rotation floats
+	if t.rotation != nil {
+		rotation = append(floats(nil), t.rotation.RawMatrix().Data...)
+	}
+
+	return tilingState{
+		Version:    FormatVersion,
+		Offsets:    mat.Row(nil, 0, t.offsets),
+		Bins:       append([]int(nil), t.bins...),
+		BinLengths: append(floats(nil), t.binLengths...),
+		MinDims:    mat.Col(nil, 0, t.minDims),
+		Seed:       t.seed,
+		Scales:     append([]Scale(nil), t.scales...),
+		Edges:      edges,
+		Categories: append([]bool(nil), t.categories...),
+		Dims:       append([]int(nil), t.dims...),
+		Low:        append(floats(nil), t.low...),
+		High:       append(floats(nil), t.high...),
+		Policy:     t.policy,
+		Squashes:   append([]Squash(nil), t.squashes...),
+		Rotation:   rotation,
+		NonFinite:  t.nonFinite,
+	}
+}
+
+// newTilingFromState returns a new Tiling with the state s
+func newTilingFromState(s tilingState) (*Tiling, error) {
+	n := len(s.Bins)
+	if n == 0 {
+		return nil, fmt.Errorf("tiling state has no dimensions")
+	}
+	if len(s.Offsets) != n || len(s.BinLengths) != n || len(s.MinDims) != n ||
+		len(s.Scales) != n || len(s.Edges) != n || len(s.Categories) != n ||
+		len(s.Dims) != n || len(s.Low) != n || len(s.High) != n ||
+		len(s.Squashes) != n {
+		return nil, fmt.Errorf("tiling state fields do not all describe %d "+
+			"dimensions", n)
+	}
+	if s.Policy < BoundsClip || s.Policy > BoundsExtend {
+		return nil, fmt.Errorf("tiling state has unknown bounds policy %v",
+			s.Policy)
+	}
+	for i := 0; i < n; i++ {
+		if s.Bins[i] < 1 {
+			return nil, fmt.Errorf("tiling state has less than 1 bin along "+
+				"dimension %d", i)
+		}
+		if len(s.Edges[i]) != 0 && len(s.Edges[i]) != s.Bins[i]+1 {
+			return nil, fmt.Errorf("tiling state has %d bin edges along "+
+				"dimension %d with %d bins", len(s.Edges[i]), i, s.Bins[i])
+		}
+		for j, edge := range s.Edges[i] {
+			if !isFinite(edge) || (j > 0 && edge <= s.Edges[i][j-1]) {
+				return nil, fmt.Errorf("tiling state has bin edges along "+
+					"dimension %d which are not finite and strictly "+
+					"increasing: %v", i, s.Edges[i])
+			}
+		}
+		if l := s.BinLengths[i]; !isFinite(l) || l <= 0 {
+			return nil, fmt.Errorf("tiling state has bin length %v along "+
+				"dimension %d, which is not finite and positive", l, i)
+		}
+		if !isFinite(s.Offsets[i]) || !isFinite(s.MinDims[i]) {
+			return nil, fmt.Errorf("tiling state has non-finite offset %v "+
+				"or minimum %v along dimension %d", s.Offsets[i],
+				s.MinDims[i], i)
+		}
+		if s.Scales[i] != ScaleLinear && s.Scales[i] != ScaleLog {
+			return nil, fmt.Errorf("tiling state has unknown scale %v",
+				s.Scales[i])
+		}
+		if f := s.Squashes[i].Func; f < SquashNone || f > SquashSigmoid {
+			return nil, fmt.Errorf("tiling state has unknown squashing "+
+				"function %v", s.Squashes[i].Func)
+		}
+		if s.Dims[i] < 0 {
+			return nil, fmt.Errorf("tiling state has negative input "+
+				"dimension %d", s.Dims[i])
+		}
+	}
+
+	if s.NonFinite < NonFiniteClip || s.NonFinite > NonFiniteTile {
+		return nil, fmt.Errorf("tiling state has unknown non-finite policy "+
+			"%v", s.NonFinite)
+	}
+	if len(s.Rotation) != 0 && len(s.Rotation) != n*n {
+		return nil, fmt.Errorf("tiling state has a rotation of %d elements "+
+			"for %d dimensions", len(s.Rotation), n)
+	}
+	for _, r := range s.Rotation {
+		if !isFinite(r) {
+			return nil, fmt.Errorf("tiling state has a non-finite rotation")
+		}
+	}
+
+	edges := make([][]float64, n)
+	for i := range s.Edges {
+		if len(s.Edges[i]) != 0 {
+			edges[i] = append([]float64(nil), s.Edges[i]...)
+		}
+	}
+
+	tiling := &Tiling{
+		offsets:    mat.NewDense(1, n, append([]float64(nil), s.Offsets...)),
+		bins:       append([]int(nil), s.Bins...),
+		binLengths: append([]float64(nil), s.BinLengths...),
+		minDims:    mat.NewVecDense(n, append([]float64(nil), s.MinDims...)),
+		seed:       s.Seed,
+		scales:     append([]Scale(nil), s.Scales...),
+		edges:      edges,
+		categories: append([]bool(nil), s.Categories...),
+		dims:       append([]int(nil), s.Dims...),
+		low:        append([]float64(nil), s.Low...),
+		high:       append([]float64(nil), s.High...),
+		policy:     s.Policy,
+		squashes:   append([]Squash(nil), s.Squashes...),
+		nonFinite:  s.NonFinite,
+	}
+	if len(s.Rotation) != 0 {
+		tiling.rotation = mat.NewDense(n, n, append([]float64(nil),
+			s.Rotation...))
+	}
+	if err := tiling.initStrides(); err != nil {
+		return nil, err
+	}
+	return tiling, nil
+}
+
+// adaptiveState holds every serializable field of an adaptiveBounds.
+// Hooks cannot be serialized.
+type adaptiveState struct {
+	MinDims floats  `json:"min_dims"`
+	MaxDims floats  `json:"max_dims"`
+	Margin  float64 `json:"margin"`
+	Fixed   []bool  `json:"fixed"`
+	Log     []bool  `json:"log"`
+}
+
+// tileCoderState holds every serializable field of a TileCoder
+type tileCoderState struct {
+	Version     int            `json:"version"`
+	Tilings     []tilingState  `json:"tilings"`
+	IncludeBias bool           `json:"include_bias"`
+	Adaptive    *adaptiveState `json:"adaptive,omitempty"`
+	Activations []float64      `json:"activations,omitempty"`
+	InputDims   int            `json:"input_dims,omitempty"`
+}
+
+// state returns the state of the receiver
+func (t *TileCoder) state() tileCoderState {
+	s := tileCoderState{
+		Version:     FormatVersion,
+		Tilings:     make([]tilingState, len(t.tilings)),
+		IncludeBias: t.includeBias,
+		Activations: append([]float64(nil), t.activations...),
+		InputDims:   t.inputDims,
+	}
+	for i := range t.tilings {
+		s.Tilings[i] = t.tilings[i].state()
+	}
+
+	if a := t.adaptive; a != nil {
+		s.Adaptive = &adaptiveState{
+			MinDims: mat.Col(nil, 0, a.minDims),
+			MaxDims: mat.Col(nil, 0, a.maxDims),
+			Margin:  a.margin,
+			Fixed:   append([]bool(nil), a.fixed...),
+			Log:     append([]bool(nil), a.log...),
+		}
+	}
+	return s
+}
+
+// setState sets the receiver to have state s. If the receiver was
+// adapting its bounds, its BoundsHook is kept. If the receiver was
+// counting visits, its counts are reset to zero for the features of s.
+// Its Metrics are removed, since they describe the old tilings; see
+// SetMetrics.
+func (t *TileCoder) setState(s tileCoderState) error {
+	if len(s.Tilings) == 0 {
+		return fmt.Errorf("tile coder state has no tilings")
+	}
+
+	tilings := make([]*Tiling, len(s.Tilings))
+	for i := range s.Tilings {
+		var err error
+		if tilings[i], err = newTilingFromState(s.Tilings[i]); err != nil {
+			return fmt.Errorf("tiling %d: %v", i, err)
+		}
+	}
+
+	if s.Activations != nil && len(s.Activations) != len(s.Tilings) {
+		return fmt.Errorf("there should be a single activation for each "+
+			"tiling: \n\thave(%d) \n\twant(%d)", len(s.Activations),
+			len(s.Tilings))
+	}
+
+	// Version 0 data does not record the input dimensions
+	inputDims := s.InputDims
+	if inputDims == 0 {
+		inputDims = tiledDims(tilings)
+	} else if inputDims < tiledDims(tilings) {
+		return fmt.Errorf("tilings tile %d input dimensions, but tile coder "+
+			"encodes %d", tiledDims(tilings), inputDims)
+	}
+
+	var adaptive *adaptiveBounds
+	if a := s.Adaptive; a != nil {
+		n := len(a.MinDims)
+		if n == 0 || len(a.MaxDims) != n || len(a.Fixed) != n ||
+			len(a.Log) != n {
+			return fmt.Errorf("adaptive state fields do not all describe " +
+				"the same dimensions")
+		}
+		adaptive = &adaptiveBounds{
+			minDims: mat.NewVecDense(n, append([]float64(nil), a.MinDims...)),
+			maxDims: mat.NewVecDense(n, append([]float64(nil), a.MaxDims...)),
+			margin:  a.Margin,
+			fixed:   append([]bool(nil), a.Fixed...),
+			log:     append([]bool(nil), a.Log...),
+		}
+		if t.adaptive != nil {
+			adaptive.hook = t.adaptive.hook
+		}
+	}
+
+	if err := t.init(tilings, s.IncludeBias); err != nil {
+		return err
+	}
+	t.inputDims = inputDims
+	t.adaptive = adaptive
+	t.activations = append([]float64(nil), s.Activations...)
+	if t.visits != nil {
+		t.visits = newVisitCounts(t.VecLength(), t.visitShards())
+	}
+	t.metrics = nil
+	return nil
+}
@@ -0,0 +1,248 @@
+package gotile
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Source provides vectors to tile code one at a time. Next returns the
+// next vector, or io.EOF once there are no more vectors. The returned
+// vector is copied before Next is called again, so a Source may reuse
+// it.
+type Source interface {
+	Next() (mat.Vector, error)
+}
+
+// chanSource is a Source which receives vectors from a channel
+type chanSource <-chan mat.Vector
+
+// ChanSource returns a Source which receives vectors from ch until ch
+// is closed
+func ChanSource(ch <-chan mat.Vector) Source {
+	return chanSource(ch)
+}
+
+// Next implements the Source interface
+func (c chanSource) Next() (mat.Vector, error) {
+	v, ok := <-c
+	if !ok {
+		return nil, io.EOF
+	}
+	return v, nil
+}
+
+// EncodeIndicesStream tile codes every vector provided by src in
+// chunks of at most chunkSize vectors. After each chunk is encoded,
+// emit is called with the matrix of non-zero indices of the chunk, in
+// the same form as returned by EncodeIndicesBatch. Only a single chunk
+// of vectors and indices is held in memory at a time, so datasets far
+// larger than memory can be tile coded. The matrix passed to emit is
+// reused for the next chunk, so it should be copied if needed after
+// emit returns.
+//
+// Encoding stops at the first error returned by src (other than
+// io.EOF) or emit, which is returned. An error is also returned if the
+// vectors provided by src differ in length, or if some tiling uses the
+// BoundsError policy and a vector falls outside its bounds, in which
+// case the error wraps ErrOutOfBounds.
+func (t *TileCoder) EncodeIndicesStream(src Source, chunkSize int,
+	emit func(indices *mat.Dense) error) error {
+	if chunkSize < 1 {
+		return fmt.Errorf("encodeIndicesStream: chunk size must be "+
+			"positive: %d", chunkSize)
+	}
+
+	var batch, indices *mat.Dense
+	n := 0 // Number of vectors in the current chunk
+
+	// flush encodes and emits the current chunk
+	flush := func() error {
+		rows, _ := batch.Dims()
+		chunk := batch.Slice(0, rows, 0, n).(*mat.Dense)
+		out := indices.Slice(0, t.numIndices(), 0, n).(*mat.Dense)
+		if err := t.TryEncodeIndicesBatchTo(chunk, out); err != nil {
+			return err
+		}
+		n = 0
+		return emit(out)
+	}
+
+	for {
+		v, err := src.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("encodeIndicesStream: %v", err)
+		}
+
+		if batch == nil {
+			batch = mat.NewDense(v.Len(), chunkSize, nil)
+			indices = mat.NewDense(t.numIndices(), chunkSize, nil)
+		}
+		if rows, _ := batch.Dims(); v.Len() != rows {
+			return fmt.Errorf("encodeIndicesStream: vector has length %d, "+
+				"want %d", v.Len(), rows)
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			batch.Set(i, n, v.AtVec(i))
+		}
+		n++
+
+		if n == chunkSize {
+			if err := flush(); err != nil {
+				return fmt.Errorf("encodeIndicesStream: %w", err)
+			}
+		}
+	}
+
+	if n > 0 {
+		if err := flush(); err != nil {
+			return fmt.Errorf("encodeIndicesStream: %w", err)
+		}
+	}
+	return nil
+}
+
+// StreamInput is the format of the observations read by StreamEncode
+type StreamInput int
+
+const (
+	// StreamAuto reads each line as a JSON array if it begins with '['
+	// and as a CSV record otherwise
+	StreamAuto StreamInput = iota
+
+	// StreamCSV reads each line as a CSV record of numbers
+	StreamCSV
+
+	// StreamJSON reads each line as a JSON array of numbers
+	StreamJSON
+)
+
+// StreamOptions configures StreamEncode. The zero value detects the
+// format of each line and separates CSV fields with commas.
+type StreamOptions struct {
+	// Input is the format of each line of input
+	Input StreamInput
+
+	// Comma separates the fields of CSV lines, or ',' if zero
+	Comma rune
+
+	// Header is whether the first non-empty line of input is a header
+	// to skip
+	Header bool
+}
+
+// StreamEncode reads one observation per line from r, tile codes it,
+// and writes the non-zero indices of its tile-coded representation to
+// w as a line holding a JSON array of integers, ordered as in
+// EncodeIndices. Lines are processed incrementally, so arbitrarily
+// large files can be piped through. Empty lines are skipped.
+//
+// StreamEncode stops at the first line which cannot be parsed or
+// encoded, returning an error which includes the line number. If some
+// tiling uses the BoundsError policy and an observation falls outside
+// its bounds, the error wraps ErrOutOfBounds.
+func (t *TileCoder) StreamEncode(r io.Reader, w io.Writer,
+	format StreamOptions) error {
+	if format.Comma == 0 {
+		format.Comma = ','
+	}
+
+	in := bufio.NewReader(r)
+	out := bufio.NewWriter(w)
+	indices := make([]float64, t.numIndices())
+	var v *mat.VecDense
+	var fields []float64
+	var line []byte
+	header := format.Header
+
+	for n := 1; ; n++ {
+		text, readErr := in.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return fmt.Errorf("streamEncode: line %d: %v", n, readErr)
+		}
+		text = strings.TrimSpace(text)
+
+		if text != "" && header {
+			header = false
+		} else if text != "" {
+			var err error
+			if fields, err = parseStreamLine(text, format, fields[:0]); err !=
+				nil {
+				return fmt.Errorf("streamEncode: line %d: %v", n, err)
+			}
+			if v == nil || v.Len() != len(fields) {
+				v = mat.NewVecDense(len(fields), nil)
+			}
+			for i, f := range fields {
+				v.SetVec(i, f)
+			}
+			if err := t.TryEncodeIndicesTo(indices, v); err != nil {
+				return fmt.Errorf("streamEncode: line %d: %w", n, err)
+			}
+
+			line = append(line[:0], '[')
+			for i, index := range indices {
+				if i > 0 {
+					line = append(line, ',')
+				}
+				line = strconv.AppendInt(line, int64(index), 10)
+			}
+			line = append(line, ']', '\n')
+			if _, err := out.Write(line); err != nil {
+				return fmt.Errorf("streamEncode: %v", err)
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("streamEncode: %v", err)
+	}
+	return nil
+}
+
+// parseStreamLine appends the numbers in the non-empty line text,
+// formatted as described by format, to fields
+func parseStreamLine(text string, format StreamOptions,
+	fields []float64) ([]float64, error) {
+	input := format.Input
+	if input == StreamAuto {
+		input = StreamCSV
+		if text[0] == '[' {
+			input = StreamJSON
+		}
+	}
+
+	switch input {
+	case StreamJSON:
+		var values []float64
+		if err := json.Unmarshal([]byte(text), &values); err != nil {
+			return nil, err
+		}
+		return append(fields, values...), nil
+
+	case StreamCSV:
+		for _, field := range strings.Split(text, string(format.Comma)) {
+			f, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, f)
+		}
+		return fields, nil
+
+	default:
+		return nil, fmt.Errorf("unknown input format %d", input)
+	}
+}
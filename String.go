@@ -0,0 +1,68 @@
+package gotile
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// EncodeString tile codes v and returns a short, URL-safe base64 token
+// of the non-zero indices of its tile-coded representation, encoded
+// with IndexCodec. Tokens are convenient for logging, message queues,
+// and keys in key-value stores, and equal vectors always produce equal
+// tokens. The indices can be recovered with DecodeString.
+//
+// If some tiling uses the BoundsError policy and v falls outside its
+// bounds, EncodeString panics. See TryEncodeString for a non-panicking
+// variant.
+func (t *TileCoder) EncodeString(v mat.Vector) string {
+	s, err := t.TryEncodeString(v)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// TryEncodeString is like EncodeString, but returns an error instead
+// of panicking. If some tiling uses the BoundsError policy and v falls
+// outside its bounds, an error wrapping ErrOutOfBounds is returned.
+func (t *TileCoder) TryEncodeString(v mat.Vector) (string, error) {
+	indices, err := t.TryEncodeIndices(v)
+	if err != nil {
+		return "", fmt.Errorf("encodeString: %w", err)
+	}
+	data := IndexCodec{}.AppendIndices(nil, indices)
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeString returns the non-zero indices of the tile-coded
+// representation held in a token returned by EncodeString, ordered as
+// in EncodeIndices. An error is returned if s is not a token of the
+// receiver's indices.
+func (t *TileCoder) DecodeString(s string) ([]float64, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("decodeString: %v", err)
+	}
+	indices, n, err := IndexCodec{}.DecodeIndices(data)
+	if err != nil {
+		return nil, fmt.Errorf("decodeString: %v", err)
+	}
+	if n != len(data) {
+		return nil, fmt.Errorf("decodeString: %d trailing bytes",
+			len(data)-n)
+	}
+
+	if len(indices) != t.numIndices() {
+		return nil, fmt.Errorf("decodeString: token holds %d indices, "+
+			"want %d", len(indices), t.numIndices())
+	}
+	for _, index := range indices {
+		if int(index) >= t.VecLength() {
+			return nil, fmt.Errorf("decodeString: index %v out of range "+
+				"[0, %d)", index, t.VecLength())
+		}
+	}
+	return indices, nil
+}
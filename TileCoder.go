@@ -2,11 +2,13 @@
 package gotile
 
 import (
+	"errors"
 	"fmt"
+	"runtime"
 	"sync"
+	"sync/atomic"
 
-	"github.com/samuelfneumann/goutils/matutils"
-
+	"gonum.org/v1/gonum/blas/blas64"
 	"gonum.org/v1/gonum/mat"
 )
 
@@ -30,14 +32,51 @@ import (
 // and hash-based tile coding is not used. This implementation also
 // uses multiple tilings, each of which consist of the name number
 // of tiles per tiling.
+//
+// Every encoded vector must have the number of dimensions of the
+// bounds given to New (see InputDims). Encoding any other vector
+// panics, or returns a *DimensionError from the Try variants of the
+// encoding methods.
 type TileCoder struct {
+	// Number of times the tiles of the receiver have been moved, added,
+	// or removed, accessed atomically. It is the first field so that it
+	// is 64-bit aligned on 32-bit platforms.
+	layout uint64
+
 	tilings     []*Tiling
 	includeBias bool
+	inputDims   int // Number of dimensions of encoded vectors
+
+	// Whether non-zero indices are returned in increasing order
+	sorted bool
+
+	// Minimum amount of work (tilings times vectors) for which tilings
+	// are encoded concurrently, 0 if tilings are always encoded
+	// sequentially, and the number of workers used when encoding
+	// concurrently, 0 to use GOMAXPROCS workers
+	concurrency int
+	workers     int
+
+	// Parameters shared by all tilings, nil unless the tilings differ
+	// only in their offsets
+	uniform *uniformTilings
+
+	// Instrumentation, nil if not instrumented
+	metrics Metrics
+
+	// Bounds tracking, nil if bounds are fixed
+	adaptive *adaptiveBounds
+
+	// Number of times each feature was activated, nil if visits are
+	// not counted
+	visits *visitCounts
 
-	// Concurrent encoding parameters
-	wait     sync.WaitGroup
-	indices  chan int
-	vIndices chan *mat.VecDense
+	// Value of the active feature of each tiling in the tile-coded
+	// representation, nil if every active feature is 1.0
+	activations []float64
+
+	// Hook called when tilings are added or removed, nil if unset
+	reindex ReindexHook
 }
 
 // NewTileCoder creates and returns a new TileCoder struct. The minDims
@@ -62,31 +101,144 @@ type TileCoder struct {
 //
 // offsetDiv controls the offset of each tiling from the origin. See
 // NewTiling for more details. If non-positive, then OffsetDiv is used.
+// Every tiling is offset using seed, so tilings with the same number of
+// bins are identical unless WithDistinctSeeds is given.
+//
+// Any opts given are applied to each tiling of the TileCoder. To
+// construct tilings over subsets of the input dimensions, use
+// WithGroups. Combining a single joint tiling with tilings over
+// individual dimensions produces both conjunctive and independent
+// features without the exponential growth of joint tilings.
 func New(minDims, maxDims mat.Vector, bins [][]int, seed uint64,
-	includeBias bool, offsetDiv float64) (*TileCoder, error) {
+	includeBias bool, offsetDiv float64, opts ...Option) (*TileCoder, error) {
+	if isNil(minDims) || isNil(maxDims) {
+		return nil, fmt.Errorf("new: %w", ErrNilInput)
+	}
+
 	// Ensure offsetDiv is positive, if not use the default value
 	if offsetDiv <= 0 {
 		offsetDiv = OffsetDiv
 	}
 
+	cfg, err := newConfig(opts)
+	if err != nil {
+		return nil, fmt.Errorf("new: %v", err)
+	}
+	if cfg.groups != nil && len(cfg.groups) != len(bins) {
+		return nil, fmt.Errorf("new: there should be a single group of "+
+			"dimensions for each tiling: \n\thave(%d) \n\twant (%d)",
+			len(cfg.groups), len(bins))
+	}
+
 	// Create each tiling
 	numTilings := len(bins)
 	tilings := make([]*Tiling, numTilings)
-	var err error
 	for tiling := range bins {
-		tilings[tiling], err = NewTiling(minDims, maxDims, bins[tiling], seed,
-			offsetDiv)
+		tilingOpts := opts
+		if cfg.groups != nil {
+			tilingOpts = append(opts[:len(opts):len(opts)],
+				WithDims(cfg.groups[tiling]...))
+		}
+
+		// Halton offsets take consecutive points of the sequence
+		tilingSeed := seed
+		if cfg.distinctSeeds || cfg.offsets == OffsetHalton {
+			tilingSeed += uint64(tiling)
+		}
+		tilings[tiling], err = NewTiling(minDims, maxDims, bins[tiling],
+			tilingSeed, offsetDiv, tilingOpts...)
 		if err != nil {
-			return nil, fmt.Errorf("new: could not create tiling %v: %v",
+			return nil, fmt.Errorf("new: could not create tiling %v: %w",
 				tiling, err)
 		}
 	}
 
-	// Channel along which encoded indices are sent
-	indices := make(chan int, numTilings)
-	vIndices := make(chan *mat.VecDense, numTilings)
-	return &TileCoder{tilings, includeBias, sync.WaitGroup{}, indices,
-		vIndices}, nil
+	tc := &TileCoder{
+		concurrency: cfg.concurrency,
+		workers:     cfg.workers,
+		metrics:     cfg.metrics,
+		sorted:      cfg.sorted,
+	}
+	if err := tc.init(tilings, includeBias); err != nil {
+		return nil, fmt.Errorf("new: %w", err)
+	}
+	tc.inputDims = minDims.Len()
+	if cfg.activations != nil {
+		if len(cfg.activations) != numTilings {
+			return nil, fmt.Errorf("new: there should be a single activation "+
+				"for each tiling: \n\thave(%d) \n\twant(%d)",
+				len(cfg.activations), numTilings)
+		}
+		tc.activations = append([]float64(nil), cfg.activations...)
+	} else if cfg.normalize {
+		tc.activations = make([]float64, numTilings)
+		for k := range tc.activations {
+			tc.activations[k] = 1 / float64(numTilings)
+		}
+	}
+	if cfg.visits {
+		tc.visits = newVisitCounts(tc.VecLength(), cfg.visitShards)
+	}
+	if cfg.adaptive {
+		tc.adaptive = newAdaptiveBounds(minDims, maxDims, cfg)
+	}
+	return tc, nil
+}
+
+// init sets the tilings and bias unit of the receiver. If the tile
+// coder would have more than MaxFeatures features, an error wrapping
+// ErrFeatureSpace is returned and the receiver is unchanged.
+func (t *TileCoder) init(tilings []*Tiling, includeBias bool) error {
+	features := 0
+	if includeBias {
+		features = 1
+	}
+	for _, tiling := range tilings {
+		var err error
+		if features, err = addFeatures(features, tiling.Tiles()); err != nil {
+			return err
+		}
+	}
+
+	t.tilings = tilings
+	t.includeBias = includeBias
+	t.uniform = newUniformTilings(tilings)
+	atomic.AddUint64(&t.layout, 1)
+	return nil
+}
+
+// SetConcurrency sets the concurrency threshold of the receiver,
+// replacing any threshold set by WithConcurrency. See WithConcurrency
+// for more details. Since the threshold only affects performance, it
+// is not serialized, and SetConcurrency can be used to restore it after
+// a TileCoder is deserialized.
+func (t *TileCoder) SetConcurrency(threshold int) {
+	if threshold < 0 {
+		threshold = 0
+	}
+	t.concurrency = threshold
+}
+
+// SetWorkers sets the number of workers used when the receiver encodes
+// concurrently, replacing any number set by WithWorkers. See
+// WithWorkers for more details. Like the concurrency threshold, the
+// number of workers is not serialized.
+func (t *TileCoder) SetWorkers(workers int) {
+	if workers < 0 {
+		workers = 0
+	}
+	t.workers = workers
+}
+
+// SetBoundsHook sets the hook called when a TileCoder using
+// WithAdaptiveBounds rescales its tilings, replacing any previous hook.
+// Since hooks cannot be serialized, SetBoundsHook can be used to
+// restore a hook after an adaptive TileCoder is deserialized. If the
+// receiver does not adapt its bounds, SetBoundsHook does nothing.
+func (t *TileCoder) SetBoundsHook(hook BoundsHook) {
+	if t.adaptive != nil {
+		t.adaptive.hook = hook
+	}
 }
 
 // EncodeIndicesBatch returns a matrix of the non-zero indices in the
@@ -100,94 +252,111 @@ func New(minDims, maxDims mat.Vector, bins [][]int, seed uint64,
 // k x c, where k is the number of non-zero indices (tilings + bias
 // unit) and c is the number of samples in the batch (the number of
 // columns in the input matrix).
+//
+// If some tiling uses the BoundsError policy and a vector in the batch
+// falls outside its bounds, EncodeIndicesBatch panics. See
+// TryEncodeIndicesBatch for a non-panicking variant.
 func (t *TileCoder) EncodeIndicesBatch(b *mat.Dense) *mat.Dense {
-	// Check if using a bias unit
-	bias := 0
-	if t.includeBias {
-		bias = 1
+	indices, err := t.TryEncodeIndicesBatch(b)
+	if err != nil {
+		panic(err)
 	}
+	return indices
+}
 
-	// Create the slice of non-zero indices
-	indices := make([]*mat.VecDense, t.NumTilings()+bias)
-	// indices := make([]*mat.VecDense, t.numTilings+bias)
+// TryEncodeIndicesBatch returns a matrix of the non-zero indices in
+// the tile coded batch of vectors, as in EncodeIndicesBatch. If some
+// tiling uses the BoundsError policy and a vector in the batch falls
+// outside its bounds, an error wrapping ErrOutOfBounds is returned.
+func (t *TileCoder) TryEncodeIndicesBatch(b *mat.Dense) (*mat.Dense, error) {
+	t.lockAdaptive()
+	defer t.unlockAdaptive()
+	if err := t.observeBatch(b); err != nil {
+		return nil, fmt.Errorf("encodeIndicesBatch: %w", err)
+	}
+	if err := t.checkBatch(b); err != nil {
+		return nil, fmt.Errorf("encodeIndicesBatch: %w", err)
+	}
 
-	// Listen on the vIndices channel for indices to set non-zero
-	t.wait.Add(1)
-	go func() {
-		for i := 0; i < t.NumTilings(); i++ {
-			index := <-t.vIndices
-			indices[i] = index
-		}
-		t.wait.Done()
-	}()
+	_, batchSize := b.Dims()
+	out := mat.NewDense(t.numIndices(), batchSize, nil)
+	t.encodeIndicesBatchTo(b, out)
+	t.orderBatch(out)
+	return out, nil
+}
 
-	// Concurrently calculate the non-zero indices for each tiling
-	t.wait.Add(t.NumTilings())
-	for i := 0; i < t.NumTilings(); i++ {
-		go func(tiling int) {
-			t.vIndices <- t.encodeBatchWithTiling(b, tiling)
-			t.wait.Done()
-		}(i)
+// EncodeIndicesBatchTo places the non-zero indices of each tile coded
+// vector in the batch b into dst, as in EncodeIndicesBatch. If dst is
+// empty, it is resized to hold the indices; otherwise it must have a
+// row for each non-zero index (tilings + bias unit) and a column for
+// each vector in b. Reusing dst avoids allocating a new matrix of
+// indices for each batch. If dst has the wrong shape, or if some
+// tiling uses the BoundsError policy and a vector in the batch falls
+// outside its bounds, EncodeIndicesBatchTo panics. See
+// TryEncodeIndicesBatchTo for a non-panicking variant.
+func (t *TileCoder) EncodeIndicesBatchTo(b *mat.Dense, dst *mat.Dense) {
+	if err := t.TryEncodeIndicesBatchTo(b, dst); err != nil {
+		panic(err)
 	}
+}
 
-	// If using a bias unit, add its index to the list of non-zero indices
-	if t.includeBias {
-		_, batchSize := b.Dims()
-		indices[len(indices)-1] = mat.NewVecDense(batchSize, nil)
+// TryEncodeIndicesBatchTo places the non-zero indices of each tile
+// coded vector in the batch b into dst, as in EncodeIndicesBatchTo. If
+// dst has the wrong shape an error is returned, and if some tiling
+// uses the BoundsError policy and a vector in the batch falls outside
+// its bounds, an error wrapping ErrOutOfBounds is returned.
+func (t *TileCoder) TryEncodeIndicesBatchTo(b *mat.Dense,
+	dst *mat.Dense) error {
+	if err := reuseAs(dst, t.numIndices(), b); err != nil {
+		return fmt.Errorf("encodeIndicesBatchTo: %w", err)
 	}
 
-	// Ensure all goroutines have finished adding non-zero indices to
-	// the indices slice before returning
-	t.wait.Wait()
-
-	out := mat.NewDense(len(indices), indices[0].Len(), nil)
-	for row := 0; row < len(indices); row++ {
-		out.SetRow(row, indices[row].RawVector().Data)
+	t.lockAdaptive()
+	defer t.unlockAdaptive()
+	if err := t.observeBatch(b); err != nil {
+		return fmt.Errorf("encodeIndicesBatchTo: %w", err)
 	}
-
-	return out
+	if err := t.checkBatch(b); err != nil {
+		return fmt.Errorf("encodeIndicesBatchTo: %w", err)
+	}
+	t.encodeIndicesBatchTo(b, dst)
+	t.orderBatch(dst)
+	return nil
 }
 
 // EncodeIndices returns a slice of the non-zero indices in the tile
 // coded vector when v is tile coded with the receiving TileCoder t.
+// The index of the active feature of tiling k is always at position k,
+// however the tilings are encoded, followed by the index 0 of the bias
+// unit if the receiver includes one. Since the features of each tiling
+// follow those of the previous tiling, the indices are in increasing
+// order apart from the bias unit. With WithSortedIndices, the bias
+// unit is placed first instead, so that all indices are in increasing
+// order. The same ordering is used by every method which returns
+// non-zero indices.
+//
+// If some tiling uses the BoundsError policy and v falls outside its
+// bounds, EncodeIndices panics. See TryEncodeIndices for a
+// non-panicking variant.
 func (t *TileCoder) EncodeIndices(v mat.Vector) []float64 {
-	// Check if using a bias unit
-	bias := 0
-	if t.includeBias {
-		bias = 1
-	}
-
-	// Create the slice of non-zero indices
-	indices := make([]float64, t.NumTilings()+bias)
-
-	// Listen on the indices channel for indices to set non-zero
-	t.wait.Add(1)
-	go func() {
-		for i := 0; i < t.NumTilings(); i++ {
-			index := float64(<-t.indices)
-			indices[i] = index
-		}
-		t.wait.Done()
-	}()
-
-	// Concurrently calculate the non-zero indices for each tiling
-	t.wait.Add(t.NumTilings())
-	for i := 0; i < t.NumTilings(); i++ {
-		go func(tiling int) {
-			t.indices <- t.encodeWithTiling(v, tiling)
-			t.wait.Done()
-		}(i)
+	indices, err := t.TryEncodeIndices(v)
+	if err != nil {
+		panic(err)
 	}
+	return indices
+}
 
-	// If using a bias unit, add its index to the list of non-zero indices
-	if t.includeBias {
-		indices[len(indices)-1] = 0.0
+// TryEncodeIndices returns a slice of the non-zero indices in the
+// tile coded vector, as in EncodeIndices. If some tiling uses the
+// BoundsError policy and v falls outside its bounds, an error wrapping
+// ErrOutOfBounds is returned.
+func (t *TileCoder) TryEncodeIndices(v mat.Vector) ([]float64, error) {
+	indices := make([]float64, t.numIndices())
+	if err := t.encodeIndicesTo(indices, v); err != nil {
+		return nil, fmt.Errorf("encodeIndices: %w", err)
 	}
-
-	// Ensure all goroutines have finished adding non-zero indices to
-	// the indices slice before returning
-	t.wait.Wait()
-	return indices
+	t.order(indices)
+	return indices, nil
 }
 
 // EncodeBatch encodes a batch of vectors held in a Dense matrix. In
@@ -198,54 +367,347 @@ func (t *TileCoder) EncodeIndices(v mat.Vector) []float64 {
 // k x c, where k is the number of features in the tile coded
 // representation and c is the number of samples in the batch (the
 // number of columns in the input matrix).
+//
+// If some tiling uses the BoundsError policy and a vector in the batch
+// falls outside its bounds, EncodeBatch panics. See TryEncodeBatch for
+// a non-panicking variant.
 func (t *TileCoder) EncodeBatch(b *mat.Dense) *mat.Dense {
+	tileCoded, err := t.TryEncodeBatch(b)
+	if err != nil {
+		panic(err)
+	}
+	return tileCoded
+}
+
+// TryEncodeBatch encodes a batch of vectors held in a Dense matrix, as
+// in EncodeBatch. If some tiling uses the BoundsError policy and a
+// vector in the batch falls outside its bounds, an error wrapping
+// ErrOutOfBounds is returned.
+func (t *TileCoder) TryEncodeBatch(b *mat.Dense) (*mat.Dense, error) {
+	t.lockAdaptive()
+	defer t.unlockAdaptive()
+	if err := t.observeBatch(b); err != nil {
+		return nil, fmt.Errorf("encodeBatch: %w", err)
+	}
+	if err := t.checkBatch(b); err != nil {
+		return nil, fmt.Errorf("encodeBatch: %w", err)
+	}
+
 	_, batchSize := b.Dims()
 	tileCoded := mat.NewDense(t.VecLength(), batchSize, nil)
+	t.encodeBatchTo(b, tileCoded)
+	return tileCoded, nil
+}
 
-	indices := t.EncodeIndicesBatch(b)
-	numIndices, _ := indices.Dims()
-	for row := 0; row < numIndices; row++ {
-		colIndices := indices.RawRowView(row)
-		for i := range colIndices {
-			tileCoded.Set(int(colIndices[i]), i, 1.0)
-		}
+// EncodeBatchTo encodes a batch of vectors held in a Dense matrix, as
+// in EncodeBatch, storing the tile coded representations in dst. If
+// dst is empty, it is resized to hold the representations; otherwise
+// it must have a row for each feature in the tile-coded representation
+// and a column for each vector in b. Reusing dst avoids allocating a
+// new VecLength x batch size matrix for each mini-batch. If dst has the
+// wrong shape, or if some tiling uses the BoundsError policy and a
+// vector in the batch falls outside its bounds, EncodeBatchTo panics.
+// See TryEncodeBatchTo for a non-panicking variant.
+func (t *TileCoder) EncodeBatchTo(b *mat.Dense, dst *mat.Dense) {
+	if err := t.TryEncodeBatchTo(b, dst); err != nil {
+		panic(err)
 	}
+}
 
-	return tileCoded
+// TryEncodeBatchTo encodes a batch of vectors held in a Dense matrix,
+// storing the tile coded representations in dst, as in EncodeBatchTo.
+// If dst has the wrong shape an error is returned, and if some tiling
+// uses the BoundsError policy and a vector in the batch falls outside
+// its bounds, an error wrapping ErrOutOfBounds is returned.
+func (t *TileCoder) TryEncodeBatchTo(b *mat.Dense, dst *mat.Dense) error {
+	if err := reuseAs(dst, t.VecLength(), b); err != nil {
+		return fmt.Errorf("encodeBatchTo: %w", err)
+	}
+
+	t.lockAdaptive()
+	defer t.unlockAdaptive()
+	if err := t.observeBatch(b); err != nil {
+		return fmt.Errorf("encodeBatchTo: %w", err)
+	}
+	if err := t.checkBatch(b); err != nil {
+		return fmt.Errorf("encodeBatchTo: %w", err)
+	}
+	dst.Zero()
+	t.encodeBatchTo(b, dst)
+	return nil
 }
 
-// Encode encodes a single vector as a tile-coded vector
+// Encode encodes a single vector as a tile-coded vector. If some
+// tiling uses the BoundsError policy and v falls outside its bounds,
+// Encode panics. See TryEncode for a non-panicking variant.
 func (t *TileCoder) Encode(v mat.Vector) *mat.VecDense {
+	tileCoded, err := t.TryEncode(v)
+	if err != nil {
+		panic(err)
+	}
+	return tileCoded
+}
+
+// TryEncode encodes a single vector as a tile-coded vector, as in
+// Encode. If some tiling uses the BoundsError policy and v falls
+// outside its bounds, an error wrapping ErrOutOfBounds is returned.
+func (t *TileCoder) TryEncode(v mat.Vector) (*mat.VecDense, error) {
 	tileCoded := mat.NewVecDense(t.VecLength(), nil)
+	if err := t.encodeTo(tileCoded, v); err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+	return tileCoded, nil
+}
 
-	for _, index := range t.EncodeIndices(v) {
-		tileCoded.SetVec(int(index), 1.0)
+// EncodeIndicesTo places the non-zero indices in the tile coded vector
+// into dst when v is tile coded with the receiver, as in EncodeIndices.
+// The length of dst must be the number of tilings plus one if the
+// receiver includes a bias unit. Unless the receiver encodes
+// concurrently (see WithConcurrency), EncodeIndicesTo does not allocate,
+// so it is suitable for encoding vectors at every step of an online
+// learning loop. If dst has the wrong length, or if some tiling uses
+// the BoundsError policy and v falls outside its bounds,
+// EncodeIndicesTo panics. See TryEncodeIndicesTo for a non-panicking
+// variant.
+func (t *TileCoder) EncodeIndicesTo(dst []float64, v mat.Vector) {
+	if err := t.TryEncodeIndicesTo(dst, v); err != nil {
+		panic(err)
 	}
-	return tileCoded
+}
+
+// TryEncodeIndicesTo places the non-zero indices in the tile coded
+// vector into dst, as in EncodeIndicesTo. If dst has the wrong length
+// an error is returned, and if some tiling uses the BoundsError policy
+// and v falls outside its bounds, an error wrapping ErrOutOfBounds is
+// returned.
+func (t *TileCoder) TryEncodeIndicesTo(dst []float64, v mat.Vector) error {
+	if len(dst) != t.numIndices() {
+		return fmt.Errorf("encodeIndicesTo: destination has length %d, "+
+			"want %d", len(dst), t.numIndices())
+	}
+	if err := t.encodeIndicesTo(dst, v); err != nil {
+		return fmt.Errorf("encodeIndicesTo: %w", err)
+	}
+	t.order(dst)
+	return nil
+}
+
+// EncodeTo encodes a single vector as a tile-coded vector, as in
+// Encode, storing the result in dst. The length of dst must be the
+// number of features in the tile-coded representation. Unless the
+// receiver encodes concurrently (see WithConcurrency), EncodeTo does
+// not allocate. If dst has the wrong length, or if some tiling uses the
+// BoundsError policy and v falls outside its bounds, EncodeTo panics.
+// See TryEncodeTo for a non-panicking variant.
+func (t *TileCoder) EncodeTo(dst *mat.VecDense, v mat.Vector) {
+	if err := t.TryEncodeTo(dst, v); err != nil {
+		panic(err)
+	}
+}
+
+// TryEncodeTo encodes a single vector as a tile-coded vector, storing
+// the result in dst, as in EncodeTo. If dst has the wrong length an
+// error is returned, and if some tiling uses the BoundsError policy
+// and v falls outside its bounds, an error wrapping ErrOutOfBounds is
+// returned.
+func (t *TileCoder) TryEncodeTo(dst *mat.VecDense, v mat.Vector) error {
+	if dst == nil {
+		return fmt.Errorf("encodeTo: %w", ErrNilInput)
+	}
+	if dst.Len() != t.VecLength() {
+		return fmt.Errorf("encodeTo: destination has length %d, want %d",
+			dst.Len(), t.VecLength())
+	}
+	if err := t.encodeTo(dst, v); err != nil {
+		return fmt.Errorf("encodeTo: %w", err)
+	}
+	return nil
+}
+
+// encodeIndicesTo places the non-zero indices in the tile coded vector
+// into dst, which must have length t.numIndices()
+func (t *TileCoder) encodeIndicesTo(dst []float64, v mat.Vector) error {
+	t.lockAdaptive()
+	defer t.unlockAdaptive()
+	if err := t.observe(v); err != nil {
+		return err
+	}
+	if err := t.check(v); err != nil {
+		return err
+	}
+	start := t.now()
+
+	// Calculate the non-zero indices for each tiling. Sequential
+	// encoding avoids the closure, so that no allocations are made.
+	if t.uniform != nil && t.sequential(1) {
+		workspace := getInts(len(t.tilings))
+		defer putInts(workspace)
+		t.encodeUniform(v, *workspace)
+		for i, index := range *workspace {
+			dst[i] = float64(index)
+		}
+	} else if t.sequential(1) {
+		for i := range t.tilings {
+			dst[i] = float64(t.encodeWithTiling(v, i))
+		}
+	} else {
+		t.forTilings(1, func(tiling, _, _ int) {
+			dst[tiling] = float64(t.encodeWithTiling(v, tiling))
+		})
+	}
+
+	t.visitIndices(dst[:len(t.tilings)])
+
+	// If using a bias unit, add its index to the list of non-zero indices
+	if t.includeBias {
+		dst[len(dst)-1] = 0.0
+	}
+	t.reportEncoded(1, start)
+	return nil
+}
+
+// encodeTo stores the tile coded representation of v in dst, which
+// must have length t.VecLength()
+func (t *TileCoder) encodeTo(dst *mat.VecDense, v mat.Vector) error {
+	if !t.sequential(1) {
+		indices := make([]float64, t.numIndices())
+		if err := t.encodeIndicesTo(indices, v); err != nil {
+			return err
+		}
+		dst.Zero()
+		for i, index := range indices[:len(t.tilings)] {
+			dst.SetVec(int(index), t.activation(i))
+		}
+		if t.includeBias {
+			dst.SetVec(0, 1.0)
+		}
+		return nil
+	}
+
+	t.lockAdaptive()
+	defer t.unlockAdaptive()
+	if err := t.observe(v); err != nil {
+		return err
+	}
+	if err := t.check(v); err != nil {
+		return err
+	}
+	start := t.now()
+	dst.Zero()
+	if t.uniform != nil {
+		workspace := getInts(len(t.tilings))
+		defer putInts(workspace)
+		t.encodeUniform(v, *workspace)
+		for i, index := range *workspace {
+			dst.SetVec(index, t.activation(i))
+		}
+		t.visitInts(*workspace, 0)
+	} else {
+		for i := range t.tilings {
+			index := t.encodeWithTiling(v, i)
+			dst.SetVec(index, t.activation(i))
+			t.visit(index)
+		}
+	}
+	if t.includeBias {
+		dst.SetVec(0, 1.0)
+	}
+	t.reportEncoded(1, start)
+	return nil
 }
 
 // ToVector converts a vector of non-zero indices to a tile-coded
-// vector
+// vector. Each feature is set to the value of the active feature of
+// its tiling (see WithActivations), and the bias unit to 1.0. If some
+// index is not the index of a feature, ToVector panics. See
+// TryToVector for a non-panicking variant.
 func (t *TileCoder) ToVector(v mat.Vector) *mat.VecDense {
+	tileCoded, err := t.TryToVector(v)
+	if err != nil {
+		panic(err)
+	}
+	return tileCoded
+}
+
+// TryToVector converts a vector of non-zero indices to a tile-coded
+// vector, as in ToVector. If v is nil, an error wrapping ErrNilInput is
+// returned, and if some index is not the integer index of a feature of
+// the receiver, an error is returned.
+func (t *TileCoder) TryToVector(v mat.Vector) (*mat.VecDense, error) {
+	if isNil(v) {
+		return nil, fmt.Errorf("toVector: %w", ErrNilInput)
+	}
 	tileCoded := mat.NewVecDense(t.VecLength(), nil)
 	for i := 0; i < v.Len(); i++ {
-		tileCoded.SetVec(int(v.AtVec(i)), 1.0)
+		x := v.AtVec(i)
+		index := int(x)
+		if float64(index) != x || index < 0 || index >= t.VecLength() {
+			return nil, fmt.Errorf("toVector: index %v out of range [0, %d)",
+				x, t.VecLength())
+		}
+		tileCoded.SetVec(index, t.featureActivation(index))
 	}
-	return tileCoded
+	return tileCoded, nil
 }
 
 // ToIndices converts a tile-coded vector to a vector of non-zero
-// indices
+// indices, ordered as in EncodeIndices. If v is not a tile-coded vector
+// of the receiver, ToIndices panics. See TryToIndices for a
+// non-panicking variant.
 func (t *TileCoder) ToIndices(v mat.Vector) *mat.VecDense {
-	indices := make([]float64, 0, t.NumTilings())
-	for i := 0; i < v.Len(); i++ {
-		if v.AtVec(i) != 0.0 {
-			indices = append(indices, float64(i))
-		} else if v.AtVec(i) != 1.0 {
-			panic("toIndices: vector is not a tile-coded vector")
+	indices, err := t.TryToIndices(v)
+	if err != nil {
+		panic(err)
+	}
+	return indices
+}
+
+// TryToIndices converts a tile-coded vector to a vector of non-zero
+// indices, as in ToIndices. An error is returned if v is not a
+// tile-coded vector of the receiver: if v does not have VecLength()
+// features, if some feature is neither 0.0 nor the value of an active
+// feature of its tiling (1.0 unless set by WithActivations), if some
+// tiling does not have exactly one active feature, or if the bias unit
+// of the receiver is not active.
+func (t *TileCoder) TryToIndices(v mat.Vector) (*mat.VecDense, error) {
+	if v.Len() != t.VecLength() {
+		return nil, fmt.Errorf("toIndices: vector has length %d, want %d",
+			v.Len(), t.VecLength())
+	}
+
+	indices := make([]float64, t.numIndices())
+	start := 0
+	if t.includeBias {
+		if x := v.AtVec(0); x != 1.0 {
+			return nil, fmt.Errorf("toIndices: bias unit has value %v, "+
+				"want 1", x)
 		}
+		start = 1
 	}
-	return mat.NewVecDense(t.NumTilings(), indices)
+	for k, tiling := range t.tilings {
+		active := 0
+		for i := start; i < start+tiling.Tiles(); i++ {
+			x := v.AtVec(i)
+			if x == 0.0 {
+				continue
+			}
+			if want := t.activation(k); x != want {
+				return nil, fmt.Errorf("toIndices: feature %d has value %v, "+
+					"want 0 or %v", i, x, want)
+			}
+			indices[k] = float64(i)
+			active++
+		}
+		if active != 1 {
+			return nil, fmt.Errorf("toIndices: tiling %d has %d active "+
+				"features, want 1", k, active)
+		}
+		start += tiling.Tiles()
+	}
+
+	// The bias index 0 is already last
+	t.order(indices)
+	return mat.NewVecDense(len(indices), indices), nil
 }
 
 // String returns a string representation of a *TileCoder
@@ -269,19 +731,219 @@ func (t *TileCoder) VecLength() int {
 	return baseVec
 }
 
+// Tilings returns the tilings of the tile coder, in the order in which
+// their features appear in the tile-coded representation. The tilings
+// themselves are shared with the tile coder and should not be
+// modified.
+func (t *TileCoder) Tilings() []*Tiling {
+	return append([]*Tiling(nil), t.tilings...)
+}
+
+// IncludeBias returns whether the tile coder includes a bias unit as
+// the first feature of the tile-coded representation
+func (t *TileCoder) IncludeBias() bool {
+	return t.includeBias
+}
+
 // NumTilings returns the number of tilings the tile coder uses for
 // encoding vectors
 func (t *TileCoder) NumTilings() int {
 	return len(t.tilings)
 }
 
-// prod calculates the product of all integers in a []int
-func prod(i []int) int {
-	prod := 1
-	for _, v := range i {
-		prod *= v
+// check returns an error if v does not have the input dimensions of
+// the receiver, if v falls outside the bounds of any tiling which uses
+// the BoundsError policy, or if v has a non-finite feature which any
+// tiling using the NonFiniteError policy tiles
+func (t *TileCoder) check(v mat.Vector) error {
+	if err := t.checkDims(v); err != nil {
+		return err
+	}
+	finite := finite(v)
+	for i, tiling := range t.tilings {
+		if tiling.policy != BoundsError &&
+			(finite || tiling.nonFinite != NonFiniteError) {
+			continue
+		}
+		if _, err := tiling.TryIndex(v); err != nil {
+			return fmt.Errorf("tiling %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// checkBatch returns an error if the vectors in the batch b do not
+// have the input dimensions of the receiver, or if any vector would
+// cause check to return an error
+func (t *TileCoder) checkBatch(b *mat.Dense) error {
+	if err := t.checkBatchDims(b); err != nil {
+		return err
+	}
+	finite := finiteBatch(b.RawMatrix())
+	for i, tiling := range t.tilings {
+		if tiling.policy != BoundsError &&
+			(finite || tiling.nonFinite != NonFiniteError) {
+			continue
+		}
+		if _, err := tiling.TryIndexBatch(b); err != nil {
+			return fmt.Errorf("tiling %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// validate returns an error if v does not have the input dimensions of
+// the receiver, or if v has a non-finite feature which any tiling
+// using the NonFiniteError policy tiles. Unlike check, vectors outside
+// the bounds of tilings are valid.
+func (t *TileCoder) validate(v mat.Vector) error {
+	if err := t.checkDims(v); err != nil {
+		return err
+	}
+	if finite(v) {
+		return nil
+	}
+	for i, tiling := range t.tilings {
+		if tiling.nonFinite != NonFiniteError {
+			continue
+		}
+		if _, err := tiling.TryIndex(v); errors.Is(err, ErrNonFinite) {
+			return fmt.Errorf("tiling %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// validateBatch returns an error if the vectors in the batch b do not
+// have the input dimensions of the receiver, or if any vector would
+// cause validate to return an error
+func (t *TileCoder) validateBatch(b *mat.Dense) error {
+	if err := t.checkBatchDims(b); err != nil {
+		return err
+	}
+	if finiteBatch(b.RawMatrix()) {
+		return nil
+	}
+	for i, tiling := range t.tilings {
+		if tiling.nonFinite != NonFiniteError {
+			continue
+		}
+		if _, err := tiling.TryIndexBatch(b); errors.Is(err, ErrNonFinite) {
+			return fmt.Errorf("tiling %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// forTilings calls encode(i, lo, hi) to encode vectors lo through
+// hi-1 of the given number of vectors with tiling i, for each tiling
+// of the receiver. If the total work reaches the concurrency threshold
+// of the receiver, the vectors are split into chunks and the chunks of
+// every tiling are encoded by a pool of workers; otherwise, each tiling
+// encodes all vectors sequentially.
+func (t *TileCoder) forTilings(vectors int, encode func(tiling, lo, hi int)) {
+	if t.sequential(vectors) {
+		for i := range t.tilings {
+			encode(i, 0, vectors)
+		}
+		return
+	}
+	workers := t.numWorkers()
+
+	// Split the vectors into enough chunks that each worker can encode
+	// a chunk with some tiling
+	chunks := (workers + len(t.tilings) - 1) / len(t.tilings)
+	if chunks > vectors {
+		chunks = vectors
+	}
+	size := (vectors + chunks - 1) / chunks
+
+	type job struct{ tiling, lo, hi int }
+	jobs := make(chan job, len(t.tilings)*chunks)
+	for i := range t.tilings {
+		for lo := 0; lo < vectors; lo += size {
+			hi := lo + size
+			if hi > vectors {
+				hi = vectors
+			}
+			jobs <- job{i, lo, hi}
+		}
+	}
+	close(jobs)
+
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+	var wait sync.WaitGroup
+	wait.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			for j := range jobs {
+				encode(j.tiling, j.lo, j.hi)
+			}
+			wait.Done()
+		}()
 	}
-	return prod
+	wait.Wait()
+}
+
+// sequential returns whether the receiver encodes the given number of
+// vectors sequentially rather than concurrently
+func (t *TileCoder) sequential(vectors int) bool {
+	work := vectors * len(t.tilings)
+	return t.concurrency <= 0 || work < t.concurrency || t.numWorkers() == 1
+}
+
+// numWorkers returns the number of workers used when the receiver
+// encodes concurrently
+func (t *TileCoder) numWorkers() int {
+	if t.workers <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return t.workers
+}
+
+// numIndices returns the number of non-zero indices in a tile coded
+// vector, which is the number of tilings plus the bias unit
+func (t *TileCoder) numIndices() int {
+	if t.includeBias {
+		return len(t.tilings) + 1
+	}
+	return len(t.tilings)
+}
+
+// FeatureRange returns the range of features [start, end) of the
+// tile-coded representation which belong to tiling number tiling. The
+// ranges of consecutive tilings are contiguous, and the bias unit, if
+// included, is the single feature before the range of the first
+// tiling. FeatureRange can be used to partition weight vectors into
+// per-tiling blocks, for example to use a separate step size for each
+// tiling. FeatureRange panics if tiling is not a tiling of the
+// receiver. See TryFeatureRange for a non-panicking variant.
+func (t *TileCoder) FeatureRange(tiling int) (start, end int) {
+	start, end, err := t.TryFeatureRange(tiling)
+	if err != nil {
+		panic(err)
+	}
+	return start, end
+}
+
+// TryFeatureRange returns the range of features [start, end) of the
+// tile-coded representation which belong to tiling number tiling, as
+// in FeatureRange. If tiling is not a tiling of the receiver, an error
+// is returned.
+func (t *TileCoder) TryFeatureRange(tiling int) (start, end int,
+	err error) {
+	if tiling < 0 || tiling >= len(t.tilings) {
+		return 0, 0, fmt.Errorf("featureRange: tiling %d out of range "+
+			"[0, %d)", tiling, len(t.tilings))
+	}
+
+	start = t.featuresBeforeTiling(tiling)
+	if t.includeBias {
+		start++
+	}
+	return start, start + t.tilings[tiling].Tiles(), nil
 }
 
 // Calculates how many features exist in the tile-coded representation
@@ -294,10 +956,73 @@ func (t *TileCoder) featuresBeforeTiling(i int) int {
 	return features
 }
 
+// activation returns the value of the active feature of tiling number
+// tiling in the tile-coded representation
+func (t *TileCoder) activation(tiling int) float64 {
+	if t.activations == nil {
+		return 1.0
+	}
+	return t.activations[tiling]
+}
+
+// featureActivation returns the value of the feature at index of the
+// tile-coded representation when that feature is active
+func (t *TileCoder) featureActivation(index int) float64 {
+	if t.activations == nil || (t.includeBias && index == 0) {
+		return 1.0
+	}
+	end := 0
+	if t.includeBias {
+		end = 1
+	}
+	for k, tiling := range t.tilings {
+		end += tiling.Tiles()
+		if index < end {
+			return t.activations[k]
+		}
+	}
+	return 1.0
+}
+
+// activeFeatures sets index[k] to the index of the tile coded feature
+// vector which is 1.0 when v is encoded with tiling number k, for each
+// tiling, without adapting bounds, counting visits, or reporting
+// metrics. The vector v must already have been checked against the
+// bounds of tilings using the BoundsError policy.
+func (t *TileCoder) activeFeatures(v mat.Vector, index []int) {
+	bias := 0
+	if t.includeBias {
+		bias = 1
+	}
+	indexOffset := bias
+	for k, tiling := range t.tilings {
+		index[k] = indexOffset + tiling.Index(v)
+		indexOffset += tiling.Tiles()
+	}
+}
+
+// encodeUniform sets index[k] to the index of the tile coded feature
+// vector which should be a 1.0 when v is encoded with tiling number k,
+// for each tiling. The receiver's tilings must differ only in their
+// offsets, and index must be zeroed.
+func (t *TileCoder) encodeUniform(v mat.Vector, index []int) {
+	t.uniform.index(v, index)
+
+	bias := 0
+	if t.includeBias {
+		bias = 1
+	}
+	tiles := t.tilings[0].Tiles()
+	for k := range index {
+		index[k] += k*tiles + bias
+	}
+}
+
 // encodeWithTiling returns the index of the tile coded feature vector
 // which should be a 1.0 when the input vector v is encoded with tiling
 // number tiling in the TileCoder.
 func (t *TileCoder) encodeWithTiling(v mat.Vector, tiling int) int {
+	start := t.now()
 	bias := 0
 	if t.includeBias {
 		bias = 1
@@ -308,32 +1033,122 @@ func (t *TileCoder) encodeWithTiling(v mat.Vector, tiling int) int {
 	indexOffset := t.featuresBeforeTiling(tiling)
 	index := t.tilings[tiling].Index(v)
 
+	t.reportTiling(tiling, 1, start)
 	return indexOffset + index + bias
 }
 
-// encodeBatchWithTiling returns the indices of the tile coded feature
-// vectors which should be a 1.0 when the input batch of vectors b is
-// encoded with tiling number tiling. The indices for the vector at
-// column i in b are also in column i in the returned matrix. Each
-// column of b is considered a vector to tile code, while each row
+// reuseAs resizes dst to have rows rows and a column for each vector
+// in the batch b if dst is empty, and otherwise returns an error if dst
+// does not have this shape. If dst or b is nil, ErrNilInput is returned.
+func reuseAs(dst *mat.Dense, rows int, b *mat.Dense) error {
+	if dst == nil || b == nil {
+		return ErrNilInput
+	}
+	_, batchSize := b.Dims()
+	if dst.IsEmpty() {
+		dst.ReuseAs(rows, batchSize)
+		return nil
+	}
+	if r, c := dst.Dims(); r != rows || c != batchSize {
+		return fmt.Errorf("destination has shape %dx%d, want %dx%d", r, c,
+			rows, batchSize)
+	}
+	return nil
+}
+
+// encodeIndicesBatchTo places the non-zero indices of each tile coded
+// vector in the batch b into dst, which must have t.numIndices() rows
+// and a column for each vector in b
+func (t *TileCoder) encodeIndicesBatchTo(b *mat.Dense, dst *mat.Dense) {
+	// Calculate the non-zero indices for each tiling over each chunk
+	// of the batch
+	start := t.now()
+	_, batchSize := b.Dims()
+	raw := b.RawMatrix()
+	t.forTilings(batchSize, func(tiling, lo, hi int) {
+		indices := dst.RawRowView(tiling)[lo:hi]
+		t.encodeBatchWithTiling(raw, tiling, lo, hi, indices)
+		t.visitIndices(indices)
+	})
+
+	// If using a bias unit, its index 0 is placed in the last row
+	if t.includeBias {
+		row := dst.RawRowView(len(t.tilings))
+		for j := range row {
+			row[j] = 0.0
+		}
+	}
+	t.reportEncoded(batchSize, start)
+}
+
+// encodeBatchTo sets the elements of the zeroed matrix dst which are
+// non-zero in the tile coded representation of each vector in the
+// batch b.
+// The elements are set directly from the index of each vector in each
+// tiling, without building a matrix of indices.
+func (t *TileCoder) encodeBatchTo(b *mat.Dense, dst *mat.Dense) {
+	bias := 0
+	if t.includeBias {
+		bias = 1
+	}
+
+	start := t.now()
+	_, batchSize := b.Dims()
+	src, raw := b.RawMatrix(), dst.RawMatrix()
+	t.forTilings(batchSize, func(tiling, lo, hi int) {
+		tilingStart := t.now()
+		workspace := getInts(hi - lo)
+		defer putInts(workspace)
+		index := *workspace
+
+		// Vectors have already been checked against the bounds of
+		// tilings using the BoundsError policy, so no error can occur
+		t.tilings[tiling].indexInts(src, lo, hi, index)
+
+		indexOffset := t.featuresBeforeTiling(tiling) + bias
+		activation := t.activation(tiling)
+		for j, ind := range index {
+			raw.Data[(indexOffset+ind)*raw.Stride+lo+j] = activation
+		}
+		t.visitInts(index, indexOffset)
+		t.reportTiling(tiling, hi-lo, tilingStart)
+	})
+
+	// If using a bias unit, it is 1.0 for every vector
+	if t.includeBias {
+		row := raw.Data[:batchSize]
+		for j := range row {
+			row[j] = 1.0
+		}
+	}
+	t.reportEncoded(batchSize, start)
+}
+
+// encodeBatchWithTiling fills dst with the indices of the tile coded
+// feature vectors which should be a 1.0 when the vectors in columns lo
+// through hi-1 of the batch b are encoded with tiling number tiling.
+// The index for the vector at column j in b is placed in dst[j-lo].
+// Each column of b is considered a vector to tile code, while each row
 // is considered a feature for each vector in the batch.
-func (t *TileCoder) encodeBatchWithTiling(b *mat.Dense,
-	tiling int) *mat.VecDense {
+func (t *TileCoder) encodeBatchWithTiling(b blas64.General, tiling, lo,
+	hi int, dst []float64) {
 	// Check if using a bias unit, if so we will need to offset the
 	// indices generated later
+	start := t.now()
 	bias := 0.
 	if t.includeBias {
 		bias = 1.
 	}
 
-	indexOffset := t.featuresBeforeTiling(tiling)
-	index := t.tilings[tiling].IndexBatch(b)
+	// Vectors have already been checked against the bounds of tilings
+	// using the BoundsError policy, so no error can occur
+	t.tilings[tiling].indexBatch(b, lo, hi, dst)
 
 	// Offset the 1.0 based on which tiling was used for the previous
 	// iteration of coding and if a bias unit was used
-	rows, _ := b.Dims()
-	ones := matutils.VecOnes(rows)
-	index.AddScaledVec(index, float64(indexOffset)+bias, ones)
-
-	return index
+	indexOffset := float64(t.featuresBeforeTiling(tiling)) + bias
+	for i := range dst {
+		dst[i] += indexOffset
+	}
+	t.reportTiling(tiling, hi-lo, start)
 }
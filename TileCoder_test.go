@@ -1,11 +1,2769 @@
 package gotile
 
 import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 
 	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/spatial/r1"
 )
 
+func TestTileCoderGroups(t *testing.T) {
+	tc, err := New(
+		mat.NewVecDense(3, []float64{0, 0, 0}),
+		mat.NewVecDense(3, []float64{1, 1, 1}),
+		[][]int{{2, 2}, {4}, {3}},
+		1,
+		false,
+		1e300,
+		WithGroups([][]int{{0, 1}, {2}, {1}}),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	if tc.VecLength() != 11 {
+		t.Errorf("vecLength: have(%v) want(%v)", tc.VecLength(), 11)
+	}
+
+	v := mat.NewVecDense(3, []float64{0.9, 0.2, 0.6})
+	want := []float64{2, 4 + 2, 8 + 0}
+	got := tc.EncodeIndices(v)
+	sort.Float64s(got)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("encodeIndices: have(%v) want(%v)", got, want)
+			break
+		}
+	}
+}
+
+func TestTileCoderAdaptiveBounds(t *testing.T) {
+	calls := 0
+	var max float64
+	hook := func(minDims, maxDims mat.Vector) {
+		calls++
+		max = maxDims.AtVec(0)
+	}
+
+	tc, err := New(
+		mat.NewVecDense(1, []float64{0}),
+		mat.NewVecDense(1, []float64{1}),
+		[][]int{{4}},
+		1,
+		false,
+		1e300,
+		WithAdaptiveBounds(0, hook),
+		WithNonFinitePolicy(NonFiniteError),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+
+	if got := tc.EncodeIndices(mat.NewVecDense(1, []float64{0.3})); got[0] != 1 {
+		t.Errorf("encodeIndices: have(%v) want(%v)", got[0], 1)
+	}
+	if calls != 0 {
+		t.Errorf("hook called for in-bounds vector")
+	}
+
+	if got := tc.EncodeIndices(mat.NewVecDense(1, []float64{2})); got[0] != 3 {
+		t.Errorf("encodeIndices: have(%v) want(%v)", got[0], 3)
+	}
+	if calls != 1 || max != 2 {
+		t.Errorf("hook: have(%v calls, max %v) want(1 calls, max 2)", calls,
+			max)
+	}
+
+	// After rescaling, each tile covers twice the range
+	if got := tc.EncodeIndices(mat.NewVecDense(1, []float64{0.3})); got[0] != 0 {
+		t.Errorf("encodeIndices: have(%v) want(%v)", got[0], 0)
+	}
+
+	// Vectors which cannot be encoded never move the bounds
+	if _, err := tc.TryEncodeIndices(mat.NewVecDense(2, []float64{5,
+		5})); !errors.Is(err, ErrDimension) {
+		t.Errorf("encodeIndices(2 dimensions): have(%v) want(%v)", err,
+			ErrDimension)
+	}
+	if _, err := tc.TryEncode(mat.NewVecDense(1, []float64{
+		math.NaN()})); !errors.Is(err, ErrNonFinite) {
+		t.Errorf("encode(NaN): have(%v) want(%v)", err, ErrNonFinite)
+	}
+	if _, err := tc.TryEncodeBatch(mat.NewDense(2, 1, []float64{5,
+		5})); !errors.Is(err, ErrDimension) {
+		t.Errorf("encodeBatch(2 dimensions): have(%v) want(%v)", err,
+			ErrDimension)
+	}
+	if _, err := tc.TryEncodeIndicesBatch(mat.NewDense(1, 2, []float64{5,
+		math.Inf(1)})); !errors.Is(err, ErrNonFinite) {
+		t.Errorf("encodeIndicesBatch(+Inf): have(%v) want(%v)", err,
+			ErrNonFinite)
+	}
+	if calls != 1 || max != 2 {
+		t.Errorf("hook after invalid vectors: have(%v calls, max %v) "+
+			"want(1 calls, max 2)", calls, max)
+	}
+}
+
+func TestTileCoderNonFinite(t *testing.T) {
+	minDims := mat.NewVecDense(2, []float64{0, 0})
+	maxDims := mat.NewVecDense(2, []float64{1, 1})
+	nan, inf := math.NaN(), math.Inf(1)
+	batch := mat.NewDense(2, 4, []float64{
+		nan, inf, -inf, 0.5,
+		0.5, 0.5, nan, 0.5,
+	})
+
+	// By default, non-finite features are clipped rather than reported,
+	// with or without the encoding shared by uniform tilings
+	tc, err := New(minDims, maxDims, [][]int{{4, 4}, {4, 4}}, 1, true, -1,
+		WithDistinctSeeds())
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	encodedBatch := tc.EncodeBatch(batch)
+	for j := 0; j < 4; j++ {
+		v := batch.ColView(j)
+		encoded := tc.Encode(v)
+		if !mat.Equal(encodedBatch.ColView(j), encoded) {
+			t.Errorf("encodeBatch(%v): batch encoding differs from encode",
+				mat.Formatted(v.T()))
+		}
+		for k, index := range tc.EncodeIndices(v)[:tc.NumTilings()] {
+			want := 1 + k*16 + tc.Tilings()[k].Index(v)
+			if int(index) != want {
+				t.Errorf("encodeIndices(%v): tiling %d: have(%v) want(%v)",
+					mat.Formatted(v.T()), k, index, want)
+			}
+		}
+	}
+
+	// Errors are only reported when asked for
+	strict, err := New(minDims, maxDims, [][]int{{4, 4}, {4, 4}}, 1, true,
+		-1, WithDistinctSeeds(), WithNonFinitePolicy(NonFiniteError))
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	if _, err := strict.TryEncode(batch.ColView(0)); !errors.Is(err,
+		ErrNonFinite) {
+		t.Errorf("tryEncode(NaN): have(%v) want(%v)", err, ErrNonFinite)
+	}
+	if _, err := strict.TryEncodeBatch(batch); !errors.Is(err,
+		ErrNonFinite) {
+		t.Errorf("tryEncodeBatch: have(%v) want(%v)", err, ErrNonFinite)
+	}
+}
+
+func TestTileCoderAdaptiveConcurrent(t *testing.T) {
+	tc, err := New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{4, 4}, {4, 4}},
+		1,
+		true,
+		-1,
+		WithAdaptiveBounds(0.1, nil),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+
+	// Vectors which widen the bounds may be encoded concurrently with
+	// vectors which do not
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				x := float64(g*100 + j)
+				v := mat.NewVecDense(2, []float64{x, -x})
+				if _, err := tc.TryEncodeIndices(v); err != nil {
+					t.Error(err)
+					return
+				}
+				b := mat.NewDense(2, 2, []float64{0.5, x, 0.5, x / 2})
+				if _, err := tc.TryEncodeBatch(b); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	minDims, maxDims := tc.Tilings()[0].Bounds()
+	if minDims[1] > -799 || maxDims[0] < 799 {
+		t.Errorf("bounds: have(%v, %v) want to cover [-799, 799]", minDims,
+			maxDims)
+	}
+
+	// Vectors which would widen the bounds past the largest float
+	// return an error, leaving the tile coder unchanged
+	v := mat.NewVecDense(2, []float64{math.MaxFloat64, 0.5})
+	if _, err := tc.TryEncodeIndices(v); err == nil {
+		t.Errorf("encodeIndices(%v): expected error", mat.Formatted(v.T()))
+	}
+	b := mat.NewDense(2, 1, []float64{math.MaxFloat64, 0.5})
+	if _, err := tc.TryEncodeBatch(b); err == nil {
+		t.Errorf("encodeBatch(%v): expected error", mat.Formatted(b.T()))
+	}
+	haveMin, haveMax := tc.Tilings()[0].Bounds()
+	if !reflect.DeepEqual(haveMin, minDims) ||
+		!reflect.DeepEqual(haveMax, maxDims) {
+		t.Errorf("bounds: have(%v, %v) want(%v, %v)", haveMin, haveMax,
+			minDims, maxDims)
+	}
+}
+
+func TestConfigForResolution(t *testing.T) {
+	config, err := ConfigForResolution(
+		mat.NewVecDense(2, []float64{0, -1}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[]float64{0.05, 0.5},
+		[]float64{0.2, 1.0},
+	)
+	if err != nil {
+		t.Fatalf("could not create config: %v", err)
+	}
+	if len(config.Bins) != 4 {
+		t.Errorf("tilings: have(%v) want(%v)", len(config.Bins), 4)
+	}
+	for _, bins := range config.Bins {
+		if bins[0] != 5 || bins[1] != 2 {
+			t.Errorf("bins: have(%v) want(%v)", bins, []int{5, 2})
+		}
+	}
+
+	tc, err := config.New()
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	if tc.VecLength() != 40 {
+		t.Errorf("vecLength: have(%v) want(%v)", tc.VecLength(), 40)
+	}
+}
+
+func TestTileCoderSeeds(t *testing.T) {
+	minDims := mat.NewVecDense(2, []float64{0, 0})
+	maxDims := mat.NewVecDense(2, []float64{1, 1})
+	bins := [][]int{{4, 4}, {4, 4}, {4, 4}}
+
+	// offsets returns the offsets of each tiling of tc
+	offsets := func(tc *TileCoder) [][]float64 {
+		o := make([][]float64, tc.NumTilings())
+		for i, tiling := range tc.Tilings() {
+			o[i] = tiling.Offsets()
+		}
+		return o
+	}
+
+	// By default, every tiling is offset using the same seed
+	shared, err := New(minDims, maxDims, bins, 5, false, -1)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	o := offsets(shared)
+	for i := 1; i < len(o); i++ {
+		if !reflect.DeepEqual(o[i], o[0]) {
+			t.Errorf("offsets(tiling %d): have(%v) want(%v)", i, o[i], o[0])
+		}
+	}
+
+	distinct, err := New(minDims, maxDims, bins, 5, false, -1,
+		WithDistinctSeeds())
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	if got := offsets(distinct); !reflect.DeepEqual(got[0], o[0]) ||
+		reflect.DeepEqual(got[1], got[0]) || reflect.DeepEqual(got[2],
+		got[1]) {
+		t.Errorf("offsets(WithDistinctSeeds): have(%v) want tiling 0 at %v "+
+			"and the others distinct", got, o[0])
+	}
+
+	// Tilings of a Config from ConfigForResolution have the same bins,
+	// and so must be offset differently
+	config, err := ConfigForResolution(minDims, maxDims,
+		[]float64{0.05, 0.05}, []float64{0.2, 0.2})
+	if err != nil {
+		t.Fatalf("could not create config: %v", err)
+	}
+	tc, err := config.New()
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	if got := offsets(tc); reflect.DeepEqual(got[1], got[0]) {
+		t.Errorf("offsets(configForResolution): have(%v) want distinct", got)
+	}
+}
+
+// newTestTileCoder returns a TileCoder over two dimensions which uses
+// most of the optional features of tilings
+func newTestTileCoder(t *testing.T) *TileCoder {
+	tc, err := New(
+		mat.NewVecDense(2, []float64{math.Inf(-1), 1}),
+		mat.NewVecDense(2, []float64{math.Inf(1), 100}),
+		[][]int{{4, 3}, {2, 5}, {3}},
+		7,
+		true,
+		-1,
+		WithSquashes(Squash{Func: SquashTanh}, Squash{}),
+		WithScales(ScaleLinear, ScaleLog),
+		WithBoundsPolicy(BoundsExtend),
+		WithGroups([][]int{{0, 1}, {1, 0}, {0}}),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	return tc
+}
+
+// assertSameEncoding fails the test if want and got encode a set of
+// vectors differently
+func assertSameEncoding(t *testing.T, want, got *TileCoder) {
+	t.Helper()
+	if want.VecLength() != got.VecLength() {
+		t.Fatalf("vecLength: have(%v) want(%v)", got.VecLength(),
+			want.VecLength())
+	}
+
+	for _, x := range []float64{-3, -0.5, 0, 0.2, 2} {
+		for _, y := range []float64{-1, 1, 5, 50, 500} {
+			v := mat.NewVecDense(2, []float64{x, y})
+			w, g := want.EncodeIndices(v), got.EncodeIndices(v)
+			sort.Float64s(w)
+			sort.Float64s(g)
+			for i := range w {
+				if w[i] != g[i] {
+					t.Errorf("encodeIndices(%v, %v): have(%v) want(%v)", x, y,
+						g, w)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestTileCoderJSON(t *testing.T) {
+	tc := newTestTileCoder(t)
+
+	data, err := json.Marshal(tc)
+	if err != nil {
+		t.Fatalf("could not marshal: %v", err)
+	}
+
+	var loaded TileCoder
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatalf("could not unmarshal: %v", err)
+	}
+	assertSameEncoding(t, tc, &loaded)
+}
+
+func TestTileCoderGob(t *testing.T) {
+	// Tile coders should be encodable when embedded in other structs
+	type experiment struct {
+		Name  string
+		Coder *TileCoder
+	}
+	tc := newTestTileCoder(t)
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(experiment{"test", tc}); err != nil {
+		t.Fatalf("could not encode: %v", err)
+	}
+
+	var loaded experiment
+	if err := gob.NewDecoder(&buf).Decode(&loaded); err != nil {
+		t.Fatalf("could not decode: %v", err)
+	}
+	assertSameEncoding(t, tc, loaded.Coder)
+}
+
+func TestTileCoderBinary(t *testing.T) {
+	tc := newTestTileCoder(t)
+
+	data, err := tc.MarshalBinary()
+	if err != nil {
+		t.Fatalf("could not marshal: %v", err)
+	}
+	jsonData, err := json.Marshal(tc)
+	if err != nil {
+		t.Fatalf("could not marshal: %v", err)
+	}
+	if len(data) >= len(jsonData) {
+		t.Errorf("binary encoding (%d bytes) is not smaller than JSON "+
+			"encoding (%d bytes)", len(data), len(jsonData))
+	}
+
+	var loaded TileCoder
+	if err := loaded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("could not unmarshal: %v", err)
+	}
+	assertSameEncoding(t, tc, &loaded)
+
+	for _, n := range []int{0, 3, len(data) / 2, len(data) - 1} {
+		if err := loaded.UnmarshalBinary(data[:n]); err == nil {
+			t.Errorf("expected error unmarshaling %d of %d bytes", n,
+				len(data))
+		}
+	}
+}
+
+func TestTileCoderUnmarshalIntoUsed(t *testing.T) {
+	tc := newTestTileCoder(t)
+	jsonData, err := json.Marshal(tc)
+	if err != nil {
+		t.Fatalf("could not marshal: %v", err)
+	}
+	binaryData, err := tc.MarshalBinary()
+	if err != nil {
+		t.Fatalf("could not marshal: %v", err)
+	}
+
+	unmarshal := map[string]func(*TileCoder) error{
+		"json": func(used *TileCoder) error {
+			return json.Unmarshal(jsonData, used)
+		},
+		"binary": func(used *TileCoder) error {
+			return used.UnmarshalBinary(binaryData)
+		},
+	}
+	for name, unmarshal := range unmarshal {
+		var metrics Counters
+		used, err := New(mat.NewVecDense(1, []float64{0}),
+			mat.NewVecDense(1, []float64{1}), [][]int{{2}}, 1, false, -1,
+			WithVisitCounts(), WithMetrics(&metrics))
+		if err != nil {
+			t.Fatalf("could not create tile coder: %v", err)
+		}
+		used.Encode(mat.NewVecDense(1, []float64{0.75}))
+
+		if err := unmarshal(used); err != nil {
+			t.Fatalf("%v: could not unmarshal: %v", name, err)
+		}
+
+		// Visits are counted from zero for the new features, and the
+		// old metrics no longer receive instrumentation
+		counts := used.VisitCounts()
+		if len(counts) != used.VecLength() {
+			t.Errorf("%v: visit counts: have(%v) want(%v)", name,
+				len(counts), used.VecLength())
+		}
+		used.Encode(mat.NewVecDense(2, []float64{0.5, 10}))
+		visited := 0
+		for _, count := range used.VisitCounts() {
+			visited += int(count)
+		}
+		if visited != used.NumTilings() {
+			t.Errorf("%v: visits: have(%v) want(%v)", name, visited,
+				used.NumTilings())
+		}
+		if calls := metrics.Calls(); calls != 1 {
+			t.Errorf("%v: metrics calls: have(%v) want(%v)", name, calls, 1)
+		}
+		assertSameEncoding(t, tc, used)
+	}
+}
+
+func TestTileCoderSaveFile(t *testing.T) {
+	tc := newTestTileCoder(t)
+	dir := t.TempDir()
+
+	for _, name := range []string{"coder.json", "coder.gob", "coder.bin"} {
+		path := filepath.Join(dir, name)
+		if err := tc.SaveFile(path); err != nil {
+			t.Fatalf("could not save %v: %v", name, err)
+		}
+
+		loaded, err := LoadFile(path)
+		if err != nil {
+			t.Fatalf("could not load %v: %v", name, err)
+		}
+		assertSameEncoding(t, tc, loaded)
+	}
+}
+
+// stateV0 returns the version 0 state of the tile coder state s
+func stateV0(s tileCoderState) tileCoderStateV0 {
+	v0 := tileCoderStateV0{
+		Tilings:     make([]tilingStateV0, len(s.Tilings)),
+		IncludeBias: s.IncludeBias,
+		Adaptive:    s.Adaptive,
+	}
+	for i, tiling := range s.Tilings {
+		v0.Tilings[i] = tilingStateV0{
+			Offsets:    tiling.Offsets,
+			Bins:       tiling.Bins,
+			BinLengths: tiling.BinLengths,
+			MinDims:    tiling.MinDims,
+			Seed:       tiling.Seed,
+			Scales:     make([]int, len(tiling.Scales)),
+			Edges:      make([][]float64, len(tiling.Edges)),
+			Categories: tiling.Categories,
+			Dims:       tiling.Dims,
+			Low:        tiling.Low,
+			High:       tiling.High,
+			Policy:     int(tiling.Policy),
+		}
+		for j := range tiling.Scales {
+			v0.Tilings[i].Scales[j] = int(tiling.Scales[j])
+		}
+		for j := range tiling.Edges {
+			v0.Tilings[i].Edges[j] = tiling.Edges[j]
+		}
+		for _, squash := range tiling.Squashes {
+			v0.Tilings[i].Squashes = append(v0.Tilings[i].Squashes, struct {
+				Func  int
+				Scale float64
+			}{int(squash.Func), squash.Scale})
+		}
+	}
+	return v0
+}
+
+// jsonV0 returns the version 0 JSON document of the state s, which
+// has no version and encodes enumerations as integers
+func jsonV0(s tileCoderStateV0) map[string]interface{} {
+	tilings := make([]interface{}, len(s.Tilings))
+	for i, tiling := range s.Tilings {
+		edges := make([]floats, len(tiling.Edges))
+		for j := range tiling.Edges {
+			edges[j] = tiling.Edges[j]
+		}
+		tilings[i] = map[string]interface{}{
+			"offsets":     floats(tiling.Offsets),
+			"bins":        tiling.Bins,
+			"bin_lengths": floats(tiling.BinLengths),
+			"min_dims":    floats(tiling.MinDims),
+			"seed":        tiling.Seed,
+			"scales":      tiling.Scales,
+			"edges":       edges,
+			"categories":  tiling.Categories,
+			"dims":        tiling.Dims,
+			"low":         floats(tiling.Low),
+			"high":        floats(tiling.High),
+			"policy":      tiling.Policy,
+			"squashes":    tiling.Squashes,
+		}
+	}
+	return map[string]interface{}{
+		"tilings":      tilings,
+		"include_bias": s.IncludeBias,
+	}
+}
+
+func TestTileCoderMigration(t *testing.T) {
+	tc := newTestTileCoder(t)
+	v0 := stateV0(tc.state())
+
+	data, err := json.Marshal(jsonV0(v0))
+	if err != nil {
+		t.Fatalf("could not marshal version 0 JSON: %v", err)
+	}
+	var fromJSON TileCoder
+	if err := json.Unmarshal(data, &fromJSON); err != nil {
+		t.Fatalf("could not unmarshal version 0 JSON: %v", err)
+	}
+	assertSameEncoding(t, tc, &fromJSON)
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v0); err != nil {
+		t.Fatalf("could not encode version 0 gob: %v", err)
+	}
+	var fromGob TileCoder
+	if err := fromGob.GobDecode(buf.Bytes()); err != nil {
+		t.Fatalf("could not decode version 0 gob: %v", err)
+	}
+	assertSameEncoding(t, tc, &fromGob)
+
+	// Migrated tile coders are saved with the current version
+	for _, loaded := range []*TileCoder{&fromJSON, &fromGob} {
+		if s := loaded.state(); s.Version != FormatVersion ||
+			s.InputDims != 2 {
+			t.Errorf("migrated state: have(version %d, %d input dims) "+
+				"want(version %d, 2 input dims)", s.Version, s.InputDims,
+				FormatVersion)
+		}
+	}
+
+	data, err = json.Marshal(tc)
+	if err != nil {
+		t.Fatalf("could not marshal: %v", err)
+	}
+	data = bytes.Replace(data, []byte(fmt.Sprintf(`"version":%d`,
+		FormatVersion)), []byte(`"version":1000`), 1)
+	var loaded TileCoder
+	if err := json.Unmarshal(data, &loaded); err == nil {
+		t.Error("expected error loading newer format version")
+	}
+}
+
+func TestTileCoderConcurrency(t *testing.T) {
+	sequential := newTestTileCoder(t)
+	concurrent := newTestTileCoder(t)
+	concurrent.SetConcurrency(1)
+
+	batch := mat.NewDense(2, 4, []float64{-3, 0, 0.2, 2, -1, 5, 50, 500})
+	want := sequential.EncodeIndicesBatch(batch)
+	for _, workers := range []int{0, 2, 5, 16} {
+		concurrent.SetWorkers(workers)
+		got := concurrent.EncodeIndicesBatch(batch)
+		if !mat.Equal(got, want) {
+			t.Errorf("%d workers: encodeIndicesBatch: have(%v) want(%v)",
+				workers, mat.Formatted(got), mat.Formatted(want))
+		}
+	}
+
+	for col := 0; col < 4; col++ {
+		v := batch.ColView(col)
+		w, g := sequential.EncodeIndices(v), concurrent.EncodeIndices(v)
+		for i := range w {
+			if g[i] != w[i] {
+				t.Errorf("encodeIndices(%v): have(%v) want(%v)", col, g, w)
+				break
+			}
+		}
+	}
+}
+
+func TestTileCoderEncodeTo(t *testing.T) {
+	tc := newTestTileCoder(t)
+	v := mat.NewVecDense(2, []float64{0.2, 50})
+
+	indices := make([]float64, tc.NumTilings()+1)
+	tileCoded := mat.NewVecDense(tc.VecLength(), nil)
+	allocs := testing.AllocsPerRun(100, func() {
+		tc.EncodeIndicesTo(indices, v)
+		tc.EncodeTo(tileCoded, v)
+	})
+	if allocs != 0 {
+		t.Errorf("allocs: have(%v) want(%v)", allocs, 0)
+	}
+
+	want := tc.EncodeIndices(v)
+	for i := range want {
+		if indices[i] != want[i] {
+			t.Errorf("encodeIndicesTo: have(%v) want(%v)", indices, want)
+			break
+		}
+	}
+	if !mat.Equal(tileCoded, tc.Encode(v)) {
+		t.Errorf("encodeTo: have(%v) want(%v)", tileCoded, tc.Encode(v))
+	}
+
+	if err := tc.TryEncodeIndicesTo(indices[1:], v); err == nil {
+		t.Error("expected error with wrong destination length")
+	}
+}
+
+func TestTileCoderEncodeBatch(t *testing.T) {
+	tc := newTestTileCoder(t)
+	batch := mat.NewDense(2, 5, []float64{-3, 0, 0.2, 2, 9, -1, 5, 50, 500, 1})
+
+	for _, concurrency := range []int{0, 1} {
+		tc.SetConcurrency(concurrency)
+		tileCoded := tc.EncodeBatch(batch)
+		for col := 0; col < 5; col++ {
+			want := tc.Encode(batch.ColView(col))
+			if got := tileCoded.ColView(col); !mat.Equal(got, want) {
+				t.Errorf("concurrency %d: encodeBatch(%d): have(%v) want(%v)",
+					concurrency, col, got, want)
+			}
+		}
+	}
+}
+
+func TestTileCoderBatchShape(t *testing.T) {
+	tc := newTestTileCoder(t)
+
+	// Batches hold a vector in each column, so the number of vectors is
+	// independent of the number of input dimensions
+	for _, size := range []int{1, 2, 3, 7} {
+		batch := mat.NewDense(2, size, nil)
+		for col := 0; col < size; col++ {
+			batch.SetCol(col, []float64{float64(col) / 4, -float64(col)})
+		}
+
+		tileCoded := tc.EncodeBatch(batch)
+		indices := tc.EncodeIndicesBatch(batch)
+		if _, cols := tileCoded.Dims(); cols != size {
+			t.Errorf("encodeBatch(size %d): have(%d) columns want(%d)", size,
+				cols, size)
+		}
+		if _, cols := indices.Dims(); cols != size {
+			t.Errorf("encodeIndicesBatch(size %d): have(%d) columns want(%d)",
+				size, cols, size)
+		}
+		for col := 0; col < size; col++ {
+			v := batch.ColView(col)
+			if !mat.Equal(tileCoded.ColView(col), tc.Encode(v)) {
+				t.Errorf("encodeBatch(size %d, %d): differs from encode",
+					size, col)
+			}
+			want := tc.EncodeIndices(v)
+			if !mat.Equal(indices.ColView(col), mat.NewVecDense(len(want),
+				want)) {
+				t.Errorf("encodeIndicesBatch(size %d, %d): differs from "+
+					"encodeIndices", size, col)
+			}
+		}
+	}
+}
+
+func TestTileCoderEncodeBatchSparse(t *testing.T) {
+	tc, err := New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{2, 2}, {4, 4}, {3, 5}},
+		1,
+		true,
+		-1,
+		WithActivations(1, 0.5, 0.25),
+		WithBoundsPolicy(BoundsError),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	batch := mat.NewDense(2, 5, []float64{
+		0, 0.2, 0.5, 0.7, 0.99,
+		0.9, 0.1, 0.5, 0.3, 0,
+	})
+
+	n := len(tc.Tilings()) + 1
+	for _, sorted := range []bool{false, true} {
+		tc.SetSortedIndices(sorted)
+		indices, values := tc.EncodeBatchSparse(batch)
+		if len(indices) != 5*n || len(values) != 5*n {
+			t.Fatalf("sorted %v: encodeBatchSparse: have(%d, %d) elements "+
+				"want(%d)", sorted, len(indices), len(values), 5*n)
+		}
+
+		// The sparse columns hold the indices of EncodeIndicesBatch and
+		// the values of EncodeBatch
+		dense := tc.EncodeBatch(batch)
+		wantIndices := tc.EncodeIndicesBatch(batch)
+		for j := 0; j < 5; j++ {
+			for k := 0; k < n; k++ {
+				index, value := indices[j*n+k], values[j*n+k]
+				if want := int(wantIndices.At(k, j)); index != want {
+					t.Errorf("sorted %v: encodeBatchSparse(%d)[%d]: index "+
+						"have(%v) want(%v)", sorted, j, k, index, want)
+				}
+				if want := dense.At(index, j); value != want {
+					t.Errorf("sorted %v: encodeBatchSparse(%d)[%d]: value "+
+						"have(%v) want(%v)", sorted, j, k, value, want)
+				}
+			}
+		}
+	}
+
+	out := mat.NewDense(2, 1, []float64{2, 0})
+	if _, _, err := tc.TryEncodeBatchSparse(out); !errors.Is(err,
+		ErrOutOfBounds) {
+		t.Errorf("encodeBatchSparse(%v): have(%v) want(%v)",
+			mat.Formatted(out.T()), err, ErrOutOfBounds)
+	}
+}
+
+func TestTileCoderEncodeBatchTo(t *testing.T) {
+	tc := newTestTileCoder(t)
+	batches := []*mat.Dense{
+		mat.NewDense(2, 3, []float64{-3, 0, 0.2, -1, 5, 50}),
+		mat.NewDense(2, 3, []float64{2, 9, 0, 500, 1, 5}),
+	}
+
+	var tileCoded, indices mat.Dense
+	for _, batch := range batches {
+		tc.EncodeBatchTo(batch, &tileCoded)
+		if want := tc.EncodeBatch(batch); !mat.Equal(&tileCoded, want) {
+			t.Errorf("encodeBatchTo: have(%v) want(%v)",
+				mat.Formatted(&tileCoded), mat.Formatted(want))
+		}
+
+		tc.EncodeIndicesBatchTo(batch, &indices)
+		if want := tc.EncodeIndicesBatch(batch); !mat.Equal(&indices, want) {
+			t.Errorf("encodeIndicesBatchTo: have(%v) want(%v)",
+				mat.Formatted(&indices), mat.Formatted(want))
+		}
+	}
+
+	wrong := mat.NewDense(tc.VecLength(), 2, nil)
+	if err := tc.TryEncodeBatchTo(batches[0], wrong); err == nil {
+		t.Error("expected error with wrong destination shape")
+	}
+}
+
+func TestTileCoderEncodeIndicesStream(t *testing.T) {
+	tc := newTestTileCoder(t)
+	batch := mat.NewDense(2, 5, []float64{-3, 0, 0.2, 2, 9, -1, 5, 50, 500, 1})
+	want := tc.EncodeIndicesBatch(batch)
+
+	ch := make(chan mat.Vector)
+	go func() {
+		for col := 0; col < 5; col++ {
+			ch <- batch.ColView(col)
+		}
+		close(ch)
+	}()
+
+	var chunks []int
+	got := mat.NewDense(tc.NumTilings()+1, 5, nil)
+	err := tc.EncodeIndicesStream(ChanSource(ch), 2,
+		func(indices *mat.Dense) error {
+			rows, cols := indices.Dims()
+			col := 0
+			for _, c := range chunks {
+				col += c
+			}
+			got.Slice(0, rows, col, col+cols).(*mat.Dense).Copy(indices)
+			chunks = append(chunks, cols)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("could not encode stream: %v", err)
+	}
+
+	if len(chunks) != 3 || chunks[2] != 1 {
+		t.Errorf("chunks: have(%v) want(%v)", chunks, []int{2, 2, 1})
+	}
+	if !mat.Equal(got, want) {
+		t.Errorf("encodeIndicesStream: have(%v) want(%v)", mat.Formatted(got),
+			mat.Formatted(want))
+	}
+}
+
+func TestTileCoderStreamEncode(t *testing.T) {
+	tc, err := New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{2, 2}},
+		1,
+		true,
+		1e300,
+		WithBoundsPolicy(BoundsError),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+
+	in := "x,y\n0.1,0.1\n\n[0.9, 0.1]\n0.1 , 0.9"
+	var buf strings.Builder
+	if err := tc.StreamEncode(strings.NewReader(in), &buf,
+		StreamOptions{Header: true}); err != nil {
+		t.Fatal(err)
+	}
+	var want strings.Builder
+	for _, v := range [][]float64{{0.1, 0.1}, {0.9, 0.1}, {0.1, 0.9}} {
+		indices := tc.EncodeIndices(mat.NewVecDense(2, v))
+		fmt.Fprintf(&want, "[%d,%d]\n", int(indices[0]),
+			int(indices[1]))
+	}
+	if buf.String() != want.String() {
+		t.Errorf("streamEncode: have(%q) want(%q)", buf.String(),
+			want.String())
+	}
+
+	buf.Reset()
+	if err := tc.StreamEncode(strings.NewReader("0.1;0.1\n"), &buf,
+		StreamOptions{Input: StreamCSV, Comma: ';'}); err != nil {
+		t.Fatal(err)
+	}
+	first := strings.SplitN(want.String(), "\n", 2)[0] + "\n"
+	if buf.String() != first {
+		t.Errorf("streamEncode(;): have(%q) want(%q)", buf.String(), first)
+	}
+
+	err = tc.StreamEncode(strings.NewReader("0.1,0.1\n0.1,a\n"), &buf,
+		StreamOptions{})
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("streamEncode: have(%v) want error on line 2", err)
+	}
+	err = tc.StreamEncode(strings.NewReader("[0.1,0.1]\n"), &buf,
+		StreamOptions{Input: StreamCSV})
+	if err == nil {
+		t.Error("expected error parsing JSON array as CSV")
+	}
+	err = tc.StreamEncode(strings.NewReader("2,0.1\n"), &buf, StreamOptions{})
+	if !errors.Is(err, ErrOutOfBounds) {
+		t.Errorf("streamEncode: have(%v) want(%v)", err, ErrOutOfBounds)
+	}
+}
+
+func TestTileCoderEncodeString(t *testing.T) {
+	tc := newTestTileCoder(t)
+	v := mat.NewVecDense(2, []float64{0.2, 50})
+
+	s := tc.EncodeString(v)
+	if s != tc.EncodeString(mat.NewVecDense(2, []float64{0.2, 50})) {
+		t.Errorf("encodeString(%v): tokens of equal vectors differ", v)
+	}
+	if strings.ContainsAny(s, "+/=") {
+		t.Errorf("encodeString(%v): have(%q) want URL-safe token", v, s)
+	}
+
+	have, err := tc.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := tc.EncodeIndices(v); !reflect.DeepEqual(have, want) {
+		t.Errorf("decodeString(%q): have(%v) want(%v)", s, have, want)
+	}
+
+	// Tokens must hold a valid index for each tiling and the bias unit
+	token := func(indices ...float64) string {
+		data := IndexCodec{}.AppendIndices(nil, indices)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	outOfRange := append([]float64{}, have...)
+	outOfRange[0] = float64(tc.VecLength())
+	for _, bad := range []string{"", "!", s[:len(s)-1], s + "AA", token(0),
+		token(outOfRange...)} {
+		if _, err := tc.DecodeString(bad); err == nil {
+			t.Errorf("decodeString(%q): expected error", bad)
+		}
+	}
+}
+
+func TestTileCoderMemoryEstimate(t *testing.T) {
+	tc, err := New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{10, 10}, {10, 10}},
+		1,
+		true,
+		-1,
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+
+	// 201 features, so that each bitset takes 4 words
+	m := tc.MemoryEstimate(100)
+	want := MemoryReport{
+		BatchSize: 100,
+		Dense:     201 * 100 * 8,
+		Indices:   3 * 100 * 8,
+		Bitset:    4 * 100 * 8,
+		Coder:     m.Coder,
+	}
+	if m != want {
+		t.Errorf("memoryEstimate(100): have(%+v) want(%+v)", m, want)
+	}
+	if m.Coder <= 0 {
+		t.Errorf("memoryEstimate(100): have coder(%v) want > 0", m.Coder)
+	}
+
+	// Visit counts are part of the state of the coder
+	if err := tc.SetVisitCounts(make([]uint64, tc.VecLength())); err != nil {
+		t.Fatal(err)
+	}
+	if c := tc.MemoryEstimate(100).Coder; c < m.Coder+201*8 {
+		t.Errorf("memoryEstimate(100): have coder(%v) want >= %v with visit "+
+			"counts", c, m.Coder+201*8)
+	}
+
+	if s := m.String(); !strings.Contains(s, "157.0 KiB") {
+		t.Errorf("string: have(%q) want dense size 157.0 KiB", s)
+	}
+}
+
+func TestTileCoderMetrics(t *testing.T) {
+	tc := newTestTileCoder(t)
+	counters := &Counters{}
+	tc.SetMetrics(counters)
+
+	tc.Encode(mat.NewVecDense(2, []float64{0.2, 50}))
+	tc.EncodeIndicesBatch(mat.NewDense(2, 4, []float64{-3, 0, 0.2, 2, -1,
+		5, 50, 500}))
+
+	if counters.Calls() != 2 || counters.Vectors() != 5 {
+		t.Errorf("counts: have(%v calls, %v vectors) want(2 calls, 5 vectors)",
+			counters.Calls(), counters.Vectors())
+	}
+	if counters.MeanBatchSize() != 2.5 {
+		t.Errorf("meanBatchSize: have(%v) want(%v)", counters.MeanBatchSize(),
+			2.5)
+	}
+	if latency := counters.TilingLatency(); len(latency) != tc.NumTilings() {
+		t.Errorf("tilingLatency: have(%v tilings) want(%v tilings)",
+			len(latency), tc.NumTilings())
+	}
+
+	tc.SetMetrics(nil)
+	tc.Encode(mat.NewVecDense(2, []float64{0.2, 50}))
+	if counters.Calls() != 2 {
+		t.Errorf("calls: have(%v) want(%v)", counters.Calls(), 2)
+	}
+}
+
+func TestTileCoderDescribeFeature(t *testing.T) {
+	tc := newTestTileCoder(t)
+
+	f, err := tc.DescribeFeature(0)
+	if err != nil || !f.Bias {
+		t.Errorf("describeFeature(0): have(%+v, %v) want(bias)", f, err)
+	}
+
+	v := mat.NewVecDense(2, []float64{0.2, 50})
+	for tiling, index := range tc.EncodeIndices(v)[:tc.NumTilings()] {
+		f, err := tc.DescribeFeature(int(index))
+		if err != nil {
+			t.Fatalf("describeFeature(%v): %v", index, err)
+		}
+		if f.Tiling != tiling {
+			t.Errorf("describeFeature(%v): tiling: have(%v) want(%v)", index,
+				f.Tiling, tiling)
+		}
+
+		// Reconstruct the index of the tile within its tiling
+		tl := tc.tilings[tiling]
+		want, got := tl.Index(v), 0
+		for k := range f.Tile {
+			got += (f.Tile[k] + 1) * tl.strides[k]
+		}
+		if got != want {
+			t.Errorf("describeFeature(%v): tile: have(%v) want(%v)", index,
+				got, want)
+		}
+	}
+
+	if _, err := tc.DescribeFeature(tc.VecLength()); err == nil {
+		t.Error("expected error with out-of-range feature")
+	}
+}
+
+func TestTileCoderReconstruct(t *testing.T) {
+	tc := newUniformTileCoder(t)
+
+	v := mat.NewVecDense(4, []float64{0.1, 0.35, 0.6, 0.95})
+	encoded := tc.EncodeIndices(v)
+	indices := make([]int, len(encoded))
+	for i := range encoded {
+		indices[i] = int(encoded[i])
+	}
+
+	// Each tile is 1/6 wide, so the average of the active tile centers
+	// should be well within one tile of the encoded vector
+	got := tc.Reconstruct(indices)
+	for i := 0; i < v.Len(); i++ {
+		if math.Abs(got.AtVec(i)-v.AtVec(i)) > 1.0/6 {
+			t.Errorf("reconstruct: have(%v) want(%v)", mat.Formatted(got.T()),
+				mat.Formatted(v.T()))
+			break
+		}
+	}
+}
+
+func TestTileCoderSimilarity(t *testing.T) {
+	tc := newUniformTileCoder(t)
+	batch := mat.NewDense(4, 3, []float64{
+		0.1, 0.11, 0.9,
+		0.2, 0.21, 0.8,
+		0.3, 0.31, 0.7,
+		0.4, 0.41, 0.6,
+	})
+	a, b, c := batch.ColView(0), batch.ColView(1), batch.ColView(2)
+
+	if got := tc.Overlap(a, a); got != tc.NumTilings() {
+		t.Errorf("overlap(a, a): have(%v) want(%v)", got, tc.NumTilings())
+	}
+	if got := tc.Similarity(a, c); got != 0 {
+		t.Errorf("similarity(a, c): have(%v) want(%v)", got, 0)
+	}
+	near := tc.Similarity(a, b)
+	if near <= 0 || near >= 1 {
+		t.Errorf("similarity(a, b): have(%v) want(in (0, 1))", near)
+	}
+
+	gram := tc.Gram(batch)
+	want := [][]float64{{1, near, 0}, {near, 1, 0}, {0, 0, 1}}
+	for i := range want {
+		for j := range want[i] {
+			if gram.At(i, j) != want[i][j] {
+				t.Errorf("gram(%v, %v): have(%v) want(%v)", i, j,
+					gram.At(i, j), want[i][j])
+			}
+		}
+	}
+
+	// The Gram matrix of an empty batch is empty
+	if gram, err := tc.TryGram(&mat.Dense{}); err != nil || !gram.IsEmpty() {
+		t.Errorf("gram(empty): have(%v, %v) want(empty matrix)", gram, err)
+	}
+}
+
+func TestTileCoderGeneralizationReport(t *testing.T) {
+	tc, err := New(
+		mat.NewVecDense(3, []float64{0, 0, 0}),
+		mat.NewVecDense(3, []float64{1, 1, 1}),
+		[][]int{{2, 4}, {4}, {8}},
+		1,
+		false,
+		-1,
+		WithGroups([][]int{{0, 1}, {1}, {0}}),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+
+	report := tc.GeneralizationReport()
+	if len(report.Dims) != 2 {
+		t.Fatalf("dims: have(%v) want(%v)", len(report.Dims), 2)
+	}
+
+	d := report.Dims[0]
+	if d.Dim != 0 || len(d.Tilings) != 2 || d.Tilings[1] != 2 {
+		t.Errorf("dim 0 tilings: have(%v) want(%v)", d.Tilings, []int{0, 2})
+	}
+	if d.GeneralizationWidth != 0.5 {
+		t.Errorf("dim 0 generalization width: have(%v) want(%v)",
+			d.GeneralizationWidth, 0.5)
+	}
+	if want := (0.5 + 0.125) / 4; math.Abs(d.Resolution-want) > 1e-12 {
+		t.Errorf("dim 0 resolution: have(%v) want(%v)", d.Resolution, want)
+	}
+	if d.OffsetSpread != math.Abs(d.Offsets[0]-d.Offsets[1]) {
+		t.Errorf("dim 0 offset spread: have(%v) want(%v)", d.OffsetSpread,
+			math.Abs(d.Offsets[0]-d.Offsets[1]))
+	}
+
+	if report.String() == "" {
+		t.Error("empty report string")
+	}
+}
+
+func TestTileCoderFeatureRange(t *testing.T) {
+	tc := newTestTileCoder(t)
+
+	// Ranges should partition the features after the bias unit
+	next := 1
+	for k := 0; k < tc.NumTilings(); k++ {
+		start, end := tc.FeatureRange(k)
+		if start != next || end-start != tc.tilings[k].Tiles() {
+			t.Errorf("featureRange(%d): have([%v, %v)) want([%v, %v))", k,
+				start, end, next, next+tc.tilings[k].Tiles())
+		}
+		next = end
+	}
+	if next != tc.VecLength() {
+		t.Errorf("last feature: have(%v) want(%v)", next, tc.VecLength())
+	}
+
+	v := mat.NewVecDense(2, []float64{0.2, 50})
+	for k, index := range tc.EncodeIndices(v)[:tc.NumTilings()] {
+		if start, end := tc.FeatureRange(k); int(index) < start ||
+			int(index) >= end {
+			t.Errorf("tiling %d: index %v outside [%v, %v)", k, index, start,
+				end)
+		}
+	}
+}
+
+func TestTileCoderFormat(t *testing.T) {
+	tc := newTestTileCoder(t)
+
+	if got := fmt.Sprintf("%v", tc); got != tc.String() {
+		t.Errorf("%%v: have(%v) want(%v)", got, tc.String())
+	}
+
+	verbose := fmt.Sprintf("%+v", tc)
+	want := fmt.Sprintf("Features: %d", tc.VecLength())
+	if !strings.Contains(verbose, want) || !strings.Contains(verbose,
+		"Tiling 2") || !strings.Contains(verbose, "Offsets") {
+		t.Errorf("%%+v: have(%v) want(bias, features, and tilings)", verbose)
+	}
+
+	// The Go-syntax representations of a TileCoder and a Tiling are
+	// valid expressions building identical values
+	for _, v := range []interface {
+		GoString() string
+		MarshalJSON() ([]byte, error)
+	}{tc, tc.Tilings()[0]} {
+		got := fmt.Sprintf("%#v", v)
+		expr, err := parser.ParseExpr(got)
+		if err != nil {
+			t.Errorf("%%#v: have(%v) want(Go syntax): %v", got, err)
+			continue
+		}
+
+		// The only string literal is the JSON encoding of the value
+		var data string
+		ast.Inspect(expr, func(n ast.Node) bool {
+			if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				data, err = strconv.Unquote(lit.Value)
+			}
+			return true
+		})
+		want, _ := v.MarshalJSON()
+		if err != nil || data != string(want) {
+			t.Errorf("%%#v: have(%v) want to build(%s)", got, want)
+		}
+		if !strings.HasPrefix(got, "func() *gotile.") {
+			t.Errorf("%%#v: have(%v) want a *gotile value", got)
+		}
+	}
+}
+
+func TestTileCoderEncodeIndicesSA(t *testing.T) {
+	tc := newTestTileCoder(t)
+	state := mat.NewVecDense(2, []float64{0.2, 50})
+	want := tc.EncodeIndices(state)
+
+	const numActions = 3
+	for action := 0; action < numActions; action++ {
+		got := tc.EncodeIndicesSA(state, action, numActions)
+		for i := range got {
+			block := int(got[i]) / tc.VecLength()
+			if block != action || got[i]-float64(action*tc.VecLength()) !=
+				want[i] {
+				t.Errorf("encodeIndicesSA(%v): have(%v) want(%v in block %v)",
+					action, got, want, action)
+				break
+			}
+		}
+	}
+
+	if _, err := tc.TryEncodeIndicesSA(state, numActions, numActions); err ==
+		nil {
+		t.Error("expected error with out-of-range action")
+	}
+}
+
+func TestTileCoderVisitCounts(t *testing.T) {
+	tc := newUniformTileCoder(t)
+	if tc.VisitCounts() != nil {
+		t.Error("visitCounts: expected nil counts when not counting visits")
+	}
+	if err := tc.SetVisitCounts(make([]uint64, tc.VecLength())); err != nil {
+		t.Fatal(err)
+	}
+
+	v := mat.NewVecDense(4, []float64{0.1, 0.4, 0.6, 0.9})
+	if bonus := tc.Bonus(v); bonus != 1 {
+		t.Errorf("bonus(%v): have(%v) want(%v)", v, bonus, 1)
+	}
+
+	// Each encoding path counts a single visit of v
+	batch := mat.NewDense(4, 1, v.RawVector().Data)
+	tc.EncodeIndices(v)
+	tc.Encode(v)
+	tc.EncodeIndicesBatch(batch)
+	tc.EncodeBatch(batch)
+	tc.SetConcurrency(1)
+	tc.EncodeIndices(v)
+
+	const visits = 5
+	if count := tc.PseudoCount(v); count != visits {
+		t.Errorf("pseudoCount(%v): have(%v) want(%v)", v, count, visits)
+	}
+	if bonus, want := tc.Bonus(v), 1/math.Sqrt(visits+1); bonus != want {
+		t.Errorf("bonus(%v): have(%v) want(%v)", v, bonus, want)
+	}
+
+	counts := tc.VisitCounts()
+	if counts[0] != 0 {
+		t.Errorf("visitCounts: bias counted %v times", counts[0])
+	}
+	for _, index := range tc.EncodeIndices(v)[:tc.NumTilings()] {
+		if counts[int(index)] != visits {
+			t.Errorf("visitCounts[%v]: have(%v) want(%v)", index,
+				counts[int(index)], visits)
+		}
+	}
+
+	tc.ResetVisitCounts()
+	if count := tc.PseudoCount(v); count != 0 {
+		t.Errorf("pseudoCount(%v) after reset: have(%v) want(%v)", v, count, 0)
+	}
+	if err := tc.SetVisitCounts(make([]uint64, 1)); err == nil {
+		t.Error("expected error with wrong number of counts")
+	}
+}
+
+func TestTileCoderVisitCountsConcurrent(t *testing.T) {
+	tc := newUniformTileCoder(t)
+	if err := tc.SetVisitCounts(make([]uint64, tc.VecLength())); err != nil {
+		t.Fatal(err)
+	}
+	tc.SetConcurrency(1)
+
+	// Parallel workers encoding the same vector contend on the same
+	// tiles, and every visit must be counted
+	const workers, encodings = 8, 1000
+	v := mat.NewVecDense(4, []float64{0.1, 0.4, 0.6, 0.9})
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			indices := make([]float64, tc.NumTilings()+1)
+			for i := 0; i < encodings; i++ {
+				tc.EncodeIndicesTo(indices, v)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if count := tc.PseudoCount(v); count != workers*encodings {
+		t.Errorf("pseudoCount(%v): have(%v) want(%v)", v, count,
+			workers*encodings)
+	}
+	counts := tc.VisitCounts()
+	for _, index := range tc.EncodeIndices(v)[:tc.NumTilings()] {
+		if counts[int(index)] != workers*encodings {
+			t.Errorf("visitCounts[%v]: have(%v) want(%v)", index,
+				counts[int(index)], workers*encodings)
+		}
+	}
+}
+
+func TestTileCoderVisitShards(t *testing.T) {
+	newCoder := func(opts ...Option) *TileCoder {
+		tc, err := New(
+			mat.NewVecDense(1, []float64{0}),
+			mat.NewVecDense(1, []float64{1}),
+			[][]int{{4}, {2}},
+			1,
+			true,
+			1e300,
+			append(opts, WithVisitCounts())...,
+		)
+		if err != nil {
+			t.Fatalf("could not create tile coder: %v", err)
+		}
+		return tc
+	}
+
+	// A single shard is never exceeded, even when the cached shard of
+	// each processor is dropped by garbage collection
+	tc := newCoder(WithVisitShards(1))
+	v := mat.NewVecDense(1, []float64{0.1})
+	const encodings = 10
+	for i := 0; i < encodings; i++ {
+		tc.EncodeIndices(v)
+		runtime.GC()
+	}
+	if shards := tc.visitShards(); shards != 1 {
+		t.Errorf("visitShards: have(%v) want(%v)", shards, 1)
+	}
+	if count := tc.PseudoCount(v); count != encodings {
+		t.Errorf("pseudoCount(%v): have(%v) want(%v)", v, count, encodings)
+	}
+
+	// Restoring counts keeps the number of shards
+	if err := tc.SetVisitCounts(tc.VisitCounts()); err != nil {
+		t.Fatal(err)
+	}
+	if shards := tc.visitShards(); shards != 1 {
+		t.Errorf("visitShards after setVisitCounts: have(%v) want(%v)",
+			shards, 1)
+	}
+
+	// By default, there is a shard for each processor up to the maximum
+	want := runtime.GOMAXPROCS(0)
+	if want > maxVisitShards {
+		want = maxVisitShards
+	}
+	for _, tc := range []*TileCoder{newCoder(), newCoder(WithVisitShards(-1))} {
+		if shards := tc.visitShards(); shards != want {
+			t.Errorf("visitShards: have(%v) want(%v)", shards, want)
+		}
+	}
+}
+
+func TestTileCoderCoverageReport(t *testing.T) {
+	tc, err := New(
+		mat.NewVecDense(1, []float64{0}),
+		mat.NewVecDense(1, []float64{1}),
+		[][]int{{4}, {2}},
+		1,
+		true,
+		1e300,
+		WithVisitCounts(),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+
+	// Visit the first tile of each tiling twice and the last tile of
+	// each tiling twice
+	for _, x := range []float64{0.1, 0.1, 0.9, 0.9} {
+		tc.EncodeIndices(mat.NewVecDense(1, []float64{x}))
+	}
+
+	report := tc.CoverageReport()
+	if want := 4.0 / 6.0; math.Abs(report.Coverage-want) > 1e-12 {
+		t.Errorf("coverage: have(%v) want(%v)", report.Coverage, want)
+	}
+
+	c := report.Tilings[0]
+	if c.Visited != 2 || c.Tiles != 4 || c.Coverage != 0.5 {
+		t.Errorf("tiling 0: have(%v/%v) want(%v/%v)", c.Visited, c.Tiles, 2, 4)
+	}
+	if want := []uint64{2, 0, 0, 2}; !reflect.DeepEqual(c.Occupancy, want) {
+		t.Errorf("tiling 0 occupancy: have(%v) want(%v)", c.Occupancy, want)
+	}
+	if math.Abs(c.Entropy-math.Log(2)) > 1e-12 ||
+		math.Abs(c.NormalizedEntropy-0.5) > 1e-12 {
+		t.Errorf("tiling 0 entropy: have(%v, %v) want(%v, %v)", c.Entropy,
+			c.NormalizedEntropy, math.Log(2), 0.5)
+	}
+
+	c = report.Tilings[1]
+	if c.Coverage != 1 || math.Abs(c.NormalizedEntropy-1) > 1e-12 {
+		t.Errorf("tiling 1: have(%v, %v) want(%v, %v)", c.Coverage,
+			c.NormalizedEntropy, 1, 1)
+	}
+	if !strings.Contains(report.String(), "Normalized Entropy") {
+		t.Errorf("string: missing header in %q", report.String())
+	}
+}
+
+func TestTileCoderStateID(t *testing.T) {
+	tc, err := New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{4, 4}, {4, 4}},
+		1,
+		true,
+		-1,
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+
+	if n := tc.NumStates(0); n != 16 {
+		t.Errorf("numStates(0): have(%v) want(%v)", n, 16)
+	}
+
+	// State IDs ignore offsets, so both tilings aggregate states into
+	// the same cells
+	v := mat.NewVecDense(2, []float64{0.3, 0.6})
+	for tiling := 0; tiling < tc.NumTilings(); tiling++ {
+		if id := tc.StateID(v, tiling); id != 6 {
+			t.Errorf("stateID(%v, %v): have(%v) want(%v)", v, tiling, id, 6)
+		}
+	}
+
+	if _, err := tc.TryStateID(v, 2); err == nil {
+		t.Error("expected error with out-of-range tiling")
+	}
+}
+
+func TestTileCoderHeatmap(t *testing.T) {
+	tc, err := New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{2, 2}},
+		1,
+		true,
+		1e300,
+		WithBoundsPolicy(BoundsError),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+
+	weights := []float64{1, 10, 20, 30, 40}
+	point := mat.NewVecDense(2, nil)
+	unit := r1.Interval{Min: 0, Max: 1}
+	heatmap, err := tc.Heatmap(weights, point, 0, 1, unit, unit, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rows vary the second dimension and columns vary the first
+	want := mat.NewDense(2, 2, []float64{11, 31, 21, 41})
+	if !mat.Equal(heatmap, want) {
+		t.Errorf("heatmap: have(%v) want(%v)", mat.Formatted(heatmap),
+			mat.Formatted(want))
+	}
+
+	// Cells sum the weights of every tile they overlap, and cells
+	// outside the bounds of the tiling are NaN
+	tests := []struct {
+		xBounds, yBounds r1.Interval
+		rows, cols       int
+		want             []float64
+	}{
+		{unit, unit, 1, 2, []float64{31, 71}},
+		{unit, unit, 1, 1, []float64{101}},
+		{r1.Interval{Min: 0.25, Max: 0.75}, r1.Interval{Min: 0, Max: 0.5}, 1,
+			1, []float64{41}},
+		{r1.Interval{Min: 0, Max: 2}, unit, 1, 2,
+			[]float64{101, math.NaN()}},
+		{r1.Interval{Min: 0.5, Max: 1.5}, r1.Interval{Min: 0.5, Max: 1}, 1, 2,
+			[]float64{41, math.NaN()}},
+	}
+	for _, test := range tests {
+		heatmap, err := tc.Heatmap(weights, point, 0, 1, test.xBounds,
+			test.yBounds, test.rows, test.cols)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := mat.NewDense(test.rows, test.cols, test.want)
+		if !equalNaN(heatmap, want) {
+			t.Errorf("heatmap(%v, %v): have(%v) want(%v)", test.xBounds,
+				test.yBounds, mat.Formatted(heatmap), mat.Formatted(want))
+		}
+	}
+
+	if _, err := tc.Heatmap(weights[1:], point, 0, 1, unit, unit, 2, 2); err ==
+		nil {
+		t.Error("expected error with wrong number of weights")
+	}
+	if _, err := tc.Heatmap(weights, point, 0, 0, unit, unit, 2, 2); err ==
+		nil {
+		t.Error("expected error when slicing a dimension twice")
+	}
+	var dimErr *DimensionError
+	_, err = tc.Heatmap(weights, mat.NewVecDense(3, nil), 0, 1, unit, unit, 2,
+		2)
+	if !errors.As(err, &dimErr) || dimErr.Have != 3 || dimErr.Want != 2 {
+		t.Errorf("heatmap: have(%v) want a dimension error", err)
+	}
+	empty := r1.Interval{Min: 1, Max: 1}
+	if _, err := tc.Heatmap(weights, point, 0, 1, empty, unit, 2, 2); err ==
+		nil {
+		t.Error("expected error with empty bounds")
+	}
+}
+
+func TestTileCoderHeatmapWrap(t *testing.T) {
+	tc, err := New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{2, 2}},
+		1,
+		true,
+		1e300,
+		WithBoundsPolicy(BoundsWrap),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+
+	// Cells past the maximum of a dimension wrap around to its minimum
+	weights := []float64{1, 10, 20, 30, 40}
+	point := mat.NewVecDense(2, nil)
+	heatmap, err := tc.Heatmap(weights, point, 0, 1,
+		r1.Interval{Min: 0.75, Max: 1.75}, r1.Interval{Min: 0, Max: 0.5}, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := mat.NewDense(1, 2, []float64{41, 41})
+	if !mat.Equal(heatmap, want) {
+		t.Errorf("heatmap: have(%v) want(%v)", mat.Formatted(heatmap),
+			mat.Formatted(want))
+	}
+}
+
+func TestTileCoderHeatmapRotated(t *testing.T) {
+	tc, err := New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{4, 4}, {4, 4}},
+		1,
+		false,
+		-1,
+		WithRotation(),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	weights := make([]float64, tc.VecLength())
+	for i := range weights {
+		weights[i] = float64(i + 1)
+	}
+
+	// A cell much smaller than the tiles overlaps only the tiles
+	// activated by its center
+	center := mat.NewVecDense(2, []float64{0.3, 0.6})
+	want := 0.0
+	for _, index := range tc.EncodeIndices(center) {
+		want += weights[int(index)]
+	}
+	const eps = 1e-9
+	heatmap, err := tc.Heatmap(weights, center, 0, 1,
+		r1.Interval{Min: 0.3 - eps, Max: 0.3 + eps},
+		r1.Interval{Min: 0.6 - eps, Max: 0.6 + eps}, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := heatmap.At(0, 0); have != want {
+		t.Errorf("heatmap(%v): have(%v) want(%v)", center, have, want)
+	}
+
+	// A cell covering the bounds overlaps more tiles than its center
+	unit := r1.Interval{Min: 0, Max: 1}
+	heatmap, err = tc.Heatmap(weights, center, 0, 1, unit, unit, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := heatmap.At(0, 0); have <= want {
+		t.Errorf("heatmap: have(%v) want more than(%v)", have, want)
+	}
+}
+
+// equalNaN returns whether a and b are equal, treating NaNs as equal
+func equalNaN(a, b mat.Matrix) bool {
+	rows, cols := a.Dims()
+	if r, c := b.Dims(); r != rows || c != cols {
+		return false
+	}
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			x, y := a.At(i, j), b.At(i, j)
+			if x != y && !(math.IsNaN(x) && math.IsNaN(y)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestNewHierarchical(t *testing.T) {
+	tc, err := NewHierarchical(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		3,
+		2,
+		1,
+		true,
+		-1,
+		[]float64{1, 0.5, 0.25},
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+
+	if n, want := tc.VecLength(), 1+2*(1+4+16); n != want {
+		t.Errorf("vecLength: have(%v) want(%v)", n, want)
+	}
+	for k, tiling := range tc.Tilings() {
+		if bins, want := tiling.Bins()[0], 1<<(k/2); bins != want {
+			t.Errorf("tiling %v bins: have(%v) want(%v)", k, bins, want)
+		}
+	}
+
+	// Each level contributes its scale for each of its tilings
+	v := mat.NewVecDense(2, []float64{0.3, 0.7})
+	encoded := tc.Encode(v)
+	if sum := mat.Sum(encoded); sum != 1+2*(1+0.5+0.25) {
+		t.Errorf("encode(%v): features sum to %v want(%v)", v, sum,
+			1+2*(1+0.5+0.25))
+	}
+	batch := tc.EncodeBatch(mat.NewDense(2, 1, []float64{0.3, 0.7}))
+	if !mat.Equal(batch.ColView(0), encoded) {
+		t.Error("encodeBatch: batch encoding differs from encode")
+	}
+	tc.SetConcurrency(1)
+	if !mat.Equal(tc.Encode(v), encoded) {
+		t.Error("encode: concurrent encoding differs from sequential")
+	}
+
+	// Scales are serialized with the tile coder
+	for _, marshal := range []func() ([]byte, error){
+		func() ([]byte, error) { return json.Marshal(tc) },
+		tc.MarshalBinary,
+	} {
+		data, err := marshal()
+		if err != nil {
+			t.Fatalf("could not marshal: %v", err)
+		}
+		var loaded TileCoder
+		if data[0] == '{' {
+			err = json.Unmarshal(data, &loaded)
+		} else {
+			err = loaded.UnmarshalBinary(data)
+		}
+		if err != nil {
+			t.Fatalf("could not unmarshal: %v", err)
+		}
+		if !mat.Equal(loaded.Encode(v), encoded) {
+			t.Error("encode: loaded tile coder encodes differently")
+		}
+	}
+
+	if _, err := NewHierarchical(mat.NewVecDense(1, []float64{0}),
+		mat.NewVecDense(1, []float64{1}), 2, 1, 1, false, -1,
+		[]float64{1}); err == nil {
+		t.Error("expected error with wrong number of level scales")
+	}
+}
+
+func TestNewCMAC(t *testing.T) {
+	const cells, generalization = 10, 4
+	tc, err := NewCMAC(
+		mat.NewVecDense(1, []float64{0}),
+		mat.NewVecDense(1, []float64{10}),
+		[]int{cells},
+		generalization,
+		false,
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+
+	if n := tc.VecLength(); n != generalization*4 {
+		t.Errorf("vecLength: have(%v) want(%v)", n, generalization*4)
+	}
+
+	// Layer k places cell c in tile ⌊(c + k) / generalization⌋
+	v := mat.NewVecDense(1, []float64{2.5})
+	want := []float64{0, 4, 9, 13}
+	if got := tc.EncodeIndices(v); !reflect.DeepEqual(got, want) {
+		t.Errorf("encodeIndices(%v): have(%v) want(%v)", v, got, want)
+	}
+
+	// Inputs share one association cell for each cell closer than the
+	// generalization parameter
+	for a := 0; a < cells; a++ {
+		for b := 0; b < cells; b++ {
+			va := mat.NewVecDense(1, []float64{float64(a) + 0.5})
+			vb := mat.NewVecDense(1, []float64{float64(b) + 0.5})
+			distance := a - b
+			if distance < 0 {
+				distance = -distance
+			}
+			want := generalization - distance
+			if want < 0 {
+				want = 0
+			}
+			if overlap := tc.Overlap(va, vb); overlap != want {
+				t.Errorf("overlap(%v, %v): have(%v) want(%v)", a, b, overlap,
+					want)
+			}
+		}
+	}
+
+	if _, err := NewCMAC(mat.NewVecDense(1, []float64{0}),
+		mat.NewVecDense(1, []float64{1}), []int{4}, 2, false,
+		WithScales(ScaleLog)); err == nil {
+		t.Error("expected error with option placing tiles")
+	}
+}
+
+func TestTileCoderMasked(t *testing.T) {
+	tc := newUniformTileCoder(t)
+	mask := tc.DropoutMask(0.5, nil)
+	mask[0], mask[1] = true, false
+
+	v := mat.NewVecDense(4, []float64{0.1, 0.4, 0.6, 0.9})
+	all := tc.EncodeIndices(v)
+	var want []float64
+	for k, index := range all[:tc.NumTilings()] {
+		if mask[k] {
+			want = append(want, index)
+		}
+	}
+	want = append(want, 0)
+
+	have := tc.EncodeIndicesMasked(v, mask)
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("encodeIndicesMasked: have(%v) want(%v)", have, want)
+	}
+
+	encoded := tc.EncodeMasked(v, mask)
+	if sum := mat.Sum(encoded); sum != float64(len(want)) {
+		t.Errorf("encodeMasked: have(%v) active features want(%v)", sum,
+			len(want))
+	}
+	for _, index := range want {
+		if encoded.AtVec(int(index)) != 1.0 {
+			t.Errorf("encodeMasked: feature %v is not active", index)
+		}
+	}
+
+	batch := mat.NewDense(4, 1, mat.Col(nil, 0, v))
+	if !mat.Equal(tc.EncodeBatchMasked(batch, mask).ColView(0), encoded) {
+		t.Error("encodeBatchMasked: batch encoding differs from encodeMasked")
+	}
+	if _, err := tc.TryEncodeMasked(v, mask[1:]); err == nil {
+		t.Error("expected error with mask of wrong length")
+	}
+}
+
+func TestTileCoderActivations(t *testing.T) {
+	minDims := mat.NewVecDense(2, []float64{0, 0})
+	maxDims := mat.NewVecDense(2, []float64{1, 1})
+	bins := [][]int{{4, 4}, {4, 4}, {2, 2}, {2, 2}}
+	v := mat.NewVecDense(2, []float64{0.3, 0.7})
+
+	normalized, err := New(minDims, maxDims, bins, 1, true, -1,
+		WithNormalizedActivations())
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	encoded := normalized.Encode(v)
+	if sum := mat.Sum(encoded); math.Abs(sum-2) > 1e-12 {
+		t.Errorf("encode: have(%v) sum want(%v)", sum, 2)
+	}
+
+	weights := []float64{0.5, 0.25, 2, 4}
+	weighted, err := New(minDims, maxDims, bins, 1, true, -1,
+		WithActivations(weights...))
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	encoded = weighted.Encode(v)
+	indices := weighted.EncodeIndices(v)
+	for k, w := range weights {
+		if have := encoded.AtVec(int(indices[k])); have != w {
+			t.Errorf("encode: tiling %d: have(%v) want(%v)", k, have, w)
+		}
+	}
+
+	// Batches and index vectors are encoded with the same activations
+	batch := mat.NewDense(2, 1, mat.Col(nil, 0, v))
+	if !mat.Equal(weighted.EncodeBatch(batch).ColView(0), encoded) {
+		t.Error("encodeBatch: batch encoding differs from encode")
+	}
+	if !mat.Equal(weighted.ToVector(mat.NewVecDense(len(indices), indices)),
+		encoded) {
+		t.Error("toVector: vector differs from encode")
+	}
+
+	if _, err := New(minDims, maxDims, bins, 1, true, -1,
+		WithActivations(1, 2)); err == nil {
+		t.Error("expected error with wrong number of activations")
+	}
+}
+
+func TestTileCoderDimensionError(t *testing.T) {
+	tc := newUniformTileCoder(t)
+	if n := tc.InputDims(); n != 4 {
+		t.Errorf("inputDims: have(%v) want(%v)", n, 4)
+	}
+
+	for _, n := range []int{3, 5} {
+		_, err := tc.TryEncode(mat.NewVecDense(n, nil))
+		var dimErr *DimensionError
+		if !errors.As(err, &dimErr) || dimErr.Have != n || dimErr.Want != 4 {
+			t.Errorf("tryEncode(%d dimensions): have(%v) want(*DimensionError)",
+				n, err)
+		}
+
+		// The rows of a batch must match the input dimensions, however
+		// many vectors the batch holds
+		for _, size := range []int{1, n, 7} {
+			batch := mat.NewDense(n, size, nil)
+			if _, err := tc.TryEncodeBatch(batch); !errors.As(err, &dimErr) ||
+				dimErr.Have != n || dimErr.Want != 4 {
+				t.Errorf("tryEncodeBatch(%d × %d): have(%v) want(%v)", n, size,
+					err, &DimensionError{Have: n, Want: 4})
+			}
+			if _, err := tc.TryEncodeIndicesBatch(batch); !errors.Is(err,
+				ErrDimension) {
+				t.Errorf("tryEncodeIndicesBatch(%d × %d): have(%v) want(%v)",
+					n, size, err, ErrDimension)
+			}
+		}
+	}
+
+	// The input dimensions are serialized
+	data, err := tc.MarshalBinary()
+	if err != nil {
+		t.Fatalf("could not marshal: %v", err)
+	}
+	var loaded TileCoder
+	if err := loaded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("could not unmarshal: %v", err)
+	}
+	if n := loaded.InputDims(); n != 4 {
+		t.Errorf("inputDims after unmarshal: have(%v) want(%v)", n, 4)
+	}
+
+	defer func() {
+		if err, ok := recover().(error); !ok || !errors.Is(err, ErrDimension) {
+			t.Errorf("encode: have(panic %v) want(panic %v)", err,
+				ErrDimension)
+		}
+	}()
+	tc.Encode(mat.NewVecDense(2, nil))
+}
+
+func TestTileCoderIndexOrder(t *testing.T) {
+	minDims := mat.NewVecDense(3, []float64{0, 0, 0})
+	maxDims := mat.NewVecDense(3, []float64{1, 1, 1})
+	bins := [][]int{{2, 3}, {5}, {4}, {3, 3}, {2}}
+	groups := [][]int{{0, 1}, {2}, {1}, {0, 2}, {0}}
+	batch := mat.NewDense(3, 4, []float64{
+		0.1, 0.9, 0.5, 0.3,
+		0.2, 0.8, 0.5, 0.6,
+		0.3, 0.7, 0.5, 0.9,
+	})
+
+	for _, sorted := range []bool{false, true} {
+		opts := []Option{WithGroups(groups), WithConcurrency(1),
+			WithWorkers(4)}
+		if sorted {
+			opts = append(opts, WithSortedIndices())
+		}
+		tc, err := New(minDims, maxDims, bins, 1, true, -1, opts...)
+		if err != nil {
+			t.Fatalf("could not create tile coder: %v", err)
+		}
+
+		indices := tc.EncodeIndicesBatch(batch)
+		for j := 0; j < 4; j++ {
+			v := batch.ColView(j)
+			have := tc.EncodeIndices(v)
+			if !reflect.DeepEqual(have, mat.Col(nil, j, indices)) {
+				t.Errorf("encodeIndicesBatch(%v): have(%v) want(%v)",
+					mat.Formatted(v.T()), mat.Col(nil, j, indices), have)
+			}
+
+			// The index of tiling k is at position k, or k + 1 when
+			// sorted, with the bias unit last or first respectively
+			tilings := have[:tc.NumTilings()]
+			bias := have[tc.NumTilings()]
+			if sorted {
+				tilings, bias = have[1:], have[0]
+				if !sort.Float64sAreSorted(have) {
+					t.Errorf("encodeIndices(%v): have(%v) which is not sorted",
+						mat.Formatted(v.T()), have)
+				}
+			}
+			if bias != 0 {
+				t.Errorf("encodeIndices(%v): have(%v) bias index want(0)",
+					mat.Formatted(v.T()), bias)
+			}
+			for k, index := range tilings {
+				if start, end := tc.FeatureRange(k); int(index) < start ||
+					int(index) >= end {
+					t.Errorf("encodeIndices(%v): index %v of tiling %d "+
+						"outside [%d, %d)", mat.Formatted(v.T()), index, k,
+						start, end)
+				}
+			}
+		}
+	}
+}
+
+func TestTileCoderSortedSimilarity(t *testing.T) {
+	tc := newUniformTileCoder(t)
+	batch := mat.NewDense(4, 3, []float64{
+		0.1, 0.11, 0.9,
+		0.2, 0.21, 0.8,
+		0.3, 0.31, 0.7,
+		0.4, 0.41, 0.6,
+	})
+	a, b, c := batch.ColView(0), batch.ColView(1), batch.ColView(2)
+	near, far := tc.Overlap(a, b), tc.Overlap(a, c)
+	gram := tc.Gram(batch)
+
+	// Moving the bias unit to the front must not change which tilings
+	// are compared
+	tc.SetSortedIndices(true)
+	if have := tc.Overlap(a, b); have != near {
+		t.Errorf("overlap(a, b): have(%v) want(%v)", have, near)
+	}
+	if have := tc.Overlap(a, c); have != far || far != 0 {
+		t.Errorf("overlap(a, c): have(%v) want(%v)", have, 0)
+	}
+	if have := tc.Gram(batch); !mat.Equal(have, gram) {
+		t.Errorf("gram: have(%v) want(%v)", mat.Formatted(have),
+			mat.Formatted(gram))
+	}
+}
+
+func TestTileCoderToIndices(t *testing.T) {
+	tc := newUniformTileCoder(t)
+	v := mat.NewVecDense(4, []float64{0.2, 0.4, 0.6, 0.8})
+	want := tc.EncodeIndices(v)
+	encoded := tc.Encode(v)
+
+	have, err := tc.TryToIndices(encoded)
+	if err != nil {
+		t.Fatalf("toIndices: %v", err)
+	}
+	if !reflect.DeepEqual(have.RawVector().Data, want) {
+		t.Errorf("toIndices: have(%v) want(%v)", have.RawVector().Data, want)
+	}
+	if !mat.Equal(tc.ToVector(have), encoded) {
+		t.Error("toVector: vector differs from encode")
+	}
+
+	malformed := map[string]func(*mat.VecDense){
+		"non-binary feature":   func(e *mat.VecDense) { e.SetVec(int(want[0]), 0.5) },
+		"missing tiling":       func(e *mat.VecDense) { e.SetVec(int(want[1]), 0) },
+		"extra active feature": func(e *mat.VecDense) { e.SetVec(int(want[2])+1, 1) },
+		"inactive bias unit":   func(e *mat.VecDense) { e.SetVec(0, 0) },
+	}
+	for name, corrupt := range malformed {
+		e := mat.VecDenseCopyOf(encoded)
+		corrupt(e)
+		if _, err := tc.TryToIndices(e); err == nil {
+			t.Errorf("toIndices(%v): expected error", name)
+		}
+	}
+	if _, err := tc.TryToIndices(mat.NewVecDense(3, nil)); err == nil {
+		t.Error("toIndices: expected error with vector of wrong length")
+	}
+}
+
+func TestTileCoderConsistencyCheck(t *testing.T) {
+	minDims := mat.NewVecDense(2, []float64{0, 0})
+	maxDims := mat.NewVecDense(2, []float64{1, 1})
+	batch := mat.NewDense(2, 5, []float64{
+		-0.5, 0.1, 0.5, 0.9, 1.5,
+		0.3, 0.2, 0.5, 0.8, 0.7,
+	})
+
+	configs := map[string][]Option{
+		"default":    nil,
+		"extend":     {WithBoundsPolicy(BoundsExtend)},
+		"concurrent": {WithConcurrency(1), WithWorkers(3)},
+		"sorted":     {WithSortedIndices(), WithNormalizedActivations()},
+		"groups":     {WithGroups([][]int{{0, 1}, {0}, {1}})},
+	}
+	for name, opts := range configs {
+		bins := [][]int{{4, 4}, {4, 4}, {4, 4}}
+		if name == "groups" {
+			bins = [][]int{{4, 4}, {4}, {4}}
+		}
+		tc, err := New(minDims, maxDims, bins, 1, true, -1,
+			append(opts, WithVisitCounts())...)
+		if err != nil {
+			t.Fatalf("could not create %v tile coder: %v", name, err)
+		}
+		if err := tc.ConsistencyCheck(batch); err != nil {
+			t.Errorf("consistencyCheck(%v): %v", name, err)
+		}
+		for _, count := range tc.VisitCounts() {
+			if count != 0 {
+				t.Errorf("consistencyCheck(%v): visits were counted", name)
+				break
+			}
+		}
+	}
+
+	// A single-vector path which diverges from the batch path is
+	// reported
+	tc, err := New(minDims, maxDims, [][]int{{4, 4}, {4, 4}}, 1, true, -1)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	tc.uniform.offsets[0][1] += 0.25
+	if err := tc.ConsistencyCheck(batch); err == nil {
+		t.Error("consistencyCheck: expected error with divergent paths")
+	}
+}
+
+func TestTileCoderFeatureSpace(t *testing.T) {
+	minDims := mat.NewVecDense(2, nil)
+	maxDims := mat.NewVecDense(2, []float64{1, 1})
+
+	// A single tiling with more than MaxFeatures tiles
+	bins := [][]int{{1 << 27, 1 << 27}}
+	if _, err := New(minDims, maxDims, bins, 1, false, 1e300); !errors.Is(err,
+		ErrFeatureSpace) {
+		t.Errorf("new(%v): have(%v) want(%v)", bins, err, ErrFeatureSpace)
+	}
+
+	// Tilings which each fit, but not together
+	bins = [][]int{{1 << 26, 1 << 26}, {1 << 26, 1 << 26}}
+	if _, err := New(minDims, maxDims, bins, 1, true, 1e300); !errors.Is(err,
+		ErrFeatureSpace) {
+		t.Errorf("new(%v): have(%v) want(%v)", bins, err, ErrFeatureSpace)
+	}
+
+	tc := newUniformTileCoder(t)
+	state := mat.NewVecDense(4, []float64{0.5, 0.5, 0.5, 0.5})
+	numActions := MaxFeatures/tc.VecLength() + 1
+	if _, err := tc.TryEncodeIndicesSA(state, 0, numActions); !errors.Is(err,
+		ErrFeatureSpace) {
+		t.Errorf("tryEncodeIndicesSA(%v actions): have(%v) want(%v)",
+			numActions, err, ErrFeatureSpace)
+	}
+	if _, err := tc.TryEncodeIndicesSA(state, 0, numActions-1); err != nil {
+		t.Errorf("tryEncodeIndicesSA(%v actions): %v", numActions-1, err)
+	}
+}
+
+func TestTileCoderPanicFree(t *testing.T) {
+	tc := newUniformTileCoder(t)
+	short := mat.NewVecDense(2, []float64{0.5, 0.5})
+	var nilVec *mat.VecDense
+
+	errs := map[string]error{}
+	_, errs["tryEncode(nil)"] = tc.TryEncode(nil)
+	_, errs["tryEncode(nil *VecDense)"] = tc.TryEncode(nilVec)
+	_, errs["tryEncodeIndices(nil)"] = tc.TryEncodeIndices(nil)
+	_, errs["tryEncodeBatch(nil)"] = tc.TryEncodeBatch(nil)
+	_, errs["tryEncodeIndicesBatch(nil)"] = tc.TryEncodeIndicesBatch(nil)
+	errs["tryEncodeTo(nil dst)"] = tc.TryEncodeTo(nil, short)
+	errs["tryEncodeBatchTo(nil dst)"] = tc.TryEncodeBatchTo(eye(4), nil)
+	_, errs["tryToVector(nil)"] = tc.TryToVector(nil)
+	_, errs["tryIndex(nil)"] = tc.Tilings()[0].TryIndex(nil)
+	_, errs["tryCell(nil)"] = tc.Tilings()[0].TryCell(nil)
+	_, errs["new(nil)"] = New(nil, nil, [][]int{{2}}, 1, false, 1e300)
+	for name, err := range errs {
+		if !errors.Is(err, ErrNilInput) {
+			t.Errorf("%v: have(%v) want(%v)", name, err, ErrNilInput)
+		}
+	}
+
+	errs = map[string]error{}
+	_, errs["tryEncode"] = tc.TryEncode(short)
+	_, errs["tryIndex"] = tc.Tilings()[0].TryIndex(short)
+	_, errs["tryIndexBatch"] = tc.Tilings()[0].TryIndexBatch(eye(2))
+	_, errs["tryOverlap"] = tc.TryOverlap(short, short)
+	for name, err := range errs {
+		if !errors.Is(err, ErrDimension) {
+			t.Errorf("%v: have(%v) want(%v)", name, err, ErrDimension)
+		}
+	}
+
+	if _, err := tc.TryToVector(mat.NewVecDense(1,
+		[]float64{float64(tc.VecLength())})); err == nil {
+		t.Error("tryToVector: expected error with index out of range")
+	}
+	if _, err := tc.TryToVector(mat.NewVecDense(1, []float64{1.5})); err == nil {
+		t.Error("tryToVector: expected error with non-integer index")
+	}
+	if _, _, err := tc.TryFeatureRange(tc.NumTilings()); err == nil {
+		t.Error("tryFeatureRange: expected error with tiling out of range")
+	}
+	if _, err := tc.TryNumStates(-1); err == nil {
+		t.Error("tryNumStates: expected error with tiling out of range")
+	}
+	if _, err := tc.TryPseudoCount(short); err == nil {
+		t.Error("tryPseudoCount: expected error without visit counts")
+	}
+	if _, err := tc.TryCoverageReport(); err == nil {
+		t.Error("tryCoverageReport: expected error without visit counts")
+	}
+	if _, err := tc.TryReconstruct([]int{tc.VecLength()}); err == nil {
+		t.Error("tryReconstruct: expected error with index out of range")
+	} else if errors.Unwrap(err) == nil {
+		t.Errorf("tryReconstruct: have(%v) want a wrapped error", err)
+	}
+	if _, err := tc.Tilings()[0].TryTileCenter(-1); err == nil {
+		t.Error("tryTileCenter: expected error with index out of range")
+	}
+}
+
+func TestTileCoderVisitHeatmap(t *testing.T) {
+	minDims := mat.NewVecDense(2, []float64{0, 0})
+	maxDims := mat.NewVecDense(2, []float64{1, 1})
+	tc, err := New(minDims, maxDims, [][]int{{4, 4}, {4, 4}}, 1, true, 1e300,
+		WithVisitCounts())
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	tc.Encode(mat.NewVecDense(2, []float64{0.1, 0.1}))
+	tc.Encode(mat.NewVecDense(2, []float64{0.2, 0.1}))
+	tc.Encode(mat.NewVecDense(2, []float64{0.9, 0.6}))
+
+	bounds := r1.Interval{Min: 0, Max: 1}
+	heatmap, err := tc.VisitHeatmap(minDims, 0, 1, bounds, bounds, 4, 4)
+	if err != nil {
+		t.Fatalf("visitHeatmap: %v", err)
+	}
+	want := mat.NewDense(4, 4, []float64{
+		2, 0, 0, 0,
+		0, 0, 0, 0,
+		0, 0, 0, 1,
+		0, 0, 0, 0,
+	})
+	if !mat.Equal(heatmap, want) {
+		t.Errorf("visitHeatmap: have(%v) want(%v)", mat.Formatted(heatmap),
+			mat.Formatted(want))
+	}
+
+	// Computing a heatmap does not count visits
+	if have := tc.PseudoCount(minDims); have != 2 {
+		t.Errorf("pseudoCount: have(%v) want(%v)", have, 2)
+	}
+
+	tc, err = New(minDims, maxDims, [][]int{{4, 4}}, 1, true, 1e300)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	if _, err := tc.VisitHeatmap(minDims, 0, 1, bounds, bounds, 4,
+		4); err == nil {
+		t.Error("visitHeatmap: expected error without visit counts")
+	}
+}
+
+func TestTileCoderDebugString(t *testing.T) {
+	minDims := mat.NewVecDense(2, []float64{0, 0})
+	maxDims := mat.NewVecDense(2, []float64{1, 1})
+	tc, err := New(minDims, maxDims, [][]int{{4, 3}, {5}}, 1, false, 1e300,
+		WithGroups([][]int{{0, 1}, {1}}))
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+
+	// Compare the drawings, ignoring the negligible offsets in headers
+	v := mat.NewVecDense(2, []float64{0.3, 0.9})
+	want := `tiling 0: dims [0 1]
+  . # . .
+  . . . .
+  . . . .
+
+tiling 1: dims [1]
+  . . . . #
+`
+	lines := strings.Split(tc.DebugString(v), "\n")
+	for i, line := range lines {
+		if j := strings.Index(line, ", offsets"); j >= 0 {
+			lines[i] = line[:j]
+		}
+	}
+	if have := strings.Join(lines, "\n"); have != want {
+		t.Errorf("debugString(%v): have(\n%v) want(\n%v)", v, have, want)
+	}
+
+	// Vectors which cannot be encoded are reported for each tiling
+	tc, err = New(minDims, maxDims, [][]int{{2}}, 1, false, 1e300,
+		WithBoundsPolicy(BoundsError), WithDims(0))
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	v = mat.NewVecDense(2, []float64{2, 0})
+	if have := tc.DebugString(v); !strings.Contains(have,
+		ErrOutOfBounds.Error()) {
+		t.Errorf("debugString(%v): have(%v) want(%v)", v, have, ErrOutOfBounds)
+	}
+}
+
+func TestTileCoderReceptiveField(t *testing.T) {
+	tc, err := New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{2, 2}, {4, 4}},
+		1,
+		true,
+		1e300,
+		WithBoundsPolicy(BoundsError),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+
+	// Find the fine tile covering the bottom left cell of the grid
+	index := make([]int, 2)
+	tc.activeFeatures(mat.NewVecDense(2, []float64{0.1, 0.1}), index)
+	feature := index[1]
+
+	point := mat.NewVecDense(2, nil)
+	unit := r1.Interval{Min: 0, Max: 1}
+	field, err := tc.ReceptiveField(feature, point, 0, 1, unit, unit, 4, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if field.Feature != feature || field.Tiling != 1 {
+		t.Errorf("receptiveField(%d): have(feature %d, tiling %d) "+
+			"want(feature %d, tiling 1)", feature, field.Feature, field.Tiling,
+			feature)
+	}
+
+	wantField := mat.NewDense(4, 4, []float64{
+		1, 0, 0, 0,
+		0, 0, 0, 0,
+		0, 0, 0, 0,
+		0, 0, 0, 0,
+	})
+	if !mat.Equal(field.Field, wantField) {
+		t.Errorf("receptiveField(%d): have(%v) want(%v)", feature,
+			mat.Formatted(field.Field), mat.Formatted(wantField))
+	}
+
+	// The coarse tile sharing the receptive field covers the bottom
+	// left quadrant
+	wantOverlap := mat.NewDense(4, 4, []float64{
+		1, 1, 0, 0,
+		1, 1, 0, 0,
+		0, 0, 0, 0,
+		0, 0, 0, 0,
+	})
+	if !mat.Equal(field.Overlap, wantOverlap) {
+		t.Errorf("receptiveField(%d): have overlap(%v) want(%v)", feature,
+			mat.Formatted(field.Overlap), mat.Formatted(wantOverlap))
+	}
+
+	// Cells outside the bounds of the tilings are NaN
+	wide := r1.Interval{Min: 0, Max: 2}
+	field, err = tc.ReceptiveField(feature, point, 0, 1, wide, unit, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !math.IsNaN(field.Field.At(0, 1)) || !math.IsNaN(field.Overlap.At(0,
+		1)) {
+		t.Errorf("receptiveField(%d): have(%v) want NaN outside bounds",
+			feature, mat.Formatted(field.Field))
+	}
+
+	if _, err := tc.ReceptiveField(0, point, 0, 1, unit, unit, 4, 4); err ==
+		nil {
+		t.Error("expected error for the bias unit")
+	}
+	if _, err := tc.ReceptiveField(tc.VecLength(), point, 0, 1, unit, unit,
+		4, 4); err == nil {
+		t.Error("expected error for a feature out of range")
+	}
+	if _, err := tc.ReceptiveField(feature, nil, 0, 1, unit, unit, 4,
+		4); !errors.Is(err, ErrNilInput) {
+		t.Errorf("receptiveField(nil): have(%v) want(%v)", err, ErrNilInput)
+	}
+}
+
+func TestTileCoderWriteSVMLight(t *testing.T) {
+	tc, err := New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{2, 2}},
+		1,
+		true,
+		1e300,
+		WithBoundsPolicy(BoundsError),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	b := mat.NewDense(2, 2, []float64{
+		0.1, 0.9,
+		0.1, 0.1,
+	})
+
+	var buf strings.Builder
+	if err := tc.WriteSVMLight(&buf, b, []float64{1, -0.5}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "1 1:1 2:1\n-0.5 1:1 4:1\n"; buf.String() != want {
+		t.Errorf("writeSVMLight: have(%q) want(%q)", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := tc.WriteSVMLight(&buf, b, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := "1:1 2:1\n1:1 4:1\n"; buf.String() != want {
+		t.Errorf("writeSVMLight(nil): have(%q) want(%q)", buf.String(), want)
+	}
+
+	if err := tc.WriteSVMLight(&buf, b, []float64{1}); err == nil {
+		t.Error("expected error with wrong number of labels")
+	}
+	b.Set(0, 0, 2)
+	if err := tc.WriteSVMLight(&buf, b, nil); !errors.Is(err,
+		ErrOutOfBounds) {
+		t.Errorf("writeSVMLight: have(%v) want(%v)", err, ErrOutOfBounds)
+	}
+}
+
+func TestTileCoderWriteMatrixMarket(t *testing.T) {
+	tc, err := New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{2, 2}},
+		1,
+		true,
+		1e300,
+		WithBoundsPolicy(BoundsError),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	b := mat.NewDense(2, 2, []float64{
+		0.1, 0.9,
+		0.1, 0.1,
+	})
+
+	var buf strings.Builder
+	if err := tc.WriteMatrixMarket(&buf, b); err != nil {
+		t.Fatal(err)
+	}
+	want := "%%MatrixMarket matrix coordinate real general\n" +
+		"2 5 4\n1 1 1\n1 2 1\n2 1 1\n2 4 1\n"
+	if buf.String() != want {
+		t.Errorf("writeMatrixMarket: have(%q) want(%q)", buf.String(), want)
+	}
+
+	b.Set(0, 0, 2)
+	if err := tc.WriteMatrixMarket(&buf, b); !errors.Is(err,
+		ErrOutOfBounds) {
+		t.Errorf("writeMatrixMarket: have(%v) want(%v)", err, ErrOutOfBounds)
+	}
+}
+
+func TestTileCoderFitOffsets(t *testing.T) {
+	// Tilings which are not offset all quantize the data identically
+	tc, err := New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{4, 4}, {4, 4}, {4, 4}, {4, 4}},
+		1,
+		true,
+		1e300,
+		WithVisitCounts(),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	data := mat.NewDense(2, 400, nil)
+	for j := 0; j < 400; j++ {
+		data.Set(0, j, 0.025+0.05*float64(j%20))
+		data.Set(1, j, 0.025+0.05*float64(j/20))
+	}
+
+	before, err := tc.QuantizationError(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fit, err := tc.FitOffsets(data, 9, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := fit.QuantizationError(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after >= before {
+		t.Errorf("fitOffsets: have error(%v) want less than %v", after,
+			before)
+	}
+	if have, err := tc.QuantizationError(data); err != nil || have != before {
+		t.Errorf("fitOffsets: receiver modified: have error(%v, %v) "+
+			"want(%v)", have, err, before)
+	}
+	if fit.NumTilings() != tc.NumTilings() || fit.VecLength() != tc.VecLength() {
+		t.Errorf("fitOffsets: have(%d tilings, %d features) want(%d, %d)",
+			fit.NumTilings(), fit.VecLength(), tc.NumTilings(), tc.VecLength())
+	}
+	fit.Encode(mat.NewVecDense(2, []float64{0.5, 0.5}))
+	var visits uint64
+	for _, count := range fit.VisitCounts() {
+		visits += count
+	}
+	if visits != uint64(fit.NumTilings()) {
+		t.Errorf("fitOffsets: have(%d visits) want(%d)", visits,
+			fit.NumTilings())
+	}
+
+	if _, err := tc.FitOffsets(data, 1, 1); err == nil {
+		t.Error("expected error with less than 2 candidates")
+	}
+	if _, err := tc.FitOffsets(data, 2, 0); err == nil {
+		t.Error("expected error with less than 1 sweep")
+	}
+	if _, err := tc.FitOffsets(nil, 2, 1); !errors.Is(err, ErrNilInput) {
+		t.Errorf("fitOffsets(nil): have(%v) want(%v)", err, ErrNilInput)
+	}
+	if _, err := tc.QuantizationError(mat.NewDense(3, 1, nil)); !errors.Is(err,
+		ErrDimension) {
+		t.Errorf("quantizationError: have(%v) want(%v)", err, ErrDimension)
+	}
+}
+
+func TestTileCoderAddRemoveTiling(t *testing.T) {
+	tc, err := New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{2, 2}, {3, 3}},
+		1,
+		true,
+		1e300,
+		WithVisitCounts(),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	var index []int
+	var features int
+	tc.SetReindexHook(func(i []int, f int) { index, features = i, f })
+
+	v := mat.NewVecDense(2, []float64{0.9, 0.9})
+	before := tc.EncodeIndices(v)
+
+	tiling, err := NewTiling(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[]int{4, 4},
+		1,
+		1e300,
+	)
+	if err != nil {
+		t.Fatalf("could not create tiling: %v", err)
+	}
+	if err := tc.AddTiling(tiling); err != nil {
+		t.Fatal(err)
+	}
+	if tc.NumTilings() != 3 || tc.VecLength() != 30 {
+		t.Errorf("addTiling: have(%d tilings, %d features) want(3, 30)",
+			tc.NumTilings(), tc.VecLength())
+	}
+	if len(index) != 14 || features != 30 || index[13] != 13 {
+		t.Errorf("addTiling: have hook(%v, %d) want identity of 14 "+
+			"features, 30", index, features)
+	}
+	after := tc.EncodeIndices(v)
+	if want := []float64{before[0], before[1], 29, before[2]}; !reflect.
+		DeepEqual(after, want) {
+		t.Errorf("addTiling: have(%v) want(%v)", after, want)
+	}
+
+	// Features of later tilings move down by the tiles of the removed
+	// tiling, and visit counts move with them
+	if err := tc.RemoveTiling(0); err != nil {
+		t.Fatal(err)
+	}
+	if tc.NumTilings() != 2 || tc.VecLength() != 26 {
+		t.Errorf("removeTiling: have(%d tilings, %d features) want(2, 26)",
+			tc.NumTilings(), tc.VecLength())
+	}
+	if len(index) != 30 || features != 26 || index[1] != -1 ||
+		index[4] != -1 || index[5] != 1 || index[29] != 25 {
+		t.Errorf("removeTiling: have hook(%v, %d)", index, features)
+	}
+	after = tc.EncodeIndices(v)
+	if want := []float64{before[1] - 4, 25, 0}; !reflect.DeepEqual(after,
+		want) {
+		t.Errorf("removeTiling: have(%v) want(%v)", after, want)
+	}
+	if counts := tc.VisitCounts(); counts[int(after[0])] != 3 ||
+		counts[int(after[1])] != 2 {
+		t.Errorf("removeTiling: have visits(%v, %v) want(3, 2)",
+			counts[int(after[0])], counts[int(after[1])])
+	}
+
+	if err := tc.RemoveTiling(2); err == nil {
+		t.Error("expected error removing tiling out of range")
+	}
+	if err := tc.RemoveTiling(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := tc.RemoveTiling(0); err == nil {
+		t.Error("expected error removing the only tiling")
+	}
+	if err := tc.AddTiling(nil); !errors.Is(err, ErrNilInput) {
+		t.Errorf("addTiling(nil): have(%v) want(%v)", err, ErrNilInput)
+	}
+	wide, err := NewTiling(
+		mat.NewVecDense(3, []float64{0, 0, 0}),
+		mat.NewVecDense(3, []float64{1, 1, 1}),
+		[]int{2, 2, 2},
+		1,
+		1e300,
+	)
+	if err != nil {
+		t.Fatalf("could not create tiling: %v", err)
+	}
+	if err := tc.AddTiling(wide); !errors.Is(err, ErrDimension) {
+		t.Errorf("addTiling: have(%v) want(%v)", err, ErrDimension)
+	}
+}
+
+func TestTileCoderSetBounds(t *testing.T) {
+	tc := newUniformTileCoder(t)
+	v := mat.NewVecDense(4, []float64{0.1, 0.4, 0.6, 0.9})
+	want := tc.EncodeIndices(v)
+
+	// Vectors at the same relative position in the new bounds activate
+	// the same features
+	minDims := mat.NewVecDense(4, []float64{-1, 0, 0, 0})
+	maxDims := mat.NewVecDense(4, []float64{1, 10, 10, 10})
+	if err := tc.SetBounds(minDims, maxDims); err != nil {
+		t.Fatal(err)
+	}
+	if tc.uniform == nil {
+		t.Error("setBounds: identical tilings no longer detected")
+	}
+	w := mat.NewVecDense(4, []float64{-0.8, 4, 6, 9})
+	if have := tc.EncodeIndices(w); !reflect.DeepEqual(have, want) {
+		t.Errorf("setBounds: have(%v) want(%v)", have, want)
+	}
+	if lengths := tc.Tilings()[0].BinLengths(); math.Abs(lengths[1]-
+		10.0/6) > 1e-12 {
+		t.Errorf("setBounds: have bin length(%v) want(%v)", lengths[1],
+			10.0/6)
+	}
+
+	// Invalid bounds leave the receiver unchanged
+	if err := tc.SetBounds(nil, maxDims); !errors.Is(err, ErrNilInput) {
+		t.Errorf("setBounds(nil): have(%v) want(%v)", err, ErrNilInput)
+	}
+	if err := tc.SetBounds(mat.NewVecDense(1, nil), mat.NewVecDense(1,
+		[]float64{1})); !errors.Is(err, ErrDimension) {
+		t.Errorf("setBounds: have(%v) want(%v)", err, ErrDimension)
+	}
+	maxDims.SetVec(3, -1)
+	if err := tc.SetBounds(minDims, maxDims); err == nil {
+		t.Error("expected error with maximum below minimum")
+	}
+	maxDims.SetVec(3, math.Inf(1))
+	if err := tc.SetBounds(minDims, maxDims); err == nil {
+		t.Error("expected error with infinite bounds")
+	}
+	if have := tc.EncodeIndices(w); !reflect.DeepEqual(have, want) {
+		t.Errorf("setBounds: have(%v) want(%v) after error", have, want)
+	}
+}
+
+func TestTileCoderWriteNPY(t *testing.T) {
+	tc, err := New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{2, 2}},
+		1,
+		true,
+		1e300,
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	b := mat.NewDense(2, 3, []float64{
+		0.1, 0.9, 0.2,
+		0.1, 0.1, 0.8,
+	})
+
+	// readNPY returns the header and data of a .npy file
+	readNPY := func(data []byte) (string, []uint64) {
+		if !bytes.HasPrefix(data, []byte("\x93NUMPY\x01\x00")) {
+			t.Fatalf("writeNPY: missing magic: %q", data)
+		}
+		n := int(binary.LittleEndian.Uint16(data[8:10]))
+		if (10+n)%64 != 0 {
+			t.Errorf("writeNPY: data not aligned: header length %d", n)
+		}
+		body := data[10+n:]
+		elements := make([]uint64, len(body)/8)
+		for i := range elements {
+			elements[i] = binary.LittleEndian.Uint64(body[8*i:])
+		}
+		return strings.TrimSpace(string(data[10 : 10+n])), elements
+	}
+
+	var buf bytes.Buffer
+	if err := tc.WriteNPY(&buf, b, false); err != nil {
+		t.Fatal(err)
+	}
+	header, elements := readNPY(buf.Bytes())
+	want := "{'descr': '<i8', 'fortran_order': False, 'shape': (3, 2), }"
+	if header != want {
+		t.Errorf("writeNPY: have header(%q) want(%q)", header, want)
+	}
+	indices := tc.EncodeIndicesBatch(b)
+	for k, element := range elements {
+		if have, want := int64(element), int64(indices.At(k%2,
+			k/2)); have != want {
+			t.Errorf("writeNPY: element %d: have(%d) want(%d)", k, have, want)
+		}
+	}
+
+	buf.Reset()
+	if err := tc.WriteNPY(&buf, b, true); err != nil {
+		t.Fatal(err)
+	}
+	header, elements = readNPY(buf.Bytes())
+	want = "{'descr': '<f8', 'fortran_order': False, 'shape': (3, 5), }"
+	if header != want {
+		t.Errorf("writeNPY: have header(%q) want(%q)", header, want)
+	}
+	features := tc.EncodeBatch(b)
+	for k, element := range elements {
+		have, want := math.Float64frombits(element), features.At(k%5, k/5)
+		if have != want {
+			t.Errorf("writeNPY: element %d: have(%v) want(%v)", k, have, want)
+		}
+	}
+
+	buf.Reset()
+	if err := tc.WriteNPZ(&buf, b, false); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()),
+		int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range archive.File {
+		names = append(names, f.Name)
+	}
+	if want := []string{"inputs.npy", "indices.npy"}; !reflect.DeepEqual(names,
+		want) {
+		t.Errorf("writeNPZ: have(%v) want(%v)", names, want)
+	}
+
+	if err := tc.WriteNPY(&buf, nil, false); !errors.Is(err, ErrNilInput) {
+		t.Errorf("writeNPY(nil): have(%v) want(%v)", err, ErrNilInput)
+	}
+}
+
+// newUniformTileCoder returns a TileCoder with 8 identical tilings
+// over 4 dimensions, which differ only in their offsets
+func newUniformTileCoder(t testing.TB) *TileCoder {
+	bins := make([][]int, 8)
+	for i := range bins {
+		bins[i] = []int{6, 6, 6, 6}
+	}
+	tc, err := New(
+		mat.NewVecDense(4, []float64{0, 0, 0, 0}),
+		mat.NewVecDense(4, []float64{1, 1, 1, 1}),
+		bins,
+		3,
+		true,
+		-1,
+		WithDistinctSeeds(),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	return tc
+}
+
+func TestTileCoderUniform(t *testing.T) {
+	tc := newUniformTileCoder(t)
+	if tc.uniform == nil {
+		t.Fatal("identical tilings not detected")
+	}
+
+	tiles := tc.tilings[0].Tiles()
+	indices := make([]float64, tc.NumTilings()+1)
+	for _, x := range []float64{-1, 0, 0.13, 0.5, 0.77, 1, 2} {
+		v := mat.NewVecDense(4, []float64{x, 1 - x, x / 2, x * x})
+		tc.EncodeIndicesTo(indices, v)
+		for k, tiling := range tc.tilings {
+			want := float64(k*tiles + tiling.Index(v) + 1)
+			if indices[k] != want {
+				t.Errorf("encodeIndices(%v): tiling %d: have(%v) want(%v)", x,
+					k, indices[k], want)
+			}
+		}
+	}
+
+	v := mat.NewVecDense(4, []float64{0.1, 0.2, 0.3, 0.4})
+	allocs := testing.AllocsPerRun(100, func() {
+		tc.EncodeIndicesTo(indices, v)
+	})
+	if allocs != 0 {
+		t.Errorf("allocs: have(%v) want(%v)", allocs, 0)
+	}
+}
+
 func BenchmarkTileCoder(b *testing.B) {
 	tc, _ := New(
 		mat.NewVecDense(8, []float64{0, 0, 0, 0, 0, 0, 0, 0}),
@@ -22,3 +2780,80 @@ func BenchmarkTileCoder(b *testing.B) {
 		tc.Encode(y)
 	}
 }
+
+func BenchmarkTileCoderBatch(b *testing.B) {
+	const batchSize = 4096
+	tc, _ := New(
+		mat.NewVecDense(4, []float64{0, 0, 0, 0}),
+		mat.NewVecDense(4, []float64{1, 1, 1, 1}),
+		[][]int{{8, 8, 8, 8}, {8, 8, 8, 8}, {8, 8, 8, 8}, {8, 8, 8, 8}},
+		12,
+		true,
+		-1.0,
+		WithConcurrency(1),
+	)
+
+	batch := mat.NewDense(4, batchSize, nil)
+	for i := 0; i < batchSize; i++ {
+		batch.SetCol(i, []float64{0.1, 0.3, 0.5, 0.7})
+	}
+
+	for i := 0; i < b.N; i++ {
+		tc.EncodeIndicesBatch(batch)
+	}
+}
+
+func BenchmarkTileCoderEncodeTo(b *testing.B) {
+	tc, _ := New(
+		mat.NewVecDense(8, []float64{0, 0, 0, 0, 0, 0, 0, 0}),
+		mat.NewVecDense(8, []float64{1, 1, 1, 1, 1, 1, 1, 1}),
+		[][]int{{8, 8, 8, 8, 8, 8, 8, 8}},
+		12,
+		true,
+		-1.0,
+	)
+
+	y := mat.NewVecDense(8, []float64{0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5})
+	indices := make([]float64, tc.NumTilings()+1)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tc.EncodeIndicesTo(indices, y)
+	}
+}
+
+func BenchmarkTileCoderVisitCountsParallel(b *testing.B) {
+	tc := newUniformTileCoder(b)
+	if err := tc.SetVisitCounts(make([]uint64, tc.VecLength())); err != nil {
+		b.Fatal(err)
+	}
+	tc.SetConcurrency(1)
+	v := mat.NewVecDense(4, []float64{0.1, 0.2, 0.3, 0.4})
+
+	b.RunParallel(func(pb *testing.PB) {
+		indices := make([]float64, tc.NumTilings()+1)
+		for pb.Next() {
+			tc.EncodeIndicesTo(indices, v)
+		}
+	})
+}
+
+func BenchmarkTileCoderUniform(b *testing.B) {
+	tc := newUniformTileCoder(b)
+	v := mat.NewVecDense(4, []float64{0.1, 0.2, 0.3, 0.4})
+	indices := make([]float64, tc.NumTilings()+1)
+
+	b.Run("Uniform", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tc.EncodeIndicesTo(indices, v)
+		}
+	})
+
+	// Disable the fast path to compare with the general path
+	tc.uniform = nil
+	b.Run("General", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tc.EncodeIndicesTo(indices, v)
+		}
+	})
+}
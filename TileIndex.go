@@ -0,0 +1,128 @@
+package gotile
+
+import (
+	"fmt"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Neighbor is a sample stored in a TileIndex which shares active tiles
+// with a query
+type Neighbor struct {
+	// ID is the identifier of the sample returned by Add
+	ID int
+
+	// Overlap is the number of tilings in which the sample and the
+	// query fall in the same tile
+	Overlap int
+}
+
+// TileIndex stores samples keyed by the active tiles of their
+// tile-coded representations, and retrieves the samples sharing the
+// most active tiles with a query. Since nearby vectors fall in the
+// same tiles in many tilings, tile coding acts as a locality-sensitive
+// hash, and a TileIndex supports k-nearest-neighbor retrieval under
+// the tile-overlap kernel, for example for episodic memory.
+//
+// A TileIndex keeps an inverted list of the samples activating each
+// tile, so that a query only visits the samples sharing at least one
+// active tile with it. The bias unit is not indexed. The tilings of
+// the TileCoder must not change while it is used by a TileIndex.
+//
+// A TileIndex is not safe for concurrent use, except that Nearest,
+// Len, and Sample may be called concurrently when Add is not.
+type TileIndex struct {
+	coder   *TileCoder
+	lists   map[int][]int
+	samples []*mat.VecDense
+}
+
+// NewTileIndex returns a new, empty TileIndex which encodes samples
+// with coder
+func NewTileIndex(coder *TileCoder) (*TileIndex, error) {
+	if coder == nil {
+		return nil, fmt.Errorf("newTileIndex: %w", ErrNilInput)
+	}
+	return &TileIndex{coder: coder, lists: make(map[int][]int)}, nil
+}
+
+// Add stores a copy of v in the index and returns its identifier,
+// which is the number of samples stored before it. Errors are returned
+// as in TryEncodeIndices.
+func (x *TileIndex) Add(v mat.Vector) (int, error) {
+	tiles, err := x.tiles(v)
+	if err != nil {
+		return 0, fmt.Errorf("add: %w", err)
+	}
+
+	id := len(x.samples)
+	for _, tile := range tiles {
+		x.lists[tile] = append(x.lists[tile], id)
+	}
+	x.samples = append(x.samples, mat.VecDenseCopyOf(v))
+	return id, nil
+}
+
+// Len returns the number of samples stored in the index
+func (x *TileIndex) Len() int {
+	return len(x.samples)
+}
+
+// Sample returns the sample with the identifier id, which should not
+// be modified
+func (x *TileIndex) Sample(id int) *mat.VecDense {
+	return x.samples[id]
+}
+
+// Nearest returns at most k of the stored samples sharing the most
+// active tiles with v, in decreasing order of overlap, with ties
+// broken by identifier. Samples sharing no active tiles with v are
+// never returned, so fewer than k samples may be returned. Errors are
+// returned as in TryEncodeIndices.
+func (x *TileIndex) Nearest(v mat.Vector, k int) ([]Neighbor, error) {
+	if k < 0 {
+		return nil, fmt.Errorf("nearest: k must be non-negative: %d", k)
+	}
+	tiles, err := x.tiles(v)
+	if err != nil {
+		return nil, fmt.Errorf("nearest: %w", err)
+	}
+
+	overlaps := make(map[int]int)
+	for _, tile := range tiles {
+		for _, id := range x.lists[tile] {
+			overlaps[id]++
+		}
+	}
+
+	neighbors := make([]Neighbor, 0, len(overlaps))
+	for id, overlap := range overlaps {
+		neighbors = append(neighbors, Neighbor{ID: id, Overlap: overlap})
+	}
+	sort.Slice(neighbors, func(i, j int) bool {
+		if neighbors[i].Overlap != neighbors[j].Overlap {
+			return neighbors[i].Overlap > neighbors[j].Overlap
+		}
+		return neighbors[i].ID < neighbors[j].ID
+	})
+	if len(neighbors) > k {
+		neighbors = neighbors[:k]
+	}
+	return neighbors, nil
+}
+
+// tiles returns the index of the active tile of v in each tiling
+func (x *TileIndex) tiles(v mat.Vector) ([]int, error) {
+	indices := make([]float64, x.coder.numIndices())
+	if err := x.coder.encodeIndicesTo(indices, v); err != nil {
+		return nil, err
+	}
+
+	// encodeIndicesTo places the index of tiling k at position k
+	tiles := make([]int, len(x.coder.tilings))
+	for k := range tiles {
+		tiles[k] = int(indices[k])
+	}
+	return tiles, nil
+}
@@ -0,0 +1,95 @@
+package gotile
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestTileIndex(t *testing.T) {
+	bins := make([][]int, 8)
+	for i := range bins {
+		bins[i] = []int{4, 4}
+	}
+	tc, err := New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		bins,
+		1,
+		true,
+		2,
+		WithBoundsPolicy(BoundsError),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	x, err := NewTileIndex(tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	samples := [][]float64{{0.1, 0.1}, {0.9, 0.9}, {0.12, 0.11}, {0.5, 0.5}}
+	for i, s := range samples {
+		id, err := x.Add(mat.NewVecDense(2, s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != i {
+			t.Errorf("add(%v): have(%d) want(%d)", s, id, i)
+		}
+	}
+	if x.Len() != len(samples) {
+		t.Errorf("len: have(%d) want(%d)", x.Len(), len(samples))
+	}
+	if have := x.Sample(2).RawVector().Data; !reflect.DeepEqual(have,
+		samples[2]) {
+		t.Errorf("sample(2): have(%v) want(%v)", have, samples[2])
+	}
+
+	// Neighbors are ranked by their overlap with the query
+	q := mat.NewVecDense(2, []float64{0.1, 0.1})
+	neighbors, err := x.Nearest(q, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(neighbors) != 2 || neighbors[0] != (Neighbor{0, 8}) ||
+		neighbors[1].ID != 2 {
+		t.Errorf("nearest(%v, 2): have(%v) want samples 0 and 2", q,
+			neighbors)
+	}
+	for _, n := range neighbors {
+		if want := tc.Overlap(q, x.Sample(n.ID)); n.Overlap != want {
+			t.Errorf("nearest(%v): sample %d has overlap %d, want %d", q,
+				n.ID, n.Overlap, want)
+		}
+	}
+
+	// Samples sharing no tiles with the query are not returned
+	all, err := x.Nearest(q, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range all {
+		if n.ID == 1 {
+			t.Errorf("nearest(%v): returned sample 1 with overlap %d", q,
+				n.Overlap)
+		}
+	}
+
+	if _, err := x.Nearest(q, -1); err == nil {
+		t.Error("expected error with negative k")
+	}
+	if _, err := x.Add(mat.NewVecDense(2, []float64{2, 0})); !errors.Is(err,
+		ErrOutOfBounds) {
+		t.Errorf("add: have(%v) want(%v)", err, ErrOutOfBounds)
+	}
+	if x.Len() != len(samples) {
+		t.Errorf("len: have(%d) want(%d) after failed add", x.Len(),
+			len(samples))
+	}
+	if _, err := NewTileIndex(nil); !errors.Is(err, ErrNilInput) {
+		t.Errorf("newTileIndex(nil): have(%v) want(%v)", err, ErrNilInput)
+	}
+}
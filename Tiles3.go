@@ -0,0 +1,195 @@
+package gotile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Tiles3Config describes a tile coder using the parameters of Python
+// tile coders built on Sutton's tiles3 library, such as those of
+// PyFixedReps. Its JSON encoding mirrors the configuration dictionaries
+// used by these libraries, for example:
+//
+//	{
+//		"dims": 2,
+//		"tiles": 8,
+//		"tilings": 8,
+//		"input_ranges": [[-1.2, 0.6], [-0.07, 0.07]],
+//		"iht_size": 1024,
+//		"bias": true,
+//		"scale_output": false
+//	}
+//
+// The field tiles may be either a single number of tiles used along
+// every dimension or a list with the number of tiles along each
+// dimension. Setting scale_output scales the active feature of each
+// tiling by 1 / tilings, as in NormalizedActivations, while the bias
+// unit remains 1.0. Since tiles3 hashes tiles into an index hash table
+// (IHT) of a fixed size, while a TileCoder uses dense tilings, iht_size
+// is only used to report the size of the table when exporting
+// configurations.
+type Tiles3Config struct {
+	Dims        int          `json:"dims"`
+	Tiles       []int        `json:"tiles"`
+	Tilings     int          `json:"tilings"`
+	InputRanges [][2]float64 `json:"input_ranges"`
+	IHTSize     int          `json:"iht_size,omitempty"`
+	Bias        bool         `json:"bias"`
+	ScaleOutput bool         `json:"scale_output"`
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, accepting
+// either a single number or a list for the field tiles
+func (c *Tiles3Config) UnmarshalJSON(data []byte) error {
+	type config Tiles3Config // Avoids recursion into UnmarshalJSON
+	var raw struct {
+		config
+		Tiles json.RawMessage `json:"tiles"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*c = Tiles3Config(raw.config)
+	var tiles int
+	if err := json.Unmarshal(raw.Tiles, &tiles); err == nil {
+		c.Tiles = make([]int, c.Dims)
+		for i := range c.Tiles {
+			c.Tiles[i] = tiles
+		}
+	} else if err := json.Unmarshal(raw.Tiles, &c.Tiles); err != nil {
+		return fmt.Errorf("tiles must be a number or list of numbers")
+	}
+	return nil
+}
+
+// ReadTiles3Config reads a Tiles3Config from the JSON document in r
+func ReadTiles3Config(r io.Reader) (Tiles3Config, error) {
+	var c Tiles3Config
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return Tiles3Config{}, fmt.Errorf("readTiles3Config: %v", err)
+	}
+	return c, nil
+}
+
+// Write writes the JSON encoding of the receiver to w
+func (c Tiles3Config) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	if err := enc.Encode(c); err != nil {
+		return fmt.Errorf("write: %v", err)
+	}
+	return nil
+}
+
+// Config returns the Config of the TileCoder closest to the receiver.
+// The returned Config uses Tilings tilings over the input ranges, each
+// with Tiles[i] tiles along dimension i. Tilings in tiles3 are
+// displaced from each other by fractions of a tile, which is
+// approximated by offsetting each tiling randomly by up to one tile
+// width using distinct seeds, starting from the given seed. If
+// ScaleOutput is set, the returned Config uses NormalizedActivations.
+func (c Tiles3Config) Config(seed uint64) (Config, error) {
+	if c.Dims < 1 || len(c.InputRanges) != c.Dims || len(c.Tiles) != c.Dims {
+		return Config{}, fmt.Errorf("config: input ranges and tiles must "+
+			"describe %d dimensions", c.Dims)
+	}
+	if c.Tilings < 1 {
+		return Config{}, fmt.Errorf("config: cannot use less than 1 tiling")
+	}
+
+	config := Config{
+		MinDims:       make([]float64, c.Dims),
+		MaxDims:       make([]float64, c.Dims),
+		Bins:          make([][]int, c.Tilings),
+		Seed:          seed,
+		IncludeBias:   c.Bias,
+		OffsetDiv:     2.0, // Offsets are spread over an entire tile
+		DistinctSeeds: true,
+
+		NormalizedActivations: c.ScaleOutput,
+	}
+	for i, r := range c.InputRanges {
+		config.MinDims[i], config.MaxDims[i] = r[0], r[1]
+	}
+	for i := range config.Bins {
+		config.Bins[i] = append([]int(nil), c.Tiles...)
+	}
+	return config, nil
+}
+
+// Tiles3ConfigFrom returns the Tiles3Config closest to the Config c.
+// Since tiles3 uses the same number of tiles for every tiling and
+// tiles every dimension jointly, c must use the same bins for each
+// tiling, and cannot use groups of dimensions, bin edges, categorical
+// dimensions, scales other than ScaleLinear, squashing, or bounds
+// policies, offset strategies, or non-finite policies other than the
+// defaults, since (Tiles3Config).Config could not restore them.
+// NormalizedActivations is exported as scale_output. The returned IHT
+// size is large enough that no tiles collide in the table.
+func Tiles3ConfigFrom(c Config) (Tiles3Config, error) {
+	if len(c.Bins) == 0 {
+		return Tiles3Config{}, fmt.Errorf("tiles3ConfigFrom: cannot use " +
+			"less than 1 tiling")
+	}
+	if c.Groups != nil || c.Edges != nil || c.Categorical != nil {
+		return Tiles3Config{}, fmt.Errorf("tiles3ConfigFrom: groups, bin " +
+			"edges, and categorical dimensions are not supported by tiles3")
+	}
+	for _, scale := range c.Scales {
+		if scale != ScaleLinear {
+			return Tiles3Config{}, fmt.Errorf("tiles3ConfigFrom: scale %v "+
+				"is not supported by tiles3", scale)
+		}
+	}
+	for _, squash := range c.Squashes {
+		if squash.Func != SquashNone {
+			return Tiles3Config{}, fmt.Errorf("tiles3ConfigFrom: squashing "+
+				"function %v is not supported by tiles3", squash.Func)
+		}
+	}
+	if c.BoundsPolicy != BoundsClip || c.OffsetStrategy != OffsetRandom ||
+		c.NonFinitePolicy != NonFiniteClip {
+		return Tiles3Config{}, fmt.Errorf("tiles3ConfigFrom: bounds " +
+			"policies, offset strategies, and non-finite policies other " +
+			"than the defaults are not supported by tiles3")
+	}
+	dims := len(c.MinDims)
+	if len(c.MaxDims) != dims {
+		return Tiles3Config{}, fmt.Errorf("tiles3ConfigFrom: bounds must "+
+			"have the same length: %d != %d", dims, len(c.MaxDims))
+	}
+	for i := range c.Bins {
+		if len(c.Bins[i]) != dims {
+			return Tiles3Config{}, fmt.Errorf("tiles3ConfigFrom: tiling %d "+
+				"does not tile every dimension", i)
+		}
+		for j := range c.Bins[i] {
+			if c.Bins[i][j] != c.Bins[0][j] {
+				return Tiles3Config{}, fmt.Errorf("tiles3ConfigFrom: all " +
+					"tilings must use the same bins")
+			}
+		}
+	}
+
+	t := Tiles3Config{
+		Dims:        dims,
+		Tiles:       append([]int(nil), c.Bins[0]...),
+		Tilings:     len(c.Bins),
+		InputRanges: make([][2]float64, dims),
+		Bias:        c.IncludeBias,
+		ScaleOutput: c.NormalizedActivations,
+	}
+	tiles := 1
+	for i := 0; i < dims; i++ {
+		t.InputRanges[i] = [2]float64{c.MinDims[i], c.MaxDims[i]}
+		tiles *= t.Tiles[i]
+	}
+
+	// Round the table size up to a power of two, as is common for IHTs
+	size := float64(tiles * t.Tilings)
+	t.IHTSize = int(math.Pow(2, math.Ceil(math.Log2(size))))
+	return t, nil
+}
@@ -3,14 +3,13 @@ package gotile
 import (
 	"fmt"
 	"math"
+	"sort"
 
 	"github.com/samuelfneumann/goutils/floatutils"
-	"github.com/samuelfneumann/goutils/matutils"
 	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/blas/blas64"
 	"gonum.org/v1/gonum/mat"
 	"gonum.org/v1/gonum/spatial/r1"
-	"gonum.org/v1/gonum/stat/distmv"
-	"gonum.org/v1/gonum/stat/samplemv"
 )
 
 // Default offset divisor. See NewTiling for more details.
@@ -21,8 +20,18 @@ type Tiling struct {
 	offsets    *mat.Dense // Offset of the tiling along each dimension
 	bins       []int      // Number of bins along each dimension
 	binLengths []float64  // Length of bins along each dimension
-	minDims    mat.Vector
+	minDims    mat.Vector // Minimum of each dimension, after scaling
 	seed       uint64
+	scales     []Scale     // Scale of each dimension
+	edges      [][]float64 // Bin edges of each dimension, nil if uniform
+	categories []bool      // Whether each dimension is categorical
+	dims       []int       // Input dimension tiled along each dimension
+	low, high  []float64   // Bounds of each dimension, before scaling
+	policy     BoundsPolicy
+	squashes   []Squash   // Squashing transform of each dimension
+	strides    []int      // Index stride of each dimension
+	rotation   *mat.Dense // Rotation of the tiled dimensions, or nil
+	nonFinite  NonFinitePolicy
 }
 
 // NewTiling returns a new tiling from minDims to maxDims along each
@@ -33,15 +42,39 @@ type Tiling struct {
 //
 // For each dimension, tilings are offset from
 // the origin by randomly sampling from a uniform distribution with
-// support [-tiling width/OffsetDiv, tiling width/OffsetDiv]^k, where
+// support [-tiling width/offsetDiv, tiling width/offsetDiv]^k, where
 // k is the number of dimension of the tiling or state space. Each
 // dimension of the tiling may be offset from the origin by a different
-// amount.
+// amount. If offsetDiv is non-positive, then OffsetDiv is used.
+// Earlier versions ignored offsetDiv and always used OffsetDiv, so
+// tilings built with any other positive offsetDiv are offset
+// differently than they were in those versions.
+//
+// Additional behaviour may be configured with opts. When a dimension
+// uses ScaleLog, tile widths and offsets are computed in log-space.
+// When a dimension is squashed with WithSquashes, its bounds may be
+// infinite, and tile widths and offsets are computed in the squashed
+// space. When a dimension is given explicit bin edges with WithEdges, its
+// offset is instead bounded by the narrowest of its bins. Dimensions
+// marked categorical with WithCategorical are never offset. With
+// WithOffsetStrategy, offsets are instead drawn from a low-discrepancy
+// sequence. By default, NaN and infinite features are treated as out
+// of bounds; see WithNonFinitePolicy.
+//
+// By default, a tiling tiles every dimension of its input vectors
+// jointly. The WithDims option restricts the tiling to a subset of the
+// input dimensions, in which case minDims and maxDims still describe
+// all input dimensions, while bins describes only the tiled dimensions
+// in the order given to WithDims. Options referring to individual
+// dimensions always refer to input dimensions.
 func NewTiling(minDims, maxDims mat.Vector, bins []int,
-	seed uint64, offsetDiv float64) (*Tiling, error) {
+	seed uint64, offsetDiv float64, opts ...Option) (*Tiling, error) {
 	// Error checking
+	if isNil(minDims) || isNil(maxDims) {
+		return nil, fmt.Errorf("newTiling: %w", ErrNilInput)
+	}
 	if minDims.Len() != maxDims.Len() {
-		msg := fmt.Sprintf("newTiing: cannot specify minimum with fewer "+
+		msg := fmt.Sprintf("newTiling: cannot specify minimum with fewer "+
 			"dimensions than maximum: %d != %d", minDims.Len(), maxDims.Len())
 		return nil, fmt.Errorf(msg)
 	}
@@ -49,71 +82,327 @@ func NewTiling(minDims, maxDims mat.Vector, bins []int,
 		msg := "newTiling: cannot have less than 1 bin per dimension"
 		return nil, fmt.Errorf(msg)
 	}
-	if len(bins) != minDims.Len() {
+
+	if offsetDiv <= 0 {
+		offsetDiv = OffsetDiv
+	}
+
+	cfg, err := newConfig(opts)
+	if err != nil {
+		return nil, fmt.Errorf("newTiling: %v", err)
+	}
+	if err := cfg.categoriesFor(minDims.Len()); err != nil {
+		return nil, fmt.Errorf("newTiling: %v", err)
+	}
+
+	// Determine which input dimensions are tiled
+	dims := cfg.dims
+	if dims == nil {
+		dims = make([]int, minDims.Len())
+		for i := range dims {
+			dims[i] = i
+		}
+	}
+	for _, i := range dims {
+		if i < 0 || i >= minDims.Len() {
+			return nil, fmt.Errorf("newTiling: tiled dimension %d out of "+
+				"range [0, %d)", i, minDims.Len())
+		}
+	}
+	if len(bins) != len(dims) {
 		msg := fmt.Sprintf("newTiling: there should be a single number of bins for "+
 			"each dimension: \n\thave(%d) \n\twant (%d)", len(bins),
-			minDims.Len())
+			len(dims))
 		return nil, fmt.Errorf(msg)
 	}
 
+	inScales := cfg.scales
+	if inScales == nil {
+		inScales = make([]Scale, minDims.Len())
+	} else if len(inScales) != minDims.Len() {
+		return nil, fmt.Errorf("newTiling: there should be a single scale "+
+			"for each dimension: \n\thave(%d) \n\twant (%d)", len(inScales),
+			minDims.Len())
+	}
+
+	inSquashes := cfg.squashes
+	if inSquashes == nil {
+		inSquashes = make([]Squash, minDims.Len())
+	} else if len(inSquashes) != minDims.Len() {
+		return nil, fmt.Errorf("newTiling: there should be a single squash "+
+			"for each dimension: \n\thave(%d) \n\twant (%d)",
+			len(inSquashes), minDims.Len())
+	}
+
+	if cfg.edges != nil && len(cfg.edges) != minDims.Len() {
+		return nil, fmt.Errorf("newTiling: there should be a single set of "+
+			"bin edges for each dimension: \n\thave(%d) \n\twant (%d)",
+			len(cfg.edges), minDims.Len())
+	}
+
 	// Calculate the length of bins and the Tiling offset bounds
 	var bounds []r1.Interval
 
-	TilingBinLengths := make([]float64, minDims.Len())
-	binLengths := TilingBinLengths
+	binLengths := make([]float64, len(dims))
+	scaledMin := mat.NewVecDense(len(dims), nil)
+	scales := make([]Scale, len(dims))
+	squashes := make([]Squash, len(dims))
+	edges := make([][]float64, len(dims))
+	categories := make([]bool, len(dims))
+	low := make([]float64, len(dims))
+	high := make([]float64, len(dims))
+
+	// Dimension k of the tiling tiles dimension i of the input vectors
+	for k, i := range dims {
+		low[k], high[k] = minDims.AtVec(i), maxDims.AtVec(i)
+		scales[k] = inScales[i]
+		squashes[k] = inSquashes[i]
+		if squashes[k].Func != SquashNone &&
+			(scales[k] != ScaleLinear || cfg.categories[i]) {
+			return nil, fmt.Errorf("newTiling: squashed dimension %d cannot "+
+				"be log-scaled or categorical", i)
+		}
+		if scales[k] == ScaleLog && minDims.AtVec(i) <= 0 {
+			return nil, fmt.Errorf("newTiling: dimension %d uses a log "+
+				"scale but has non-positive minimum %v", i, minDims.AtVec(i))
+		}
+
+		// Categorical dimensions place each integer in its own tile,
+		// which are never offset
+		if cfg.categories[i] {
+			min, max := minDims.AtVec(i), maxDims.AtVec(i)
+			if min != math.Trunc(min) || max != math.Trunc(max) {
+				return nil, fmt.Errorf("newTiling: categorical dimension "+
+					"%d must have integer bounds", i)
+			}
+			if scales[k] != ScaleLinear ||
+				(cfg.edges != nil && cfg.edges[i] != nil) {
+				return nil, fmt.Errorf("newTiling: categorical dimension "+
+					"%d cannot be scaled or use bin edges", i)
+			}
+			if bins[k] != int(max-min)+1 {
+				return nil, fmt.Errorf("newTiling: categorical dimension "+
+					"%d should have one bin per category: \n\thave(%d) "+
+					"\n\twant (%d)", i, bins[k], int(max-min)+1)
+			}
+
+			categories[k] = true
+			scaledMin.SetVec(k, min-0.5)
+			binLengths[k] = 1.0
+			bounds = append(bounds, r1.Interval{Min: 0, Max: 0})
+			continue
+		}
+
+		if maxDims.AtVec(i) <= minDims.AtVec(i) {
+			return nil, fmt.Errorf("newTiling: maximum of dimension %d "+
+				"must exceed its minimum: %v <= %v", i, maxDims.AtVec(i),
+				minDims.AtVec(i))
+		}
+		if bins[k] < 1 {
+			return nil, fmt.Errorf("newTiling: cannot have less than 1 "+
+				"bin along dimension %d", i)
+		}
+
+		// Calculate the length of bins in the scaled space
+		min := scales[k].apply(squashes[k].Apply(minDims.AtVec(i)))
+		max := scales[k].apply(squashes[k].Apply(maxDims.AtVec(i)))
+		binLength := (max - min) / float64(bins[k])
+		bound := binLength / offsetDiv // Bounds Tiling offsets
+
+		// Explicit bin edges take the place of equal-width bins
+		if cfg.edges != nil && cfg.edges[i] != nil {
+			e := cfg.edges[i]
+			if len(e)-1 != bins[k] {
+				return nil, fmt.Errorf("newTiling: %d bin edges given for "+
+					"dimension %d with %d bins", len(e), i, bins[k])
+			}
+			if e[0] != minDims.AtVec(i) || e[len(e)-1] != maxDims.AtVec(i) {
+				return nil, fmt.Errorf("newTiling: bin edges of dimension "+
+					"%d must start at the minimum and end at the maximum", i)
+			}
 
-	for i := 0; i < minDims.Len(); i++ {
-		// Calculate the length of bins
-		binLength := (maxDims.AtVec(i) - minDims.AtVec(i))
-		binLength /= float64(bins[i])
-		bound := binLength / OffsetDiv // Bounds Tiling offsets
+			edges[k] = make([]float64, len(e))
+			narrowest := math.Inf(1)
+			for j := range e {
+				edges[k][j] = scales[k].apply(squashes[k].Apply(e[j]))
+				if j > 0 {
+					narrowest = math.Min(narrowest, edges[k][j]-edges[k][j-1])
+				}
+			}
+			bound = narrowest / offsetDiv
+		}
 
-		binLengths[i] = binLength
+		scaledMin.SetVec(k, min)
+		binLengths[k] = binLength
 		bounds = append(bounds, r1.Interval{Min: -bound, Max: bound})
 	}
 
-	// Create RNG for uniform sampling of Tiling offsets
-	source := rand.NewSource(seed)
-	u := distmv.NewUniform(bounds, source)
-	sampler := samplemv.IID{Dist: u}
-
 	// Calculate offsets
-	offsets := mat.NewDense(1, len(bounds), nil)
-	sampler.Sample(offsets)
+	offsets := sampleOffsets(cfg.offsets, bounds, seed)
+
+	tiling := &Tiling{offsets, bins, binLengths, scaledMin, seed, scales,
+		edges, categories, append([]int(nil), dims...), low, high,
+		cfg.policy, squashes, nil, nil, cfg.nonFinite}
+	if err := tiling.initStrides(); err != nil {
+		return nil, fmt.Errorf("newTiling: %w", err)
+	}
 
-	return &Tiling{offsets, bins, binLengths, minDims, seed}, nil
+	if cfg.rotate {
+		if cfg.policy != BoundsClip && cfg.policy != BoundsError {
+			return nil, fmt.Errorf("newTiling: rotated tilings must use the "+
+				"BoundsClip or BoundsError policy: %v", cfg.policy)
+		}
+		for k, i := range dims {
+			if categories[k] || edges[k] != nil {
+				return nil, fmt.Errorf("newTiling: rotated dimension %d "+
+					"cannot be categorical or use bin edges", i)
+			}
+		}
+		tiling.rotation = randomRotation(len(dims), rand.New(
+			rand.NewSource(seed)))
+	}
+	return tiling, nil
 }
 
-// Index will return the index of the tile within which v falls
+// NewTilingEdges returns a new tiling defined by explicit bin edges
+// along each dimension. The edges along dimension i must be strictly
+// increasing, and the tiling will have len(edges[i])-1 bins along
+// dimension i, with bin j covering [edges[i][j], edges[i][j+1]). The
+// first and last edges of each dimension bound the tiling. The
+// arguments seed, offsetDiv, and opts are as in NewTiling.
+func NewTilingEdges(edges [][]float64, seed uint64, offsetDiv float64,
+	opts ...Option) (*Tiling, error) {
+	minDims := mat.NewVecDense(len(edges), nil)
+	maxDims := mat.NewVecDense(len(edges), nil)
+	bins := make([]int, len(edges))
+	for i := range edges {
+		if len(edges[i]) < 2 {
+			return nil, fmt.Errorf("newTilingEdges: dimension %d must have "+
+				"at least 2 bin edges", i)
+		}
+		minDims.SetVec(i, edges[i][0])
+		maxDims.SetVec(i, edges[i][len(edges[i])-1])
+		bins[i] = len(edges[i]) - 1
+	}
+
+	opts = append(opts, WithEdges(edges))
+	return NewTiling(minDims, maxDims, bins, seed, offsetDiv, opts...)
+}
+
+// Index will return the index of the tile within which v falls. If
+// the tiling uses the BoundsError policy and v falls outside the bounds
+// of the tiling, Index panics. See TryIndex for a non-panicking variant.
 func (t *Tiling) Index(v mat.Vector) int {
+	index, err := t.TryIndex(v)
+	if err != nil {
+		panic(err)
+	}
+	return index
+}
+
+// TryIndex returns the index of the tile within which v falls. If the
+// tiling uses the BoundsError policy and v falls outside the bounds of
+// the tiling, an error wrapping ErrOutOfBounds is returned. If v is nil
+// or does not have each dimension tiled by the tiling, an error
+// wrapping ErrNilInput or ErrDimension is returned.
+func (t *Tiling) TryIndex(v mat.Vector) (int, error) {
+	if err := t.checkVector(v); err != nil {
+		return 0, fmt.Errorf("index: %w", err)
+	}
+	if t.nonFinite == NonFiniteTile && t.anyNonFinite(v) {
+		return t.nonFiniteTile(), nil
+	}
+	if t.rotation != nil {
+		x := make([]float64, len(t.bins))
+		for i := range x {
+			x[i] = v.AtVec(t.dims[i])
+		}
+		index, err := t.rotatedIndex(x)
+		if err != nil {
+			return 0, fmt.Errorf("index: %w", err)
+		}
+		return index, nil
+	}
+
 	index := 0
 
 	// Tile code the vector based on the current Tiling
 	// We loop through each feature to calculate the tile index to
 	// set to 1.0 along this feature dimension
 	for i := len(t.bins) - 1; i > -1; i-- {
-		// Offset the Tiling
-		data := v.AtVec(i) + t.offsets.At(0, i)
-
 		// Calculate the index of the tile along the current feature
 		// dimension in which the feature falls
-		tile := math.Floor((data - t.minDims.AtVec(i)) / t.binLengths[i])
-
-		// Clip tile to within Tiling bounds
-		tile = floatutils.Clip(tile, 0.0, float64(t.bins[i]-1))
+		tileIndex, err := t.place(i, v.AtVec(t.dims[i]))
+		if err != nil {
+			return 0, fmt.Errorf("index: %w", err)
+		}
 
 		// Calculate the index into the tile-coded representation
 		// that should be 1.0 for this Tiling
-		tileIndex := int(tile)
-		if i == len(t.bins)-1 {
-			index += tileIndex
-		} else {
-			index += tileIndex * t.bins[i+1]
-		}
+		index += tileIndex * t.strides[i]
+	}
+	return index, nil
+}
+
+// IndexSlice returns the index of the tile within which x falls, as in
+// Index, where x[i] is input dimension i. The tiling's BoundsPolicy is
+// applied as in Index, but x is read directly, without the overhead of
+// the mat.Vector interface, so that single tilings can be used cheaply
+// on their own, for example for state aggregation. If the tiling uses
+// the BoundsError policy and x falls outside the bounds of the tiling,
+// IndexSlice panics. See TryIndexSlice for a non-panicking variant.
+func (t *Tiling) IndexSlice(x []float64) int {
+	index, err := t.TryIndexSlice(x)
+	if err != nil {
+		panic(err)
 	}
 	return index
 }
 
+// TryIndexSlice returns the index of the tile within which x falls, as
+// in IndexSlice. If the tiling uses the BoundsError policy and x falls
+// outside the bounds of the tiling, an error wrapping ErrOutOfBounds is
+// returned. If x does not have each dimension tiled by the tiling, a
+// *DimensionError is returned.
+func (t *Tiling) TryIndexSlice(x []float64) (int, error) {
+	if want := tiledDims([]*Tiling{t}); len(x) < want {
+		return 0, fmt.Errorf("indexSlice: %w", &DimensionError{
+			Have: len(x),
+			Want: want,
+		})
+	}
+	if t.nonFinite == NonFiniteTile {
+		for i, d := range t.dims {
+			if t.isNonFinite(i, x[d]) {
+				return t.nonFiniteTile(), nil
+			}
+		}
+	}
+	if t.rotation != nil {
+		// Rotation transforms the features in place, so they are copied
+		// to keep x unchanged
+		tiled := make([]float64, len(t.bins))
+		for i := range tiled {
+			tiled[i] = x[t.dims[i]]
+		}
+		index, err := t.rotatedIndex(tiled)
+		if err != nil {
+			return 0, fmt.Errorf("indexSlice: %w", err)
+		}
+		return index, nil
+	}
+
+	index := 0
+	for i := len(t.bins) - 1; i > -1; i-- {
+		tileIndex, err := t.place(i, x[t.dims[i]])
+		if err != nil {
+			return 0, fmt.Errorf("indexSlice: %w", err)
+		}
+		index += tileIndex * t.strides[i]
+	}
+	return index, nil
+}
+
 // IndexBatch returns the indices within which each vector in a batch
 // of vectors falls. The batch of vectors b should be such that each
 // columns is a vector to tile code, and each row corresponds to a
@@ -127,52 +416,381 @@ func (t *Tiling) Index(v mat.Vector) int {
 //					⎣v_1m  v_2m  ...  v_nm ⎦
 //		v⃗_i	 =	 sample/vector i in the batch
 //		v_ij	=	coordinate/feature j of sample vector i
+//
+// If the tiling uses the BoundsError policy and some vector falls
+// outside the bounds of the tiling, IndexBatch panics. See
+// TryIndexBatch for a non-panicking variant.
 func (t *Tiling) IndexBatch(b *mat.Dense) *mat.VecDense {
-	rows, _ := b.Dims()
+	index, err := t.TryIndexBatch(b)
+	if err != nil {
+		panic(err)
+	}
+	return index
+}
+
+// TryIndexBatch returns the indices within which each vector in a
+// batch of vectors falls, as in IndexBatch. If the tiling uses the
+// BoundsError policy and some vector falls outside the bounds of the
+// tiling, an error wrapping ErrOutOfBounds is returned. If b is nil or
+// the vectors do not have each dimension tiled by the tiling, an error
+// wrapping ErrNilInput or ErrDimension is returned.
+func (t *Tiling) TryIndexBatch(b *mat.Dense) (*mat.VecDense, error) {
+	if err := t.checkBatch(b); err != nil {
+		return nil, fmt.Errorf("indexBatch: %w", err)
+	}
+	_, cols := b.Dims()
+	index := mat.NewVecDense(cols, nil)
+	if err := t.indexBatch(b.RawMatrix(), 0, cols,
+		index.RawVector().Data); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
 
-	// A vector of 1.0's will be needed for calculations later
-	ones := matutils.VecOnes(rows)
+// indexBatch sets dst[j-lo] to the index of the vector in column j of
+// the batch b, for each column j in [lo, hi). Tile coordinates are
+// accumulated into an integer workspace taken from the pool of scratch
+// buffers, so that large indices are computed exactly.
+func (t *Tiling) indexBatch(b blas64.General, lo, hi int,
+	dst []float64) error {
+	workspace := getInts(hi - lo)
+	defer putInts(workspace)
+	index := *workspace
 
-	data := mat.NewVecDense(rows, nil)
+	if err := t.indexInts(b, lo, hi, index); err != nil {
+		return err
+	}
+	for j := range index {
+		dst[j] = float64(index[j])
+	}
+	return nil
+}
 
-	index := mat.NewVecDense(rows, nil)
+// indexInts adds the index of the vector in column j of the batch b to
+// index[j-lo], for each column j in [lo, hi). Features are read
+// directly from the backing data of b, without copying rows of the
+// batch.
+func (t *Tiling) indexInts(b blas64.General, lo, hi int,
+	index []int) error {
+	index = index[:hi-lo]
 
-	for i := len(t.bins) - 1; i > -1; i-- {
-		// Clone the next batch of features into the data vector
-		data.CloneFromVec(b.RowView(i))
+	// With the NonFiniteTile policy, vectors with non-finite features
+	// are skipped and placed in the non-finite tile at the end
+	var skip []bool
+	if t.nonFinite == NonFiniteTile {
+		for i, d := range t.dims {
+			for j, x := range b.Data[d*b.Stride+lo : d*b.Stride+hi] {
+				if t.isNonFinite(i, x) {
+					if skip == nil {
+						skip = make([]bool, hi-lo)
+					}
+					skip[j] = true
+				}
+			}
+		}
+	}
+	if skip != nil {
+		defer func() {
+			for j := range skip {
+				if skip[j] {
+					index[j] += t.nonFiniteTile()
+				}
+			}
+		}()
+	}
 
-		// Offset the Tiling
-		data.AddScaledVec(data, t.offsets.At(0, i), ones)
+	if t.rotation != nil {
+		x := make([]float64, len(t.bins))
+		for j := lo; j < hi; j++ {
+			if skip != nil && skip[j-lo] {
+				continue
+			}
+			for i := range x {
+				x[i] = b.Data[t.dims[i]*b.Stride+j]
+			}
+			tile, err := t.rotatedIndex(x)
+			if err != nil {
+				return fmt.Errorf("indexBatch: vector %d: %w", j, err)
+			}
+			index[j-lo] += tile
+		}
+		return nil
+	}
+
+	for i := range t.bins {
+		row := t.dims[i] * b.Stride
+		features := b.Data[row+lo : row+hi]
+		stride := t.strides[i]
+
+		if t.scales[i] != ScaleLinear || t.edges[i] != nil ||
+			t.policy != BoundsClip || t.squashes[i].Func != SquashNone {
+			// Place each feature individually
+			for j, x := range features {
+				if skip != nil && skip[j] {
+					continue
+				}
+				tile, err := t.place(i, x)
+				if err != nil {
+					return fmt.Errorf("indexBatch: vector %d: %w", lo+j, err)
+				}
+				index[j] += tile * stride
+			}
+			continue
+		}
 
 		// Calculate which tile each feature is in along the current
-		// dimension. Subtracting the minimum dimension will ensure that
-		// the data is between [0, 1] before multiplying by the bin
-		// length in VecFloor. The integer value of this * binLength
-		// is the tile along the current dimension that the feature is in:
-		//
-		// binLengths[i] = max - min / binLength
-		// (data - min) / ((max - min) / binLength) =
-		// = ((data - min) / (max - min)) * binLength = IND
-		// int(IND) == index into Tiling along current dimension
-		data.AddScaledVec(data, -t.minDims.AtVec(i), ones)
-		matutils.VecFloor(data, t.binLengths[i])
-
-		// If out-of-bounds, use the last tile
-		matutils.VecClip(data, 0.0, float64(t.bins[i]-1))
+		// dimension after offsetting the Tiling. Subtracting the
+		// minimum dimension ensures that the integer part of the
+		// feature divided by the bin length is the tile along the
+		// current dimension that the feature is in. If out-of-bounds,
+		// the feature is clipped to the first or last tile.
+		offset, min := t.offsets.At(0, i), t.minDims.AtVec(i)
+		binLength := t.binLengths[i]
+		last := float64(t.bins[i] - 1)
+		for j, x := range features {
+			if skip != nil && skip[j] {
+				continue
+			}
+			if math.IsNaN(x) || math.IsInf(x, 0) {
+				tile, err := t.placeNonFinite(i, x)
+				if err != nil {
+					return fmt.Errorf("indexBatch: vector %d: %w", lo+j, err)
+				}
+				index[j] += tile * stride
+				continue
+			}
+			tile := math.Floor((x + offset - min) / binLength)
+			index[j] += int(floatutils.Clip(tile, 0.0, last)) * stride
+		}
+	}
+	return nil
+}
 
-		// Calculate the index into the tile-coded representation
-		// that should be 1.0 for this Tiling
-		if i == len(t.bins)-1 {
-			index.AddVec(index, data)
-		} else {
-			index.AddScaledVec(index, float64(t.bins[i+1]), data)
+// place returns the tile along dimension i in which the input feature
+// x falls, after scaling and offsetting the feature and applying the
+// BoundsPolicy of the tiling. For the BoundsExtend policy, the
+// returned tile includes the underflow tile at the start of the
+// dimension.
+func (t *Tiling) place(i int, x float64) (int, error) {
+	return t.placeOffset(i, x, t.offsets.At(0, i))
+}
+
+// placeOffset returns the index of the tile along dimension i in which
+// the input feature x falls when dimension i is offset by offset
+func (t *Tiling) placeOffset(i int, x, offset float64) (int, error) {
+	if t.isNonFinite(i, x) {
+		return t.placeNonFinite(i, x)
+	}
+	x = t.transform(i, x)
+	lower := t.minDims.AtVec(i)
+	upper := lower + float64(t.bins[i])*t.binLengths[i]
+	outside := x < lower || x > upper
+
+	switch t.policy {
+	case BoundsError:
+		if outside {
+			return 0, fmt.Errorf("dimension %d: value outside [%v, %v]: %w",
+				t.dims[i], t.low[i], t.high[i], ErrOutOfBounds)
+		}
+
+	case BoundsWrap:
+		// Wrap the feature into the bounds of the tiling, then wrap
+		// the tile in case the offset moved the feature past the end
+		width := upper - lower
+		x = lower + math.Mod(x-lower, width)
+		if x < lower {
+			x += width
+		}
+		tile := int(t.tile(i, x+offset)) % t.bins[i]
+		if tile < 0 {
+			tile += t.bins[i]
 		}
+		return tile, nil
+
+	case BoundsExtend:
+		if x < lower {
+			return 0, nil
+		} else if x > upper {
+			return t.bins[i] + 1, nil
+		}
+		tile := t.tile(i, x+offset)
+		return int(floatutils.Clip(tile, 0.0, float64(t.bins[i]-1))) + 1, nil
 	}
 
-	return index
+	// Clip tile to within Tiling bounds
+	tile := t.tile(i, x+offset)
+	return int(floatutils.Clip(tile, 0.0, float64(t.bins[i]-1))), nil
 }
 
-// Tiles returns the number of tiles in the tiling
+// transform moves the input feature x along dimension i into the
+// space in which the tiling is uniform, by squashing and then scaling
+// the feature
+func (t *Tiling) transform(i int, x float64) float64 {
+	return t.scales[i].apply(t.squashes[i].Apply(x))
+}
+
+// size returns the number of tiles along dimension i of the tiling,
+// including any tiles added by its BoundsPolicy
+func (t *Tiling) size(i int) int {
+	if t.policy == BoundsExtend {
+		return t.bins[i] + 2
+	}
+	return t.bins[i]
+}
+
+// tile returns the tile along dimension i in which the scaled and
+// offset value data falls. The returned tile is not clipped to within
+// the bounds of the Tiling.
+func (t *Tiling) tile(i int, data float64) float64 {
+	if e := t.edges[i]; e != nil {
+		// Find the last edge which does not exceed data
+		return float64(sort.Search(len(e), func(j int) bool {
+			return e[j] > data
+		}) - 1)
+	}
+	return math.Floor((data - t.minDims.AtVec(i)) / t.binLengths[i])
+}
+
+// rescale moves the bounds of the tiling to minDims and maxDims, which
+// describe all input dimensions. The number of bins along each
+// dimension is kept, and bin lengths, bin edges, and offsets are
+// stretched so that each offset remains the same fraction of the
+// width of a tile. Categorical dimensions are never rescaled.
+func (t *Tiling) rescale(minDims, maxDims mat.Vector) error {
+	// Validate the new bounds before modifying the tiling
+	if err := t.checkRescale(minDims, maxDims); err != nil {
+		return err
+	}
+
+	scaledMin := t.minDims.(*mat.VecDense)
+	for k, i := range t.dims {
+		if t.categories[k] {
+			continue
+		}
+
+		oldMin := t.transform(k, t.low[k])
+		oldMax := t.transform(k, t.high[k])
+		min := t.transform(k, minDims.AtVec(i))
+		max := t.transform(k, maxDims.AtVec(i))
+		stretch := (max - min) / (oldMax - oldMin)
+
+		if e := t.edges[k]; e != nil {
+			for j := range e {
+				e[j] = min + (e[j]-oldMin)*stretch
+			}
+		}
+		t.binLengths[k] = (max - min) / float64(t.bins[k])
+		t.offsets.Set(0, k, t.offsets.At(0, k)*stretch)
+		scaledMin.SetVec(k, min)
+		t.low[k], t.high[k] = minDims.AtVec(i), maxDims.AtVec(i)
+	}
+	return nil
+}
+
+// checkRescale returns an error if the tiling cannot be rescaled to
+// the bounds minDims and maxDims with rescale
+func (t *Tiling) checkRescale(minDims, maxDims mat.Vector) error {
+	for k, i := range t.dims {
+		if i >= minDims.Len() || i >= maxDims.Len() {
+			return fmt.Errorf("rescale: no bounds given for dimension %d", i)
+		}
+		if t.categories[k] {
+			continue
+		}
+		if maxDims.AtVec(i) <= minDims.AtVec(i) {
+			return fmt.Errorf("rescale: maximum of dimension %d must "+
+				"exceed its minimum: %v <= %v", i, maxDims.AtVec(i),
+				minDims.AtVec(i))
+		}
+		if t.scales[k] == ScaleLog && minDims.AtVec(i) <= 0 {
+			return fmt.Errorf("rescale: dimension %d uses a log scale but "+
+				"has non-positive minimum %v", i, minDims.AtVec(i))
+		}
+		min := t.transform(k, minDims.AtVec(i))
+		max := t.transform(k, maxDims.AtVec(i))
+		if math.IsInf(max-min, 0) || math.IsNaN(max-min) {
+			return fmt.Errorf("rescale: bounds of dimension %d are not "+
+				"finite: [%v, %v]", i, minDims.AtVec(i), maxDims.AtVec(i))
+		}
+	}
+	return nil
+}
+
+// Squashes returns the squashing transform applied to each dimension
+// of the tiling before it is tiled
+func (t *Tiling) Squashes() []Squash {
+	return append([]Squash(nil), t.squashes...)
+}
+
+// Dims returns the input dimensions tiled by the tiling, in the order
+// in which they are tiled
+func (t *Tiling) Dims() []int {
+	return append([]int(nil), t.dims...)
+}
+
+// Offsets returns the offset of the tiling along each dimension, in
+// the space in which tiles are equally spaced
+func (t *Tiling) Offsets() []float64 {
+	return append([]float64(nil), t.offsets.RawRowView(0)...)
+}
+
+// Bins returns the number of bins along each dimension of the tiling,
+// not including any tiles added by its BoundsPolicy
+func (t *Tiling) Bins() []int {
+	return append([]int(nil), t.bins...)
+}
+
+// BinLengths returns the length of the bins along each dimension of
+// the tiling, in the space in which tiles are equally spaced. For
+// dimensions with explicit bin edges, this is the mean bin length.
+func (t *Tiling) BinLengths() []float64 {
+	return append([]float64(nil), t.binLengths...)
+}
+
+// Bounds returns the minimum and maximum of each dimension of the
+// tiling, in the input space
+func (t *Tiling) Bounds() (minDims, maxDims []float64) {
+	return append([]float64(nil), t.low...), append([]float64(nil), t.high...)
+}
+
+// Policy returns the BoundsPolicy of the tiling
+func (t *Tiling) Policy() BoundsPolicy {
+	return t.policy
+}
+
+// initStrides computes the stride of each dimension of the tiling,
+// which is the number of tiles spanned by a single step along the
+// dimension in the index of a tile. The last dimension varies fastest.
+// If the tiling would have more than MaxFeatures tiles, an error
+// wrapping ErrFeatureSpace is returned.
+func (t *Tiling) initStrides() error {
+	t.strides = make([]int, len(t.bins))
+	stride := 1
+	for i := len(t.bins) - 1; i > -1; i-- {
+		t.strides[i] = stride
+
+		var err error
+		if stride, err = mulFeatures(stride, t.size(i)); err != nil {
+			return err
+		}
+	}
+	if t.nonFinite == NonFiniteTile {
+		if _, err := addFeatures(stride, 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Tiles returns the number of tiles in the tiling, including the
+// non-finite tile of the NonFiniteTile policy
 func (t *Tiling) Tiles() int {
-	return prod(t.bins)
+	tiles := 1
+	for i := range t.bins {
+		tiles *= t.size(i)
+	}
+	if t.nonFinite == NonFiniteTile {
+		tiles++
+	}
+	return tiles
 }
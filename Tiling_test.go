@@ -0,0 +1,832 @@
+package gotile
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestTilingLogScale(t *testing.T) {
+	// Use an infinite offset divisor so that the tiling is not offset
+	// from the origin
+	tiling, err := NewTiling(
+		mat.NewVecDense(1, []float64{1}),
+		mat.NewVecDense(1, []float64{1000}),
+		[]int{3},
+		1,
+		1e300,
+		WithScales(ScaleLog),
+	)
+	if err != nil {
+		t.Fatalf("could not create tiling: %v", err)
+	}
+
+	inputs := []float64{-1, 2, 9, 11, 99, 101, 999, 5000}
+	want := []int{0, 0, 0, 1, 1, 2, 2, 2}
+	for i := range inputs {
+		v := mat.NewVecDense(1, []float64{inputs[i]})
+		if got := tiling.Index(v); got != want[i] {
+			t.Errorf("index(%v): have(%v) want(%v)", inputs[i], got, want[i])
+		}
+	}
+
+	batch := mat.NewDense(1, len(inputs), inputs)
+	indices := tiling.IndexBatch(batch)
+	for i := range want {
+		if got := int(indices.AtVec(i)); got != want[i] {
+			t.Errorf("indexBatch(%v): have(%v) want(%v)", inputs[i], got,
+				want[i])
+		}
+	}
+
+	_, err = NewTiling(
+		mat.NewVecDense(1, []float64{0}),
+		mat.NewVecDense(1, []float64{1000}),
+		[]int{3},
+		1,
+		-1,
+		WithScales(ScaleLog),
+	)
+	if err == nil {
+		t.Error("expected error with non-positive log-scaled minimum")
+	}
+}
+
+func TestTilingEdges(t *testing.T) {
+	tiling, err := NewTilingEdges(
+		[][]float64{{0, 0.1, 0.5, 2.0, 10.0}, {-1, 0, 1}},
+		1,
+		1e300,
+	)
+	if err != nil {
+		t.Fatalf("could not create tiling: %v", err)
+	}
+	if tiling.Tiles() != 8 {
+		t.Errorf("tiles: have(%v) want(%v)", tiling.Tiles(), 8)
+	}
+
+	inputs := [][]float64{
+		{-5, -5}, {0.05, 0.5}, {0.3, -0.5}, {1.0, 0.5}, {9, -0.5}, {11, 11},
+	}
+	want := []int{0, 1, 2, 5, 6, 7}
+	batch := mat.NewDense(2, len(inputs), nil)
+	for i := range inputs {
+		batch.SetCol(i, inputs[i])
+		v := mat.NewVecDense(2, inputs[i])
+		if got := tiling.Index(v); got != want[i] {
+			t.Errorf("index(%v): have(%v) want(%v)", inputs[i], got, want[i])
+		}
+	}
+
+	indices := tiling.IndexBatch(batch)
+	for i := range want {
+		if got := int(indices.AtVec(i)); got != want[i] {
+			t.Errorf("indexBatch(%v): have(%v) want(%v)", inputs[i], got,
+				want[i])
+		}
+	}
+
+	_, err = NewTilingEdges([][]float64{{0, 1, 1}}, 1, -1)
+	if err == nil {
+		t.Error("expected error with non-increasing bin edges")
+	}
+}
+
+func TestTilingCategorical(t *testing.T) {
+	minDims := mat.NewVecDense(2, []float64{0, 0})
+	maxDims := mat.NewVecDense(2, []float64{1, 2})
+
+	for seed := uint64(0); seed < 10; seed++ {
+		tiling, err := NewTiling(minDims, maxDims, []int{4, 3}, seed, 1,
+			WithCategorical(1))
+		if err != nil {
+			t.Fatalf("could not create tiling: %v", err)
+		}
+
+		for category := 0; category < 3; category++ {
+			v := mat.NewVecDense(2, []float64{0.5, float64(category)})
+			if got := tiling.Index(v) % 3; got != category {
+				t.Errorf("seed %v: category: have(%v) want(%v)", seed, got,
+					category)
+			}
+		}
+	}
+
+	_, err := NewTiling(minDims, maxDims, []int{4, 2}, 0, -1,
+		WithCategorical(1))
+	if err == nil {
+		t.Error("expected error with too few categorical bins")
+	}
+}
+
+func TestTilingBoundsPolicy(t *testing.T) {
+	inputs := []float64{-0.5, 0.5, 1.5}
+	want := map[BoundsPolicy][]int{
+		BoundsClip:   {0, 2, 3},
+		BoundsWrap:   {2, 2, 2},
+		BoundsExtend: {0, 3, 5},
+	}
+
+	for policy, w := range want {
+		tiling, err := NewTiling(
+			mat.NewVecDense(1, []float64{0}),
+			mat.NewVecDense(1, []float64{1}),
+			[]int{4},
+			1,
+			1e300,
+			WithBoundsPolicy(policy),
+		)
+		if err != nil {
+			t.Fatalf("could not create tiling: %v", err)
+		}
+
+		indices := tiling.IndexBatch(mat.NewDense(1, len(inputs), inputs))
+		for i := range inputs {
+			v := mat.NewVecDense(1, []float64{inputs[i]})
+			if got := tiling.Index(v); got != w[i] {
+				t.Errorf("%v: index(%v): have(%v) want(%v)", policy,
+					inputs[i], got, w[i])
+			}
+			if got := int(indices.AtVec(i)); got != w[i] {
+				t.Errorf("%v: indexBatch(%v): have(%v) want(%v)", policy,
+					inputs[i], got, w[i])
+			}
+			if got := tiling.IndexSlice(inputs[i : i+1]); got != w[i] {
+				t.Errorf("%v: indexSlice(%v): have(%v) want(%v)", policy,
+					inputs[i], got, w[i])
+			}
+		}
+	}
+
+	tiling, err := NewTiling(
+		mat.NewVecDense(1, []float64{0}),
+		mat.NewVecDense(1, []float64{1}),
+		[]int{4},
+		1,
+		-1,
+		WithBoundsPolicy(BoundsError),
+	)
+	if err != nil {
+		t.Fatalf("could not create tiling: %v", err)
+	}
+	if _, err := tiling.TryIndex(mat.NewVecDense(1, []float64{0.5})); err != nil {
+		t.Errorf("unexpected error for in-bounds vector: %v", err)
+	}
+	_, err = tiling.TryIndex(mat.NewVecDense(1, []float64{1.5}))
+	if !errors.Is(err, ErrOutOfBounds) {
+		t.Errorf("tryIndex: have(%v) want(%v)", err, ErrOutOfBounds)
+	}
+	_, err = tiling.TryIndexBatch(mat.NewDense(1, len(inputs), inputs))
+	if !errors.Is(err, ErrOutOfBounds) {
+		t.Errorf("tryIndexBatch: have(%v) want(%v)", err, ErrOutOfBounds)
+	}
+	if _, err := tiling.TryIndexSlice([]float64{1.5}); !errors.Is(err,
+		ErrOutOfBounds) {
+		t.Errorf("tryIndexSlice: have(%v) want(%v)", err, ErrOutOfBounds)
+	}
+	if _, err := tiling.TryIndexSlice(nil); !errors.Is(err, ErrDimension) {
+		t.Errorf("tryIndexSlice(nil): have(%v) want(%v)", err, ErrDimension)
+	}
+}
+
+func TestFitTiling(t *testing.T) {
+	const samples = 100
+	data := mat.NewDense(1, samples, nil)
+	for i := 0; i < samples; i++ {
+		data.Set(0, i, float64(i*i))
+	}
+
+	tiling, err := FitTiling(data, []int{4})
+	if err != nil {
+		t.Fatalf("could not fit tiling: %v", err)
+	}
+
+	counts := make([]int, tiling.Tiles())
+	indices := tiling.IndexBatch(data)
+	for i := 0; i < samples; i++ {
+		counts[int(indices.AtVec(i))]++
+	}
+	for i, count := range counts {
+		if count < 24 || count > 26 {
+			t.Errorf("tile %d: have(%v samples) want(~25 samples)", i, count)
+		}
+	}
+}
+
+func TestTilingSquash(t *testing.T) {
+	tiling, err := NewTiling(
+		mat.NewVecDense(1, []float64{math.Inf(-1)}),
+		mat.NewVecDense(1, []float64{math.Inf(1)}),
+		[]int{4},
+		1,
+		1e300,
+		WithSquashes(Squash{Func: SquashTanh, Scale: 2}),
+	)
+	if err != nil {
+		t.Fatalf("could not create tiling: %v", err)
+	}
+
+	inputs := []float64{-100, -0.1, 0.1, 100}
+	for i := range inputs {
+		v := mat.NewVecDense(1, []float64{inputs[i]})
+		if got := tiling.Index(v); got != i {
+			t.Errorf("index(%v): have(%v) want(%v)", inputs[i], got, i)
+		}
+	}
+
+	for _, f := range []SquashFunc{SquashTanh, SquashArctan, SquashSigmoid} {
+		s := Squash{Func: f, Scale: 3}
+		if got := s.Inverse(s.Apply(1.5)); math.Abs(got-1.5) > 1e-9 {
+			t.Errorf("%v: inverse: have(%v) want(%v)", f, got, 1.5)
+		}
+	}
+}
+
+func TestTilingIndexBatchScratch(t *testing.T) {
+	tiling, err := NewTiling(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[]int{4, 3},
+		1,
+		-1,
+	)
+	if err != nil {
+		t.Fatalf("could not create tiling: %v", err)
+	}
+
+	// Batches of different sizes reuse the same scratch buffers
+	for _, size := range []int{5, 2, 7, 1} {
+		batch := mat.NewDense(2, size, nil)
+		for i := 0; i < size; i++ {
+			batch.SetCol(i, []float64{float64(i) / 7, 1 - float64(i)/7})
+		}
+
+		indices := tiling.IndexBatch(batch)
+		for i := 0; i < size; i++ {
+			want := tiling.Index(batch.ColView(i))
+			if got := int(indices.AtVec(i)); got != want {
+				t.Errorf("batch size %d: indexBatch(%d): have(%v) want(%v)",
+					size, i, got, want)
+			}
+		}
+	}
+}
+
+func TestTilingStrides(t *testing.T) {
+	bins := []int{2, 3, 4}
+	tiling, err := NewTiling(
+		mat.NewVecDense(3, []float64{0, 0, 0}),
+		mat.NewVecDense(3, []float64{1, 1, 1}),
+		bins,
+		1,
+		1e300,
+	)
+	if err != nil {
+		t.Fatalf("could not create tiling: %v", err)
+	}
+
+	// The center of every tile should have a distinct index
+	seen := make(map[int]bool, tiling.Tiles())
+	for i := 0; i < bins[0]; i++ {
+		for j := 0; j < bins[1]; j++ {
+			for k := 0; k < bins[2]; k++ {
+				v := mat.NewVecDense(3, []float64{
+					(float64(i) + 0.5) / float64(bins[0]),
+					(float64(j) + 0.5) / float64(bins[1]),
+					(float64(k) + 0.5) / float64(bins[2]),
+				})
+				index := tiling.Index(v)
+				if want := (i*bins[1]+j)*bins[2] + k; index != want {
+					t.Errorf("index(%v, %v, %v): have(%v) want(%v)", i, j, k,
+						index, want)
+				}
+				seen[index] = true
+			}
+		}
+	}
+	if len(seen) != tiling.Tiles() {
+		t.Errorf("distinct indices: have(%v) want(%v)", len(seen),
+			tiling.Tiles())
+	}
+}
+
+func BenchmarkTilingIndex(b *testing.B) {
+	tiling, _ := NewTiling(
+		mat.NewVecDense(6, []float64{0, 0, 0, 0, 0, 0}),
+		mat.NewVecDense(6, []float64{1, 1, 1, 1, 1, 1}),
+		[]int{8, 8, 8, 8, 8, 8},
+		1,
+		-1,
+	)
+	v := mat.NewVecDense(6, []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6})
+
+	for i := 0; i < b.N; i++ {
+		tiling.Index(v)
+	}
+}
+
+func BenchmarkTilingIndexSlice(b *testing.B) {
+	tiling, _ := NewTiling(
+		mat.NewVecDense(6, []float64{0, 0, 0, 0, 0, 0}),
+		mat.NewVecDense(6, []float64{1, 1, 1, 1, 1, 1}),
+		[]int{8, 8, 8, 8, 8, 8},
+		1,
+		-1,
+	)
+	x := []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tiling.IndexSlice(x)
+	}
+}
+
+func BenchmarkTilingIndexBatch(b *testing.B) {
+	const batchSize = 1024
+	tiling, _ := NewTiling(
+		mat.NewVecDense(6, []float64{0, 0, 0, 0, 0, 0}),
+		mat.NewVecDense(6, []float64{1, 1, 1, 1, 1, 1}),
+		[]int{8, 8, 8, 8, 8, 8},
+		1,
+		-1,
+	)
+	batch := mat.NewDense(6, batchSize, nil)
+	for i := 0; i < batchSize; i++ {
+		batch.SetCol(i, []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6})
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tiling.IndexBatch(batch)
+	}
+}
+
+func TestTilingTileCenter(t *testing.T) {
+	for _, policy := range []BoundsPolicy{BoundsClip, BoundsExtend} {
+		tiling, err := NewTiling(
+			mat.NewVecDense(2, []float64{-1, 1}),
+			mat.NewVecDense(2, []float64{1, 1000}),
+			[]int{4, 3},
+			5,
+			-1,
+			WithScales(ScaleLinear, ScaleLog),
+			WithBoundsPolicy(policy),
+		)
+		if err != nil {
+			t.Fatalf("could not create tiling: %v", err)
+		}
+
+		for i := 0; i < tiling.Tiles(); i++ {
+			center := tiling.TileCenter(i)
+			if got := tiling.Index(mat.NewVecDense(2, center)); got != i {
+				t.Errorf("%v: index(tileCenter(%v)): have(%v) want(%v)",
+					policy, i, got, i)
+			}
+
+			for k, bound := range tiling.TileBounds(i) {
+				if center[k] < bound.Min || center[k] > bound.Max {
+					t.Errorf("%v: tileCenter(%v): %v outside %v", policy, i,
+						center[k], bound)
+				}
+			}
+		}
+	}
+}
+
+func TestTilingOffsetDiv(t *testing.T) {
+	minDims := mat.NewVecDense(2, []float64{0, 0})
+	maxDims := mat.NewVecDense(2, []float64{4, 4})
+
+	// Offsets are bounded by the bin length divided by the given
+	// offsetDiv, or by OffsetDiv if offsetDiv is non-positive
+	for _, offsetDiv := range []float64{4, 1e300, -1} {
+		bound := 1 / offsetDiv
+		if offsetDiv <= 0 {
+			bound = 1 / OffsetDiv
+		}
+
+		largest := 0.0
+		for seed := uint64(0); seed < 50; seed++ {
+			tiling, err := NewTiling(minDims, maxDims, []int{4, 4}, seed,
+				offsetDiv)
+			if err != nil {
+				t.Fatalf("could not create tiling: %v", err)
+			}
+			for _, offset := range tiling.Offsets() {
+				largest = math.Max(largest, math.Abs(offset))
+			}
+		}
+		if largest > bound {
+			t.Errorf("offsets(offsetDiv=%v): have(%v) want(within %v)",
+				offsetDiv, largest, bound)
+		}
+		if largest < bound/2 {
+			t.Errorf("offsets(offsetDiv=%v): have(%v) want(near %v)",
+				offsetDiv, largest, bound)
+		}
+	}
+}
+
+func TestTilingAccessors(t *testing.T) {
+	tiling, err := NewTiling(
+		mat.NewVecDense(3, []float64{0, -1, 2}),
+		mat.NewVecDense(3, []float64{1, 1, 4}),
+		[]int{4, 2},
+		1,
+		-1,
+		WithDims(2, 0),
+	)
+	if err != nil {
+		t.Fatalf("could not create tiling: %v", err)
+	}
+
+	if bins := tiling.Bins(); bins[0] != 4 || bins[1] != 2 {
+		t.Errorf("bins: have(%v) want(%v)", bins, []int{4, 2})
+	}
+	if lengths := tiling.BinLengths(); lengths[0] != 0.5 || lengths[1] != 0.5 {
+		t.Errorf("binLengths: have(%v) want(%v)", lengths, []float64{0.5, 0.5})
+	}
+	minDims, maxDims := tiling.Bounds()
+	if minDims[0] != 2 || maxDims[0] != 4 || minDims[1] != 0 || maxDims[1] != 1 {
+		t.Errorf("bounds: have(%v, %v) want(%v, %v)", minDims, maxDims,
+			[]float64{2, 0}, []float64{4, 1})
+	}
+	for i, offset := range tiling.Offsets() {
+		if math.Abs(offset) > 0.5/OffsetDiv {
+			t.Errorf("offsets[%d]: have(%v) want(within %v)", i, offset,
+				0.5/OffsetDiv)
+		}
+	}
+
+	// Accessors return copies
+	tiling.Bins()[0] = 100
+	if tiling.Bins()[0] != 4 {
+		t.Error("bins: modifying returned slice modified tiling")
+	}
+}
+
+func TestTilingRotation(t *testing.T) {
+	minDims := mat.NewVecDense(2, []float64{0, -10})
+	maxDims := mat.NewVecDense(2, []float64{1, 10})
+	tiling, err := NewTiling(minDims, maxDims, []int{4, 4}, 2, -1,
+		WithRotation(), WithBoundsPolicy(BoundsError))
+	if err != nil {
+		t.Fatalf("could not create tiling: %v", err)
+	}
+
+	// The rotation is orthonormal
+	r := tiling.Rotation()
+	var product mat.Dense
+	product.Mul(r, r.T())
+	if !mat.EqualApprox(&product, eye(2), 1e-12) {
+		t.Errorf("rotation: have(%v) which is not orthonormal",
+			mat.Formatted(r))
+	}
+
+	// Every input within the bounds, including the corners, is within
+	// the bounds after rotation, and both index paths agree
+	const n = 11
+	batch := mat.NewDense(2, n*n, nil)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			batch.Set(0, i*n+j, float64(i)/(n-1))
+			batch.Set(1, i*n+j, -10+20*float64(j)/(n-1))
+		}
+	}
+	indices, err := tiling.TryIndexBatch(batch)
+	if err != nil {
+		t.Fatalf("indexBatch: %v", err)
+	}
+	for j := 0; j < n*n; j++ {
+		index, err := tiling.TryIndex(batch.ColView(j))
+		if err != nil {
+			t.Fatalf("index(%v): %v", mat.Formatted(batch.ColView(j).T()), err)
+		}
+		if float64(index) != indices.AtVec(j) {
+			t.Errorf("index(%v): have(%v) want(%v)",
+				mat.Formatted(batch.ColView(j).T()), index, indices.AtVec(j))
+		}
+		x := mat.Col(nil, j, batch)
+		if index := tiling.IndexSlice(x); float64(index) != indices.AtVec(j) {
+			t.Errorf("indexSlice(%v): have(%v) want(%v)", x, index,
+				indices.AtVec(j))
+		}
+	}
+	if _, err := tiling.TryIndex(mat.NewVecDense(2, []float64{2, 0})); err ==
+		nil {
+		t.Error("expected error with out-of-bounds vector")
+	}
+
+	// The rotation is serialized with the tiling
+	data, err := tiling.MarshalBinary()
+	if err != nil {
+		t.Fatalf("could not marshal: %v", err)
+	}
+	var loaded Tiling
+	if err := loaded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("could not unmarshal: %v", err)
+	}
+	if !mat.Equal(loaded.Rotation(), r) {
+		t.Errorf("rotation after unmarshal: have(%v) want(%v)",
+			mat.Formatted(loaded.Rotation()), mat.Formatted(r))
+	}
+
+	if _, err := NewTiling(minDims, maxDims, []int{4, 4}, 2, -1,
+		WithRotation(), WithBoundsPolicy(BoundsWrap)); err == nil {
+		t.Error("expected error rotating a tiling with BoundsWrap")
+	}
+}
+
+func TestOffsetHalton(t *testing.T) {
+	const tilings = 16
+	minDims := mat.NewVecDense(2, []float64{0, 0})
+	maxDims := mat.NewVecDense(2, []float64{1, 1})
+	bins := make([][]int, tilings)
+	for k := range bins {
+		bins[k] = []int{4, 4}
+	}
+	tc, err := New(minDims, maxDims, bins, 0, false, 1,
+		WithOffsetStrategy(OffsetHalton))
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+
+	// Along the first dimension, consecutive points of the Halton
+	// sequence place exactly one offset in each of tilings equal
+	// subintervals of the allowed offsets
+	const bound = 0.25
+	seen := make([]bool, tilings)
+	for k, tiling := range tc.Tilings() {
+		offset := tiling.Offsets()[0]
+		if offset < -bound || offset > bound {
+			t.Fatalf("offsets(%d): have(%v) want(within [%v, %v])", k,
+				offset, -bound, bound)
+		}
+		bucket := int((offset + bound) / (2 * bound) * tilings)
+		if seen[bucket] {
+			t.Errorf("offsets(%d): have(%v) which shares subinterval %d", k,
+				offset, bucket)
+		}
+		seen[bucket] = true
+	}
+
+	// Offsets are deterministic given the seed
+	tiling, err := NewTiling(minDims, maxDims, []int{4, 4}, 3, 1,
+		WithOffsetStrategy(OffsetHalton))
+	if err != nil {
+		t.Fatalf("could not create tiling: %v", err)
+	}
+	have, want := tiling.Offsets(), tc.Tilings()[3].Offsets()
+	for i := range want {
+		if have[i] != want[i] {
+			t.Errorf("offsets(seed 3): have(%v) want(%v)", have, want)
+			break
+		}
+	}
+
+	var strategy OffsetStrategy
+	if err := strategy.UnmarshalText([]byte("halton")); err != nil ||
+		strategy != OffsetHalton {
+		t.Errorf("unmarshalText(halton): have(%v, %v) want(%v)", strategy,
+			err, OffsetHalton)
+	}
+}
+
+func TestTilingNonFinite(t *testing.T) {
+	minDims := mat.NewVecDense(2, []float64{0, 0})
+	maxDims := mat.NewVecDense(2, []float64{1, 1})
+	nan, inf := math.NaN(), math.Inf(1)
+	batch := mat.NewDense(2, 4, []float64{
+		nan, inf, -inf, 0.5,
+		0.5, 0.5, 0.5, 0.5,
+	})
+
+	tiling, err := NewTiling(minDims, maxDims, []int{4, 4}, 1, 1e300,
+		WithNonFinitePolicy(NonFiniteError))
+	if err != nil {
+		t.Fatalf("could not create tiling: %v", err)
+	}
+	if _, err := tiling.TryIndex(batch.ColView(0)); !errors.Is(err,
+		ErrNonFinite) {
+		t.Errorf("index(NaN): have(%v) want(%v)", err, ErrNonFinite)
+	}
+	if _, err := tiling.TryIndexBatch(batch); !errors.Is(err, ErrNonFinite) {
+		t.Errorf("indexBatch: have(%v) want(%v)", err, ErrNonFinite)
+	}
+
+	// Clipped non-finite features fall in the first or last tile, which
+	// is the default
+	clip, err := NewTiling(minDims, maxDims, []int{4, 4}, 1, 1e300)
+	if err != nil {
+		t.Fatalf("could not create tiling: %v", err)
+	}
+	if p := clip.NonFinitePolicy(); p != NonFiniteClip {
+		t.Errorf("nonFinitePolicy: have(%v) want(%v)", p, NonFiniteClip)
+	}
+	indices := clip.IndexBatch(batch)
+	for j, want := range []int{2, 14, 2, 10} {
+		if have := clip.Index(batch.ColView(j)); have != want ||
+			indices.AtVec(j) != float64(want) {
+			t.Errorf("index(%v): have(%v, %v) want(%v)",
+				mat.Formatted(batch.ColView(j).T()), have, indices.AtVec(j),
+				want)
+		}
+	}
+
+	// Vectors with non-finite features fall in the non-finite tile
+	tile, err := NewTiling(minDims, maxDims, []int{4, 4}, 1, 1e300,
+		WithNonFinitePolicy(NonFiniteTile))
+	if err != nil {
+		t.Fatalf("could not create tiling: %v", err)
+	}
+	if n := tile.Tiles(); n != 17 {
+		t.Errorf("tiles: have(%v) want(%v)", n, 17)
+	}
+	indices = tile.IndexBatch(batch)
+	for j, want := range []int{16, 16, 16, 10} {
+		if have := tile.Index(batch.ColView(j)); have != want ||
+			indices.AtVec(j) != float64(want) {
+			t.Errorf("index(%v): have(%v, %v) want(%v)",
+				mat.Formatted(batch.ColView(j).T()), have, indices.AtVec(j),
+				want)
+		}
+	}
+
+	data, err := tile.MarshalBinary()
+	if err != nil {
+		t.Fatalf("could not marshal: %v", err)
+	}
+	var loaded Tiling
+	if err := loaded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("could not unmarshal: %v", err)
+	}
+	if p := loaded.NonFinitePolicy(); p != NonFiniteTile {
+		t.Errorf("nonFinitePolicy after unmarshal: have(%v) want(%v)", p,
+			NonFiniteTile)
+	}
+
+	// Squashed infinite features are finite
+	squashed, err := NewTiling(mat.NewVecDense(1, []float64{-inf}),
+		mat.NewVecDense(1, []float64{inf}), []int{4}, 1, 1e300,
+		WithSquashes(Squash{Func: SquashTanh, Scale: 1}))
+	if err != nil {
+		t.Fatalf("could not create tiling: %v", err)
+	}
+	if index, err := squashed.TryIndex(mat.NewVecDense(1,
+		[]float64{inf})); err != nil || index != 3 {
+		t.Errorf("index(+Inf): have(%v, %v) want(3)", index, err)
+	}
+}
+
+func TestTilingBinaryMalformed(t *testing.T) {
+	minDims := mat.NewVecDense(2, []float64{0, 0})
+	maxDims := mat.NewVecDense(2, []float64{1, 1})
+	tiling, err := NewTiling(minDims, maxDims, []int{4, 4}, 2, -1,
+		WithRotation())
+	if err != nil {
+		t.Fatalf("could not create tiling: %v", err)
+	}
+	data, err := tiling.MarshalBinary()
+	if err != nil {
+		t.Fatalf("could not marshal: %v", err)
+	}
+
+	// Truncating the rotation is an error
+	var loaded Tiling
+	for _, n := range []int{0, 3, len(data) - 9, len(data) - 2} {
+		if err := loaded.UnmarshalBinary(data[:n]); err == nil {
+			t.Errorf("expected error unmarshaling %d of %d bytes", n,
+				len(data))
+		}
+	}
+
+	// A rotation over many dimensions is rejected before allocating it.
+	// Each dimension takes 54 bytes when its fields are zero, but its
+	// rotation would take 8 bytes per dimension squared.
+	const dims = 1 << 16
+	malformed := append([]byte(nil), tilingHeader...)
+	malformed = appendUvarint(malformed, dims)
+	malformed = append(malformed, make([]byte, 9+dims*54)...)
+	malformed = append(malformed, 1, 0)
+	if err := loaded.UnmarshalBinary(malformed); err == nil {
+		t.Error("expected error unmarshaling a rotation larger than the data")
+	}
+}
+
+func TestTilingRenderSVG(t *testing.T) {
+	minDims := mat.NewVecDense(3, []float64{0, -1, 0})
+	maxDims := mat.NewVecDense(3, []float64{1, 1, 1})
+	tiling, err := NewTiling(minDims, maxDims, []int{4, 3}, 1, 4,
+		WithDims(1, 0))
+	if err != nil {
+		t.Fatalf("could not create tiling: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tiling.RenderSVG(&buf, [2]int{0, 1}); err != nil {
+		t.Fatalf("renderSVG: %v", err)
+	}
+
+	// The image is well-formed, with the background and one rectangle
+	// for each tile inside the image
+	rects := 0
+	dec := xml.NewDecoder(&buf)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("renderSVG: invalid SVG: %v", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if ok && start.Name.Local == "rect" {
+			rects++
+			for _, attr := range start.Attr {
+				if v, err := strconv.ParseFloat(attr.Value, 64); err == nil &&
+					(v < 0 || v > svgSize+2*svgMargin) {
+					t.Errorf("renderSVG: %v=%v outside image", attr.Name.Local,
+						v)
+				}
+			}
+		}
+	}
+	if have, want := rects, tiling.Tiles()+1; have != want {
+		t.Errorf("renderSVG: have(%v rects) want(%v)", have, want)
+	}
+
+	if err := tiling.RenderSVG(&buf, [2]int{0, 2}); err == nil {
+		t.Error("renderSVG: expected error with untiled dimension")
+	}
+	if err := tiling.RenderSVG(&buf, [2]int{1, 1}); err == nil {
+		t.Error("renderSVG: expected error with repeated dimension")
+	}
+}
+
+// eye returns the n × n identity matrix
+func eye(n int) *mat.Dense {
+	m := mat.NewDense(n, n, nil)
+	for i := 0; i < n; i++ {
+		m.Set(i, i, 1)
+	}
+	return m
+}
+
+func TestTilingUnmarshalInvalid(t *testing.T) {
+	tiling, err := NewTiling(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[]int{4, 4}, 1, 1e300,
+	)
+	if err != nil {
+		t.Fatalf("could not create tiling: %v", err)
+	}
+	data, err := json.Marshal(tiling)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		field, value string
+	}{
+		{"bin_lengths", "[0, 0]"},
+		{"bin_lengths", "[0.25, -0.25]"},
+		{"bin_lengths", `[0.25, "+Inf"]`},
+		{"bin_lengths", `["NaN", 0.25]`},
+		{"offsets", `[0, "NaN"]`},
+		{"min_dims", `["-Inf", 0]`},
+		{"edges", "[[0, 0.5, 0.5, 0.75, 1], null]"},
+		{"edges", "[[0, 0.75, 0.5, 0.9, 1], null]"},
+		{"edges", `[[0, 0.25, 0.5, 0.75, "+Inf"], null]`},
+		{"rotation", `[1, 0, 0, "NaN"]`},
+	}
+	for _, test := range tests {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			t.Fatal(err)
+		}
+		fields[test.field] = json.RawMessage(test.value)
+		invalid, err := json.Marshal(fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var loaded Tiling
+		if err := json.Unmarshal(invalid, &loaded); err == nil {
+			t.Errorf("unmarshalJSON(%s: %s): expected error", test.field,
+				test.value)
+		}
+	}
+
+	// The unchanged state is valid
+	var loaded Tiling
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Errorf("unmarshalJSON: %v", err)
+	}
+}
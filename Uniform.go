@@ -0,0 +1,92 @@
+package gotile
+
+import (
+	"math"
+
+	"github.com/samuelfneumann/goutils/floatutils"
+	"gonum.org/v1/gonum/mat"
+)
+
+// uniformTilings holds the parameters shared by the tilings of a
+// TileCoder when every tiling uses the same bins over the same input
+// dimensions and differs from the others only in its offsets. This is
+// by far the most common configuration, and allows a vector to be
+// encoded with all tilings in a single pass over its dimensions.
+type uniformTilings struct {
+	dims       []int     // Input dimension tiled along each dimension
+	minDims    []float64 // Minimum of each dimension
+	binLengths []float64 // Length of bins along each dimension
+	last       []float64 // Last tile along each dimension
+	strides    []int     // Index stride of each dimension
+
+	// offsets[i][k] is the offset of tiling k along dimension i
+	offsets [][]float64
+}
+
+// newUniformTilings returns the parameters shared by tilings, or nil
+// if the tilings differ in more than their offsets or use features
+// which require each input to be placed individually (non-linear
+// scales, bin edges, squashing, a BoundsPolicy other than BoundsClip,
+// or the NonFiniteTile policy). Non-finite features are clipped, so
+// vectors must be checked for non-finite features before they are
+// indexed if some tiling uses the NonFiniteError policy.
+func newUniformTilings(tilings []*Tiling) *uniformTilings {
+	if len(tilings) == 0 {
+		return nil
+	}
+
+	first := tilings[0]
+	for _, t := range tilings {
+		if t.policy != BoundsClip || len(t.bins) != len(first.bins) ||
+			t.rotation != nil || t.nonFinite == NonFiniteTile {
+			return nil
+		}
+		for i := range t.bins {
+			if t.scales[i] != ScaleLinear || t.edges[i] != nil ||
+				t.squashes[i].Func != SquashNone ||
+				t.bins[i] != first.bins[i] || t.dims[i] != first.dims[i] ||
+				t.binLengths[i] != first.binLengths[i] ||
+				t.minDims.AtVec(i) != first.minDims.AtVec(i) {
+				return nil
+			}
+		}
+	}
+
+	n := len(first.bins)
+	u := &uniformTilings{
+		dims:       append([]int(nil), first.dims...),
+		minDims:    make([]float64, n),
+		binLengths: append([]float64(nil), first.binLengths...),
+		last:       make([]float64, n),
+		strides:    append([]int(nil), first.strides...),
+		offsets:    make([][]float64, n),
+	}
+	for i := 0; i < n; i++ {
+		u.minDims[i] = first.minDims.AtVec(i)
+		u.last[i] = float64(first.bins[i] - 1)
+		u.offsets[i] = make([]float64, len(tilings))
+		for k, t := range tilings {
+			u.offsets[i][k] = t.offsets.At(0, i)
+		}
+	}
+	return u
+}
+
+// index adds the index of v in tiling k to index[k], for each tiling
+func (u *uniformTilings) index(v mat.Vector, index []int) {
+	for i, d := range u.dims {
+		x := v.AtVec(d)
+		min, binLength, last := u.minDims[i], u.binLengths[i], u.last[i]
+		stride := u.strides[i]
+
+		if math.IsNaN(x) {
+			// NaN is below the minimum of every tiling, as with the
+			// NonFiniteClip policy
+			continue
+		}
+		for k, offset := range u.offsets[i] {
+			tile := math.Floor((x + offset - min) / binLength)
+			index[k] += int(floatutils.Clip(tile, 0.0, last)) * stride
+		}
+	}
+}
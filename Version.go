@@ -0,0 +1,286 @@
+package gotile
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+)
+
+// FormatVersion is the version of the format used by all serialized
+// tilings and tile coders. It is increased whenever the serialized
+// fields change, and data saved with older versions is migrated to the
+// current version when loaded. Data saved with newer versions cannot be
+// loaded.
+//
+// Version 0 data was saved before versions were recorded. It encodes
+// scales, bounds policies, and squashing functions as integers rather
+// than by name, and does not record the activations, rotations, or
+// non-finite policies of tilings, nor the number of input dimensions of
+// tile coders, which are given their default values when loaded.
+const FormatVersion = 1
+
+// jsonMigration migrates a JSON document describing a tiling from its
+// version to the next version
+type jsonMigration func(tiling map[string]interface{}) error
+
+// jsonMigrations[v] migrates JSON tilings from version v to version v+1
+var jsonMigrations = []jsonMigration{
+	migrateTilingJSONV0,
+}
+
+// checkVersion returns an error if data of version v cannot be loaded
+func checkVersion(v int) error {
+	if v < 0 || v > FormatVersion {
+		return fmt.Errorf("unsupported format version %d (current version "+
+			"is %d)", v, FormatVersion)
+	}
+	return nil
+}
+
+// migrateJSON migrates the JSON document data, describing a tiling if
+// coder is false and a tile coder otherwise, to the current version
+func migrateJSON(data []byte, coder bool) ([]byte, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	version := 0
+	if v, ok := doc["version"].(float64); ok {
+		version = int(v)
+	}
+	if err := checkVersion(version); err != nil {
+		return nil, err
+	}
+	if version == FormatVersion {
+		return data, nil
+	}
+
+	// Collect the tilings to migrate
+	tilings := []interface{}{doc}
+	if coder {
+		tilings, _ = doc["tilings"].([]interface{})
+	}
+
+	for v := version; v < FormatVersion; v++ {
+		for i := range tilings {
+			tiling, ok := tilings[i].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("tiling %d is not an object", i)
+			}
+			if err := jsonMigrations[v](tiling); err != nil {
+				return nil, fmt.Errorf("migrating tiling %d from version "+
+					"%d: %v", i, v, err)
+			}
+			tiling["version"] = v + 1
+		}
+	}
+	doc["version"] = FormatVersion
+	return json.Marshal(doc)
+}
+
+// migrateTilingJSONV0 migrates a version 0 JSON tiling to version 1 by
+// replacing integer enumerations with their names
+func migrateTilingJSONV0(tiling map[string]interface{}) error {
+	// name returns the name of enumeration value v as a JSON value
+	name := func(v interface{}, m func(int) ([]byte, error)) (interface{},
+		error) {
+		i, ok := v.(float64)
+		if !ok {
+			return v, nil // Already migrated
+		}
+		text, err := m(int(i))
+		return string(text), err
+	}
+	scale := func(i int) ([]byte, error) { return Scale(i).MarshalText() }
+	policy := func(i int) ([]byte, error) {
+		return BoundsPolicy(i).MarshalText()
+	}
+	squash := func(i int) ([]byte, error) {
+		return SquashFunc(i).MarshalText()
+	}
+
+	var err error
+	if scales, ok := tiling["scales"].([]interface{}); ok {
+		for i := range scales {
+			if scales[i], err = name(scales[i], scale); err != nil {
+				return err
+			}
+		}
+	}
+	if p, ok := tiling["policy"]; ok {
+		if tiling["policy"], err = name(p, policy); err != nil {
+			return err
+		}
+	}
+	if squashes, ok := tiling["squashes"].([]interface{}); ok {
+		for i := range squashes {
+			s, ok := squashes[i].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			// Version 0 squashes used untagged field names
+			if f, ok := s["Func"]; ok {
+				if s["func"], err = name(f, squash); err != nil {
+					return err
+				}
+				delete(s, "Func")
+			}
+			if scale, ok := s["Scale"]; ok {
+				s["scale"] = scale
+				delete(s, "Scale")
+			}
+		}
+	}
+	return nil
+}
+
+// gobVersion returns the format version of gob encoded state data,
+// which describes a tiling if coder is false and a tile coder otherwise
+func gobVersion(data []byte, coder bool) (int, error) {
+	// Gob ignores fields which are not in the destination, so only the
+	// version is decoded. Version 0 data has no version field, and gob
+	// requires at least one field to match, so a field which is in
+	// every version is decoded as well.
+	var version int
+	var err error
+	dec := gob.NewDecoder(bytes.NewReader(data))
+	if coder {
+		var v struct {
+			Version     int
+			IncludeBias bool
+		}
+		err = dec.Decode(&v)
+		version = v.Version
+	} else {
+		var v struct {
+			Version int
+			Seed    uint64
+		}
+		err = dec.Decode(&v)
+		version = v.Version
+	}
+	if err != nil {
+		return 0, err
+	}
+	return version, checkVersion(version)
+}
+
+// decodeTilingGob decodes and migrates a gob encoded tiling state
+func decodeTilingGob(data []byte) (tilingState, error) {
+	version, err := gobVersion(data, false)
+	if err != nil {
+		return tilingState{}, err
+	}
+
+	dec := gob.NewDecoder(bytes.NewReader(data))
+	if version == 0 {
+		var s tilingStateV0
+		if err := dec.Decode(&s); err != nil {
+			return tilingState{}, err
+		}
+		return s.migrate(), nil
+	}
+
+	var s tilingState
+	err = dec.Decode(&s)
+	return s, err
+}
+
+// decodeTileCoderGob decodes and migrates a gob encoded tile coder
+// state
+func decodeTileCoderGob(data []byte) (tileCoderState, error) {
+	version, err := gobVersion(data, true)
+	if err != nil {
+		return tileCoderState{}, err
+	}
+
+	dec := gob.NewDecoder(bytes.NewReader(data))
+	if version == 0 {
+		var s tileCoderStateV0
+		if err := dec.Decode(&s); err != nil {
+			return tileCoderState{}, err
+		}
+		return s.migrate(), nil
+	}
+
+	var s tileCoderState
+	err = dec.Decode(&s)
+	return s, err
+}
+
+// tilingStateV0 is the version 0 state of a tiling, which encoded
+// enumerations as integers
+type tilingStateV0 struct {
+	Offsets    []float64
+	Bins       []int
+	BinLengths []float64
+	MinDims    []float64
+	Seed       uint64
+	Scales     []int
+	Edges      [][]float64
+	Categories []bool
+	Dims       []int
+	Low        []float64
+	High       []float64
+	Policy     int
+	Squashes   []struct {
+		Func  int
+		Scale float64
+	}
+}
+
+// migrate returns the current version of the state
+func (s tilingStateV0) migrate() tilingState {
+	m := tilingState{
+		Version:    FormatVersion,
+		Offsets:    s.Offsets,
+		Bins:       s.Bins,
+		BinLengths: s.BinLengths,
+		MinDims:    s.MinDims,
+		Seed:       s.Seed,
+		Scales:     make([]Scale, len(s.Scales)),
+		Edges:      make([]floats, len(s.Edges)),
+		Categories: s.Categories,
+		Dims:       s.Dims,
+		Low:        s.Low,
+		High:       s.High,
+		Policy:     BoundsPolicy(s.Policy),
+		Squashes:   make([]Squash, len(s.Squashes)),
+	}
+	for i := range s.Scales {
+		m.Scales[i] = Scale(s.Scales[i])
+	}
+	for i := range s.Edges {
+		m.Edges[i] = s.Edges[i]
+	}
+	for i := range s.Squashes {
+		m.Squashes[i] = Squash{
+			Func:  SquashFunc(s.Squashes[i].Func),
+			Scale: s.Squashes[i].Scale,
+		}
+	}
+	return m
+}
+
+// tileCoderStateV0 is the version 0 state of a tile coder
+type tileCoderStateV0 struct {
+	Tilings     []tilingStateV0
+	IncludeBias bool
+	Adaptive    *adaptiveState
+}
+
+// migrate returns the current version of the state
+func (s tileCoderStateV0) migrate() tileCoderState {
+	m := tileCoderState{
+		Version:     FormatVersion,
+		Tilings:     make([]tilingState, len(s.Tilings)),
+		IncludeBias: s.IncludeBias,
+		Adaptive:    s.Adaptive,
+	}
+	for i := range s.Tilings {
+		m.Tilings[i] = s.Tilings[i].migrate()
+	}
+	return m
+}
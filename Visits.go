@@ -0,0 +1,328 @@
+package gotile
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/spatial/r1"
+)
+
+// maxVisitShards is the default maximum number of shards of visit
+// counts
+const maxVisitShards = 8
+
+// WithVisitCounts makes a TileCoder count the number of times each tile
+// is activated by the vectors it encodes. The counts can be used to
+// compute count-based exploration bonuses with Bonus. Counting adds an
+// atomic increment per tiling to each encoded vector. Counts are
+// sharded, so that vectors may be encoded concurrently, for example by
+// parallel rollout workers, without contending on shared counters.
+//
+// Each shard holds a count of 8 bytes for every feature, so visit
+// counts take up to shards * VecLength() * 8 bytes. Shards are
+// allocated as goroutines running on different processors first count
+// visits. Since goroutines move between processors, even a single
+// goroutine encoding vectors may eventually allocate every shard. The
+// number of shards defaults to runtime.GOMAXPROCS(0), capped at 8, and
+// can be set with WithVisitShards. This option is only used by New.
+func WithVisitCounts() Option {
+	return func(c *config) error {
+		c.visits = true
+		return nil
+	}
+}
+
+// WithVisitShards sets the number of shards of the visit counts of a
+// TileCoder counting visits (see WithVisitCounts). A single shard uses
+// the least memory, VecLength() * 8 bytes, but makes concurrent
+// encoders contend on shared counters, while more shards reduce
+// contention at the cost of VecLength() * 8 bytes each. A non-positive
+// number of shards uses the default, runtime.GOMAXPROCS(0) capped at 8.
+// The number of shards is kept when visit counts are restored with
+// SetVisitCounts. This option is only used by New.
+func WithVisitShards(shards int) Option {
+	return func(c *config) error {
+		if shards < 0 {
+			shards = 0
+		}
+		c.visitShards = shards
+		return nil
+	}
+}
+
+// SetVisitCounts sets the number of times each feature has been
+// activated, enabling visit counting if it is not already enabled.
+// The counts must have length VecLength(). If counts is nil, visit
+// counting is disabled. Visit counts are not serialized with the
+// receiver, so that SetVisitCounts should be used to restore them
+// after a TileCoder is loaded. If visit counting is enabled by
+// SetVisitCounts, the default number of shards is used (see
+// WithVisitShards).
+func (t *TileCoder) SetVisitCounts(counts []uint64) error {
+	if counts == nil {
+		t.visits = nil
+		return nil
+	}
+	if len(counts) != t.VecLength() {
+		return fmt.Errorf("setVisitCounts: there should be a count for "+
+			"each feature: \n\thave(%d) \n\twant(%d)", len(counts),
+			t.VecLength())
+	}
+	t.visits = newVisitCounts(len(counts), t.visitShards())
+	copy(t.visits.shard(0), counts)
+	return nil
+}
+
+// VisitCounts returns the number of times each feature has been
+// activated by the vectors encoded by the receiver, or nil if the
+// receiver does not count visits. The bias unit is never counted.
+func (t *TileCoder) VisitCounts() []uint64 {
+	if t.visits == nil {
+		return nil
+	}
+	counts := make([]uint64, t.visits.features)
+	for i := range counts {
+		counts[i] = t.visits.count(i)
+	}
+	return counts
+}
+
+// ResetVisitCounts sets the visit count of each feature to 0
+func (t *TileCoder) ResetVisitCounts() {
+	if t.visits != nil {
+		t.visits.reset()
+	}
+}
+
+// PseudoCount returns the visit count of the tiles activated by v,
+// averaged over tilings. Computing the pseudo-count of v does not
+// count as a visit. If the receiver does not count visits, or if some
+// tiling uses the BoundsError policy and v falls outside its bounds,
+// PseudoCount panics. See TryPseudoCount for a non-panicking variant.
+func (t *TileCoder) PseudoCount(v mat.Vector) float64 {
+	count, err := t.TryPseudoCount(v)
+	if err != nil {
+		panic(err)
+	}
+	return count
+}
+
+// TryPseudoCount returns the visit count of the tiles activated by v,
+// averaged over tilings, as in PseudoCount. If the receiver does not
+// count visits an error is returned, and if v cannot be encoded an
+// error is returned as in TryEncode.
+func (t *TileCoder) TryPseudoCount(v mat.Vector) (float64, error) {
+	if t.visits == nil {
+		return 0, fmt.Errorf("pseudoCount: visit counts are not tracked")
+	}
+	if err := t.check(v); err != nil {
+		return 0, fmt.Errorf("pseudoCount: %w", err)
+	}
+
+	workspace := getInts(len(t.tilings))
+	defer putInts(workspace)
+	t.activeFeatures(v, *workspace)
+
+	count := 0.0
+	for _, index := range *workspace {
+		count += float64(t.visits.count(index))
+	}
+	return count / float64(len(t.tilings)), nil
+}
+
+// Bonus returns the count-based exploration bonus of v,
+// 1 / sqrt(n + 1), where n is the pseudo-count of v returned by
+// PseudoCount. The bonus is 1 for vectors whose tiles have never been
+// visited, and decreases as they are visited. Bonus panics under the
+// same conditions as PseudoCount. See TryBonus for a non-panicking
+// variant.
+func (t *TileCoder) Bonus(v mat.Vector) float64 {
+	return 1 / math.Sqrt(t.PseudoCount(v)+1)
+}
+
+// TryBonus returns the count-based exploration bonus of v, as in Bonus.
+// Errors are returned under the same conditions as TryPseudoCount.
+func (t *TileCoder) TryBonus(v mat.Vector) (float64, error) {
+	count, err := t.TryPseudoCount(v)
+	if err != nil {
+		return 0, fmt.Errorf("bonus: %w", err)
+	}
+	return 1 / math.Sqrt(count+1), nil
+}
+
+// visitShards returns the number of shards of the receiver's visit
+// counts, or 0 if the receiver does not count visits
+func (t *TileCoder) visitShards() int {
+	if t.visits == nil {
+		return 0
+	}
+	return len(t.visits.shards)
+}
+
+// visit increments the visit count of feature index, if the receiver
+// counts visits. Visits may be counted concurrently.
+func (t *TileCoder) visit(index int) {
+	if t.visits != nil {
+		counts := t.visits.local()
+		atomic.AddUint64(&counts[index], 1)
+	}
+}
+
+// visitIndices increments the visit count of each feature in indices,
+// as in visit, looking up the shard of the counts only once
+func (t *TileCoder) visitIndices(indices []float64) {
+	if t.visits != nil {
+		counts := t.visits.local()
+		for _, index := range indices {
+			atomic.AddUint64(&counts[int(index)], 1)
+		}
+	}
+}
+
+// visitInts increments the visit count of feature offset+i for each i
+// in indices, as in visitIndices
+func (t *TileCoder) visitInts(indices []int, offset int) {
+	if t.visits != nil {
+		counts := t.visits.local()
+		for _, index := range indices {
+			atomic.AddUint64(&counts[offset+index], 1)
+		}
+	}
+}
+
+// VisitHeatmap returns the visit frequency of the tiles over a 2D slice
+// of input space, so that users can see which parts of the input space
+// have been explored. The slice and grid are as in Heatmap, and element
+// (i, j) of the returned matrix is the sum of the visit counts of the
+// tiles overlapping the cell in row i and column j, divided by the
+// number of tilings as in PseudoCount. Cells outside the bounds of a
+// tiling using the BoundsError policy are NaN. If the receiver does
+// not count visits, an error is returned.
+func (t *TileCoder) VisitHeatmap(point mat.Vector, x, y int, xBounds,
+	yBounds r1.Interval, rows, cols int) (*mat.Dense, error) {
+	counts := t.VisitCounts()
+	if counts == nil {
+		return nil, fmt.Errorf("visitHeatmap: visit counts are not tracked")
+	}
+
+	// Weighting each feature by its visit count, divided by the number
+	// of tilings, turns the heatmap of weights into a heatmap of
+	// pseudo-counts
+	weights := make([]float64, len(counts))
+	for i, count := range counts {
+		weights[i] = float64(count) / float64(len(t.tilings))
+	}
+	if t.includeBias {
+		weights[0] = 0
+	}
+
+	heatmap, err := t.Heatmap(weights, point, x, y, xBounds, yBounds, rows,
+		cols)
+	if err != nil {
+		return nil, fmt.Errorf("visitHeatmap: %w", err)
+	}
+	return heatmap, nil
+}
+
+// visitCounts counts the visits of each feature in shards, each of
+// which is a separate array of counts. Goroutines running on different
+// processors mostly increment different shards, so that concurrent
+// encoders do not contend on the cache lines of shared counters. The
+// count of a feature is the sum of its counts over shards. Shards are
+// allocated when first used. Since the shard of each processor is
+// cached in a sync.Pool, which may drop its cache at any garbage
+// collection, a goroutine may be given a new shard at any time, and
+// every shard may eventually be allocated.
+type visitCounts struct {
+	features int
+	shards   []visitShard
+
+	// ids caches the index of a shard for each processor. Since a
+	// sync.Pool keeps a cache per processor, goroutines running on the
+	// same processor usually get the same shard.
+	ids  sync.Pool
+	next uint32
+}
+
+// visitShard is a lazily allocated shard of visit counts
+type visitShard struct {
+	once   sync.Once
+	counts atomic.Value // []uint64, unset until allocated
+}
+
+// newVisitCounts returns new visit counts of features features split
+// into shards shards. A non-positive number of shards uses a shard for
+// each processor, up to maxVisitShards.
+func newVisitCounts(features, shards int) *visitCounts {
+	if shards <= 0 {
+		shards = runtime.GOMAXPROCS(0)
+		if shards > maxVisitShards {
+			shards = maxVisitShards
+		}
+	}
+	c := &visitCounts{
+		features: features,
+		shards:   make([]visitShard, shards),
+	}
+	c.ids.New = func() interface{} {
+		id := int(atomic.AddUint32(&c.next, 1)-1) % len(c.shards)
+		return &id
+	}
+	return c
+}
+
+// shard returns the counts of shard i, allocating them if needed
+func (c *visitCounts) shard(i int) []uint64 {
+	s := &c.shards[i]
+	if counts := c.allocated(i); counts != nil {
+		return counts
+	}
+	s.once.Do(func() {
+		// Padding the counts keeps the cache lines at the end of each
+		// shard from being shared with other allocations
+		s.counts.Store(make([]uint64, c.features, c.features+8))
+	})
+	return c.allocated(i)
+}
+
+// allocated returns the counts of shard i, or nil if the shard has not
+// been allocated
+func (c *visitCounts) allocated(i int) []uint64 {
+	counts, _ := c.shards[i].counts.Load().([]uint64)
+	return counts
+}
+
+// local returns the counts of the shard of the current processor.
+// Counts must be incremented atomically, since goroutines may migrate
+// between processors while holding a shard.
+func (c *visitCounts) local() []uint64 {
+	id := c.ids.Get().(*int)
+	counts := c.shard(*id)
+	c.ids.Put(id)
+	return counts
+}
+
+// count returns the visit count of feature index, summed over shards
+func (c *visitCounts) count(index int) uint64 {
+	var count uint64
+	for i := range c.shards {
+		if counts := c.allocated(i); counts != nil {
+			count += atomic.LoadUint64(&counts[index])
+		}
+	}
+	return count
+}
+
+// reset sets each visit count to 0
+func (c *visitCounts) reset() {
+	for i := range c.shards {
+		counts := c.allocated(i)
+		for j := range counts {
+			atomic.StoreUint64(&counts[j], 0)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Package arrow encodes batches of vectors as Apache Arrow record
+// batches, holding the active indices of the tile-coded representation
+// of each vector along with any passthrough columns, such as rewards
+// or episode identifiers. Arrow records can be shared with dataframe
+// tooling without copying, written to files or streams with the
+// Arrow IPC format, or sent with Arrow Flight, for large offline
+// feature generation jobs.
+package arrow
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/apache/arrow/go/v9/arrow"
+	"github.com/apache/arrow/go/v9/arrow/array"
+	"github.com/apache/arrow/go/v9/arrow/memory"
+	"github.com/samuelfneumann/gotile"
+	"gonum.org/v1/gonum/mat"
+)
+
+// IndicesField is the name of the column holding the active indices of
+// the tile-coded representation of each vector
+const IndicesField = "indices"
+
+// Encoder encodes batches of vectors as Arrow records with a fixed
+// schema. The first column of each record, named IndicesField, has
+// type list<int32> and holds the active indices of the tile-coded
+// representation of each vector, ordered as in EncodeIndices. The
+// remaining columns are passthrough columns given along with each
+// batch.
+type Encoder struct {
+	coder  *gotile.TileCoder
+	schema *arrow.Schema
+	mem    memory.Allocator
+}
+
+// NewEncoder returns a new Encoder which encodes vectors with coder,
+// with the passthrough columns described by fields. Arrays are
+// allocated with mem, or with the default Go allocator if mem is nil.
+func NewEncoder(coder *gotile.TileCoder, fields []arrow.Field,
+	mem memory.Allocator) (*Encoder, error) {
+	if coder == nil {
+		return nil, fmt.Errorf("newEncoder: %w", gotile.ErrNilInput)
+	}
+	if coder.VecLength()-1 > math.MaxInt32 {
+		return nil, fmt.Errorf("newEncoder: %d features cannot be indexed "+
+			"by int32", coder.VecLength())
+	}
+	for _, field := range fields {
+		if field.Name == IndicesField {
+			return nil, fmt.Errorf("newEncoder: passthrough column cannot "+
+				"be named %q", IndicesField)
+		}
+	}
+	if mem == nil {
+		mem = memory.DefaultAllocator
+	}
+
+	schema := arrow.NewSchema(append([]arrow.Field{{
+		Name: IndicesField,
+		Type: arrow.ListOf(arrow.PrimitiveTypes.Int32),
+	}}, fields...), nil)
+	return &Encoder{coder: coder, schema: schema, mem: mem}, nil
+}
+
+// Schema returns the schema of the records returned by the receiver
+func (e *Encoder) Schema() *arrow.Schema {
+	return e.schema
+}
+
+// Encode tile codes each vector in the batch b, where each column of b
+// is a vector to encode, and returns a record with a row for each
+// vector. There should be a passthrough column for each field given to
+// NewEncoder, of the same type and with an element for each vector.
+// The caller should Release the returned record once it is no longer
+// needed.
+//
+// If some tiling uses the BoundsError policy and a vector falls
+// outside its bounds, an error wrapping gotile.ErrOutOfBounds is
+// returned.
+func (e *Encoder) Encode(b *mat.Dense, columns ...arrow.Array) (
+	arrow.Record, error) {
+	indices, err := e.coder.TryEncodeIndicesBatch(b)
+	if err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+	rows, vectors := indices.Dims()
+
+	fields := e.schema.Fields()[1:]
+	if len(columns) != len(fields) {
+		return nil, fmt.Errorf("encode: there should be a passthrough "+
+			"column for each field: \n\thave(%d) \n\twant(%d)", len(columns),
+			len(fields))
+	}
+	for i, column := range columns {
+		if !arrow.TypeEqual(column.DataType(), fields[i].Type) {
+			return nil, fmt.Errorf("encode: column %q has type %v, want %v",
+				fields[i].Name, column.DataType(), fields[i].Type)
+		}
+		if column.Len() != vectors {
+			return nil, fmt.Errorf("encode: column %q has length %d, want "+
+				"%d", fields[i].Name, column.Len(), vectors)
+		}
+	}
+
+	builder := array.NewListBuilder(e.mem, arrow.PrimitiveTypes.Int32)
+	defer builder.Release()
+	values := builder.ValueBuilder().(*array.Int32Builder)
+	values.Reserve(rows * vectors)
+	for j := 0; j < vectors; j++ {
+		builder.Append(true)
+		for i := 0; i < rows; i++ {
+			values.UnsafeAppend(int32(indices.At(i, j)))
+		}
+	}
+	list := builder.NewArray()
+	defer list.Release()
+
+	cols := append([]arrow.Array{list}, columns...)
+	return array.NewRecord(e.schema, cols, int64(vectors)), nil
+}
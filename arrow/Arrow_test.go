@@ -0,0 +1,121 @@
+package arrow
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/apache/arrow/go/v9/arrow"
+	"github.com/apache/arrow/go/v9/arrow/array"
+	"github.com/apache/arrow/go/v9/arrow/ipc"
+	"github.com/apache/arrow/go/v9/arrow/memory"
+	"github.com/samuelfneumann/gotile"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestEncoder(t *testing.T) {
+	tc, err := gotile.New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{2, 2}, {3, 3}},
+		1,
+		true,
+		2,
+		gotile.WithBoundsPolicy(gotile.BoundsError),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+
+	fields := []arrow.Field{{Name: "reward", Type: arrow.PrimitiveTypes.Float64}}
+	e, err := NewEncoder(tc, fields, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := mat.NewDense(2, 3, []float64{
+		0.1, 0.9, 0.4,
+		0.2, 0.1, 0.8,
+	})
+	rewards := array.NewFloat64Builder(mem)
+	rewards.AppendValues([]float64{1, -1, 0.5}, nil)
+	reward := rewards.NewArray()
+	rewards.Release()
+	defer reward.Release()
+
+	record, err := e.Encode(b, reward)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer record.Release()
+	if record.NumRows() != 3 || record.NumCols() != 2 {
+		t.Fatalf("encode: have(%d x %d) want(3 x 2)", record.NumRows(),
+			record.NumCols())
+	}
+
+	// Records survive a round trip through the Arrow IPC format
+	var buf bytes.Buffer
+	w := ipc.NewWriter(&buf, ipc.WithSchema(e.Schema()), ipc.WithAllocator(mem))
+	if err := w.Write(record); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := ipc.NewReader(&buf, ipc.WithAllocator(mem))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Release()
+	if !r.Next() {
+		t.Fatalf("could not read record: %v", r.Err())
+	}
+	read := r.Record()
+
+	want := tc.EncodeIndicesBatch(b)
+	list := read.Column(0).(*array.List)
+	values := list.ListValues().(*array.Int32)
+	offsets := list.Offsets()
+	for j := 0; j < 3; j++ {
+		start, end := offsets[j], offsets[j+1]
+		if int(end-start) != tc.NumTilings()+1 {
+			t.Fatalf("encode: row %d has %d indices, want %d", j, end-start,
+				tc.NumTilings()+1)
+		}
+		for i := start; i < end; i++ {
+			if have, want := values.Value(int(i)), int32(want.At(int(i-start),
+				j)); have != want {
+				t.Errorf("encode: row %d: have(%d) want(%d)", j, have, want)
+			}
+		}
+	}
+	if have := read.Column(1).(*array.Float64).Value(1); have != -1 {
+		t.Errorf("encode: have reward(%v) want(-1)", have)
+	}
+
+	// Passthrough columns must match the schema and the batch
+	if _, err := e.Encode(b); err == nil {
+		t.Error("expected error with missing passthrough column")
+	}
+	short := array.NewSlice(reward, 0, 2)
+	defer short.Release()
+	if _, err := e.Encode(b, short); err == nil {
+		t.Error("expected error with short passthrough column")
+	}
+	if _, err := e.Encode(nil, reward); !errors.Is(err, gotile.ErrNilInput) {
+		t.Errorf("encode(nil): have(%v) want(%v)", err, gotile.ErrNilInput)
+	}
+	b.Set(0, 0, 2)
+	if _, err := e.Encode(b, reward); !errors.Is(err,
+		gotile.ErrOutOfBounds) {
+		t.Errorf("encode: have(%v) want(%v)", err, gotile.ErrOutOfBounds)
+	}
+
+	if _, err := NewEncoder(tc, []arrow.Field{{Name: IndicesField,
+		Type: arrow.PrimitiveTypes.Int32}}, mem); err == nil {
+		t.Error("expected error with passthrough column named indices")
+	}
+}
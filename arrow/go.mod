@@ -0,0 +1,34 @@
+module github.com/samuelfneumann/gotile/arrow
+
+go 1.17
+
+replace github.com/samuelfneumann/gotile => ../
+
+require (
+	github.com/apache/arrow/go/v9 v9.0.0
+	github.com/samuelfneumann/gotile v0.0.0-00010101000000-000000000000
+	gonum.org/v1/gonum v0.9.3
+)
+
+require (
+	github.com/BurntSushi/toml v1.2.1 // indirect
+	github.com/andybalholm/brotli v1.0.4 // indirect
+	github.com/apache/thrift v0.15.0 // indirect
+	github.com/goccy/go-json v0.9.6 // indirect
+	github.com/golang/snappy v0.0.4 // indirect
+	github.com/google/flatbuffers v2.0.5+incompatible // indirect
+	github.com/klauspost/asmfmt v1.3.1 // indirect
+	github.com/klauspost/compress v1.14.2 // indirect
+	github.com/klauspost/cpuid/v2 v2.0.9 // indirect
+	github.com/minio/asm2plan9s v0.0.0-20200509001527-cdd76441f9d8 // indirect
+	github.com/minio/c2goasm v0.0.0-20190812172519-36a3d3bbc4f3 // indirect
+	github.com/pierrec/lz4/v4 v4.1.12 // indirect
+	github.com/samuelfneumann/goutils v0.0.0-20211111214126-5491a5616c35 // indirect
+	github.com/zeebo/xxh3 v1.0.1 // indirect
+	golang.org/x/exp v0.0.0-20211216164055-b2b84827b756 // indirect
+	golang.org/x/mod v0.6.0-dev.0.20220106191415-9b9b3d81d5e3 // indirect
+	golang.org/x/sys v0.0.0-20220412211240-33da011f77ad // indirect
+	golang.org/x/tools v0.1.10 // indirect
+	golang.org/x/xerrors v0.0.0-20220411194840-2f41105eb62f // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
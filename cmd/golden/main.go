@@ -0,0 +1,90 @@
+// Command golden generates and verifies golden vectors, a canonical set
+// of input vectors and the indices of their tile-coded
+// representations, for a tile coder configuration file.
+//
+// Generate golden vectors for a configuration and save them alongside
+// the weights learned with it:
+//
+//	golden -config config.yaml -n 100 -seed 1 > golden.json
+//
+// After upgrading gotile, verify that the feature mapping is unchanged,
+// either for the configuration or for a saved tile coder:
+//
+//	golden -config config.yaml -verify golden.json
+//	golden -coder coder.bin -verify golden.json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/samuelfneumann/gotile"
+)
+
+func main() {
+	config := flag.String("config", "", "configuration file (YAML, TOML, or JSON)")
+	coder := flag.String("coder", "", "saved tile coder to verify")
+	verify := flag.String("verify", "", "golden vectors to verify")
+	n := flag.Int("n", 100, "number of sampled vectors to generate")
+	seed := flag.Uint64("seed", 0, "seed for sampling vectors")
+	flag.Parse()
+
+	if err := run(*config, *coder, *verify, *n, *seed); err != nil {
+		fmt.Fprintf(os.Stderr, "golden: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(config, coder, verify string, n int, seed uint64) error {
+	if verify == "" {
+		if config == "" {
+			return fmt.Errorf("-config is required to generate golden vectors")
+		}
+		c, err := gotile.LoadConfigFile(config)
+		if err != nil {
+			return err
+		}
+		g, err := gotile.NewGolden(c, n, seed)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "\t")
+		return enc.Encode(g)
+	}
+
+	data, err := os.ReadFile(verify)
+	if err != nil {
+		return err
+	}
+	var g gotile.Golden
+	if err := json.Unmarshal(data, &g); err != nil {
+		return fmt.Errorf("%s: %v", verify, err)
+	}
+
+	switch {
+	case coder != "":
+		tc, err := gotile.LoadFile(coder)
+		if err != nil {
+			return err
+		}
+		if err := g.Verify(tc); err != nil {
+			return err
+		}
+	case config != "":
+		c, err := gotile.LoadConfigFile(config)
+		if err != nil {
+			return err
+		}
+		if err := g.VerifyConfig(c); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("-config or -coder is required to verify " +
+			"golden vectors")
+	}
+	fmt.Printf("%d golden vectors verified\n", len(g.Vectors))
+	return nil
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/samuelfneumann/gotile"
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+)
+
+// bench runs the bench subcommand, which measures the throughput of
+// single-vector and batch encoding with a tile coder on the local
+// machine
+func bench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	var coder coderFlags
+	coder.register(fs)
+	batches := fs.String("batches", "1,16,256,4096", "comma-separated batch sizes")
+	workers := fs.String("workers", "", "comma-separated numbers of workers for "+
+		"parallel batch encoding (default 2, 4, ... up to GOMAXPROCS)")
+	duration := fs.Duration("duration", 250*time.Millisecond,
+		"minimum duration of each measurement")
+	seed := fs.Uint64("sample-seed", 0, "seed for sampling input vectors")
+	fs.Parse(args)
+
+	tc, err := coder.load()
+	if err != nil {
+		return err
+	}
+	sizes, err := parseInts(*batches)
+	if err != nil {
+		return fmt.Errorf("-batches: %v", err)
+	}
+	var counts []int
+	if *workers == "" {
+		for w := 2; w <= runtime.GOMAXPROCS(0); w *= 2 {
+			counts = append(counts, w)
+		}
+	} else if counts, err = parseInts(*workers); err != nil {
+		return fmt.Errorf("-workers: %v", err)
+	}
+
+	b := benchmark{
+		tc:       tc,
+		duration: *duration,
+		rng:      rand.New(rand.NewSource(*seed)),
+	}
+	return b.run(os.Stdout, sizes, counts)
+}
+
+// benchmark measures the throughput of a tile coder
+type benchmark struct {
+	tc       *gotile.TileCoder
+	duration time.Duration
+	rng      *rand.Rand
+}
+
+// run measures the throughput of single-vector encoding and of
+// encoding batches of each of the given sizes, both sequentially and
+// in parallel with each of the given numbers of workers, and writes a
+// report to w
+func (b benchmark) run(w io.Writer, sizes, workers []int) error {
+	fmt.Fprintf(w, "tilings: %d\nfeatures: %d\nGOMAXPROCS: %d\n\n",
+		b.tc.NumTilings(), b.tc.VecLength(), runtime.GOMAXPROCS(0))
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "mode\tbatch\tworkers\tvectors/s\tns/vector\t")
+
+	single := b.sample(1)
+	v := mat.NewVecDense(single.RawMatrix().Rows, single.RawMatrix().Data)
+	indices := make([]float64, len(b.tc.EncodeIndices(v)))
+	b.report(tw, "indices", 1, 1, b.measure(1, func() {
+		b.tc.EncodeIndicesTo(indices, v)
+	}))
+	dense := mat.NewVecDense(b.tc.VecLength(), nil)
+	b.report(tw, "dense", 1, 1, b.measure(1, func() {
+		b.tc.EncodeTo(dense, v)
+	}))
+
+	for _, size := range sizes {
+		if size <= 0 {
+			return fmt.Errorf("batch size must be positive: %d", size)
+		}
+		batch := b.sample(size)
+		dst := mat.NewDense(len(indices), size, nil)
+		encode := func() { b.tc.EncodeIndicesBatchTo(batch, dst) }
+
+		b.tc.SetConcurrency(0)
+		b.report(tw, "sequential", size, 1, b.measure(size, encode))
+
+		b.tc.SetConcurrency(1)
+		for _, n := range workers {
+			b.tc.SetWorkers(n)
+			b.report(tw, "parallel", size, n, b.measure(size, encode))
+		}
+	}
+	return tw.Flush()
+}
+
+// report writes a row of the report for a measured time per vector
+func (b benchmark) report(w io.Writer, mode string, batch, workers int,
+	perVector time.Duration) {
+	fmt.Fprintf(w, "%s\t%d\t%d\t%.4g\t%.4g\t\n", mode, batch, workers,
+		float64(time.Second)/float64(perVector), float64(perVector))
+}
+
+// measure calls f, which encodes the given number of vectors, until at
+// least the duration of the receiver has elapsed, and returns the mean
+// time taken per vector
+func (b benchmark) measure(vectors int, f func()) time.Duration {
+	f() // warm up any pooled workspaces
+
+	calls := 1
+	for {
+		start := time.Now()
+		for i := 0; i < calls; i++ {
+			f()
+		}
+		elapsed := time.Since(start)
+		if elapsed >= b.duration {
+			perVector := elapsed / time.Duration(calls*vectors)
+			if perVector <= 0 {
+				perVector = 1
+			}
+			return perVector
+		}
+		calls *= 2
+	}
+}
+
+// sample returns a batch of the given number of input vectors, one per
+// column, sampled uniformly within the bounds of the tilings of the
+// receiver's tile coder. Along dimensions with infinite bounds, samples
+// are drawn from a standard normal distribution about the finite bound
+// (or 0), and dimensions which are not tiled are 0.
+func (b benchmark) sample(size int) *mat.Dense {
+	dims := 0
+	for _, tiling := range b.tc.Tilings() {
+		for _, dim := range tiling.Dims() {
+			if dim+1 > dims {
+				dims = dim + 1
+			}
+		}
+	}
+
+	lo := make([]float64, dims)
+	hi := make([]float64, dims)
+	tiled := make([]bool, dims)
+	for _, tiling := range b.tc.Tilings() {
+		low, high := tiling.Bounds()
+		for i, dim := range tiling.Dims() {
+			if !tiled[dim] {
+				lo[dim], hi[dim], tiled[dim] = low[i], high[i], true
+			}
+		}
+	}
+
+	batch := mat.NewDense(dims, size, nil)
+	for i := 0; i < dims; i++ {
+		if !tiled[i] {
+			continue
+		}
+		for j := 0; j < size; j++ {
+			switch {
+			case !math.IsInf(lo[i], 0) && !math.IsInf(hi[i], 0):
+				batch.Set(i, j, lo[i]+b.rng.Float64()*(hi[i]-lo[i]))
+			case !math.IsInf(lo[i], 0):
+				batch.Set(i, j, lo[i]+b.rng.NormFloat64())
+			case !math.IsInf(hi[i], 0):
+				batch.Set(i, j, hi[i]+b.rng.NormFloat64())
+			default:
+				batch.Set(i, j, b.rng.NormFloat64())
+			}
+		}
+	}
+	return batch
+}
+
+// parseInts parses a comma-separated list of ints
+func parseInts(s string) ([]int, error) {
+	fields := strings.Split(s, ",")
+	ints := make([]int, len(fields))
+	for i, field := range fields {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, err
+		}
+		ints[i] = n
+	}
+	return ints, nil
+}
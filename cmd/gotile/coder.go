@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/samuelfneumann/gotile"
+)
+
+// coderFlags holds the flags describing the tile coder used by a
+// subcommand
+type coderFlags struct {
+	config    string
+	coder     string
+	min       string
+	max       string
+	bins      string
+	seed      uint64
+	bias      bool
+	offsetDiv float64
+}
+
+// register adds the receiver's flags to fs
+func (c *coderFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.config, "config", "", "configuration file (YAML, TOML, or JSON)")
+	fs.StringVar(&c.coder, "coder", "", "saved tile coder")
+	fs.StringVar(&c.min, "min", "", "comma-separated minimum of each input dimension")
+	fs.StringVar(&c.max, "max", "", "comma-separated maximum of each input dimension")
+	fs.StringVar(&c.bins, "bins", "", "comma-separated bins of each tiling, such as 8x8,8x8")
+	fs.Uint64Var(&c.seed, "seed", 0, "seed for tiling offsets")
+	fs.BoolVar(&c.bias, "bias", false, "include a bias unit")
+	fs.Float64Var(&c.offsetDiv, "offset-div", 1, "divisor of tiling offsets")
+}
+
+// load returns the tile coder described by the receiver. A saved tile
+// coder takes precedence over a configuration file, which takes
+// precedence over the remaining flags.
+func (c *coderFlags) load() (*gotile.TileCoder, error) {
+	if c.coder != "" {
+		return gotile.LoadFile(c.coder)
+	}
+	if c.config != "" {
+		return gotile.NewFromConfigFile(c.config)
+	}
+	if c.min == "" || c.max == "" || c.bins == "" {
+		return nil, fmt.Errorf("-coder, -config, or -min, -max, and -bins " +
+			"are required")
+	}
+
+	config := gotile.Config{
+		Seed:        c.seed,
+		IncludeBias: c.bias,
+		OffsetDiv:   c.offsetDiv,
+	}
+	var err error
+	if config.MinDims, err = parseFloats(c.min); err != nil {
+		return nil, fmt.Errorf("-min: %v", err)
+	}
+	if config.MaxDims, err = parseFloats(c.max); err != nil {
+		return nil, fmt.Errorf("-max: %v", err)
+	}
+	if config.Bins, err = parseBins(c.bins); err != nil {
+		return nil, fmt.Errorf("-bins: %v", err)
+	}
+	return config.New()
+}
+
+// parseFloats parses a comma-separated list of floats
+func parseFloats(s string) ([]float64, error) {
+	fields := strings.Split(s, ",")
+	floats := make([]float64, len(fields))
+	for i, field := range fields {
+		f, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, err
+		}
+		floats[i] = f
+	}
+	return floats, nil
+}
+
+// parseBins parses a comma-separated list of tilings, each of which
+// lists the bins along each dimension separated by an x
+func parseBins(s string) ([][]int, error) {
+	tilings := strings.Split(s, ",")
+	bins := make([][]int, len(tilings))
+	for i, tiling := range tilings {
+		dims := strings.Split(strings.TrimSpace(tiling), "x")
+		bins[i] = make([]int, len(dims))
+		for j, dim := range dims {
+			b, err := strconv.Atoi(dim)
+			if err != nil {
+				return nil, err
+			}
+			bins[i][j] = b
+		}
+	}
+	return bins, nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseFloats(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []float64
+		wantErr bool
+	}{
+		{in: "1", want: []float64{1}},
+		{in: "-1.2,0.07", want: []float64{-1.2, 0.07}},
+		{in: " 1 , 2e3,-inf", want: []float64{1, 2e3, math.Inf(-1)}},
+		{in: "", wantErr: true},
+		{in: "1,,2", wantErr: true},
+		{in: "1,a", wantErr: true},
+		{in: "1;2", wantErr: true},
+	}
+
+	for _, test := range tests {
+		have, err := parseFloats(test.in)
+		if (err != nil) != test.wantErr {
+			t.Errorf("parseFloats(%q): have error(%v) want error(%v)", test.in,
+				err, test.wantErr)
+			continue
+		}
+		if !test.wantErr && !reflect.DeepEqual(have, test.want) {
+			t.Errorf("parseFloats(%q): have(%v) want(%v)", test.in, have,
+				test.want)
+		}
+	}
+}
+
+func TestParseBins(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    [][]int
+		wantErr bool
+	}{
+		{in: "8", want: [][]int{{8}}},
+		{in: "8x8,8x8", want: [][]int{{8, 8}, {8, 8}}},
+		{in: "4x3, 2x5,3", want: [][]int{{4, 3}, {2, 5}, {3}}},
+		{in: "", wantErr: true},
+		{in: "8x", wantErr: true},
+		{in: "8x8,", wantErr: true},
+		{in: "8*8", wantErr: true},
+		{in: "8x1.5", wantErr: true},
+	}
+
+	for _, test := range tests {
+		have, err := parseBins(test.in)
+		if (err != nil) != test.wantErr {
+			t.Errorf("parseBins(%q): have error(%v) want error(%v)", test.in,
+				err, test.wantErr)
+			continue
+		}
+		if !test.wantErr && !reflect.DeepEqual(have, test.want) {
+			t.Errorf("parseBins(%q): have(%v) want(%v)", test.in, have,
+				test.want)
+		}
+	}
+}
+
+func TestCoderFlagsLoad(t *testing.T) {
+	dir := t.TempDir()
+	saved := filepath.Join(dir, "coder.json")
+	if err := newTestTileCoder(t).SaveFile(saved); err != nil {
+		t.Fatal(err)
+	}
+	config := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(config, []byte("min_dims: [0, 0, 0]\n"+
+		"max_dims: [1, 1, 1]\nbins: [[2, 2, 2]]\nseed: 1\n"+
+		"include_bias: false\noffset_div: 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		flags   coderFlags
+		want    int // VecLength of the loaded tile coder
+		wantErr bool
+	}{
+		{
+			name: "flags",
+			flags: coderFlags{min: "0,0", max: "1,1", bins: "4x4,2x2",
+				offsetDiv: 1},
+			want: 16 + 4,
+		},
+		{
+			name: "bias",
+			flags: coderFlags{min: "0,0", max: "1,1", bins: "4x4", bias: true,
+				offsetDiv: 1},
+			want: 16 + 1,
+		},
+		{name: "coder", flags: coderFlags{coder: saved}, want: 4 + 1},
+		{name: "config", flags: coderFlags{config: config}, want: 8},
+		{
+			name: "coderPrecedence",
+			flags: coderFlags{coder: saved, config: config, min: "0",
+				max: "1", bins: "2"},
+			want: 4 + 1,
+		},
+		{
+			name:  "configPrecedence",
+			flags: coderFlags{config: config, min: "0", max: "1", bins: "2"},
+			want:  8,
+		},
+		{name: "none", wantErr: true},
+		{name: "noBins", flags: coderFlags{min: "0", max: "1"}, wantErr: true},
+		{
+			name:    "badMin",
+			flags:   coderFlags{min: "a", max: "1", bins: "2"},
+			wantErr: true,
+		},
+		{
+			name:    "badMax",
+			flags:   coderFlags{min: "0", max: "1,b", bins: "2"},
+			wantErr: true,
+		},
+		{
+			name:    "badBins",
+			flags:   coderFlags{min: "0", max: "1", bins: "2y2"},
+			wantErr: true,
+		},
+		{
+			name:    "mismatchedBounds",
+			flags:   coderFlags{min: "0,0", max: "1", bins: "2x2", offsetDiv: 1},
+			wantErr: true,
+		},
+		{
+			name:    "missingCoder",
+			flags:   coderFlags{coder: filepath.Join(dir, "missing.json")},
+			wantErr: true,
+		},
+		{
+			name:    "missingConfig",
+			flags:   coderFlags{config: filepath.Join(dir, "missing.yaml")},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		tc, err := test.flags.load()
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: load: have error(%v) want error(%v)", test.name,
+				err, test.wantErr)
+			continue
+		}
+		if !test.wantErr && tc.VecLength() != test.want {
+			t.Errorf("%s: load: have vecLength(%d) want(%d)", test.name,
+				tc.VecLength(), test.want)
+		}
+	}
+}
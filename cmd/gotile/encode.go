@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/samuelfneumann/gotile"
+	"gonum.org/v1/gonum/mat"
+)
+
+// encode runs the encode subcommand, which tile codes each row of a
+// CSV file of observations
+func encode(args []string) error {
+	fs := flag.NewFlagSet("encode", flag.ExitOnError)
+	var coder coderFlags
+	coder.register(fs)
+	in := fs.String("in", "", "CSV file of observations (default stdin)")
+	out := fs.String("out", "", "output file (default stdout)")
+	format := fs.String("format", "csv", "output format: csv or jsonl")
+	dense := fs.Bool("dense", false, "write dense feature vectors rather than active indices")
+	header := fs.Bool("header", false, "skip the first row of the input")
+	fs.Parse(args)
+
+	if *format != "csv" && *format != "jsonl" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	tc, err := coder.load()
+	if err != nil {
+		return err
+	}
+
+	r := io.Reader(os.Stdin)
+	if *in != "" {
+		f, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	buf := bufio.NewWriter(w)
+
+	if err := encodeCSV(tc, r, buf, *format, *dense, *header); err != nil {
+		return err
+	}
+	return buf.Flush()
+}
+
+// encodeCSV tile codes each row of the CSV document in r with tc,
+// writing the active indices, or the dense feature vector if dense is
+// true, of each row to w in the given format
+func encodeCSV(tc *gotile.TileCoder, r io.Reader, w io.Writer, format string,
+	dense, header bool) error {
+	records := csv.NewReader(r)
+	records.ReuseRecord = true
+
+	var rows *csv.Writer
+	var lines *json.Encoder
+	if format == "csv" {
+		rows = csv.NewWriter(w)
+	} else {
+		lines = json.NewEncoder(w)
+	}
+
+	var v *mat.VecDense
+	var record []string
+	for line := 1; ; line++ {
+		fields, err := records.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if header && line == 1 {
+			continue
+		}
+
+		if v == nil || v.Len() != len(fields) {
+			v = mat.NewVecDense(len(fields), nil)
+		}
+		for i, field := range fields {
+			f, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return fmt.Errorf("line %d: %v", line, err)
+			}
+			v.SetVec(i, f)
+		}
+
+		var features []float64
+		if dense {
+			var vec *mat.VecDense
+			vec, err = tc.TryEncode(v)
+			if vec != nil {
+				features = vec.RawVector().Data
+			}
+		} else {
+			features, err = tc.TryEncodeIndices(v)
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %v", line, err)
+		}
+
+		if lines != nil {
+			if err := lines.Encode(features); err != nil {
+				return err
+			}
+			continue
+		}
+		record = record[:0]
+		for _, f := range features {
+			record = append(record, strconv.FormatFloat(f, 'g', -1, 64))
+		}
+		if err := rows.Write(record); err != nil {
+			return err
+		}
+	}
+
+	if rows != nil {
+		rows.Flush()
+		return rows.Error()
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/samuelfneumann/gotile"
+	"gonum.org/v1/gonum/mat"
+)
+
+// newTestTileCoder returns a tile coder with a single 2x2 tiling of the
+// unit square, nearly no offset, and a bias unit
+func newTestTileCoder(t *testing.T) *gotile.TileCoder {
+	tc, err := gotile.New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{2, 2}},
+		1,
+		true,
+		1e300,
+		gotile.WithBoundsPolicy(gotile.BoundsError),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	return tc
+}
+
+func TestEncodeCSV(t *testing.T) {
+	tc := newTestTileCoder(t)
+
+	tests := []struct {
+		name   string
+		in     string
+		format string
+		dense  bool
+		header bool
+		want   string
+		err    string
+	}{
+		{
+			name:   "indices",
+			in:     "0.2,0.7\n0.9,0.1\n",
+			format: "csv",
+			want:   "2,0\n3,0\n",
+		},
+		{
+			name:   "dense",
+			in:     "0.2,0.7\n0.6,0.6\n",
+			format: "csv",
+			dense:  true,
+			want:   "1,0,1,0,0\n1,0,0,0,1\n",
+		},
+		{
+			name:   "jsonl",
+			in:     "0.2,0.7\n0.9,0.1\n",
+			format: "jsonl",
+			want:   "[2,0]\n[3,0]\n",
+		},
+		{
+			name:   "denseJSONL",
+			in:     "0.9,0.1\n",
+			format: "jsonl",
+			dense:  true,
+			want:   "[1,0,0,1,0]\n",
+		},
+		{
+			name:   "header",
+			in:     "x,y\n0.2,0.7\n",
+			format: "csv",
+			header: true,
+			want:   "2,0\n",
+		},
+		{
+			name:   "empty",
+			in:     "",
+			format: "csv",
+			want:   "",
+		},
+		{
+			name:   "unskippedHeader",
+			in:     "x,y\n0.2,0.7\n",
+			format: "csv",
+			err:    "line 1",
+		},
+		{
+			name:   "notFloat",
+			in:     "0.2,0.7\n0.2,a\n",
+			format: "csv",
+			err:    "line 2",
+		},
+		{
+			name:   "tooFewColumns",
+			in:     "0.2\n",
+			format: "csv",
+			err:    "line 1",
+		},
+		{
+			name:   "tooManyColumns",
+			in:     "0.2,0.7,0.1\n",
+			format: "jsonl",
+			err:    "line 1",
+		},
+		{
+			name:   "raggedRows",
+			in:     "0.2,0.7\n0.2\n",
+			format: "csv",
+			err:    "wrong number of fields",
+		},
+		{
+			name:   "outOfBounds",
+			in:     "0.2,0.7\n2,0.7\n",
+			format: "csv",
+			err:    "line 2",
+		},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		err := encodeCSV(tc, strings.NewReader(test.in), &out, test.format,
+			test.dense, test.header)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: encodeCSV: have(%v) want error containing(%q)",
+					test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: encodeCSV: %v", test.name, err)
+			continue
+		}
+		if have := out.String(); have != test.want {
+			t.Errorf("%s: encodeCSV: have(%q) want(%q)", test.name, have,
+				test.want)
+		}
+	}
+}
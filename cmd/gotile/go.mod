@@ -0,0 +1,29 @@
+module github.com/samuelfneumann/gotile/cmd/gotile
+
+go 1.17
+
+replace (
+	github.com/samuelfneumann/gotile => ../../
+	github.com/samuelfneumann/gotile/grpcencoder => ../../grpcencoder
+)
+
+require (
+	github.com/samuelfneumann/gotile v0.0.0-00010101000000-000000000000
+	github.com/samuelfneumann/gotile/grpcencoder v0.0.0-00010101000000-000000000000
+	golang.org/x/exp v0.0.0-20211111183329-cb5df436b1a8
+	gonum.org/v1/gonum v0.9.3
+	google.golang.org/grpc v1.50.1
+)
+
+require (
+	github.com/BurntSushi/toml v1.2.1 // indirect
+	github.com/golang/protobuf v1.5.2 // indirect
+	github.com/samuelfneumann/goutils v0.0.0-20211111214126-5491a5616c35 // indirect
+	golang.org/x/net v0.0.0-20220127200216-cd36cc0744dd // indirect
+	golang.org/x/sys v0.0.0-20220412211240-33da011f77ad // indirect
+	golang.org/x/text v0.3.7 // indirect
+	golang.org/x/tools v0.1.8-0.20211029000441-d6a9af8af023 // indirect
+	google.golang.org/genproto v0.0.0-20220126215142-9970aeb2e350 // indirect
+	google.golang.org/protobuf v1.28.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/samuelfneumann/gotile"
+)
+
+// coderSummary describes a tile coder for the inspect subcommand
+type coderSummary struct {
+	Features int             `json:"features"`
+	Bias     bool            `json:"bias"`
+	Tilings  []tilingSummary `json:"tilings"`
+}
+
+// tilingSummary describes a single tiling of a tile coder for the
+// inspect subcommand
+type tilingSummary struct {
+	Dims    []int     `json:"dims"`
+	Bins    []int     `json:"bins"`
+	Min     []float64 `json:"min"`
+	Max     []float64 `json:"max"`
+	Offsets []float64 `json:"offsets"`
+	Policy  string    `json:"policy"`
+	Start   int       `json:"start"`
+	End     int       `json:"end"`
+}
+
+// inspect runs the inspect subcommand, which prints the bounds, bins,
+// offsets, and feature ranges of a saved tile coder
+func inspect(args []string) error {
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	var coder coderFlags
+	coder.register(fs)
+	asJSON := fs.Bool("json", false, "print the summary as JSON")
+	fs.Parse(args)
+
+	// Allow the saved tile coder to be given as an argument
+	if coder.coder == "" && fs.NArg() == 1 {
+		coder.coder = fs.Arg(0)
+	}
+	tc, err := coder.load()
+	if err != nil {
+		return err
+	}
+
+	summary := summarize(tc)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "\t")
+		return enc.Encode(summary)
+	}
+	return summary.write(os.Stdout)
+}
+
+// summarize returns a summary of tc
+func summarize(tc *gotile.TileCoder) coderSummary {
+	summary := coderSummary{
+		Features: tc.VecLength(),
+		Bias:     tc.IncludeBias(),
+	}
+	for i, tiling := range tc.Tilings() {
+		low, high := tiling.Bounds()
+		start, end := tc.FeatureRange(i)
+		summary.Tilings = append(summary.Tilings, tilingSummary{
+			Dims:    tiling.Dims(),
+			Bins:    tiling.Bins(),
+			Min:     low,
+			Max:     high,
+			Offsets: tiling.Offsets(),
+			Policy:  tiling.Policy().String(),
+			Start:   start,
+			End:     end,
+		})
+	}
+	return summary
+}
+
+// write writes the receiver to w as a table with a row for each tiling
+func (s coderSummary) write(w io.Writer) error {
+	fmt.Fprintf(w, "features: %d\nbias: %v\ntilings: %d\n\n", s.Features,
+		s.Bias, len(s.Tilings))
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "tiling\tdims\tbins\tmin\tmax\toffsets\tpolicy\tfeatures")
+	for i, tiling := range s.Tilings {
+		fmt.Fprintf(tw, "%d\t%v\t%v\t%.4g\t%.4g\t%.4g\t%s\t[%d, %d)\n", i,
+			tiling.Dims, tiling.Bins, tiling.Min, tiling.Max,
+			tiling.Offsets, tiling.Policy, tiling.Start, tiling.End)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,84 @@
+// Command gotile tile codes observations from the command line, so
+// that the package can be used from pipelines which are not written in
+// Go.
+//
+// The encode subcommand reads a CSV file of observations, one per row,
+// and writes the tile-coded representation of each observation as a
+// row of CSV or a line of JSON. The tile coder is described by a
+// configuration file, by a saved tile coder, or by flags:
+//
+//	gotile encode -config config.yaml -in obs.csv > features.csv
+//	gotile encode -coder coder.bin -in obs.csv -format jsonl -dense
+//	gotile encode -min -1.2,-0.07 -max 0.6,0.07 -bins 8x8,8x8 < obs.csv
+//
+// The inspect subcommand prints the bounds, bins, offsets, and feature
+// count of a saved tile coder, along with the range of features of each
+// tiling, so that saved experiment artifacts can be audited:
+//
+//	gotile inspect coder.bin
+//	gotile inspect -json coder.bin
+//
+// The bench subcommand measures the throughput of single-vector and
+// batch encoding on the local machine, sequentially and in parallel
+// with various numbers of workers and batch sizes, to help choose the
+// concurrency settings of a tile coder:
+//
+//	gotile bench -config config.yaml -batches 64,1024 -workers 2,4,8
+//
+// The serve subcommand serves the feature mapping of a tile coder over
+// HTTP and JSON, as described in package server, or over gRPC, as
+// described in package grpcencoder:
+//
+//	gotile serve -config config.yaml -addr localhost:8080
+//	gotile serve -config config.yaml -addr localhost:9090 -grpc
+//
+// Run gotile <subcommand> -h for the flags of each subcommand.
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// commands maps the name of each subcommand to the function running it
+// with the remaining command-line arguments
+var commands = map[string]func(args []string) error{
+	"bench":   bench,
+	"encode":  encode,
+	"inspect": inspect,
+	"serve":   serve,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "gotile: unknown subcommand %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "gotile %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+// usage prints the available subcommands to stderr
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "usage: gotile <subcommand> [flags]")
+	fmt.Fprintln(os.Stderr, "subcommands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "\t%s\n", name)
+	}
+}
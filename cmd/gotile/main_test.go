@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samuelfneumann/gotile/grpcencoder"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// TestMain runs the gotile command instead of the tests when the test
+// binary is run by gotile, so that subcommands can be smoke tested as
+// separate processes
+func TestMain(m *testing.M) {
+	if os.Getenv("GOTILE_TEST_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// command returns a command running the gotile command with args
+func command(args ...string) *exec.Cmd {
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "GOTILE_TEST_MAIN=1")
+	return cmd
+}
+
+// saveTestTileCoder saves the tile coder returned by newTestTileCoder
+// to a temporary file, returning its path
+func saveTestTileCoder(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "coder.bin")
+	if err := newTestTileCoder(t).SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// start starts cmd, stopping it when the test completes
+func start(t *testing.T, cmd *exec.Cmd) {
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+}
+
+// freeAddr returns a local address which was free when checked
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func TestInspect(t *testing.T) {
+	path := saveTestTileCoder(t)
+
+	out, err := command("inspect", path).Output()
+	if err != nil {
+		t.Fatalf("inspect: %v", err)
+	}
+	for _, want := range []string{"features: 5", "bias: true", "tilings: 1",
+		"[1, 5)"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("inspect: have(%q) want to contain(%q)", out, want)
+		}
+	}
+
+	out, err = command("inspect", "-json", "-coder", path).Output()
+	if err != nil {
+		t.Fatalf("inspect -json: %v", err)
+	}
+	var summary coderSummary
+	if err := json.Unmarshal(out, &summary); err != nil {
+		t.Fatalf("inspect -json: %v", err)
+	}
+	if summary.Features != 5 || len(summary.Tilings) != 1 ||
+		summary.Tilings[0].Policy != "BoundsError" {
+		t.Errorf("inspect -json: have(%+v) want 5 features and a single "+
+			"tiling with the error policy", summary)
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing.bin")
+	if err := command("inspect", missing).Run(); err == nil {
+		t.Errorf("inspect(%v): expected error", missing)
+	}
+}
+
+func TestBench(t *testing.T) {
+	path := saveTestTileCoder(t)
+
+	out, err := command("bench", "-coder", path, "-batches", "1,8",
+		"-workers", "2", "-duration", "1ms").Output()
+	if err != nil {
+		t.Fatalf("bench: %v", err)
+	}
+
+	// A row is written for each single-vector mode, and for each batch
+	// size both sequentially and with each number of workers
+	rows := 0
+	for _, line := range strings.Split(string(out), "\n") {
+		for _, mode := range []string{"indices", "dense", "sequential",
+			"parallel"} {
+			if strings.HasPrefix(strings.TrimSpace(line), mode) {
+				rows++
+			}
+		}
+	}
+	if rows != 2+2*2 {
+		t.Errorf("bench: have(%d) rows want(%d): %s", rows, 2+2*2, out)
+	}
+
+	if err := command("bench", "-coder", path, "-batches", "0").Run(); err ==
+		nil {
+		t.Error("bench: expected error with batch size 0")
+	}
+}
+
+func TestServe(t *testing.T) {
+	path := saveTestTileCoder(t)
+
+	// Serve over HTTP and encode a vector
+	addr := freeAddr(t)
+	start(t, command("serve", "-coder", path, "-addr", addr))
+
+	var resp *http.Response
+	var err error
+	for deadline := time.Now().Add(10 * time.Second); ; {
+		resp, err = http.Post("http://"+addr+"/encode", "application/json",
+			strings.NewReader(`{"vector": [0.2, 0.7]}`))
+		if err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+	var body bytes.Buffer
+	body.ReadFrom(resp.Body)
+	resp.Body.Close()
+	if want := `{"encoding":{"indices":[2,0]}}`; strings.TrimSpace(
+		body.String()) != want {
+		t.Errorf("serve: have(%s) want(%s)", body.String(), want)
+	}
+
+	// Serve over gRPC and describe the tile coder
+	addr = freeAddr(t)
+	start(t, command("serve", "-coder", path, "-addr", addr, "-grpc"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithBlock(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("serve -grpc: %v", err)
+	}
+	defer conn.Close()
+	info, err := grpcencoder.NewEncoderClient(conn).Info(ctx,
+		&grpcencoder.InfoRequest{})
+	if err != nil || info.GetLength() != 5 {
+		t.Errorf("serve -grpc: info: have(%v, %v) want length(5)", info, err)
+	}
+}
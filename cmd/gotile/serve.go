@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/samuelfneumann/gotile/grpcencoder"
+	"github.com/samuelfneumann/gotile/server"
+	"google.golang.org/grpc"
+)
+
+// serve runs the serve subcommand, which serves the feature mapping of
+// a tile coder over HTTP or gRPC until the process is stopped
+func serve(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	var coder coderFlags
+	coder.register(fs)
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	useGRPC := fs.Bool("grpc", false, "serve gRPC rather than HTTP")
+	fs.Parse(args)
+
+	tc, err := coder.load()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "serving %d features on %s\n", tc.VecLength(),
+		*addr)
+	if !*useGRPC {
+		return http.ListenAndServe(*addr, server.New(tc))
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	g := grpc.NewServer()
+	grpcencoder.RegisterEncoderServer(g, grpcencoder.NewServer(tc))
+	return g.Serve(listener)
+}
@@ -0,0 +1,281 @@
+// Command libgotile builds gotile as a C shared library, so that
+// programs in other languages, such as Python reinforcement learning
+// codebases, can call this exact implementation and are guaranteed to
+// construct the same features as Go programs. Build the library with:
+//
+//	go build -buildmode=c-shared -o libgotile.so ./cmd/libgotile
+//
+// which also writes the C header libgotile.h. The python directory of
+// the repository holds a thin ctypes wrapper around the library.
+//
+// Tile coders are referred to by opaque handles, which must be freed
+// with gotile_free and must not be used afterwards. Vectors are passed
+// as arrays of doubles, and batches as row-major arrays holding one
+// vector per row. Functions return 0 on success and -1 on error, in
+// which case gotile_error copies a description of the error into a
+// buffer. Errors are kept for each handle, so that threads using
+// different tile coders never see each other's lastError: gotile_error
+// called with a handle describes the most recent error of a function
+// called with that handle, and called with handle 0 describes the most
+// recent error of gotile_new or gotile_load, or of a function called
+// with an invalid handle. Tile coders may encode from several threads
+// at once, but threads sharing a handle should serialize their calls
+// if they need the error of each call.
+package main
+
+/*
+#include <stdint.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"runtime/cgo"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"github.com/samuelfneumann/gotile"
+	"gonum.org/v1/gonum/mat"
+)
+
+// lastError holds the most recent error of a tile coder handle, or of
+// the functions without a valid handle
+type lastError struct {
+	mu  sync.Mutex
+	err error
+}
+
+// set records err as the most recent error of the receiver and
+// returns -1
+func (e *lastError) set(err error) C.int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.err = err
+	return -1
+}
+
+// get returns the most recent error of the receiver
+func (e *lastError) get() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.err
+}
+
+// handle is the value referred to by each tile coder handle
+type handle struct {
+	tc *gotile.TileCoder
+	lastError
+}
+
+// global holds the most recent error of the functions without a valid
+// handle
+var global lastError
+
+func main() {}
+
+// coder returns the handle referred to by h
+func coder(h C.uintptr_t) (*handle, error) {
+	if h == 0 {
+		return nil, fmt.Errorf("invalid handle")
+	}
+	hnd, ok := cgo.Handle(h).Value().(*handle)
+	if !ok {
+		return nil, fmt.Errorf("invalid handle")
+	}
+	return hnd, nil
+}
+
+//export gotile_error
+func gotile_error(h C.uintptr_t, buf *C.char, n C.int) C.int {
+	errs := &global
+	if h != 0 {
+		hnd, err := coder(h)
+		if err != nil {
+			return global.set(err)
+		}
+		errs = &hnd.lastError
+	}
+	err := errs.get()
+	if err == nil || n <= 0 {
+		return 0
+	}
+
+	msg := err.Error()
+	if len(msg) > int(n)-1 {
+		msg = msg[:int(n)-1]
+	}
+	dst := unsafe.Slice((*byte)(unsafe.Pointer(buf)), int(n))
+	copy(dst, msg)
+	dst[len(msg)] = 0
+	return C.int(len(msg))
+}
+
+//export gotile_new
+func gotile_new(config *C.char, h *C.uintptr_t) C.int {
+	c, err := gotile.ReadJSONConfig(strings.NewReader(C.GoString(config)))
+	if err != nil {
+		return global.set(err)
+	}
+	tc, err := c.New()
+	if err != nil {
+		return global.set(err)
+	}
+	*h = C.uintptr_t(cgo.NewHandle(&handle{tc: tc}))
+	return 0
+}
+
+//export gotile_load
+func gotile_load(path *C.char, h *C.uintptr_t) C.int {
+	tc, err := gotile.LoadFile(C.GoString(path))
+	if err != nil {
+		return global.set(err)
+	}
+	*h = C.uintptr_t(cgo.NewHandle(&handle{tc: tc}))
+	return 0
+}
+
+//export gotile_free
+func gotile_free(h C.uintptr_t) {
+	if h != 0 {
+		cgo.Handle(h).Delete()
+	}
+}
+
+//export gotile_vec_length
+func gotile_vec_length(h C.uintptr_t) C.int {
+	hnd, err := coder(h)
+	if err != nil {
+		return global.set(err)
+	}
+	return C.int(hnd.tc.VecLength())
+}
+
+//export gotile_num_indices
+func gotile_num_indices(h C.uintptr_t) C.int {
+	hnd, err := coder(h)
+	if err != nil {
+		return global.set(err)
+	}
+	tc := hnd.tc
+	if tc.IncludeBias() {
+		return C.int(tc.NumTilings() + 1)
+	}
+	return C.int(tc.NumTilings())
+}
+
+//export gotile_encode
+func gotile_encode(h C.uintptr_t, v *C.double, dims C.int,
+	out *C.double) C.int {
+	hnd, err := coder(h)
+	if err != nil {
+		return global.set(err)
+	}
+	tc := hnd.tc
+	encoded, err := tc.TryEncode(vector(v, dims))
+	if err != nil {
+		return hnd.set(err)
+	}
+	mat.Col(doubles(out, tc.VecLength()), 0, encoded)
+	return 0
+}
+
+//export gotile_encode_indices
+func gotile_encode_indices(h C.uintptr_t, v *C.double, dims C.int,
+	out *C.int64_t) C.int {
+	hnd, err := coder(h)
+	if err != nil {
+		return global.set(err)
+	}
+	tc := hnd.tc
+	indices, err := tc.TryEncodeIndices(vector(v, dims))
+	if err != nil {
+		return hnd.set(err)
+	}
+	dst := int64s(out, len(indices))
+	for i, index := range indices {
+		dst[i] = int64(index)
+	}
+	return 0
+}
+
+//export gotile_encode_batch
+func gotile_encode_batch(h C.uintptr_t, b *C.double, vectors, dims C.int,
+	out *C.double) C.int {
+	hnd, err := coder(h)
+	if err != nil {
+		return global.set(err)
+	}
+	tc := hnd.tc
+	encoded, err := tc.TryEncodeBatch(batch(b, vectors, dims))
+	if err != nil {
+		return hnd.set(err)
+	}
+	transpose(doubles(out, int(vectors)*tc.VecLength()), encoded)
+	return 0
+}
+
+//export gotile_encode_indices_batch
+func gotile_encode_indices_batch(h C.uintptr_t, b *C.double, vectors,
+	dims C.int, out *C.int64_t) C.int {
+	hnd, err := coder(h)
+	if err != nil {
+		return global.set(err)
+	}
+	tc := hnd.tc
+	encoded, err := tc.TryEncodeIndicesBatch(batch(b, vectors, dims))
+	if err != nil {
+		return hnd.set(err)
+	}
+
+	rows, cols := encoded.Dims()
+	dst := int64s(out, rows*cols)
+	for j := 0; j < cols; j++ {
+		for i := 0; i < rows; i++ {
+			dst[j*rows+i] = int64(encoded.At(i, j))
+		}
+	}
+	return 0
+}
+
+// vector returns a copy of the C array v of length dims as a vector
+func vector(v *C.double, dims C.int) *mat.VecDense {
+	if v == nil || dims <= 0 {
+		return nil
+	}
+	return mat.NewVecDense(int(dims), append([]float64(nil),
+		doubles(v, int(dims))...))
+}
+
+// batch returns a copy of the row-major C array b of vectors by dims
+// as a batch, with one vector per column
+func batch(b *C.double, vectors, dims C.int) *mat.Dense {
+	if b == nil || vectors <= 0 || dims <= 0 {
+		return nil
+	}
+	rows := mat.NewDense(int(vectors), int(dims), doubles(b,
+		int(vectors*dims)))
+	var cols mat.Dense
+	cols.CloneFrom(rows.T())
+	return &cols
+}
+
+// transpose copies the transpose of m into the row-major array dst
+func transpose(dst []float64, m *mat.Dense) {
+	rows, cols := m.Dims()
+	for j := 0; j < cols; j++ {
+		for i := 0; i < rows; i++ {
+			dst[j*rows+i] = m.At(i, j)
+		}
+	}
+}
+
+// doubles returns the C array p of length n as a slice
+func doubles(p *C.double, n int) []float64 {
+	return unsafe.Slice((*float64)(unsafe.Pointer(p)), n)
+}
+
+// int64s returns the C array p of length n as a slice
+func int64s(p *C.int64_t, n int) []int64 {
+	return unsafe.Slice((*int64)(unsafe.Pointer(p)), n)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// buildLibrary builds the C shared library into a temporary directory,
+// returning the directory, or skips the test if cgo is unavailable
+func buildLibrary(t *testing.T) string {
+	out, err := exec.Command("go", "env", "CGO_ENABLED").Output()
+	if err != nil || strings.TrimSpace(string(out)) != "1" {
+		t.Skip("cgo is unavailable")
+	}
+	if _, err := exec.LookPath("gcc"); err != nil {
+		t.Skip("gcc is unavailable")
+	}
+
+	dir := t.TempDir()
+	build := exec.Command("go", "build", "-buildmode=c-shared", "-o",
+		filepath.Join(dir, "libgotile.so"), ".")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("could not build library: %v: %s", err, out)
+	}
+	return dir
+}
+
+func TestRoundTrip(t *testing.T) {
+	dir := buildLibrary(t)
+
+	roundtrip := filepath.Join(dir, "roundtrip")
+	cc := exec.Command("gcc", "-o", roundtrip, "-I", dir,
+		filepath.Join("testdata", "roundtrip.c"), "-L", dir, "-lgotile",
+		"-Wl,-rpath,"+dir)
+	if out, err := cc.CombinedOutput(); err != nil {
+		t.Fatalf("could not compile roundtrip.c: %v: %s", err, out)
+	}
+
+	out, err := exec.Command(roundtrip).Output()
+	if err != nil {
+		t.Fatalf("roundtrip: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	want := []string{"indices 2 0", "dense 1 0 1 0 0", "status -1"}
+	if len(lines) != len(want)+1 {
+		t.Fatalf("roundtrip: have(%q) want %d lines", out, len(want)+1)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("roundtrip: have(%q) want(%q)", lines[i], want[i])
+		}
+	}
+	if !strings.HasPrefix(lines[3], "error ") ||
+		!strings.Contains(lines[3], "out of bounds") {
+		t.Errorf("roundtrip: have(%q) want the out of bounds error", lines[3])
+	}
+}
+
+func TestPython(t *testing.T) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 is unavailable")
+	}
+	dir := buildLibrary(t)
+
+	cmd := exec.Command(python, "-m", "unittest", "discover", "-s",
+		filepath.Join("..", "..", "python"))
+	cmd.Env = append(os.Environ(), "GOTILE_LIB="+filepath.Join(dir,
+		"libgotile.so"))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("python tests: %v: %s", err, out)
+	}
+}
@@ -0,0 +1,50 @@
+// roundtrip creates a tile coder through libgotile, encodes a vector
+// inside and a vector outside its bounds, and frees it, printing the
+// results for main_test.go to check.
+#include <stdio.h>
+
+#include "libgotile.h"
+
+static const char *config =
+	"{\"min_dims\": [0, 0], \"max_dims\": [1, 1], \"bins\": [[2, 2]], "
+	"\"seed\": 1, \"include_bias\": true, \"offset_div\": 1e300, "
+	"\"bounds_policy\": \"error\"}";
+
+int main(void) {
+	uintptr_t h;
+	if (gotile_new((char *)config, &h) != 0) {
+		char msg[1024];
+		gotile_error(0, msg, sizeof msg);
+		fprintf(stderr, "gotile_new: %s\n", msg);
+		return 1;
+	}
+
+	int n = gotile_num_indices(h), length = gotile_vec_length(h);
+	double v[2] = {0.2, 0.7};
+	int64_t indices[16];
+	double dense[16];
+	if (n > 16 || length > 16 || gotile_encode_indices(h, v, 2, indices) != 0 ||
+	    gotile_encode(h, v, 2, dense) != 0) {
+		fprintf(stderr, "could not encode\n");
+		return 1;
+	}
+	printf("indices");
+	for (int i = 0; i < n; i++) {
+		printf(" %lld", (long long)indices[i]);
+	}
+	printf("\ndense");
+	for (int i = 0; i < length; i++) {
+		printf(" %g", dense[i]);
+	}
+	printf("\n");
+
+	// The error of a failed call is kept by its handle
+	double out[2] = {2, 0.7};
+	char msg[1024] = "";
+	printf("status %d\n", gotile_encode_indices(h, out, 2, indices));
+	gotile_error(h, msg, sizeof msg);
+	printf("error %s\n", msg);
+
+	gotile_free(h);
+	return 0;
+}
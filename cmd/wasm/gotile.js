@@ -0,0 +1,64 @@
+// gotile.js loads the gotile WebAssembly module built from cmd/wasm.
+// The wasm_exec.js support file of the Go distribution, which defines
+// the global Go class, must be loaded first.
+
+// load instantiates the gotile module from source, which is either the
+// URL of gotile.wasm or its contents as a BufferSource, and returns an
+// object holding the TileCoder class.
+export async function load(source = "gotile.wasm") {
+  const go = new Go();
+  const { instance } = typeof source === "string"
+    ? await WebAssembly.instantiateStreaming(fetch(source), go.importObject)
+    : await WebAssembly.instantiate(source, go.importObject);
+  go.run(instance);
+
+  // check throws any Error returned by the module
+  const check = (value) => {
+    if (value instanceof Error) {
+      throw value;
+    }
+    return value;
+  };
+
+  // TileCoder tile codes observations with the tile coder constructed
+  // from a gotile Config, given as an object or a JSON string with the
+  // fields of its JSON encoding. Call release once the TileCoder is no
+  // longer needed.
+  class TileCoder {
+    constructor(config) {
+      const json = typeof config === "string" ? config : JSON.stringify(config);
+      this.coder = check(globalThis.gotileNewTileCoder(json));
+      this.vecLength = this.coder.vecLength;
+      this.numIndices = this.coder.numIndices;
+    }
+
+    // encode returns the dense feature vector of v as a Float64Array
+    encode(v) {
+      return check(this.coder.encode(v));
+    }
+
+    // encodeIndices returns the indices of the non-zero features of v
+    encodeIndices(v) {
+      return Array.from(check(this.coder.encodeIndices(v)));
+    }
+
+    // encodeBatch returns the dense feature vector of each observation
+    // in batch
+    encodeBatch(batch) {
+      return Array.from(batch, (v) => this.encode(v));
+    }
+
+    // encodeIndicesBatch returns the indices of the non-zero features
+    // of each observation in batch
+    encodeIndicesBatch(batch) {
+      return Array.from(batch, (v) => this.encodeIndices(v));
+    }
+
+    // release frees the resources held by the TileCoder
+    release() {
+      this.coder.release();
+    }
+  }
+
+  return { TileCoder };
+}
@@ -0,0 +1,145 @@
+//go:build js && wasm
+// +build js,wasm
+
+// Command wasm builds gotile as a WebAssembly module, so that
+// browser-based demos can tile code observations client-side with the
+// same features as Go programs. Build the module with:
+//
+//	GOOS=js GOARCH=wasm go build -o gotile.wasm ./cmd/wasm
+//
+// and load it with gotile.js, alongside the wasm_exec.js support file
+// of the Go distribution:
+//
+//	import { load } from "./gotile.js";
+//
+//	const gotile = await load("gotile.wasm");
+//	const coder = new gotile.TileCoder({
+//		min_dims: [-1.2, -0.07],
+//		max_dims: [0.6, 0.07],
+//		bins: [[8, 8], [8, 8]],
+//		seed: 1,
+//		include_bias: true,
+//		offset_div: 1.5,
+//	});
+//	const indices = coder.encodeIndices([-0.5, 0]);
+//
+// The module defines a global gotileNewTileCoder function, taking a
+// Config as a JSON string, which gotile.js wraps. Since Go functions
+// called from JavaScript cannot throw, functions return an Error
+// rather than throwing it, and gotile.js throws any returned Error.
+package main
+
+import (
+	"fmt"
+	"strings"
+	"syscall/js"
+
+	"github.com/samuelfneumann/gotile"
+	"gonum.org/v1/gonum/mat"
+)
+
+func main() {
+	js.Global().Set("gotileNewTileCoder", js.FuncOf(newTileCoder))
+	select {}
+}
+
+// jsError returns err as a JavaScript Error
+func jsError(err error) js.Value {
+	return js.Global().Get("Error").New(err.Error())
+}
+
+// newTileCoder returns a JavaScript object wrapping the TileCoder
+// constructed from the Config given as a JSON string in args[0]
+func newTileCoder(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return jsError(fmt.Errorf("newTileCoder: expected a JSON config"))
+	}
+	c, err := gotile.ReadJSONConfig(strings.NewReader(args[0].String()))
+	if err != nil {
+		return jsError(err)
+	}
+	tc, err := c.New()
+	if err != nil {
+		return jsError(err)
+	}
+
+	numIndices := tc.NumTilings()
+	if tc.IncludeBias() {
+		numIndices++
+	}
+
+	var funcs []js.Func
+	method := func(f func(args []js.Value) (interface{}, error)) js.Func {
+		fn := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			v, err := f(args)
+			if err != nil {
+				return jsError(err)
+			}
+			return v
+		})
+		funcs = append(funcs, fn)
+		return fn
+	}
+
+	obj := js.Global().Get("Object").New()
+	obj.Set("vecLength", tc.VecLength())
+	obj.Set("numIndices", numIndices)
+	obj.Set("encode", method(func(args []js.Value) (interface{}, error) {
+		v, err := vector(args)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := tc.TryEncode(v)
+		if err != nil {
+			return nil, err
+		}
+		return float64Array(mat.Col(nil, 0, encoded)), nil
+	}))
+	obj.Set("encodeIndices", method(func(args []js.Value) (interface{},
+		error) {
+		v, err := vector(args)
+		if err != nil {
+			return nil, err
+		}
+		indices, err := tc.TryEncodeIndices(v)
+		if err != nil {
+			return nil, err
+		}
+		return float64Array(indices), nil
+	}))
+	obj.Set("release", js.FuncOf(func(this js.Value,
+		args []js.Value) interface{} {
+		for _, fn := range funcs {
+			fn.Release()
+		}
+		return nil
+	}))
+	return obj
+}
+
+// vector returns the JavaScript array or typed array args[0] as a
+// vector
+func vector(args []js.Value) (*mat.VecDense, error) {
+	if len(args) != 1 || args[0].Type() != js.TypeObject {
+		return nil, fmt.Errorf("expected an array")
+	}
+	n := args[0].Length()
+	if n == 0 {
+		return nil, fmt.Errorf("cannot encode an empty vector")
+	}
+
+	v := mat.NewVecDense(n, nil)
+	for i := 0; i < n; i++ {
+		v.SetVec(i, args[0].Index(i).Float())
+	}
+	return v, nil
+}
+
+// float64Array returns data as a JavaScript Float64Array
+func float64Array(data []float64) js.Value {
+	array := js.Global().Get("Float64Array").New(len(data))
+	for i, x := range data {
+		array.SetIndex(i, x)
+	}
+	return array
+}
@@ -3,12 +3,15 @@ module github.com/samuelfneumann/gotile
 go 1.17
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/samuelfneumann/goutils v0.0.0-20211111214126-5491a5616c35
 	golang.org/x/exp v0.0.0-20211111183329-cb5df436b1a8
 	gonum.org/v1/gonum v0.9.3
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/samuelfneumann/golearn v0.0.0-20211102131952-ab8bf97157d4 // indirect
+	github.com/kr/pretty v0.1.0 // indirect
 	golang.org/x/tools v0.1.8-0.20211029000441-d6a9af8af023 // indirect
+	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127 // indirect
 )
@@ -0,0 +1,140 @@
+// Package gorgonia converts between the gonum matrices used by gotile
+// and gorgonia tensors, and tile codes batches held in tensors, so
+// that models built with gorgonia can consume tile-coded features
+// without converting through gonum types by hand.
+//
+// Following gorgonia conventions, batches held in tensors are
+// batch-major, with a row for each sample, whereas gotile batches hold
+// a column for each sample. The encoding functions of this package
+// take and return batch-major tensors.
+package gorgonia
+
+import (
+	"fmt"
+
+	"github.com/samuelfneumann/gotile"
+	"gonum.org/v1/gonum/mat"
+	"gorgonia.org/tensor"
+)
+
+// FromMat returns a new float64 tensor holding a copy of m, with the
+// same shape as m
+func FromMat(m mat.Matrix) *tensor.Dense {
+	rows, cols := m.Dims()
+	backing := make([]float64, rows*cols)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			backing[i*cols+j] = m.At(i, j)
+		}
+	}
+	return tensor.New(tensor.WithShape(rows, cols), tensor.WithBacking(backing))
+}
+
+// ToMat returns a new matrix holding a copy of the float64 or float32
+// matrix t, with the same shape as t. A vector is returned as a column
+// vector.
+func ToMat(t tensor.Tensor) (*mat.Dense, error) {
+	if t == nil {
+		return nil, fmt.Errorf("toMat: %w", gotile.ErrNilInput)
+	}
+
+	shape := t.Shape()
+	var rows, cols int
+	switch t.Dims() {
+	case 1:
+		rows, cols = shape[0], 1
+	case 2:
+		rows, cols = shape[0], shape[1]
+	default:
+		return nil, fmt.Errorf("toMat: tensor must be a vector or matrix: "+
+			"%w \n\thave(%v)", gotile.ErrDimension, shape)
+	}
+
+	m := mat.NewDense(rows, cols, nil)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			coords := []int{i, j}[:t.Dims()]
+			v, err := t.At(coords...)
+			if err != nil {
+				return nil, fmt.Errorf("toMat: %v", err)
+			}
+			switch v := v.(type) {
+			case float64:
+				m.Set(i, j, v)
+			case float32:
+				m.Set(i, j, float64(v))
+			default:
+				return nil, fmt.Errorf("toMat: tensor must hold float64 or "+
+					"float32 elements: have(%v)", t.Dtype())
+			}
+		}
+	}
+	return m, nil
+}
+
+// EncodeBatch tile codes each row of the batch-major tensor x with
+// coder, and returns a float64 tensor with a row holding the
+// VecLength() features of the tile-coded representation of each row
+// of x.
+//
+// If some tiling uses the BoundsError policy and a row falls outside
+// its bounds, an error wrapping gotile.ErrOutOfBounds is returned.
+func EncodeBatch(coder *gotile.TileCoder, x tensor.Tensor) (*tensor.Dense,
+	error) {
+	b, err := batch(coder, x)
+	if err != nil {
+		return nil, fmt.Errorf("encodeBatch: %w", err)
+	}
+	encoded, err := coder.TryEncodeBatch(b)
+	if err != nil {
+		return nil, fmt.Errorf("encodeBatch: %w", err)
+	}
+	return FromMat(encoded.T()), nil
+}
+
+// EncodeIndicesBatch tile codes each row of the batch-major tensor x
+// with coder, and returns an int tensor with a row holding the
+// non-zero indices of the tile-coded representation of each row of x,
+// ordered as in EncodeIndices, which can be used directly for
+// embedding lookups.
+//
+// If some tiling uses the BoundsError policy and a row falls outside
+// its bounds, an error wrapping gotile.ErrOutOfBounds is returned.
+func EncodeIndicesBatch(coder *gotile.TileCoder, x tensor.Tensor) (
+	*tensor.Dense, error) {
+	b, err := batch(coder, x)
+	if err != nil {
+		return nil, fmt.Errorf("encodeIndicesBatch: %w", err)
+	}
+	indices, err := coder.TryEncodeIndicesBatch(b)
+	if err != nil {
+		return nil, fmt.Errorf("encodeIndicesBatch: %w", err)
+	}
+
+	rows, cols := indices.Dims()
+	backing := make([]int, rows*cols)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			backing[j*rows+i] = int(indices.At(i, j))
+		}
+	}
+	return tensor.New(tensor.WithShape(cols, rows),
+		tensor.WithBacking(backing)), nil
+}
+
+// batch returns the batch-major tensor x as a batch to encode with
+// coder, with a column for each row of x
+func batch(coder *gotile.TileCoder, x tensor.Tensor) (*mat.Dense, error) {
+	if coder == nil {
+		return nil, gotile.ErrNilInput
+	}
+	if x != nil && x.Dims() != 2 {
+		return nil, fmt.Errorf("tensor must be a matrix: %w \n\thave(%v)",
+			gotile.ErrDimension, x.Shape())
+	}
+	m, err := ToMat(x)
+	if err != nil {
+		return nil, err
+	}
+	return mat.DenseCopyOf(m.T()), nil
+}
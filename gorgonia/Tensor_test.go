@@ -0,0 +1,125 @@
+package gorgonia
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/samuelfneumann/gotile"
+	"gonum.org/v1/gonum/mat"
+	"gorgonia.org/tensor"
+)
+
+func TestConvert(t *testing.T) {
+	m := mat.NewDense(2, 3, []float64{1, 2, 3, 4, 5, 6})
+	x := FromMat(m)
+	if shape := x.Shape(); !shape.Eq(tensor.Shape{2, 3}) {
+		t.Errorf("fromMat: have shape(%v) want(%v)", shape, tensor.Shape{2, 3})
+	}
+	if v, _ := x.At(1, 0); v != 4.0 {
+		t.Errorf("fromMat: have(%v) want(%v)", v, 4.0)
+	}
+
+	back, err := ToMat(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mat.Equal(back, m) {
+		t.Errorf("toMat: have(%v) want(%v)", mat.Formatted(back),
+			mat.Formatted(m))
+	}
+
+	x32 := tensor.New(tensor.WithShape(3), tensor.WithBacking([]float32{1, 2,
+		3}))
+	back, err = ToMat(x32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := mat.NewDense(3, 1, []float64{1, 2, 3}); !mat.Equal(back, want) {
+		t.Errorf("toMat: have(%v) want(%v)", mat.Formatted(back),
+			mat.Formatted(want))
+	}
+
+	ints := tensor.New(tensor.WithShape(2), tensor.WithBacking([]int{1, 2}))
+	if _, err := ToMat(ints); err == nil {
+		t.Error("expected error with int tensor")
+	}
+	cube := tensor.New(tensor.WithShape(1, 1, 2), tensor.WithBacking(
+		[]float64{1, 2}))
+	if _, err := ToMat(cube); !errors.Is(err, gotile.ErrDimension) {
+		t.Errorf("toMat: have(%v) want(%v)", err, gotile.ErrDimension)
+	}
+}
+
+func TestEncodeBatch(t *testing.T) {
+	tc, err := gotile.New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{2, 2}, {3, 3}},
+		1,
+		true,
+		2,
+		gotile.WithBoundsPolicy(gotile.BoundsError),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+
+	// Three samples, one per row
+	x := tensor.New(tensor.WithShape(3, 2), tensor.WithBacking([]float64{
+		0.1, 0.2,
+		0.9, 0.1,
+		0.4, 0.8,
+	}))
+	b := mat.NewDense(2, 3, []float64{
+		0.1, 0.9, 0.4,
+		0.2, 0.1, 0.8,
+	})
+
+	features, err := EncodeBatch(tc, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	have, err := ToMat(features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := tc.EncodeBatch(b).T(); !mat.Equal(have, want) {
+		t.Errorf("encodeBatch: have(%v) want(%v)", mat.Formatted(have),
+			mat.Formatted(want))
+	}
+
+	indices, err := EncodeIndicesBatch(tc, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shape := indices.Shape(); !shape.Eq(tensor.Shape{3, 3}) {
+		t.Errorf("encodeIndicesBatch: have shape(%v) want(%v)", shape,
+			tensor.Shape{3, 3})
+	}
+	want := tc.EncodeIndicesBatch(b)
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			if v, _ := indices.At(i, j); v != int(want.At(j, i)) {
+				t.Errorf("encodeIndicesBatch(%d, %d): have(%v) want(%v)", i, j,
+					v, want.At(j, i))
+			}
+		}
+	}
+
+	if _, err := EncodeBatch(tc, tensor.New(tensor.WithShape(2),
+		tensor.WithBacking([]float64{0, 0}))); !errors.Is(err,
+		gotile.ErrDimension) {
+		t.Errorf("encodeBatch: have(%v) want(%v)", err, gotile.ErrDimension)
+	}
+	if _, err := EncodeIndicesBatch(tc, nil); !errors.Is(err,
+		gotile.ErrNilInput) {
+		t.Errorf("encodeIndicesBatch(nil): have(%v) want(%v)", err,
+			gotile.ErrNilInput)
+	}
+	x.SetAt(2.0, 0, 0)
+	if _, err := EncodeIndicesBatch(tc, x); !errors.Is(err,
+		gotile.ErrOutOfBounds) {
+		t.Errorf("encodeIndicesBatch: have(%v) want(%v)", err,
+			gotile.ErrOutOfBounds)
+	}
+}
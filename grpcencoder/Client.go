@@ -0,0 +1,99 @@
+package grpcencoder
+
+import (
+	"context"
+	"io"
+
+	"google.golang.org/grpc"
+)
+
+// Client encodes vectors with a remote Encoder service, returning the
+// indices of the non-zero features of each vector ordered as in
+// EncodeIndices. Use the generated EncoderClient for the other formats.
+type Client struct {
+	client EncoderClient
+}
+
+// NewClient returns a new Client using the connection cc
+func NewClient(cc grpc.ClientConnInterface) *Client {
+	return &Client{client: NewEncoderClient(cc)}
+}
+
+// EncodeIndices returns the indices of the non-zero features of v
+func (c *Client) EncodeIndices(ctx context.Context, v []float64) ([]int,
+	error) {
+	e, err := c.client.Encode(ctx, &EncodeRequest{Vector: v})
+	if err != nil {
+		return nil, err
+	}
+	return ints(e.GetIndices()), nil
+}
+
+// EncodeIndicesBatch returns the indices of the non-zero features of
+// each vector in vs, in order
+func (c *Client) EncodeIndicesBatch(ctx context.Context, vs [][]float64) (
+	[][]int, error) {
+	req := &EncodeBatchRequest{Vectors: make([]*Vector, len(vs))}
+	for i, v := range vs {
+		req.Vectors[i] = &Vector{Values: v}
+	}
+
+	resp, err := c.client.EncodeBatch(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	indices := make([][]int, len(resp.GetEncodings()))
+	for i, e := range resp.GetEncodings() {
+		indices[i] = ints(e.GetIndices())
+	}
+	return indices, nil
+}
+
+// Stream opens a stream for encoding observations one at a time over a
+// single persistent call, which avoids the overhead of a call per
+// observation in online control loops. The stream is closed when ctx
+// is done.
+func (c *Client) Stream(ctx context.Context) (*Stream, error) {
+	stream, err := c.client.EncodeStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Stream{stream: stream}, nil
+}
+
+// Stream encodes observations over a single persistent call. A Stream
+// should not be used concurrently.
+type Stream struct {
+	stream Encoder_EncodeStreamClient
+}
+
+// EncodeIndices sends v on the stream and returns the indices of the
+// non-zero features of v. Once an error is returned, the stream is
+// closed.
+func (s *Stream) EncodeIndices(v []float64) ([]int, error) {
+	// Send returns io.EOF if the server closed the stream, in which
+	// case the status of the stream is returned by Recv
+	if err := s.stream.Send(&EncodeRequest{Vector: v}); err != nil &&
+		err != io.EOF {
+		return nil, err
+	}
+	e, err := s.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	return ints(e.GetIndices()), nil
+}
+
+// Close closes the sending side of the stream
+func (s *Stream) Close() error {
+	return s.stream.CloseSend()
+}
+
+// ints converts indices to ints
+func ints(indices []int64) []int {
+	converted := make([]int, len(indices))
+	for i, index := range indices {
+		converted[i] = int(index)
+	}
+	return converted
+}
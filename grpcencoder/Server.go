@@ -0,0 +1,243 @@
+// Package grpcencoder serves the feature mapping of a gotile Coder over
+// gRPC, which has lower overhead than the JSON of package server for
+// high-rate clients such as simulation farms. The service is defined
+// in gotile.proto, from which clients in other languages can be
+// generated, and includes a bidirectional stream for continuous online
+// encoding of observations.
+//
+// Serve a coder with:
+//
+//	g := grpc.NewServer()
+//	grpcencoder.RegisterEncoderServer(g, grpcencoder.NewServer(tc))
+//	g.Serve(listener)
+//
+// and encode vectors with a Client, or with the generated EncoderClient.
+package grpcencoder
+
+// The generated code must be regenerated with the pinned versions of
+// protoc and its plugins, which match the versions of
+// google.golang.org/protobuf and google.golang.org/grpc in go.mod:
+//
+//	go install google.golang.org/protobuf/cmd/protoc-gen-go@v1.28.1
+//	go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@v1.2.0
+//	go generate    # in this directory, with protoc 3.21.9 on the PATH
+//
+// The first directive fails if any other version of protoc is used.
+//go:generate sh -c "protoc --version | grep -qx 'libprotoc 3.21.9' || { echo 'grpcencoder: protoc 3.21.9 is required' >&2; exit 1; }"
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative gotile.proto
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/samuelfneumann/gotile"
+	"gonum.org/v1/gonum/mat"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements EncoderServer, serving the feature mapping of a
+// Coder. RPCs are encoded concurrently if the Coder is a TileCoder,
+// which may encode concurrently even when it adapts its bounds. Since
+// other Coders may update their state as they encode, a Server encodes
+// one RPC at a time for them. Batches are encoded with the batch
+// methods of the Coder.
+type Server struct {
+	UnimplementedEncoderServer
+	mu     sync.Mutex
+	serial bool // Whether RPCs are encoded one at a time
+	coder  gotile.TryCoder
+}
+
+var _ EncoderServer = (*Server)(nil)
+
+// NewServer returns a new Server serving the feature mapping of coder
+func NewServer(coder gotile.TryCoder) *Server {
+	_, concurrent := coder.(*gotile.TileCoder)
+	return &Server{coder: coder, serial: !concurrent}
+}
+
+// Info implements the Info method of the EncoderServer interface
+func (s *Server) Info(ctx context.Context, req *InfoRequest) (*InfoResponse,
+	error) {
+	return &InfoResponse{
+		Length: int64(s.coder.VecLength()),
+		Coder:  fmt.Sprintf("%v", s.coder),
+	}, nil
+}
+
+// Encode implements the Encode method of the EncoderServer interface
+func (s *Server) Encode(ctx context.Context, req *EncodeRequest) (*Encoding,
+	error) {
+	if err := checkFormat(req.GetFormat()); err != nil {
+		return nil, err
+	}
+	v := req.GetVector()
+	if len(v) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "encode: cannot "+
+			"encode an empty vector")
+	}
+
+	s.lock()
+	defer s.unlock()
+	vec := mat.NewVecDense(len(v), v)
+	if req.GetFormat() == Format_FORMAT_INDICES {
+		indices, err := s.coder.TryEncodeIndices(vec)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return indicesEncoding(indices), nil
+	}
+
+	features, err := s.coder.TryEncode(vec)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return encoding(features, req.GetFormat()), nil
+}
+
+// indicesBatchCoder is implemented by Coders, such as TileCoders, which
+// can encode the indices of the non-zero features of a whole batch
+type indicesBatchCoder interface {
+	TryEncodeIndicesBatch(b *mat.Dense) (*mat.Dense, error)
+}
+
+// EncodeBatch implements the EncodeBatch method of the EncoderServer
+// interface
+func (s *Server) EncodeBatch(ctx context.Context, req *EncodeBatchRequest) (
+	*EncodeBatchResponse, error) {
+	if err := checkFormat(req.GetFormat()); err != nil {
+		return nil, err
+	}
+	vectors := req.GetVectors()
+	resp := &EncodeBatchResponse{Encodings: make([]*Encoding, len(vectors))}
+	if len(vectors) == 0 {
+		return resp, nil
+	}
+
+	dims := len(vectors[0].GetValues())
+	for i, v := range vectors {
+		if len(v.GetValues()) == 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "encodeBatch: "+
+				"vector %d: cannot encode an empty vector", i)
+		}
+		if len(v.GetValues()) != dims {
+			return nil, status.Errorf(codes.InvalidArgument, "encodeBatch: "+
+				"vector %d has %d dimensions, but vector 0 has %d", i,
+				len(v.GetValues()), dims)
+		}
+	}
+	b := mat.NewDense(dims, len(vectors), nil)
+	for j, v := range vectors {
+		b.SetCol(j, v.GetValues())
+	}
+
+	s.lock()
+	defer s.unlock()
+	if req.GetFormat() == Format_FORMAT_INDICES {
+		c, ok := s.coder.(indicesBatchCoder)
+		if !ok {
+			for j := range resp.Encodings {
+				indices, err := s.coder.TryEncodeIndices(b.ColView(j))
+				if err != nil {
+					return nil, status.Errorf(codes.InvalidArgument,
+						"encodeBatch: vector %d: %v", j, err)
+				}
+				resp.Encodings[j] = indicesEncoding(indices)
+			}
+			return resp, nil
+		}
+
+		indices, err := c.TryEncodeIndicesBatch(b)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		for j := range resp.Encodings {
+			resp.Encodings[j] = indicesEncoding(mat.Col(nil, j, indices))
+		}
+		return resp, nil
+	}
+
+	features, err := s.coder.TryEncodeBatch(b)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	for j := range resp.Encodings {
+		resp.Encodings[j] = encoding(features.ColView(j), req.GetFormat())
+	}
+	return resp, nil
+}
+
+// EncodeStream implements the EncodeStream method of the EncoderServer
+// interface
+func (s *Server) EncodeStream(stream Encoder_EncodeStreamServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		e, err := s.Encode(stream.Context(), req)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(e); err != nil {
+			return err
+		}
+	}
+}
+
+// lock locks the receiver if it encodes one RPC at a time
+func (s *Server) lock() {
+	if s.serial {
+		s.mu.Lock()
+	}
+}
+
+// unlock unlocks the receiver after lock
+func (s *Server) unlock() {
+	if s.serial {
+		s.mu.Unlock()
+	}
+}
+
+// checkFormat returns an error if f is unknown
+func checkFormat(f Format) error {
+	switch f {
+	case Format_FORMAT_INDICES, Format_FORMAT_SPARSE, Format_FORMAT_DENSE:
+		return nil
+	}
+	return status.Error(codes.InvalidArgument,
+		fmt.Sprintf("unknown format %v", f))
+}
+
+// indicesEncoding returns the Encoding in the indices format of the
+// indices of the non-zero features of a vector
+func indicesEncoding(indices []float64) *Encoding {
+	e := &Encoding{Indices: make([]int64, len(indices))}
+	for i, index := range indices {
+		e.Indices[i] = int64(index)
+	}
+	return e
+}
+
+// encoding returns the Encoding of the feature vector features in the
+// sparse or dense format f
+func encoding(features mat.Vector, f Format) *Encoding {
+	if f == Format_FORMAT_DENSE {
+		return &Encoding{Features: mat.Col(nil, 0, features)}
+	}
+
+	e := &Encoding{}
+	for i := 0; i < features.Len(); i++ {
+		if value := features.AtVec(i); value != 0 {
+			e.Indices = append(e.Indices, int64(i))
+			e.Values = append(e.Values, value)
+		}
+	}
+	return e
+}
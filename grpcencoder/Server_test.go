@@ -0,0 +1,223 @@
+package grpcencoder
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/samuelfneumann/gotile"
+	"gonum.org/v1/gonum/mat"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestServer(t *testing.T) {
+	tc, err := gotile.New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{2, 2}, {2, 2}},
+		1,
+		true,
+		1e300,
+		gotile.WithBoundsPolicy(gotile.BoundsError),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	g := grpc.NewServer()
+	RegisterEncoderServer(g, NewServer(tc))
+	go g.Serve(listener)
+	defer g.Stop()
+
+	ctx := context.Background()
+	conn, err := grpc.DialContext(ctx, "bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn,
+			error) {
+			return listener.Dial()
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := NewClient(conn)
+
+	vectors := [][]float64{{0.2, 0.7}, {0.9, 0.1}}
+	want := make([][]int, len(vectors))
+	for i, v := range vectors {
+		for _, index := range tc.EncodeIndices(mat.NewVecDense(2, v)) {
+			want[i] = append(want[i], int(index))
+		}
+	}
+	equal := func(have, want []int) bool {
+		if len(have) != len(want) {
+			return false
+		}
+		for i := range have {
+			if have[i] != want[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	indices, err := client.EncodeIndices(ctx, vectors[0])
+	if err != nil || !equal(indices, want[0]) {
+		t.Errorf("encodeIndices(%v): have(%v, %v) want(%v)", vectors[0],
+			indices, err, want[0])
+	}
+
+	batch, err := client.EncodeIndicesBatch(ctx, vectors)
+	if err != nil || len(batch) != len(vectors) {
+		t.Fatalf("encodeIndicesBatch: have(%v, %v) want(%v)", batch, err,
+			want)
+	}
+	for i := range batch {
+		if !equal(batch[i], want[i]) {
+			t.Errorf("encodeIndicesBatch: have(%v) want(%v)", batch, want)
+		}
+	}
+
+	stream, err := client.Stream(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range vectors {
+		indices, err := stream.EncodeIndices(v)
+		if err != nil || !equal(indices, want[i]) {
+			t.Errorf("stream(%v): have(%v, %v) want(%v)", v, indices, err,
+				want[i])
+		}
+	}
+
+	// Vectors which cannot be encoded close the stream
+	_, err = stream.EncodeIndices([]float64{2, 0})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("stream: have(%v) want(%v)", err, codes.InvalidArgument)
+	}
+
+	dense, err := NewEncoderClient(conn).Encode(ctx, &EncodeRequest{
+		Vector: vectors[0],
+		Format: Format_FORMAT_DENSE,
+	})
+	if err != nil || len(dense.GetFeatures()) != tc.VecLength() {
+		t.Errorf("encode: have(%v, %v) want %d features", dense, err,
+			tc.VecLength())
+	}
+
+	info, err := NewEncoderClient(conn).Info(ctx, &InfoRequest{})
+	if err != nil || info.GetLength() != int64(tc.VecLength()) {
+		t.Errorf("info: have(%v, %v) want length(%d)", info, err,
+			tc.VecLength())
+	}
+
+	for _, v := range [][]float64{nil, {0.5}, {2, 0}} {
+		_, err := client.EncodeIndices(ctx, v)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("encodeIndices(%v): have(%v) want(%v)", v, err,
+				codes.InvalidArgument)
+		}
+	}
+}
+
+func TestServerBatch(t *testing.T) {
+	tc, err := gotile.New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{4, 4}, {4, 4}},
+		1,
+		true,
+		-1,
+		gotile.WithAdaptiveBounds(0.1, nil),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	s := NewServer(tc)
+	ctx := context.Background()
+	formats := []Format{
+		Format_FORMAT_INDICES,
+		Format_FORMAT_SPARSE,
+		Format_FORMAT_DENSE,
+	}
+
+	// RPCs are encoded concurrently, even by an adaptive tile coder
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				x := float64(g*50 + j)
+				for _, format := range formats {
+					_, err := s.EncodeBatch(ctx, &EncodeBatchRequest{
+						Vectors: []*Vector{
+							{Values: []float64{x, -x}},
+							{Values: []float64{0.5, 0.5}},
+						},
+						Format: format,
+					})
+					if err != nil {
+						t.Error(err)
+						return
+					}
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	// Batches are encoded as each of their vectors would be
+	vectors := []*Vector{
+		{Values: []float64{0.2, 0.7}},
+		{Values: []float64{0.9, 0.1}},
+		{Values: []float64{-3, 40}},
+	}
+	for _, format := range formats {
+		batch, err := s.EncodeBatch(ctx, &EncodeBatchRequest{
+			Vectors: vectors,
+			Format:  format,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, v := range vectors {
+			single, err := s.Encode(ctx, &EncodeRequest{
+				Vector: v.GetValues(),
+				Format: format,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !proto.Equal(batch.GetEncodings()[i], single) {
+				t.Errorf("encodeBatch(%v, %v): have(%v) want(%v)",
+					v.GetValues(), format, batch.GetEncodings()[i], single)
+			}
+		}
+	}
+
+	for _, vectors := range [][]*Vector{
+		{{Values: []float64{0.5, 0.5}}, {Values: []float64{0.5}}},
+		{{Values: []float64{0.5, 0.5}}, {}},
+		{{Values: []float64{0.5}}, {Values: []float64{0.5}}},
+	} {
+		_, err := s.EncodeBatch(ctx, &EncodeBatchRequest{Vectors: vectors})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("encodeBatch(%v): have(%v) want(%v)", vectors, err,
+				codes.InvalidArgument)
+		}
+	}
+	_, err = s.EncodeBatch(ctx, &EncodeBatchRequest{Format: Format(7)})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("encodeBatch(format 7): have(%v) want(%v)", err,
+			codes.InvalidArgument)
+	}
+}
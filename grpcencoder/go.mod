@@ -0,0 +1,31 @@
+module github.com/samuelfneumann/gotile/grpcencoder
+
+go 1.17
+
+replace github.com/samuelfneumann/gotile => ../
+
+require (
+	github.com/samuelfneumann/gotile v0.0.0-00010101000000-000000000000
+	gonum.org/v1/gonum v0.9.3
+	google.golang.org/grpc v1.50.1
+	google.golang.org/protobuf v1.28.1
+)
+
+require (
+	github.com/BurntSushi/toml v1.2.1 // indirect
+	github.com/golang/protobuf v1.5.2 // indirect
+	github.com/samuelfneumann/goutils v0.0.0-20211111214126-5491a5616c35 // indirect
+	golang.org/x/exp v0.0.0-20211111183329-cb5df436b1a8 // indirect
+	golang.org/x/net v0.0.0-20220127200216-cd36cc0744dd // indirect
+	golang.org/x/sys v0.0.0-20220412211240-33da011f77ad // indirect
+	golang.org/x/text v0.3.7 // indirect
+	golang.org/x/tools v0.1.8-0.20211029000441-d6a9af8af023 // indirect
+	golang.org/x/xerrors v0.0.0-20220411194840-2f41105eb62f // indirect
+	google.golang.org/genproto v0.0.0-20220126215142-9970aeb2e350 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+require (
+	github.com/google/go-cmp v0.5.7 // indirect
+	github.com/kr/text v0.1.0 // indirect
+)
@@ -0,0 +1,258 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: gotile.proto
+
+package grpcencoder
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// EncoderClient is the client API for Encoder service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type EncoderClient interface {
+	// Info describes the coder
+	Info(ctx context.Context, in *InfoRequest, opts ...grpc.CallOption) (*InfoResponse, error)
+	// Encode encodes a single vector
+	Encode(ctx context.Context, in *EncodeRequest, opts ...grpc.CallOption) (*Encoding, error)
+	// EncodeBatch encodes a batch of vectors
+	EncodeBatch(ctx context.Context, in *EncodeBatchRequest, opts ...grpc.CallOption) (*EncodeBatchResponse, error)
+	// EncodeStream encodes each vector received on the stream, sending
+	// its encoding in order. The stream is closed with an error if some
+	// vector cannot be encoded.
+	EncodeStream(ctx context.Context, opts ...grpc.CallOption) (Encoder_EncodeStreamClient, error)
+}
+
+type encoderClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEncoderClient(cc grpc.ClientConnInterface) EncoderClient {
+	return &encoderClient{cc}
+}
+
+func (c *encoderClient) Info(ctx context.Context, in *InfoRequest, opts ...grpc.CallOption) (*InfoResponse, error) {
+	out := new(InfoResponse)
+	err := c.cc.Invoke(ctx, "/gotile.Encoder/Info", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *encoderClient) Encode(ctx context.Context, in *EncodeRequest, opts ...grpc.CallOption) (*Encoding, error) {
+	out := new(Encoding)
+	err := c.cc.Invoke(ctx, "/gotile.Encoder/Encode", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *encoderClient) EncodeBatch(ctx context.Context, in *EncodeBatchRequest, opts ...grpc.CallOption) (*EncodeBatchResponse, error) {
+	out := new(EncodeBatchResponse)
+	err := c.cc.Invoke(ctx, "/gotile.Encoder/EncodeBatch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *encoderClient) EncodeStream(ctx context.Context, opts ...grpc.CallOption) (Encoder_EncodeStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Encoder_ServiceDesc.Streams[0], "/gotile.Encoder/EncodeStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &encoderEncodeStreamClient{stream}
+	return x, nil
+}
+
+type Encoder_EncodeStreamClient interface {
+	Send(*EncodeRequest) error
+	Recv() (*Encoding, error)
+	grpc.ClientStream
+}
+
+type encoderEncodeStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *encoderEncodeStreamClient) Send(m *EncodeRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *encoderEncodeStreamClient) Recv() (*Encoding, error) {
+	m := new(Encoding)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// EncoderServer is the server API for Encoder service.
+// All implementations must embed UnimplementedEncoderServer
+// for forward compatibility
+type EncoderServer interface {
+	// Info describes the coder
+	Info(context.Context, *InfoRequest) (*InfoResponse, error)
+	// Encode encodes a single vector
+	Encode(context.Context, *EncodeRequest) (*Encoding, error)
+	// EncodeBatch encodes a batch of vectors
+	EncodeBatch(context.Context, *EncodeBatchRequest) (*EncodeBatchResponse, error)
+	// EncodeStream encodes each vector received on the stream, sending
+	// its encoding in order. The stream is closed with an error if some
+	// vector cannot be encoded.
+	EncodeStream(Encoder_EncodeStreamServer) error
+	mustEmbedUnimplementedEncoderServer()
+}
+
+// UnimplementedEncoderServer must be embedded to have forward compatible implementations.
+type UnimplementedEncoderServer struct {
+}
+
+func (UnimplementedEncoderServer) Info(context.Context, *InfoRequest) (*InfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Info not implemented")
+}
+func (UnimplementedEncoderServer) Encode(context.Context, *EncodeRequest) (*Encoding, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Encode not implemented")
+}
+func (UnimplementedEncoderServer) EncodeBatch(context.Context, *EncodeBatchRequest) (*EncodeBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EncodeBatch not implemented")
+}
+func (UnimplementedEncoderServer) EncodeStream(Encoder_EncodeStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method EncodeStream not implemented")
+}
+func (UnimplementedEncoderServer) mustEmbedUnimplementedEncoderServer() {}
+
+// UnsafeEncoderServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EncoderServer will
+// result in compilation errors.
+type UnsafeEncoderServer interface {
+	mustEmbedUnimplementedEncoderServer()
+}
+
+func RegisterEncoderServer(s grpc.ServiceRegistrar, srv EncoderServer) {
+	s.RegisterService(&Encoder_ServiceDesc, srv)
+}
+
+func _Encoder_Info_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EncoderServer).Info(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gotile.Encoder/Info",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EncoderServer).Info(ctx, req.(*InfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Encoder_Encode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EncoderServer).Encode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gotile.Encoder/Encode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EncoderServer).Encode(ctx, req.(*EncodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Encoder_EncodeBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncodeBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EncoderServer).EncodeBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gotile.Encoder/EncodeBatch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EncoderServer).EncodeBatch(ctx, req.(*EncodeBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Encoder_EncodeStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(EncoderServer).EncodeStream(&encoderEncodeStreamServer{stream})
+}
+
+type Encoder_EncodeStreamServer interface {
+	Send(*Encoding) error
+	Recv() (*EncodeRequest, error)
+	grpc.ServerStream
+}
+
+type encoderEncodeStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *encoderEncodeStreamServer) Send(m *Encoding) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *encoderEncodeStreamServer) Recv() (*EncodeRequest, error) {
+	m := new(EncodeRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Encoder_ServiceDesc is the grpc.ServiceDesc for Encoder service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Encoder_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gotile.Encoder",
+	HandlerType: (*EncoderServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Info",
+			Handler:    _Encoder_Info_Handler,
+		},
+		{
+			MethodName: "Encode",
+			Handler:    _Encoder_Encode_Handler,
+		},
+		{
+			MethodName: "EncodeBatch",
+			Handler:    _Encoder_EncodeBatch_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "EncodeStream",
+			Handler:       _Encoder_EncodeStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "gotile.proto",
+}
@@ -0,0 +1,71 @@
+// Package linear implements linear function approximation over the
+// sparse, binary features produced by tile coding
+package linear
+
+import "fmt"
+
+// LinearApprox is a linear function approximator over binary features,
+// such as those produced by a gotile.TileCoder. Since each feature is
+// either 0 or 1, predictions and updates only touch the weights of the
+// active features, given by their indices, so their cost depends on
+// the number of tilings rather than the number of features.
+type LinearApprox struct {
+	weights []float64
+}
+
+// New returns a new LinearApprox over features features, such as the
+// VecLength of a TileCoder, with all weights initialized to init.
+// A non-zero init can be used for optimistic initialization.
+func New(features int, init float64) (*LinearApprox, error) {
+	if features < 1 {
+		return nil, fmt.Errorf("new: cannot use less than 1 feature")
+	}
+
+	weights := make([]float64, features)
+	if init != 0 {
+		for i := range weights {
+			weights[i] = init
+		}
+	}
+	return &LinearApprox{weights: weights}, nil
+}
+
+// Predict returns the prediction for the feature vector whose active
+// features are given by indices, which is the sum of the weights of
+// the active features
+func (l *LinearApprox) Predict(indices []int) float64 {
+	prediction := 0.0
+	for _, i := range indices {
+		prediction += l.weights[i]
+	}
+	return prediction
+}
+
+// Update moves the weight of each active feature given by indices by
+// stepSize * delta, where delta is an error such as a TD error.
+// Tile-coded representations have one active feature per tiling (plus
+// a bias unit), so stepSize is commonly divided by the number of
+// active features.
+func (l *LinearApprox) Update(indices []int, delta, stepSize float64) {
+	step := stepSize * delta
+	for _, i := range indices {
+		l.weights[i] += step
+	}
+}
+
+// Weights returns the weight vector of the approximator. The returned
+// slice is shared with the approximator, so modifying it modifies the
+// approximator.
+func (l *LinearApprox) Weights() []float64 {
+	return l.weights
+}
+
+// Indices converts the indices returned by the EncodeIndices methods
+// of a gotile.TileCoder to the integer indices used by LinearApprox
+func Indices(encoded []float64) []int {
+	indices := make([]int, len(encoded))
+	for i := range encoded {
+		indices[i] = int(encoded[i])
+	}
+	return indices
+}
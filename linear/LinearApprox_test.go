@@ -0,0 +1,46 @@
+package linear
+
+import (
+	"math"
+	"testing"
+
+	"github.com/samuelfneumann/gotile"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestLinearApprox(t *testing.T) {
+	bins := [][]int{{8}, {8}, {8}, {8}}
+	tc, err := gotile.New(
+		mat.NewVecDense(1, []float64{0}),
+		mat.NewVecDense(1, []float64{1}),
+		bins,
+		1,
+		true,
+		-1,
+		gotile.WithDistinctSeeds(),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+
+	l, err := New(tc.VecLength(), 0)
+	if err != nil {
+		t.Fatalf("could not create approximator: %v", err)
+	}
+
+	// Learn f(x) = sin(2πx) by stochastic gradient descent
+	target := func(x float64) float64 { return math.Sin(2 * math.Pi * x) }
+	stepSize := 0.1 / float64(tc.NumTilings()+1)
+	for step := 0; step < 20000; step++ {
+		x := float64(step%97) / 97
+		indices := Indices(tc.EncodeIndices(mat.NewVecDense(1, []float64{x})))
+		l.Update(indices, target(x)-l.Predict(indices), stepSize)
+	}
+
+	for _, x := range []float64{0.1, 0.25, 0.5, 0.8} {
+		indices := Indices(tc.EncodeIndices(mat.NewVecDense(1, []float64{x})))
+		if got := l.Predict(indices); math.Abs(got-target(x)) > 0.2 {
+			t.Errorf("predict(%v): have(%v) want(%v)", x, got, target(x))
+		}
+	}
+}
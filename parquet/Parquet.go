@@ -0,0 +1,119 @@
+// Package parquet streams tile-coded samples into Parquet files, so
+// that large tile-coded offline reinforcement learning datasets can be
+// stored compactly and queried by standard tools. Each row of a file
+// holds the active indices of the tile-coded representation of a
+// sample as a repeated int32 column, along with any passthrough
+// columns, as in the records of package arrow.
+package parquet
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/apache/arrow/go/v9/arrow"
+	"github.com/apache/arrow/go/v9/arrow/memory"
+	"github.com/apache/arrow/go/v9/parquet"
+	"github.com/apache/arrow/go/v9/parquet/compress"
+	"github.com/apache/arrow/go/v9/parquet/pqarrow"
+	"github.com/samuelfneumann/gotile"
+	gotilearrow "github.com/samuelfneumann/gotile/arrow"
+	"gonum.org/v1/gonum/mat"
+)
+
+// DefaultRowGroupSize is the default number of samples in each row
+// group of a Parquet file
+const DefaultRowGroupSize = 64 * 1024
+
+// Options configures a Writer. The zero value writes row groups of
+// DefaultRowGroupSize samples with Snappy compression.
+type Options struct {
+	// RowGroupSize is the maximum number of samples in each row
+	// group. Batches are buffered into the current row group until it
+	// is full, so that row groups are independent of the size of the
+	// batches written.
+	RowGroupSize int64
+
+	// Compression is the compression codec of each column, or Snappy
+	// if unset
+	Compression *compress.Compression
+
+	// Allocator allocates the arrays of each batch, or the default Go
+	// allocator if nil
+	Allocator memory.Allocator
+}
+
+// Writer streams tile-coded samples into a Parquet file. Close must be
+// called once every sample has been written to write the file footer.
+type Writer struct {
+	encoder *gotilearrow.Encoder
+	file    *pqarrow.FileWriter
+}
+
+// NewWriter returns a new Writer which writes the tile-coded samples
+// encoded by coder to w, with the passthrough columns described by
+// fields
+func NewWriter(w io.Writer, coder *gotile.TileCoder, fields []arrow.Field,
+	opts Options) (*Writer, error) {
+	if opts.RowGroupSize < 0 {
+		return nil, fmt.Errorf("newWriter: row group size must be "+
+			"non-negative: %d", opts.RowGroupSize)
+	}
+	if opts.RowGroupSize == 0 {
+		opts.RowGroupSize = DefaultRowGroupSize
+	}
+	codec := compress.Codecs.Snappy
+	if opts.Compression != nil {
+		codec = *opts.Compression
+	}
+
+	encoder, err := gotilearrow.NewEncoder(coder, fields, opts.Allocator)
+	if err != nil {
+		return nil, fmt.Errorf("newWriter: %w", err)
+	}
+
+	props := []parquet.WriterProperty{
+		parquet.WithMaxRowGroupLength(opts.RowGroupSize),
+		parquet.WithCompression(codec),
+	}
+	if opts.Allocator != nil {
+		props = append(props, parquet.WithAllocator(opts.Allocator))
+	}
+	file, err := pqarrow.NewFileWriter(encoder.Schema(), w,
+		parquet.NewWriterProperties(props...),
+		pqarrow.DefaultWriterProps())
+	if err != nil {
+		return nil, fmt.Errorf("newWriter: %v", err)
+	}
+	return &Writer{encoder: encoder, file: file}, nil
+}
+
+// Schema returns the Arrow schema of the samples written by the
+// receiver
+func (w *Writer) Schema() *arrow.Schema {
+	return w.encoder.Schema()
+}
+
+// Write tile codes each vector in the batch b, where each column of b
+// is a vector to encode, and writes a row for each vector, along with
+// the passthrough columns, as in (*arrow.Encoder).Encode
+func (w *Writer) Write(b *mat.Dense, columns ...arrow.Array) error {
+	record, err := w.encoder.Encode(b, columns...)
+	if err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	defer record.Release()
+
+	if err := w.file.WriteBuffered(record); err != nil {
+		return fmt.Errorf("write: %v", err)
+	}
+	return nil
+}
+
+// Close writes any buffered row group and the footer of the file. If
+// the io.Writer given to NewWriter is an io.Closer, it is also closed.
+func (w *Writer) Close() error {
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("close: %v", err)
+	}
+	return nil
+}
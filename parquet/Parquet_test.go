@@ -0,0 +1,108 @@
+package parquet
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/apache/arrow/go/v9/arrow"
+	"github.com/apache/arrow/go/v9/arrow/array"
+	"github.com/apache/arrow/go/v9/arrow/memory"
+	"github.com/apache/arrow/go/v9/parquet/file"
+	"github.com/apache/arrow/go/v9/parquet/pqarrow"
+	"github.com/samuelfneumann/gotile"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestWriter(t *testing.T) {
+	tc, err := gotile.New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{2, 2}, {3, 3}},
+		1,
+		true,
+		2,
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+
+	var buf bytes.Buffer
+	fields := []arrow.Field{{Name: "step", Type: arrow.PrimitiveTypes.Int64}}
+	w, err := NewWriter(&buf, tc, fields, Options{RowGroupSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Write 5 samples in batches of 3 and 2
+	inputs := mat.NewDense(2, 5, []float64{
+		0.1, 0.9, 0.4, 0.6, 0.3,
+		0.2, 0.1, 0.8, 0.5, 0.9,
+	})
+	for _, cols := range [][2]int{{0, 3}, {3, 5}} {
+		steps := array.NewInt64Builder(memory.DefaultAllocator)
+		for j := cols[0]; j < cols[1]; j++ {
+			steps.Append(int64(j))
+		}
+		step := steps.NewArray()
+		steps.Release()
+
+		batch := inputs.Slice(0, 2, cols[0], cols[1]).(*mat.Dense)
+		if err := w.Write(batch, step); err != nil {
+			t.Fatal(err)
+		}
+		step.Release()
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := file.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if r.NumRows() != 5 || r.NumRowGroups() != 3 {
+		t.Errorf("write: have(%d rows, %d row groups) want(5 rows, 3 row "+
+			"groups)", r.NumRows(), r.NumRowGroups())
+	}
+
+	fr, err := pqarrow.NewFileReader(r, pqarrow.ArrowReadProperties{},
+		memory.DefaultAllocator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := fr.ReadTable(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Release()
+
+	want := tc.EncodeIndicesBatch(inputs)
+	tr := array.NewTableReader(table, 5)
+	defer tr.Release()
+	if !tr.Next() {
+		t.Fatal("could not read table")
+	}
+	record := tr.Record()
+	list := record.Column(0).(*array.List)
+	values := list.ListValues().(*array.Int32)
+	offsets := list.Offsets()
+	for j := 0; j < 5; j++ {
+		for i := offsets[j]; i < offsets[j+1]; i++ {
+			if have, want := values.Value(int(i)), int32(want.At(
+				int(i-offsets[j]), j)); have != want {
+				t.Errorf("write: sample %d: have(%d) want(%d)", j, have, want)
+			}
+		}
+		if have := record.Column(1).(*array.Int64).Value(j); have !=
+			int64(j) {
+			t.Errorf("write: sample %d: have step(%d) want(%d)", j, have, j)
+		}
+	}
+
+	if _, err := NewWriter(&buf, tc, nil, Options{RowGroupSize: -1}); err ==
+		nil {
+		t.Error("expected error with negative row group size")
+	}
+}
@@ -0,0 +1,170 @@
+// Package plot renders the tiles of two-dimensional slices of tilings
+// with gonum/plot, so that the bounds and offsets of tilings, and the
+// tiles activated by a vector, can be inspected visually. Plots are
+// saved as PNG, SVG, or any other format supported by gonum/plot with
+// (*plot.Plot).Save.
+package plot
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/samuelfneumann/gotile"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/spatial/r1"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+)
+
+// ActiveAlpha is the opacity with which the active tile of each tiling
+// is filled
+const ActiveAlpha = 0.3
+
+// TileCoder returns a plot of the tiles of each tiling of tc along the
+// input dimensions x and y, as in Tilings
+func TileCoder(tc *gotile.TileCoder, x, y int, v mat.Vector) (*plot.Plot,
+	error) {
+	p, err := Tilings(tc.Tilings(), x, y, v)
+	if err != nil {
+		return nil, fmt.Errorf("tileCoder: %w", err)
+	}
+	return p, nil
+}
+
+// Tilings returns a plot of the tiles of tilings along the input
+// dimensions x and y, drawing the outline of each tile of tiling k in
+// the k-th color of the default palette so that the offsets between
+// tilings are visible. Tilings which do not tile both x and y are
+// skipped. Tiles are drawn in the input space, so that log-scaled and
+// squashed dimensions produce unevenly spaced tiles, and the underflow
+// and overflow tiles of the BoundsExtend policy are clipped to the
+// bounds of the tiling.
+//
+// If v is not nil, the tile of each tiling in which v falls is filled
+// with the color of the tiling, with opacity ActiveAlpha. An error is
+// returned if some tiling cannot index v, if no tiling tiles both x and
+// y, or if some plotted tiling has infinite bounds along x or y.
+func Tilings(tilings []*gotile.Tiling, x, y int, v mat.Vector) (*plot.Plot,
+	error) {
+	if x == y {
+		return nil, fmt.Errorf("tilings: cannot plot dimension %d against "+
+			"itself", x)
+	}
+
+	p := plot.New()
+	p.X.Label.Text = fmt.Sprintf("dimension %d", x)
+	p.Y.Label.Text = fmt.Sprintf("dimension %d", y)
+
+	plotted := 0
+	for k, tiling := range tilings {
+		i, j := position(tiling, x), position(tiling, y)
+		if i < 0 || j < 0 {
+			continue
+		}
+		low, high := tiling.Bounds()
+		for _, d := range []int{i, j} {
+			if math.IsInf(low[d], 0) || math.IsInf(high[d], 0) {
+				return nil, fmt.Errorf("tilings: tiling %d has infinite "+
+					"bounds along dimension %d", k, tiling.Dims()[d])
+			}
+		}
+		clip := [2]r1.Interval{
+			{Min: low[i], Max: high[i]},
+			{Min: low[j], Max: high[j]},
+		}
+		c := plotutil.Color(plotted)
+
+		grid, err := plotter.NewPolygon(tiles(tiling, i, j, clip)...)
+		if err != nil {
+			return nil, fmt.Errorf("tilings: tiling %d: %v", k, err)
+		}
+		grid.Color = nil
+		grid.LineStyle.Color = c
+		p.Add(grid)
+		p.Legend.Add(fmt.Sprintf("tiling %d", k), grid)
+
+		if v != nil {
+			index, err := tiling.TryIndex(v)
+			if err != nil {
+				return nil, fmt.Errorf("tilings: tiling %d: %w", k, err)
+			}
+			bounds, err := tiling.TryTileBounds(index)
+			if err != nil {
+				// The non-finite tile covers no region
+				continue
+			}
+			active, err := plotter.NewPolygon(rectangle(bounds[i],
+				bounds[j], clip))
+			if err != nil {
+				return nil, fmt.Errorf("tilings: tiling %d: %v", k, err)
+			}
+			active.Color = fill(c)
+			active.LineStyle.Color = c
+			p.Add(active)
+		}
+		plotted++
+	}
+
+	if plotted == 0 {
+		return nil, fmt.Errorf("tilings: no tiling tiles both dimensions "+
+			"%d and %d", x, y)
+	}
+	return p, nil
+}
+
+// position returns the position of the input dimension d in the
+// dimensions of tiling, or -1 if tiling does not tile d
+func position(tiling *gotile.Tiling, d int) int {
+	for i, dim := range tiling.Dims() {
+		if dim == d {
+			return i
+		}
+	}
+	return -1
+}
+
+// tiles returns the outline of each distinct tile of tiling projected
+// onto the dimensions of the tiling at positions i and j, clipped to
+// clip
+func tiles(tiling *gotile.Tiling, i, j int,
+	clip [2]r1.Interval) []plotter.XYer {
+	seen := make(map[[4]float64]bool)
+	var outlines []plotter.XYer
+	for index := 0; index < tiling.Tiles(); index++ {
+		bounds, err := tiling.TryTileBounds(index)
+		if err != nil {
+			// The non-finite tile covers no region
+			continue
+		}
+		key := [4]float64{bounds[i].Min, bounds[i].Max, bounds[j].Min,
+			bounds[j].Max}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		outlines = append(outlines, rectangle(bounds[i], bounds[j], clip))
+	}
+	return outlines
+}
+
+// rectangle returns the corners of the rectangle x × y, clipped to
+// clip
+func rectangle(x, y r1.Interval, clip [2]r1.Interval) plotter.XYs {
+	x0, x1 := math.Max(x.Min, clip[0].Min), math.Min(x.Max, clip[0].Max)
+	y0, y1 := math.Max(y.Min, clip[1].Min), math.Min(y.Max, clip[1].Max)
+	return plotter.XYs{{X: x0, Y: y0}, {X: x1, Y: y0}, {X: x1, Y: y1},
+		{X: x0, Y: y1}}
+}
+
+// fill returns c with opacity ActiveAlpha
+func fill(c color.Color) color.Color {
+	r, g, b, _ := c.RGBA()
+	return color.NRGBA{
+		R: uint8(r >> 8),
+		G: uint8(g >> 8),
+		B: uint8(b >> 8),
+		A: uint8(math.Round(ActiveAlpha * 255)),
+	}
+}
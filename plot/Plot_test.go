@@ -0,0 +1,96 @@
+package plot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/samuelfneumann/gotile"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/spatial/r1"
+	"gonum.org/v1/plot/vg"
+)
+
+func TestTileCoder(t *testing.T) {
+	minDims := mat.NewVecDense(3, []float64{0, 0, 0})
+	maxDims := mat.NewVecDense(3, []float64{1, 1, 1})
+	tc, err := gotile.New(minDims, maxDims, [][]int{{4, 4}, {4, 4}, {2}}, 1,
+		true, -1, gotile.WithGroups([][]int{{0, 1}, {1, 0}, {2}}))
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+
+	v := mat.NewVecDense(3, []float64{0.3, 0.6, 0.9})
+	p, err := TileCoder(tc, 0, 1, v)
+	if err != nil {
+		t.Fatalf("tileCoder: %v", err)
+	}
+
+	// Each tile of a tiling over dimensions 0 and 1 is outlined once,
+	// within the bounds of the tiling
+	tiling := tc.Tilings()[1]
+	clip := [2]r1.Interval{{Min: 0, Max: 1}, {Min: 0, Max: 1}}
+	outlines := tiles(tiling, 1, 0, clip)
+	if have, want := len(outlines), tiling.Tiles(); have != want {
+		t.Errorf("tiles: have(%v) want(%v)", have, want)
+	}
+	for _, outline := range outlines {
+		for k := 0; k < outline.Len(); k++ {
+			x, y := outline.XY(k)
+			if x < 0 || x > 1 || y < 0 || y > 1 {
+				t.Errorf("tiles: corner (%v, %v) outside bounds", x, y)
+			}
+		}
+	}
+
+	dir := t.TempDir()
+	for _, name := range []string{"tilings.png", "tilings.svg"} {
+		path := filepath.Join(dir, name)
+		if err := p.Save(4*vg.Inch, 4*vg.Inch, path); err != nil {
+			t.Fatalf("could not save %v: %v", name, err)
+		}
+		if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+			t.Errorf("save(%v): have empty file", name)
+		}
+	}
+
+	if _, err := TileCoder(tc, 0, 0, nil); err == nil {
+		t.Error("expected error plotting a dimension against itself")
+	}
+	if _, err := TileCoder(tc, 0, 2, nil); err == nil {
+		t.Error("expected error with no tiling over both dimensions")
+	}
+}
+
+func TestVisits(t *testing.T) {
+	minDims := mat.NewVecDense(2, []float64{0, 0})
+	maxDims := mat.NewVecDense(2, []float64{1, 1})
+	tc, err := gotile.New(minDims, maxDims, [][]int{{4, 4}, {4, 4}}, 1, true,
+		-1, gotile.WithVisitCounts())
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	for _, x := range []float64{0.1, 0.2, 0.15} {
+		tc.Encode(mat.NewVecDense(2, []float64{x, x}))
+	}
+
+	bounds := r1.Interval{Min: 0, Max: 1}
+	p, err := Visits(tc, minDims, 0, 1, bounds, bounds, 10, 10)
+	if err != nil {
+		t.Fatalf("visits: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "visits.png")
+	if err := p.Save(4*vg.Inch, 4*vg.Inch, path); err != nil {
+		t.Fatalf("could not save heatmap: %v", err)
+	}
+
+	untracked, err := gotile.New(minDims, maxDims, [][]int{{4, 4}}, 1, true,
+		-1)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	if _, err := Visits(untracked, minDims, 0, 1, bounds, bounds, 10,
+		10); err == nil {
+		t.Error("expected error without visit counts")
+	}
+}
@@ -0,0 +1,80 @@
+package plot
+
+import (
+	"fmt"
+
+	"github.com/samuelfneumann/gotile"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/spatial/r1"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/palette"
+	"gonum.org/v1/plot/plotter"
+)
+
+// Colors is the number of colors in the palette of visit heatmaps
+const Colors = 64
+
+// Visits returns a heatmap of the visit frequency of the tiles of tc
+// over a 2D slice of input space, as computed by
+// (*gotile.TileCoder).VisitHeatmap, so that users can see at a glance
+// which parts of the input space have been explored. Unexplored cells
+// are drawn in the coolest color, and cells outside the bounds of a
+// tiling using the BoundsError policy are left blank. An error is
+// returned if tc does not count visits.
+func Visits(tc *gotile.TileCoder, point mat.Vector, x, y int, xBounds,
+	yBounds r1.Interval, rows, cols int) (*plot.Plot, error) {
+	heatmap, err := tc.VisitHeatmap(point, x, y, xBounds, yBounds, rows,
+		cols)
+	if err != nil {
+		return nil, fmt.Errorf("visits: %w", err)
+	}
+
+	grid := &grid{heatmap: heatmap, x: xBounds, y: yBounds}
+	h := plotter.NewHeatMap(grid, palette.Heat(Colors, 1))
+
+	// Unexplored cells take the coolest color, and the color range is
+	// kept non-empty when no cell has been visited
+	h.Min = 0
+	if !(h.Max > 0) {
+		h.Max = 1
+	}
+
+	p := plot.New()
+	p.Title.Text = "Visit frequency"
+	p.X.Label.Text = fmt.Sprintf("dimension %d", x)
+	p.Y.Label.Text = fmt.Sprintf("dimension %d", y)
+	p.Add(h)
+	p.X.Min, p.X.Max = xBounds.Min, xBounds.Max
+	p.Y.Min, p.Y.Max = yBounds.Min, yBounds.Max
+	return p, nil
+}
+
+// grid is a plotter.GridXYZ over the cells of a heatmap, whose rows are
+// ordered by increasing y and columns by increasing x
+type grid struct {
+	heatmap *mat.Dense
+	x, y    r1.Interval
+}
+
+// Dims returns the number of columns and rows of the grid
+func (g *grid) Dims() (c, r int) {
+	r, c = g.heatmap.Dims()
+	return c, r
+}
+
+// Z returns the value of the cell in column c and row r
+func (g *grid) Z(c, r int) float64 {
+	return g.heatmap.At(r, c)
+}
+
+// X returns the center of the cells in column c
+func (g *grid) X(c int) float64 {
+	_, cols := g.heatmap.Dims()
+	return g.x.Min + (float64(c)+0.5)*(g.x.Max-g.x.Min)/float64(cols)
+}
+
+// Y returns the center of the cells in row r
+func (g *grid) Y(r int) float64 {
+	rows, _ := g.heatmap.Dims()
+	return g.y.Min + (float64(r)+0.5)*(g.y.Max-g.y.Min)/float64(rows)
+}
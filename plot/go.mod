@@ -0,0 +1,28 @@
+module github.com/samuelfneumann/gotile/plot
+
+go 1.17
+
+replace github.com/samuelfneumann/gotile => ../
+
+require (
+	github.com/samuelfneumann/gotile v0.0.0-00010101000000-000000000000
+	gonum.org/v1/gonum v0.9.3
+	gonum.org/v1/plot v0.10.1
+)
+
+require (
+	git.sr.ht/~sbinet/gg v0.3.1 // indirect
+	github.com/BurntSushi/toml v1.2.1 // indirect
+	github.com/ajstarks/svgo v0.0.0-20211024235047-1546f124cd8b // indirect
+	github.com/go-fonts/liberation v0.2.0 // indirect
+	github.com/go-latex/latex v0.0.0-20210823091927-c0d11ff05a81 // indirect
+	github.com/go-pdf/fpdf v0.5.0 // indirect
+	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
+	github.com/kr/text v0.1.0 // indirect
+	github.com/samuelfneumann/goutils v0.0.0-20211111214126-5491a5616c35 // indirect
+	golang.org/x/exp v0.0.0-20211111183329-cb5df436b1a8 // indirect
+	golang.org/x/image v0.0.0-20211028202545-6944b10bf410 // indirect
+	golang.org/x/text v0.3.7 // indirect
+	golang.org/x/tools v0.1.8-0.20211029000441-d6a9af8af023 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,172 @@
+// Package presets provides named, ready-made tile coder configurations
+// for classic reinforcement learning environments, so that experiments
+// are reproducible without copying bounds and bin counts between
+// codebases
+package presets
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/samuelfneumann/gotile"
+)
+
+// registry holds each registered preset by name
+var (
+	mu       sync.RWMutex
+	registry = map[string]gotile.Config{
+		// Mountain Car (Sutton and Barto, 2018): 8 tilings of 8 × 8
+		// tiles over position and velocity
+		"mountain-car": {
+			MinDims:       []float64{-1.2, -0.07},
+			MaxDims:       []float64{0.6, 0.07},
+			Bins:          repeat([]int{8, 8}, 8),
+			Seed:          1,
+			IncludeBias:   true,
+			OffsetDiv:     gotile.OffsetDiv,
+			DistinctSeeds: true,
+		},
+
+		// Acrobot: 12 tilings of 6 tiles along each joint angle and
+		// angular velocity, with the velocities bounded by the limits
+		// of the environment
+		"acrobot": {
+			MinDims:       []float64{-math.Pi, -math.Pi, -4 * math.Pi, -9 * math.Pi},
+			MaxDims:       []float64{math.Pi, math.Pi, 4 * math.Pi, 9 * math.Pi},
+			Bins:          repeat([]int{6, 6, 6, 6}, 12),
+			Seed:          1,
+			IncludeBias:   true,
+			OffsetDiv:     gotile.OffsetDiv,
+			DistinctSeeds: true,
+		},
+
+		// Cart-Pole: 8 tilings of 6 tiles along the cart position and
+		// velocity and the pole angle and angular velocity, with the
+		// position and angle bounded by the termination conditions of
+		// the environment
+		"cart-pole": {
+			MinDims:       []float64{-2.4, -3, -0.2095, -3.5},
+			MaxDims:       []float64{2.4, 3, 0.2095, 3.5},
+			Bins:          repeat([]int{6, 6, 6, 6}, 8),
+			Seed:          1,
+			IncludeBias:   true,
+			OffsetDiv:     gotile.OffsetDiv,
+			DistinctSeeds: true,
+		},
+
+		// Puddle World (Sutton, 1996): 5 tilings of 5 × 5 tiles over
+		// the unit square
+		"puddle-world": {
+			MinDims:       []float64{0, 0},
+			MaxDims:       []float64{1, 1},
+			Bins:          repeat([]int{5, 5}, 5),
+			Seed:          1,
+			IncludeBias:   true,
+			OffsetDiv:     gotile.OffsetDiv,
+			DistinctSeeds: true,
+		},
+	}
+)
+
+// New returns a new TileCoder constructed from the preset called name.
+// Any opts given are applied after the options of the preset.
+func New(name string, opts ...gotile.Option) (*gotile.TileCoder, error) {
+	c, err := Config(name)
+	if err != nil {
+		return nil, fmt.Errorf("new: %v", err)
+	}
+	tc, err := c.New(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("new: preset %q: %v", name, err)
+	}
+	return tc, nil
+}
+
+// Config returns the configuration of the preset called name, which
+// may be modified without affecting the preset
+func Config(name string) (gotile.Config, error) {
+	mu.RLock()
+	c, ok := registry[name]
+	mu.RUnlock()
+	if !ok {
+		return gotile.Config{}, fmt.Errorf("config: unknown preset %q", name)
+	}
+	return copyConfig(c), nil
+}
+
+// Register adds the configuration c as a preset called name. An error
+// is returned if a preset called name already exists.
+func Register(name string, c gotile.Config) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := registry[name]; ok {
+		return fmt.Errorf("register: preset %q already exists", name)
+	}
+	registry[name] = copyConfig(c)
+	return nil
+}
+
+// Names returns the names of all presets in sorted order
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// repeat returns n copies of the bins of a single tiling
+func repeat(bins []int, n int) [][]int {
+	tilings := make([][]int, n)
+	for i := range tilings {
+		tilings[i] = append([]int(nil), bins...)
+	}
+	return tilings
+}
+
+// copyConfig returns a copy of c which shares no slices with c
+func copyConfig(c gotile.Config) gotile.Config {
+	c.MinDims = copyFloats(c.MinDims)
+	c.MaxDims = copyFloats(c.MaxDims)
+	c.Bins = copyInts2(c.Bins)
+	if c.Scales != nil {
+		c.Scales = append([]gotile.Scale(nil), c.Scales...)
+	}
+	if c.Squashes != nil {
+		c.Squashes = append([]gotile.Squash(nil), c.Squashes...)
+	}
+	if c.Edges != nil {
+		edges := make([][]float64, len(c.Edges))
+		for i := range edges {
+			edges[i] = copyFloats(c.Edges[i])
+		}
+		c.Edges = edges
+	}
+	if c.Categorical != nil {
+		c.Categorical = append([]int(nil), c.Categorical...)
+	}
+	c.Groups = copyInts2(c.Groups)
+	return c
+}
+
+// copyFloats returns a copy of x
+func copyFloats(x []float64) []float64 {
+	return append([]float64(nil), x...)
+}
+
+// copyInts2 returns a deep copy of x, or nil if x is nil
+func copyInts2(x [][]int) [][]int {
+	if x == nil {
+		return nil
+	}
+	y := make([][]int, len(x))
+	for i := range y {
+		y[i] = append([]int(nil), x[i]...)
+	}
+	return y
+}
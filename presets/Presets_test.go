@@ -0,0 +1,62 @@
+package presets
+
+import (
+	"testing"
+
+	"github.com/samuelfneumann/gotile"
+)
+
+func TestNew(t *testing.T) {
+	for _, name := range Names() {
+		tc, err := New(name)
+		if err != nil {
+			t.Errorf("new(%q): %v", name, err)
+			continue
+		}
+
+		// Presets are deterministic
+		other, _ := New(name)
+		if tc.String() != other.String() {
+			t.Errorf("new(%q): presets differ between calls", name)
+		}
+	}
+
+	tc, err := New("mountain-car")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := tc.VecLength(); n != 8*8*8+1 {
+		t.Errorf("mountain-car vecLength: have(%v) want(%v)", n, 8*8*8+1)
+	}
+
+	if _, err := New("unknown"); err == nil {
+		t.Error("expected error with unknown preset")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	c, err := Config("puddle-world")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Modifying a returned Config does not modify the preset
+	c.Bins[0][0] = 100
+	if again, _ := Config("puddle-world"); again.Bins[0][0] != 5 {
+		t.Errorf("config: preset modified through returned config")
+	}
+
+	if err := Register("puddle-world", c); err == nil {
+		t.Error("expected error registering an existing preset")
+	}
+	if err := Register("test-puddle-world", c); err != nil {
+		t.Fatal(err)
+	}
+	tc, err := New("test-puddle-world", gotile.WithVisitCounts())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tc.VisitCounts() == nil {
+		t.Error("new: options not applied to registered preset")
+	}
+}
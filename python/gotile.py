@@ -0,0 +1,188 @@
+"""Thin ctypes wrapper around the gotile C shared library.
+
+Build the library from the root of the repository with:
+
+    go build -buildmode=c-shared -o libgotile.so ./cmd/libgotile
+
+and point the GOTILE_LIB environment variable at it, or place it next
+to this file. Features computed through this wrapper are exactly those
+computed by the Go package:
+
+    import gotile
+
+    coder = gotile.TileCoder({
+        "min_dims": [-1.2, -0.07],
+        "max_dims": [0.6, 0.07],
+        "bins": [[8, 8]] * 8,
+        "seed": 1,
+        "include_bias": True,
+        "offset_div": 1.5,
+    })
+    indices = coder.encode_indices([-0.5, 0.0])
+
+Results are NumPy arrays if NumPy is installed, and lists otherwise.
+"""
+
+import ctypes
+import json
+import os
+
+try:
+    import numpy
+except ImportError:
+    numpy = None
+
+__all__ = ["GotileError", "TileCoder"]
+
+
+class GotileError(Exception):
+    """An error returned by the gotile library."""
+
+
+def _load():
+    path = os.environ.get("GOTILE_LIB")
+    if path is None:
+        path = os.path.join(os.path.dirname(os.path.abspath(__file__)),
+                            "libgotile.so")
+    lib = ctypes.CDLL(path)
+
+    handle = ctypes.c_size_t
+    doubles = ctypes.POINTER(ctypes.c_double)
+    int64s = ctypes.POINTER(ctypes.c_int64)
+    signatures = {
+        "gotile_error": ([handle, ctypes.c_char_p, ctypes.c_int],
+                         ctypes.c_int),
+        "gotile_new": ([ctypes.c_char_p, ctypes.POINTER(handle)],
+                       ctypes.c_int),
+        "gotile_load": ([ctypes.c_char_p, ctypes.POINTER(handle)],
+                        ctypes.c_int),
+        "gotile_free": ([handle], None),
+        "gotile_vec_length": ([handle], ctypes.c_int),
+        "gotile_num_indices": ([handle], ctypes.c_int),
+        "gotile_encode": ([handle, doubles, ctypes.c_int, doubles],
+                          ctypes.c_int),
+        "gotile_encode_indices": ([handle, doubles, ctypes.c_int, int64s],
+                                  ctypes.c_int),
+        "gotile_encode_batch": ([handle, doubles, ctypes.c_int,
+                                 ctypes.c_int, doubles], ctypes.c_int),
+        "gotile_encode_indices_batch": ([handle, doubles, ctypes.c_int,
+                                         ctypes.c_int, int64s],
+                                        ctypes.c_int),
+    }
+    for name, (argtypes, restype) in signatures.items():
+        f = getattr(lib, name)
+        f.argtypes = argtypes
+        f.restype = restype
+    return lib
+
+
+_lib = _load()
+
+
+def _check(status, handle=0):
+    if status < 0:
+        buf = ctypes.create_string_buffer(1024)
+        _lib.gotile_error(handle, buf, len(buf))
+        raise GotileError(buf.value.decode())
+    return status
+
+
+def _doubles(values):
+    values = [float(x) for x in values]
+    return (ctypes.c_double * len(values))(*values), len(values)
+
+
+def _batch(batch):
+    rows = [list(v) for v in batch]
+    dims = len(rows[0]) if rows else 0
+    if any(len(v) != dims for v in rows):
+        raise ValueError("every vector of a batch must have the same length")
+    b, _ = _doubles(x for v in rows for x in v)
+    return rows, b, dims
+
+
+def _result(array, shape=None):
+    if numpy is not None:
+        result = numpy.ctypeslib.as_array(array).copy()
+        return result if shape is None else result.reshape(shape)
+    result = list(array)
+    if shape is None:
+        return result
+    rows, cols = shape
+    return [result[i * cols:(i + 1) * cols] for i in range(rows)]
+
+
+class TileCoder:
+    """A tile coder constructed from a gotile Config.
+
+    config is a dict with the fields of a gotile Config as in its JSON
+    encoding, such as min_dims, max_dims, bins, seed, include_bias, and
+    offset_div.
+    """
+
+    def __init__(self, config=None, _handle=None):
+        if _handle is None:
+            handle = ctypes.c_size_t()
+            _check(_lib.gotile_new(json.dumps(config).encode(),
+                                   ctypes.byref(handle)))
+            _handle = handle.value
+        self._handle = _handle
+        self.vec_length = _check(_lib.gotile_vec_length(self._handle),
+                                 self._handle)
+        self.num_indices = _check(_lib.gotile_num_indices(self._handle),
+                                  self._handle)
+
+    @classmethod
+    def load(cls, path):
+        """Loads a tile coder saved by gotile's SaveFile."""
+        handle = ctypes.c_size_t()
+        _check(_lib.gotile_load(os.fspath(path).encode(),
+                                ctypes.byref(handle)))
+        return cls(_handle=handle.value)
+
+    def close(self):
+        """Frees the tile coder."""
+        if getattr(self, "_handle", 0):
+            _lib.gotile_free(self._handle)
+            self._handle = 0
+
+    def __del__(self):
+        self.close()
+
+    def __enter__(self):
+        return self
+
+    def __exit__(self, *exc):
+        self.close()
+
+    def encode(self, v):
+        """Returns the dense feature vector of v."""
+        v, dims = _doubles(v)
+        out = (ctypes.c_double * self.vec_length)()
+        _check(_lib.gotile_encode(self._handle, v, dims, out), self._handle)
+        return _result(out)
+
+    def encode_indices(self, v):
+        """Returns the indices of the non-zero features of v."""
+        v, dims = _doubles(v)
+        out = (ctypes.c_int64 * self.num_indices)()
+        _check(_lib.gotile_encode_indices(self._handle, v, dims, out),
+               self._handle)
+        return _result(out)
+
+    def encode_batch(self, batch):
+        """Returns the dense feature vector of each row of batch."""
+        rows, b, dims = _batch(batch)
+        out = (ctypes.c_double * (len(rows) * self.vec_length))()
+        _check(_lib.gotile_encode_batch(self._handle, b, len(rows), dims,
+                                        out), self._handle)
+        return _result(out, (len(rows), self.vec_length))
+
+    def encode_indices_batch(self, batch):
+        """Returns the indices of the non-zero features of each row of
+        batch."""
+        rows, b, dims = _batch(batch)
+        out = (ctypes.c_int64 * (len(rows) * self.num_indices))()
+        _check(_lib.gotile_encode_indices_batch(self._handle, b, len(rows),
+                                                dims, out), self._handle)
+        return _result(out, (len(rows), self.num_indices))
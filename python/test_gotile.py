@@ -0,0 +1,63 @@
+"""Tests of the gotile ctypes wrapper.
+
+Build the library and run the tests from the root of the repository
+with:
+
+    go build -buildmode=c-shared -o python/libgotile.so ./cmd/libgotile
+    python3 -m unittest discover python
+
+or run go test ./cmd/libgotile, which does both.
+"""
+
+import unittest
+
+import gotile
+
+CONFIG = {
+    "min_dims": [0, 0],
+    "max_dims": [1, 1],
+    "bins": [[2, 2]],
+    "seed": 1,
+    "include_bias": True,
+    "offset_div": 1e300,
+    "bounds_policy": "error",
+}
+
+
+def _lists(result):
+    return result.tolist() if hasattr(result, "tolist") else result
+
+
+class TileCoderTest(unittest.TestCase):
+    def setUp(self):
+        self.coder = gotile.TileCoder(CONFIG)
+        self.addCleanup(self.coder.close)
+
+    def test_lengths(self):
+        self.assertEqual(self.coder.vec_length, 5)
+        self.assertEqual(self.coder.num_indices, 2)
+
+    def test_encode(self):
+        self.assertEqual(_lists(self.coder.encode_indices([0.2, 0.7])),
+                         [2, 0])
+        self.assertEqual(_lists(self.coder.encode([0.2, 0.7])),
+                         [1, 0, 1, 0, 0])
+
+    def test_encode_batch(self):
+        batch = [[0.2, 0.7], [0.9, 0.1]]
+        self.assertEqual(_lists(self.coder.encode_indices_batch(batch)),
+                         [[2, 0], [3, 0]])
+        self.assertEqual(_lists(self.coder.encode_batch(batch)),
+                         [[1, 0, 1, 0, 0], [1, 0, 0, 1, 0]])
+
+    def test_errors(self):
+        with self.assertRaisesRegex(gotile.GotileError, "out of bounds"):
+            self.coder.encode_indices([2, 0.7])
+        with self.assertRaises(gotile.GotileError):
+            self.coder.encode_indices([0.2])
+        with self.assertRaises(gotile.GotileError):
+            gotile.TileCoder(dict(CONFIG, max_dims=[1]))
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,193 @@
+// Package rpcencoder provides a lightweight encoder over persistent
+// net/rpc connections for real-time control loops. A client registers
+// a tile coder Config once per connection, then streams observations
+// over the connection and receives the indices of their tile-coded
+// representations, without the per-request configuration and
+// handshake overhead of package server.
+//
+// Serve connections with:
+//
+//	listener, err := net.Listen("tcp", "localhost:9091")
+//	...
+//	go rpcencoder.Serve(listener)
+//
+// and encode observations with:
+//
+//	client, err := rpcencoder.Dial("tcp", "localhost:9091", config)
+//	...
+//	indices, err := client.EncodeIndices(observation)
+package rpcencoder
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/rpc"
+	"sync"
+
+	"github.com/samuelfneumann/gotile"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Encoder is the net/rpc service served on each connection. Each
+// connection has its own Encoder, which holds the TileCoder registered
+// by the client.
+type Encoder struct {
+	mu sync.Mutex
+	tc *gotile.TileCoder
+}
+
+// Register constructs the TileCoder used by the connection from c,
+// replacing any previously registered TileCoder, and sets length to
+// the number of features in its tile-coded representation
+func (e *Encoder) Register(c gotile.Config, length *int) error {
+	tc, err := c.New()
+	if err != nil {
+		return fmt.Errorf("register: %v", err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.tc = tc
+	*length = tc.VecLength()
+	return nil
+}
+
+// EncodeIndices sets indices to the indices of the non-zero features
+// of the tile-coded representation of v, ordered as in EncodeIndices
+func (e *Encoder) EncodeIndices(v []float64, indices *[]int) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.tc == nil {
+		return fmt.Errorf("encodeIndices: no coder registered")
+	}
+
+	encoded, err := e.encode(v)
+	if err != nil {
+		return fmt.Errorf("encodeIndices: %v", err)
+	}
+	*indices = encoded
+	return nil
+}
+
+// EncodeIndicesBatch sets indices to the indices of the non-zero
+// features of the tile-coded representation of each vector in vs, in
+// order
+func (e *Encoder) EncodeIndicesBatch(vs [][]float64, indices *[][]int) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.tc == nil {
+		return fmt.Errorf("encodeIndicesBatch: no coder registered")
+	}
+
+	batch := make([][]int, len(vs))
+	for i, v := range vs {
+		var err error
+		if batch[i], err = e.encode(v); err != nil {
+			return fmt.Errorf("encodeIndicesBatch: vector %d: %v", i, err)
+		}
+	}
+	*indices = batch
+	return nil
+}
+
+// encode returns the indices of the non-zero features of v
+func (e *Encoder) encode(v []float64) ([]int, error) {
+	if len(v) == 0 {
+		return nil, fmt.Errorf("cannot encode an empty vector")
+	}
+	encoded, err := e.tc.TryEncodeIndices(mat.NewVecDense(len(v), v))
+	if err != nil {
+		return nil, err
+	}
+
+	indices := make([]int, len(encoded))
+	for i, index := range encoded {
+		indices[i] = int(index)
+	}
+	return indices, nil
+}
+
+// ServeConn serves a single connection with its own Encoder, blocking
+// until the client hangs up
+func ServeConn(conn io.ReadWriteCloser) {
+	s := rpc.NewServer()
+	if err := s.Register(&Encoder{}); err != nil {
+		panic(err) // Encoder is always a valid service
+	}
+	s.ServeConn(conn)
+}
+
+// Serve accepts connections on l, serving each with its own Encoder in
+// a new goroutine. Serve blocks until l is closed, and returns the
+// error which stopped it accepting connections.
+func Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go ServeConn(conn)
+	}
+}
+
+// Client encodes observations over a persistent connection with a
+// registered Config. A Client is safe for concurrent use.
+type Client struct {
+	client *rpc.Client
+	length int
+}
+
+// Dial connects to the Encoder served at address on the named network,
+// and registers c with it
+func Dial(network, address string, c gotile.Config) (*Client, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, fmt.Errorf("dial: %v", err)
+	}
+	client, err := NewClient(conn, c)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("dial: %v", err)
+	}
+	return client, nil
+}
+
+// NewClient returns a new Client using the connection conn, and
+// registers c with the Encoder served on it
+func NewClient(conn io.ReadWriteCloser, c gotile.Config) (*Client, error) {
+	client := &Client{client: rpc.NewClient(conn)}
+	if err := client.client.Call("Encoder.Register", c,
+		&client.length); err != nil {
+		client.client.Close()
+		return nil, fmt.Errorf("newClient: %v", err)
+	}
+	return client, nil
+}
+
+// VecLength returns the number of features in the tile-coded
+// representation of the registered Config
+func (c *Client) VecLength() int {
+	return c.length
+}
+
+// EncodeIndices returns the indices of the non-zero features of the
+// tile-coded representation of v, ordered as in EncodeIndices
+func (c *Client) EncodeIndices(v []float64) ([]int, error) {
+	var indices []int
+	err := c.client.Call("Encoder.EncodeIndices", v, &indices)
+	return indices, err
+}
+
+// EncodeIndicesBatch returns the indices of the non-zero features of
+// the tile-coded representation of each vector in vs, in order
+func (c *Client) EncodeIndicesBatch(vs [][]float64) ([][]int, error) {
+	var indices [][]int
+	err := c.client.Call("Encoder.EncodeIndicesBatch", vs, &indices)
+	return indices, err
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	return c.client.Close()
+}
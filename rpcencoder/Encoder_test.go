@@ -0,0 +1,80 @@
+package rpcencoder
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/samuelfneumann/gotile"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestClient(t *testing.T) {
+	config := gotile.Config{
+		MinDims:      []float64{0, 0},
+		MaxDims:      []float64{1, 1},
+		Bins:         [][]int{{2, 2}, {3, 3}},
+		Seed:         1,
+		IncludeBias:  true,
+		OffsetDiv:    2,
+		BoundsPolicy: gotile.BoundsError,
+	}
+	tc, err := config.New()
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go Serve(listener)
+
+	client, err := Dial("tcp", listener.Addr().String(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if client.VecLength() != tc.VecLength() {
+		t.Errorf("vecLength: have(%d) want(%d)", client.VecLength(),
+			tc.VecLength())
+	}
+
+	vectors := [][]float64{{0.2, 0.7}, {0.9, 0.1}, {0.5, 0.5}}
+	want := make([][]int, len(vectors))
+	for i, v := range vectors {
+		for _, index := range tc.EncodeIndices(mat.NewVecDense(2, v)) {
+			want[i] = append(want[i], int(index))
+		}
+
+		indices, err := client.EncodeIndices(v)
+		if err != nil || !reflect.DeepEqual(indices, want[i]) {
+			t.Errorf("encodeIndices(%v): have(%v, %v) want(%v)", v, indices,
+				err, want[i])
+		}
+	}
+
+	batch, err := client.EncodeIndicesBatch(vectors)
+	if err != nil || !reflect.DeepEqual(batch, want) {
+		t.Errorf("encodeIndicesBatch: have(%v, %v) want(%v)", batch, err,
+			want)
+	}
+
+	// Errors are returned without closing the connection
+	for _, v := range [][]float64{nil, {0.5}, {2, 0}} {
+		if _, err := client.EncodeIndices(v); err == nil {
+			t.Errorf("encodeIndices(%v): expected error", v)
+		}
+	}
+	if _, err := client.EncodeIndices(vectors[0]); err != nil {
+		t.Errorf("encodeIndices(%v): %v", vectors[0], err)
+	}
+
+	// Each connection registers its own coder
+	server, conn := net.Pipe()
+	go ServeConn(server)
+	if _, err := NewClient(conn, gotile.Config{}); err == nil {
+		t.Error("expected error registering an invalid config")
+	}
+}
@@ -0,0 +1,318 @@
+// Package server serves the feature mapping of a gotile Coder over
+// HTTP and JSON, so that programs which are not written in Go can use
+// exactly the same features as Go programs without reimplementing
+// them.
+//
+// A Server handles two endpoints. GET /info describes the coder:
+//
+//	{"length": 513, "coder": "..."}
+//
+// POST /encode encodes a single vector or a batch of vectors:
+//
+//	{"vector": [0.1, 0.2], "format": "indices"}
+//	{"vectors": [[0.1, 0.2], [0.3, 0.4]], "format": "sparse"}
+//
+// and responds with a single encoding or an encoding for each vector
+// of the batch, in order:
+//
+//	{"encoding": {"indices": [0, 17, 81]}}
+//	{"encodings": [{"indices": [...], "values": [...]}, ...]}
+//
+// The format is one of "indices" (the default), which returns the
+// indices of the non-zero features ordered as in EncodeIndices,
+// "sparse", which returns the indices of the non-zero features in
+// increasing order along with their values, or "dense", which returns
+// every feature. Errors are reported with a non-2xx status code and a
+// body of the form {"error": "..."}.
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/samuelfneumann/gotile"
+	"gonum.org/v1/gonum/mat"
+)
+
+// MaxBodyBytes is the maximum size of the body of a request to encode
+// vectors
+const MaxBodyBytes = 32 << 20
+
+// Encoding formats of a Request
+const (
+	Indices = "indices"
+	Sparse  = "sparse"
+	Dense   = "dense"
+)
+
+// Request is the body of a request to the /encode endpoint. Exactly one
+// of Vector and Vectors should be set.
+type Request struct {
+	Vector  []float64   `json:"vector,omitempty"`
+	Vectors [][]float64 `json:"vectors,omitempty"`
+	Format  string      `json:"format,omitempty"`
+}
+
+// Encoding is the encoding of a single vector. Indices holds the
+// indices of the non-zero features for the "indices" and "sparse"
+// formats, Values holds their values for the "sparse" format, and
+// Features holds every feature for the "dense" format.
+type Encoding struct {
+	Indices  []int     `json:"indices,omitempty"`
+	Values   []float64 `json:"values,omitempty"`
+	Features []float64 `json:"features,omitempty"`
+}
+
+// Response is the body of a response from the /encode endpoint.
+// Encoding is set when the Request set Vector, and Encodings is set
+// when the Request set Vectors.
+type Response struct {
+	Encoding  *Encoding  `json:"encoding,omitempty"`
+	Encodings []Encoding `json:"encodings,omitempty"`
+}
+
+// Info is the body of a response from the /info endpoint
+type Info struct {
+	Length int    `json:"length"`
+	Coder  string `json:"coder"`
+}
+
+// Server is an http.Handler serving the feature mapping of a Coder.
+// Requests are encoded concurrently if the Coder is a TileCoder, which
+// may encode concurrently even when it adapts its bounds. Since other
+// Coders may update their state as they encode, a Server encodes one
+// request at a time for them. Batches are encoded with the batch
+// methods of the Coder.
+type Server struct {
+	mu     sync.Mutex
+	serial bool // Whether requests are encoded one at a time
+	coder  gotile.TryCoder
+	mux    *http.ServeMux
+}
+
+// New returns a new Server serving the feature mapping of coder
+func New(coder gotile.TryCoder) *Server {
+	_, concurrent := coder.(*gotile.TileCoder)
+	s := &Server{coder: coder, serial: !concurrent, mux: http.NewServeMux()}
+	s.mux.HandleFunc("/info", s.info)
+	s.mux.HandleFunc("/encode", s.encode)
+	return s
+}
+
+// NewFromConfigFile returns a new Server serving the feature mapping
+// of the TileCoder constructed from the Config in the file at path.
+// See gotile.LoadConfigFile for the supported formats.
+func NewFromConfigFile(path string) (*Server, error) {
+	tc, err := gotile.NewFromConfigFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("newFromConfigFile: %v", err)
+	}
+	return New(tc), nil
+}
+
+// ServeHTTP implements the http.Handler interface
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// info handles requests to the /info endpoint
+func (s *Server) info(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s "+
+			"not allowed", r.Method))
+		return
+	}
+	writeJSON(w, http.StatusOK, s.Info())
+}
+
+// Info returns a description of the receiver's Coder
+func (s *Server) Info() Info {
+	return Info{
+		Length: s.coder.VecLength(),
+		Coder:  fmt.Sprintf("%v", s.coder),
+	}
+}
+
+// encode handles requests to the /encode endpoint
+func (s *Server) encode(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s "+
+			"not allowed", r.Method))
+		return
+	}
+
+	var req Request
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := s.Encode(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Encode returns the Response to req, or an error if req is malformed
+// or some vector cannot be encoded
+func (s *Server) Encode(req Request) (Response, error) {
+	if (req.Vector == nil) == (req.Vectors == nil) {
+		return Response{}, fmt.Errorf("encode: exactly one of vector and " +
+			"vectors must be set")
+	}
+	switch req.Format {
+	case "":
+		req.Format = Indices
+	case Indices, Sparse, Dense:
+	default:
+		return Response{}, fmt.Errorf("encode: unknown format %q", req.Format)
+	}
+
+	if s.serial {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+
+	if req.Vector != nil {
+		e, err := s.encodeVector(req.Vector, req.Format)
+		if err != nil {
+			return Response{}, fmt.Errorf("encode: %v", err)
+		}
+		return Response{Encoding: &e}, nil
+	}
+
+	encodings, err := s.encodeBatch(req.Vectors, req.Format)
+	if err != nil {
+		return Response{}, fmt.Errorf("encode: %v", err)
+	}
+	return Response{Encodings: encodings}, nil
+}
+
+// indicesBatchCoder is implemented by Coders, such as TileCoders, which
+// can encode the indices of the non-zero features of a whole batch
+type indicesBatchCoder interface {
+	TryEncodeIndicesBatch(b *mat.Dense) (*mat.Dense, error)
+}
+
+// encodeBatch returns the encoding of each vector in vectors in the
+// given format, encoding the vectors together as a single batch
+func (s *Server) encodeBatch(vectors [][]float64, format string) (
+	[]Encoding, error) {
+	if len(vectors) == 0 {
+		return []Encoding{}, nil
+	}
+	dims := len(vectors[0])
+	for i, v := range vectors {
+		if len(v) == 0 {
+			return nil, fmt.Errorf("vector %d: cannot encode an empty "+
+				"vector", i)
+		}
+		if len(v) != dims {
+			return nil, fmt.Errorf("vector %d has %d dimensions, but "+
+				"vector 0 has %d", i, len(v), dims)
+		}
+	}
+	b := mat.NewDense(dims, len(vectors), nil)
+	for j, v := range vectors {
+		b.SetCol(j, v)
+	}
+
+	encodings := make([]Encoding, len(vectors))
+	if format == Indices {
+		c, ok := s.coder.(indicesBatchCoder)
+		if !ok {
+			for j, v := range vectors {
+				var err error
+				if encodings[j], err = s.encodeVector(v, format); err != nil {
+					return nil, fmt.Errorf("vector %d: %v", j, err)
+				}
+			}
+			return encodings, nil
+		}
+
+		indices, err := c.TryEncodeIndicesBatch(b)
+		if err != nil {
+			return nil, err
+		}
+		rows, _ := indices.Dims()
+		for j := range encodings {
+			encodings[j].Indices = make([]int, rows)
+			for i := range encodings[j].Indices {
+				encodings[j].Indices[i] = int(indices.At(i, j))
+			}
+		}
+		return encodings, nil
+	}
+
+	features, err := s.coder.TryEncodeBatch(b)
+	if err != nil {
+		return nil, err
+	}
+	for j := range encodings {
+		encodings[j] = encoding(features.ColView(j), format)
+	}
+	return encodings, nil
+}
+
+// encodeVector returns the encoding of v in the given format
+func (s *Server) encodeVector(v []float64, format string) (Encoding, error) {
+	if len(v) == 0 {
+		return Encoding{}, fmt.Errorf("cannot encode an empty vector")
+	}
+	vec := mat.NewVecDense(len(v), v)
+
+	if format == Indices {
+		indices, err := s.coder.TryEncodeIndices(vec)
+		if err != nil {
+			return Encoding{}, err
+		}
+		e := Encoding{Indices: make([]int, len(indices))}
+		for i, index := range indices {
+			e.Indices[i] = int(index)
+		}
+		return e, nil
+	}
+
+	features, err := s.coder.TryEncode(vec)
+	if err != nil {
+		return Encoding{}, err
+	}
+	return encoding(features, format), nil
+}
+
+// encoding returns the Encoding of the feature vector features in the
+// "sparse" or "dense" format
+func encoding(features mat.Vector, format string) Encoding {
+	if format == Dense {
+		return Encoding{Features: mat.Col(nil, 0, features)}
+	}
+
+	var e Encoding
+	for i := 0; i < features.Len(); i++ {
+		if value := features.AtVec(i); value != 0 {
+			e.Indices = append(e.Indices, i)
+			e.Values = append(e.Values, value)
+		}
+	}
+	return e
+}
+
+// writeJSON writes v to w as JSON with the given status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes err to w as JSON with the given status code
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, struct {
+		Error string `json:"error"`
+	}{err.Error()})
+}
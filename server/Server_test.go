@@ -0,0 +1,204 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/samuelfneumann/gotile"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestServer(t *testing.T) {
+	tc, err := gotile.New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{2, 2}, {2, 2}},
+		1,
+		true,
+		1e300,
+		gotile.WithBoundsPolicy(gotile.BoundsError),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	s := httptest.NewServer(New(tc))
+	defer s.Close()
+
+	post := func(body string) (int, Response) {
+		r, err := http.Post(s.URL+"/encode", "application/json",
+			strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Body.Close()
+
+		var resp Response
+		if r.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return r.StatusCode, resp
+	}
+
+	// Served indices match those of the tile coder
+	v := mat.NewVecDense(2, []float64{0.2, 0.7})
+	want := tc.EncodeIndices(v)
+	status, resp := post(`{"vector": [0.2, 0.7]}`)
+	if status != http.StatusOK || resp.Encoding == nil {
+		t.Fatalf("encode: have status(%d) want(%d)", status, http.StatusOK)
+	}
+	if len(resp.Encoding.Indices) != len(want) {
+		t.Fatalf("encode: have(%v) want(%v)", resp.Encoding.Indices, want)
+	}
+	for i := range want {
+		if resp.Encoding.Indices[i] != int(want[i]) {
+			t.Errorf("encode: have(%v) want(%v)", resp.Encoding.Indices, want)
+			break
+		}
+	}
+
+	// Batches are encoded in order, and the sparse format holds the
+	// non-zero features in increasing order
+	status, resp = post(`{"vectors": [[0.2, 0.7], [0.9, 0.1]], ` +
+		`"format": "sparse"}`)
+	if status != http.StatusOK || len(resp.Encodings) != 2 {
+		t.Fatalf("encode: have status(%d) encodings(%d) want(%d, 2)", status,
+			len(resp.Encodings), http.StatusOK)
+	}
+	for i, x := range [][]float64{{0.2, 0.7}, {0.9, 0.1}} {
+		dense := tc.Encode(mat.NewVecDense(2, x))
+		var indices []int
+		var values []float64
+		for j := 0; j < dense.Len(); j++ {
+			if dense.AtVec(j) != 0 {
+				indices = append(indices, j)
+				values = append(values, dense.AtVec(j))
+			}
+		}
+		if !reflect.DeepEqual(resp.Encodings[i].Indices, indices) ||
+			!reflect.DeepEqual(resp.Encodings[i].Values, values) {
+			t.Errorf("encode(%v): have(%v) want(%v %v)", x, resp.Encodings[i],
+				indices, values)
+		}
+	}
+
+	status, resp = post(`{"vector": [0.2, 0.7], "format": "dense"}`)
+	if status != http.StatusOK || len(resp.Encoding.Features) != tc.VecLength() {
+		t.Errorf("encode: have status(%d) features(%d) want(%d, %d)", status,
+			len(resp.Encoding.Features), http.StatusOK, tc.VecLength())
+	}
+
+	// Malformed requests and vectors which cannot be encoded are
+	// rejected
+	for _, body := range []string{
+		`{"vector": [2, 0]}`,
+		`{"vector": [0.5]}`,
+		`{"vector": [0.5, 0.5], "vectors": [[0.5, 0.5]]}`,
+		`{}`,
+		`{"vector": [0.5, 0.5], "format": "csv"}`,
+		`{"vector": [0.5, 0.5], "extra": 1}`,
+		`not json`,
+	} {
+		if status, _ := post(body); status != http.StatusBadRequest {
+			t.Errorf("encode(%s): have status(%d) want(%d)", body, status,
+				http.StatusBadRequest)
+		}
+	}
+
+	r, err := http.Get(s.URL + "/info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Body.Close()
+	var info Info
+	if err := json.NewDecoder(r.Body).Decode(&info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Length != tc.VecLength() {
+		t.Errorf("info: have length(%d) want(%d)", info.Length, tc.VecLength())
+	}
+
+	r, err = http.Get(s.URL + "/encode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+	if r.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("encode: have status(%d) want(%d)", r.StatusCode,
+			http.StatusMethodNotAllowed)
+	}
+}
+
+func TestServerBatch(t *testing.T) {
+	tc, err := gotile.New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{4, 4}, {4, 4}},
+		1,
+		true,
+		-1,
+		gotile.WithAdaptiveBounds(0.1, nil),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	s := New(tc)
+
+	// Requests are encoded concurrently, even by an adaptive tile coder
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				x := float64(g*50 + j)
+				for _, format := range []string{Indices, Sparse, Dense} {
+					_, err := s.Encode(Request{
+						Vectors: [][]float64{{x, -x}, {0.5, 0.5}},
+						Format:  format,
+					})
+					if err != nil {
+						t.Error(err)
+						return
+					}
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	// Batches are encoded as each of their vectors would be
+	vectors := [][]float64{{0.2, 0.7}, {0.9, 0.1}, {-3, 40}}
+	for _, format := range []string{Indices, Sparse, Dense} {
+		batch, err := s.Encode(Request{Vectors: vectors, Format: format})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, v := range vectors {
+			single, err := s.Encode(Request{Vector: v, Format: format})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(batch.Encodings[i], *single.Encoding) {
+				t.Errorf("encode(%v, %s): have(%v) want(%v)", v, format,
+					batch.Encodings[i], *single.Encoding)
+			}
+		}
+	}
+
+	for _, vectors := range [][][]float64{
+		{{0.5, 0.5}, {0.5}},
+		{{0.5, 0.5}, {}},
+		{{0.5}, {0.5}},
+	} {
+		if _, err := s.Encode(Request{Vectors: vectors}); err == nil {
+			t.Errorf("encode(%v): expected error", vectors)
+		}
+	}
+}
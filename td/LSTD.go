@@ -0,0 +1,120 @@
+package td
+
+import (
+	"fmt"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// LSTD implements least-squares TD (Bradtke and Barto, 1996) for batch
+// policy evaluation with binary features, given by the indices of the
+// active features of each state. The A matrix and b vector are
+// accumulated as sparse outer products of the active features, and
+// only the features which were active in some transition take part in
+// solving for the weights.
+type LSTD struct {
+	features int
+	discount float64
+
+	a map[int]map[int]float64 // Sparse A matrix, indexed by row then column
+	b map[int]float64         // Sparse b vector
+}
+
+// NewLSTD returns a new LSTD helper over features features
+func NewLSTD(features int, discount float64) (*LSTD, error) {
+	if features < 1 {
+		return nil, fmt.Errorf("newLSTD: cannot use less than 1 feature")
+	}
+	if discount < 0 || discount > 1 {
+		return nil, fmt.Errorf("newLSTD: discount must be in [0, 1]")
+	}
+
+	l := &LSTD{features: features, discount: discount}
+	l.Reset()
+	return l, nil
+}
+
+// Reset discards all accumulated transitions
+func (l *LSTD) Reset() {
+	l.a = make(map[int]map[int]float64)
+	l.b = make(map[int]float64)
+}
+
+// Add accumulates the transition from the state whose active features
+// are given by indices to the state whose active features are given by
+// next, receiving reward. If terminal is true, the next state is
+// terminal and next is ignored. That is, Add performs
+//
+//	A += x (x - γx')ᵀ
+//	b += r x
+//
+// where x and x' are the binary feature vectors of the two states and
+// x' is 0 for terminal states.
+func (l *LSTD) Add(indices []int, reward float64, next []int,
+	terminal bool) {
+	for _, i := range indices {
+		row, ok := l.a[i]
+		if !ok {
+			row = make(map[int]float64)
+			l.a[i] = row
+		}
+		for _, j := range indices {
+			row[j]++
+		}
+		if !terminal {
+			for _, j := range next {
+				row[j] -= l.discount
+			}
+		}
+		l.b[i] += reward
+	}
+}
+
+// Solve returns the weights w solving (A + regularization * I) w = b
+// for the accumulated transitions. Only the features which were active
+// in the current state of some transition are solved for, and the
+// weights of all other features are 0. The regularization should be
+// positive unless enough transitions have been added for A to be
+// invertible.
+func (l *LSTD) Solve(regularization float64) ([]float64, error) {
+	weights := make([]float64, l.features)
+	if len(l.a) == 0 {
+		return weights, nil
+	}
+
+	// Solve the reduced system over the active features
+	active := make([]int, 0, len(l.a))
+	for i := range l.a {
+		active = append(active, i)
+	}
+	sort.Ints(active)
+	position := make(map[int]int, len(active))
+	for p, i := range active {
+		position[i] = p
+	}
+
+	n := len(active)
+	a := mat.NewDense(n, n, nil)
+	b := mat.NewVecDense(n, nil)
+	for p, i := range active {
+		for j, value := range l.a[i] {
+			// Features which only appear in next states have no row in
+			// A, and their weights are not solved for
+			if q, ok := position[j]; ok {
+				a.Set(p, q, value)
+			}
+		}
+		a.Set(p, p, a.At(p, p)+regularization)
+		b.SetVec(p, l.b[i])
+	}
+
+	var w mat.VecDense
+	if err := w.SolveVec(a, b); err != nil {
+		return nil, fmt.Errorf("solve: %v", err)
+	}
+	for p, i := range active {
+		weights[i] = w.AtVec(p)
+	}
+	return weights, nil
+}
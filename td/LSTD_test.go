@@ -0,0 +1,50 @@
+package td
+
+import (
+	"math"
+	"testing"
+
+	"golang.org/x/exp/rand"
+)
+
+func TestLSTD(t *testing.T) {
+	const states = 5
+	encode := func(state int) []int { return []int{state} }
+
+	lstd, err := NewLSTD(states, 1)
+	if err != nil {
+		t.Fatalf("could not create LSTD: %v", err)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for episode := 0; episode < 2000; episode++ {
+		state := states / 2
+		for {
+			next := state + 1
+			if rng.Intn(2) == 0 {
+				next = state - 1
+			}
+
+			if next < 0 {
+				lstd.Add(encode(state), 0, nil, true)
+				break
+			} else if next >= states {
+				lstd.Add(encode(state), 1, nil, true)
+				break
+			}
+			lstd.Add(encode(state), 0, encode(next), false)
+			state = next
+		}
+	}
+
+	weights, err := lstd.Solve(1e-6)
+	if err != nil {
+		t.Fatalf("could not solve: %v", err)
+	}
+	for state := 0; state < states; state++ {
+		want := float64(state+1) / (states + 1)
+		if got := weights[state]; math.Abs(got-want) > 0.05 {
+			t.Errorf("weights[%v]: have(%v) want(%v)", state, got, want)
+		}
+	}
+}
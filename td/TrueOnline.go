@@ -0,0 +1,147 @@
+// Package td implements temporal difference learning over the sparse,
+// binary features produced by tile coding
+package td
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/samuelfneumann/gotile/linear"
+)
+
+// DefaultTraceThreshold is the magnitude below which eligibility
+// traces are dropped by default. See SetTraceThreshold.
+const DefaultTraceThreshold = 1e-8
+
+// TrueOnline implements true online TD(λ) (van Seijen et al., 2016)
+// for policy evaluation with binary features, given by the indices of
+// the active features of each state, such as those returned by the
+// EncodeIndices methods of a gotile.TileCoder.
+//
+// Only eligibility traces of non-negligible magnitude are stored, so
+// that each step updates only the weights of recently active features
+// rather than the entire weight vector.
+type TrueOnline struct {
+	approx   *linear.LinearApprox
+	stepSize float64
+	discount float64
+	lambda   float64
+
+	traces    map[int]float64 // Non-negligible eligibility traces
+	threshold float64         // Traces below this magnitude are dropped
+	current   []int           // Active features of the current state
+	vOld      float64         // Value of the current state before update
+}
+
+// NewTrueOnline returns a new true online TD(λ) learner over features
+// features with all weights initialized to 0. The step size is
+// commonly divided by the number of active features in each state.
+func NewTrueOnline(features int, stepSize, discount,
+	lambda float64) (*TrueOnline, error) {
+	if stepSize <= 0 {
+		return nil, fmt.Errorf("newTrueOnline: step size must be positive")
+	}
+	if discount < 0 || discount > 1 {
+		return nil, fmt.Errorf("newTrueOnline: discount must be in [0, 1]")
+	}
+	if lambda < 0 || lambda > 1 {
+		return nil, fmt.Errorf("newTrueOnline: lambda must be in [0, 1]")
+	}
+
+	approx, err := linear.New(features, 0)
+	if err != nil {
+		return nil, fmt.Errorf("newTrueOnline: %v", err)
+	}
+
+	return &TrueOnline{
+		approx:    approx,
+		stepSize:  stepSize,
+		discount:  discount,
+		lambda:    lambda,
+		traces:    make(map[int]float64),
+		threshold: DefaultTraceThreshold,
+	}, nil
+}
+
+// SetTraceThreshold sets the magnitude below which eligibility traces
+// are dropped. Larger thresholds make steps cheaper at the cost of
+// truncating the traces sooner.
+func (t *TrueOnline) SetTraceThreshold(threshold float64) {
+	t.threshold = math.Abs(threshold)
+}
+
+// Start begins a new episode in the state whose active features are
+// given by indices, clearing all eligibility traces
+func (t *TrueOnline) Start(indices []int) {
+	for i := range t.traces {
+		delete(t.traces, i)
+	}
+	t.current = append(t.current[:0], indices...)
+	t.vOld = 0
+}
+
+// Step updates the weights after a transition from the current state
+// to the state whose active features are given by next, receiving
+// reward. If terminal is true, the next state is terminal and has a
+// value of 0, next is ignored, and Start must be called before the
+// next Step. Step returns the TD error of the transition.
+func (t *TrueOnline) Step(reward float64, next []int, terminal bool) float64 {
+	if t.current == nil {
+		panic("step: must call start before step")
+	}
+
+	x := t.current
+	v := t.approx.Predict(x)
+	vNext := 0.0
+	if !terminal {
+		vNext = t.approx.Predict(next)
+	}
+	delta := reward + t.discount*vNext - v
+
+	// Dutch traces: z = γλz + (1 - αγλ zᵀx) x
+	decay := t.discount * t.lambda
+	zx := 0.0
+	for _, i := range x {
+		zx += t.traces[i]
+	}
+	for i, z := range t.traces {
+		if z *= decay; math.Abs(z) < t.threshold {
+			delete(t.traces, i)
+		} else {
+			t.traces[i] = z
+		}
+	}
+	coef := 1 - t.stepSize*decay*zx
+	for _, i := range x {
+		t.traces[i] += coef
+	}
+
+	// w = w + α(δ + v - vOld) z - α(v - vOld) x
+	weights := t.approx.Weights()
+	traceStep := t.stepSize * (delta + v - t.vOld)
+	for i, z := range t.traces {
+		weights[i] += traceStep * z
+	}
+	t.approx.Update(x, -(v - t.vOld), t.stepSize)
+
+	if terminal {
+		t.current = nil
+		t.vOld = 0
+	} else {
+		t.current = append(t.current[:0], next...)
+		t.vOld = vNext
+	}
+	return delta
+}
+
+// Predict returns the value of the state whose active features are
+// given by indices
+func (t *TrueOnline) Predict(indices []int) float64 {
+	return t.approx.Predict(indices)
+}
+
+// Weights returns the weight vector of the learner. The returned slice
+// is shared with the learner.
+func (t *TrueOnline) Weights() []float64 {
+	return t.approx.Weights()
+}
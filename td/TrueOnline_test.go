@@ -0,0 +1,69 @@
+package td
+
+import (
+	"math"
+	"testing"
+
+	"github.com/samuelfneumann/gotile"
+	"github.com/samuelfneumann/gotile/linear"
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/mat"
+)
+
+// TestTrueOnline evaluates the random policy on a 5-state random walk,
+// whose true state values are 1/6, 2/6, ..., 5/6
+func TestTrueOnline(t *testing.T) {
+	const states = 5
+
+	// Use a single tiling without offsets, which is a tabular
+	// representation of the states
+	tc, err := gotile.New(
+		mat.NewVecDense(1, []float64{0}),
+		mat.NewVecDense(1, []float64{states}),
+		[][]int{{states}},
+		1,
+		false,
+		1e300,
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+	encode := func(state int) []int {
+		v := mat.NewVecDense(1, []float64{float64(state) + 0.5})
+		return linear.Indices(tc.EncodeIndices(v))
+	}
+
+	learner, err := NewTrueOnline(tc.VecLength(), 0.02, 1, 0.8)
+	if err != nil {
+		t.Fatalf("could not create learner: %v", err)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for episode := 0; episode < 3000; episode++ {
+		state := states / 2
+		learner.Start(encode(state))
+		for {
+			if rng.Intn(2) == 0 {
+				state--
+			} else {
+				state++
+			}
+
+			if state < 0 {
+				learner.Step(0, nil, true)
+				break
+			} else if state >= states {
+				learner.Step(1, nil, true)
+				break
+			}
+			learner.Step(0, encode(state), false)
+		}
+	}
+
+	for state := 0; state < states; state++ {
+		want := float64(state+1) / (states + 1)
+		if got := learner.Predict(encode(state)); math.Abs(got-want) > 0.1 {
+			t.Errorf("predict(%v): have(%v) want(%v)", state, got, want)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Package tfrecord writes tile-coded samples to TFRecord files of
+// tf.train.Example protos, so that tile-coded features can feed
+// TensorFlow input pipelines, such as those built with
+// tf.data.TFRecordDataset, for hybrid linear and deep experiments.
+//
+// Each Example holds the active indices of the tile-coded
+// representation of a sample as the int64 list feature named
+// IndicesFeature, ordered as in EncodeIndices, and optionally the raw
+// sample as the float list feature named ObservationFeature. Examples
+// can be parsed in TensorFlow with tf.io.parse_single_example and the
+// feature spec
+//
+//	{
+//		"indices": tf.io.FixedLenFeature([num_indices], tf.int64),
+//		"observation": tf.io.FixedLenFeature([features], tf.float32),
+//	}
+//
+// where num_indices is the number of tilings plus one if the coder
+// includes a bias unit.
+package tfrecord
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+
+	"github.com/samuelfneumann/gotile"
+	"gonum.org/v1/gonum/mat"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	// IndicesFeature is the name of the feature holding the active
+	// indices of the tile-coded representation of each sample
+	IndicesFeature = "indices"
+
+	// ObservationFeature is the name of the feature holding each raw
+	// sample, if observations are written
+	ObservationFeature = "observation"
+)
+
+// Field numbers of the tf.train.Example protos, from
+// tensorflow/core/example/feature.proto and example.proto
+const (
+	exampleFeatures  protowire.Number = 1 // Example.features
+	featuresFeature  protowire.Number = 1 // Features.feature
+	mapKey           protowire.Number = 1 // map entry key
+	mapValue         protowire.Number = 2 // map entry value
+	featureFloatList protowire.Number = 2 // Feature.float_list
+	featureInt64List protowire.Number = 3 // Feature.int64_list
+	listValue        protowire.Number = 1 // FloatList.value, Int64List.value
+)
+
+// crcTable is the Castagnoli table used to checksum TFRecords
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Options configures a Writer
+type Options struct {
+	// Observations is whether each raw sample is written along with
+	// the indices of its tile-coded representation
+	Observations bool
+}
+
+// Writer writes tile-coded samples to a TFRecord file, with one
+// tf.train.Example record per sample
+type Writer struct {
+	w     io.Writer
+	coder *gotile.TileCoder
+	opts  Options
+
+	// Buffers reused between records
+	record, example, feature []byte
+}
+
+// NewWriter returns a new Writer which writes the samples encoded by
+// coder to w
+func NewWriter(w io.Writer, coder *gotile.TileCoder, opts Options) (*Writer,
+	error) {
+	if w == nil || coder == nil {
+		return nil, fmt.Errorf("newWriter: %w", gotile.ErrNilInput)
+	}
+	return &Writer{w: w, coder: coder, opts: opts}, nil
+}
+
+// Write tile codes each vector in the batch b, where each column of b
+// is a vector to encode, and writes a record for each vector.
+//
+// If some tiling uses the BoundsError policy and a vector falls
+// outside its bounds, an error wrapping gotile.ErrOutOfBounds is
+// returned and no records are written.
+func (w *Writer) Write(b *mat.Dense) error {
+	indices, err := w.coder.TryEncodeIndicesBatch(b)
+	if err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	rows, cols := indices.Dims()
+	features, _ := b.Dims()
+
+	for j := 0; j < cols; j++ {
+		w.example = w.example[:0]
+
+		w.feature = w.feature[:0]
+		for i := 0; i < rows; i++ {
+			w.feature = protowire.AppendVarint(w.feature,
+				uint64(int64(indices.At(i, j))))
+		}
+		w.appendFeature(IndicesFeature, featureInt64List)
+
+		if w.opts.Observations {
+			w.feature = w.feature[:0]
+			for i := 0; i < features; i++ {
+				w.feature = protowire.AppendFixed32(w.feature,
+					math.Float32bits(float32(b.At(i, j))))
+			}
+			w.appendFeature(ObservationFeature, featureFloatList)
+		}
+
+		// Wrap the map entries as the Features of an Example
+		w.record = w.record[:0]
+		w.record = protowire.AppendTag(w.record, exampleFeatures,
+			protowire.BytesType)
+		w.record = protowire.AppendBytes(w.record, w.example)
+
+		if err := w.writeRecord(w.record); err != nil {
+			return fmt.Errorf("write: %v", err)
+		}
+	}
+	return nil
+}
+
+// appendFeature appends to the encoded Features of the current example
+// the feature called name, holding a packed list of the given kind
+// whose encoded values are held in w.feature
+func (w *Writer) appendFeature(name string, kind protowire.Number) {
+	var list []byte
+	list = protowire.AppendTag(list, listValue, protowire.BytesType)
+	list = protowire.AppendBytes(list, w.feature)
+
+	var feature []byte
+	feature = protowire.AppendTag(feature, kind, protowire.BytesType)
+	feature = protowire.AppendBytes(feature, list)
+
+	var entry []byte
+	entry = protowire.AppendTag(entry, mapKey, protowire.BytesType)
+	entry = protowire.AppendString(entry, name)
+	entry = protowire.AppendTag(entry, mapValue, protowire.BytesType)
+	entry = protowire.AppendBytes(entry, feature)
+
+	w.example = protowire.AppendTag(w.example, featuresFeature,
+		protowire.BytesType)
+	w.example = protowire.AppendBytes(w.example, entry)
+}
+
+// writeRecord writes data to the underlying io.Writer as a TFRecord,
+// which is framed as
+//
+//	uint64 length
+//	uint32 masked crc of length
+//	byte   data[length]
+//	uint32 masked crc of data
+//
+// with all integers little-endian
+func (w *Writer) writeRecord(data []byte) error {
+	var header [12]byte
+	binary.LittleEndian.PutUint64(header[:8], uint64(len(data)))
+	binary.LittleEndian.PutUint32(header[8:], maskedCRC(header[:8]))
+	var footer [4]byte
+	binary.LittleEndian.PutUint32(footer[:], maskedCRC(data))
+
+	for _, p := range [][]byte{header[:], data, footer[:]} {
+		if _, err := w.w.Write(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// maskedCRC returns the masked CRC-32C checksum of data used by
+// TFRecords
+func maskedCRC(data []byte) uint32 {
+	crc := crc32.Checksum(data, crcTable)
+	return (crc>>15 | crc<<17) + 0xa282ead8
+}
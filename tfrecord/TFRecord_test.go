@@ -0,0 +1,151 @@
+package tfrecord
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/samuelfneumann/gotile"
+	"gonum.org/v1/gonum/mat"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestWriter(t *testing.T) {
+	tc, err := gotile.New(
+		mat.NewVecDense(2, []float64{0, 0}),
+		mat.NewVecDense(2, []float64{1, 1}),
+		[][]int{{2, 2}, {3, 3}},
+		1,
+		true,
+		2,
+		gotile.WithBoundsPolicy(gotile.BoundsError),
+	)
+	if err != nil {
+		t.Fatalf("could not create tile coder: %v", err)
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, tc, Options{Observations: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := mat.NewDense(2, 3, []float64{
+		0.1, 0.9, 0.4,
+		0.2, 0.1, 0.8,
+	})
+	if err := w.Write(b); err != nil {
+		t.Fatal(err)
+	}
+
+	want := tc.EncodeIndicesBatch(b)
+	for j := 0; j < 3; j++ {
+		data, err := readRecord(&buf)
+		if err != nil {
+			t.Fatalf("record %d: %v", j, err)
+		}
+		features := parseExample(t, data)
+
+		indices := features[IndicesFeature]
+		if len(indices) != tc.NumTilings()+1 {
+			t.Fatalf("record %d: have(%d indices) want(%d)", j, len(indices),
+				tc.NumTilings()+1)
+		}
+		for i, index := range indices {
+			if index != want.At(i, j) {
+				t.Errorf("record %d: have(%v) want(%v)", j, index, want.At(i, j))
+			}
+		}
+		for i, x := range features[ObservationFeature] {
+			if x != float64(float32(b.At(i, j))) {
+				t.Errorf("record %d: have observation(%v) want(%v)", j, x,
+					b.At(i, j))
+			}
+		}
+	}
+	if _, err := readRecord(&buf); err != io.EOF {
+		t.Errorf("write: have(%v) want(%v) after last record", err, io.EOF)
+	}
+
+	b.Set(0, 0, 2)
+	if err := w.Write(b); !errors.Is(err, gotile.ErrOutOfBounds) {
+		t.Errorf("write: have(%v) want(%v)", err, gotile.ErrOutOfBounds)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("write: wrote %d bytes on error", buf.Len())
+	}
+}
+
+// readRecord reads and checks the next TFRecord from r
+func readRecord(r io.Reader) ([]byte, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	if maskedCRC(header[:8]) != binary.LittleEndian.Uint32(header[8:]) {
+		return nil, errors.New("length checksum mismatch")
+	}
+	data := make([]byte, binary.LittleEndian.Uint64(header[:8])+4)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	data, footer := data[:len(data)-4], data[len(data)-4:]
+	if maskedCRC(data) != binary.LittleEndian.Uint32(footer) {
+		return nil, errors.New("data checksum mismatch")
+	}
+	return data, nil
+}
+
+// parseExample parses the packed int64 and float lists of a
+// tf.train.Example
+func parseExample(t *testing.T, data []byte) map[string][]float64 {
+	features := make(map[string][]float64)
+	example := field(t, data, exampleFeatures)
+	for len(example) > 0 {
+		_, _, n := protowire.ConsumeTag(example)
+		entry, m := protowire.ConsumeBytes(example[n:])
+		example = example[n+m:]
+
+		name := string(field(t, entry, mapKey))
+		feature := field(t, entry, mapValue)
+		num, _, n := protowire.ConsumeTag(feature)
+		list, _ := protowire.ConsumeBytes(feature[n:])
+		packed := field(t, list, listValue)
+
+		for len(packed) > 0 {
+			if num == featureInt64List {
+				v, n := protowire.ConsumeVarint(packed)
+				features[name] = append(features[name], float64(int64(v)))
+				packed = packed[n:]
+			} else {
+				v, n := protowire.ConsumeFixed32(packed)
+				features[name] = append(features[name],
+					float64(math.Float32frombits(v)))
+				packed = packed[n:]
+			}
+		}
+	}
+	return features
+}
+
+// field returns the first length-delimited field of the encoded
+// message data with field number num
+func field(t *testing.T, data []byte, num protowire.Number) []byte {
+	for len(data) > 0 {
+		n, typ, m := protowire.ConsumeTag(data)
+		if m < 0 {
+			t.Fatalf("could not parse tag: %v", protowire.ParseError(m))
+		}
+		data = data[m:]
+		m = protowire.ConsumeFieldValue(n, typ, data)
+		if n == num && typ == protowire.BytesType {
+			v, _ := protowire.ConsumeBytes(data)
+			return v
+		}
+		data = data[m:]
+	}
+	t.Fatalf("could not find field %d", num)
+	return nil
+}
@@ -0,0 +1,20 @@
+module github.com/samuelfneumann/gotile/tfrecord
+
+go 1.17
+
+replace github.com/samuelfneumann/gotile => ../
+
+require (
+	github.com/samuelfneumann/gotile v0.0.0-00010101000000-000000000000
+	gonum.org/v1/gonum v0.9.3
+	google.golang.org/protobuf v1.28.1
+)
+
+require (
+	github.com/BurntSushi/toml v1.2.1 // indirect
+	github.com/kr/text v0.1.0 // indirect
+	github.com/samuelfneumann/goutils v0.0.0-20211111214126-5491a5616c35 // indirect
+	golang.org/x/exp v0.0.0-20211111183329-cb5df436b1a8 // indirect
+	golang.org/x/tools v0.1.8-0.20211029000441-d6a9af8af023 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)